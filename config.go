@@ -0,0 +1,147 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+	_ "time/tzdata"
+
+	"go-todolist/handlers"
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// openStorage 使用 driver 对应的驱动打开存储，未知驱动或打开失败时退出
+func openStorage(driver, dsn string) storage.TodoStorage {
+	s, err := storage.Open(driver, dsn)
+	if err != nil {
+		log.Fatalf("打开存储失败: %v", err)
+	}
+	// STORAGE_MAX_RETRIES 大于 0 时对临时错误按指数退避重试，缓存在外层，命中缓存时不访问存储
+	if retries := envInt("STORAGE_MAX_RETRIES", 0); retries > 0 {
+		policy := storage.DefaultRetryPolicy()
+		policy.MaxRetries = retries
+		s = storage.NewRetrying(s, policy)
+	}
+	// CACHE_TTL 大于 0 时缓存列表和单条查询的结果
+	if ttl := envInt("CACHE_TTL", 0); ttl > 0 {
+		return storage.NewCached(s, time.Duration(ttl)*time.Second)
+	}
+	return s
+}
+
+// runMigrate 执行数据库迁移并输出迁移前后的版本，供 -migrate 命令行模式使用
+func runMigrate(driver, dsn string) {
+	target, err := storage.MigrationTarget(driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+	from, to, err := storage.Migrate(driver, dsn)
+	if err != nil {
+		log.Fatalf("迁移失败: %v", err)
+	}
+	fmt.Printf("当前版本: %d\n目标版本: %d\n", from, target)
+	if from == to {
+		fmt.Println("✅ 数据库已是最新版本")
+		return
+	}
+	fmt.Printf("✅ 已迁移到版本 %d\n", to)
+}
+
+// loadHandlerConfig 从环境变量读取处理器配置
+func loadHandlerConfig() handlers.Config {
+	config := handlers.DefaultConfig()
+	config.MaxLimit = envInt("MAX_LIMIT", config.MaxLimit)
+	config.RejectOverLimit = os.Getenv("LIMIT_OVERFLOW") == "reject"
+	config.AdminKey = os.Getenv("ADMIN_KEY")
+	config.NormalizeTags = os.Getenv("NORMALIZE_TAGS") == "true"
+	config.MaxBatchSize = envInt("MAX_BATCH_SIZE", config.MaxBatchSize)
+	config.StrictQuery = os.Getenv("STRICT_QUERY") == "true"
+	config.UniqueExternalIDs = os.Getenv("UNIQUE_EXTERNAL_IDS") == "true"
+	config.ReadOnly = os.Getenv("READ_ONLY") == "true"
+	config.CreateDedupWindow = time.Duration(envInt("CREATE_DEDUP_WINDOW", 0)) * time.Second
+	config.CapacitySoftLimit = envInt("CAPACITY_SOFT_LIMIT", config.CapacitySoftLimit)
+	if name := os.Getenv("TIMEZONE"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Fatalf("无效的时区 %s: %v", name, err)
+		}
+		config.Location = loc
+	}
+	return config
+}
+
+// loadLimits 从环境变量读取字段长度与数量上限，未设置的项使用默认值
+func loadLimits() models.Limits {
+	limits := models.DefaultLimits()
+	limits.TitleLength = envInt("MAX_TITLE_LENGTH", limits.TitleLength)
+	limits.DescriptionLength = envInt("MAX_DESCRIPTION_LENGTH", limits.DescriptionLength)
+	limits.TagCount = envInt("MAX_TAGS", limits.TagCount)
+	limits.TagLength = envInt("MAX_TAG_LENGTH", limits.TagLength)
+	limits.SubtaskCount = envInt("MAX_SUBTASKS", limits.SubtaskCount)
+	limits.SubtaskTitleLength = envInt("MAX_SUBTASK_TITLE_LENGTH", limits.SubtaskTitleLength)
+	limits.CommentLength = envInt("MAX_COMMENT_LENGTH", limits.CommentLength)
+	limits.ListIDLength = envInt("MAX_LIST_ID_LENGTH", limits.ListIDLength)
+	limits.ExternalIDLength = envInt("MAX_EXTERNAL_ID_LENGTH", limits.ExternalIDLength)
+	return limits
+}
+
+// registerValidationRules 根据环境变量注册自定义校验规则
+func registerValidationRules() {
+	pattern := os.Getenv("TITLE_PATTERN")
+	if pattern == "" {
+		return
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		log.Fatalf("无效的 TITLE_PATTERN: %v", err)
+	}
+
+	message := "标题格式不符合要求: " + pattern
+	models.RegisterCreateRule(func(req *models.CreateTodoRequest) error {
+		if !re.MatchString(req.Title) {
+			return &models.ValidationError{Field: "title", Message: message}
+		}
+		return nil
+	})
+	models.RegisterUpdateRule(func(req *models.UpdateTodoRequest) error {
+		if req.Title != nil && !re.MatchString(*req.Title) {
+			return &models.ValidationError{Field: "title", Message: message}
+		}
+		return nil
+	})
+}
+
+// gzipLevel 读取 GZIP_LEVEL 压缩级别，取值 -2 到 9，默认为 gzip.DefaultCompression
+func gzipLevel() int {
+	level := envInt("GZIP_LEVEL", gzip.DefaultCompression)
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		log.Fatalf("无效的 GZIP_LEVEL: %d，取值范围为 %d 到 %d", level, gzip.HuffmanOnly, gzip.BestCompression)
+	}
+	return level
+}
+
+// envInt 读取整数类型的环境变量，未设置或格式错误时返回默认值
+func envInt(name string, defaultValue int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("环境变量 %s 格式无效，使用默认值 %d", name, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+func envString(name, defaultValue string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return defaultValue
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-todolist/storage"
+)
+
+// adminKeyHeader 携带管理密钥的请求头
+const adminKeyHeader = "X-Admin-Key"
+
+// maxRestoreSize 恢复请求体的最大字节数，备份包含历史版本，因此比导入的限制更大
+const maxRestoreSize = 50 << 20
+
+// ClearResponse 清空操作的响应结构
+type ClearResponse struct {
+	Cleared int `json:"cleared"`
+}
+
+// RestoreResponse 恢复操作的响应结构
+type RestoreResponse struct {
+	Restored int `json:"restored"`
+}
+
+// AdminHandler 返回处理 /api/admin/ 下管理接口的处理器，所有请求都需携带正确的管理密钥。
+// 只读模式下拒绝清空和恢复，备份只读取数据，仍然允许
+func (h *TodoHandler) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.authorizeAdmin(w, r) {
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/admin")
+		switch path {
+		case "/reset":
+			if r.Method != http.MethodPost {
+				writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+				return
+			}
+			if h.config.ReadOnly {
+				writeErrorResponse(w, http.StatusForbidden, readOnlyMessage)
+				return
+			}
+			h.handleReset(w, r)
+		case "/backup":
+			if r.Method != http.MethodPost {
+				writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+				return
+			}
+			h.handleBackup(w, r)
+		case "/restore":
+			if r.Method != http.MethodPost {
+				writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+				return
+			}
+			if h.config.ReadOnly {
+				writeErrorResponse(w, http.StatusForbidden, readOnlyMessage)
+				return
+			}
+			h.handleRestore(w, r)
+		case "/latency":
+			if r.Method != http.MethodGet {
+				writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+				return
+			}
+			writeJSONResponse(w, http.StatusOK, h.latency.Summary())
+		case "/retries":
+			if r.Method != http.MethodGet {
+				writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+				return
+			}
+			writeJSONResponse(w, http.StatusOK, h.retryStats())
+		default:
+			writeErrorResponse(w, http.StatusNotFound, "路径未找到")
+		}
+	})
+}
+
+// retryStats 返回存储重试统计，依次解开存储装饰器查找 RetryStorage，未启用重试时返回空列表
+func (h *TodoHandler) retryStats() []storage.RetryStats {
+	s := h.storage
+	for {
+		if retrying, ok := s.(*storage.RetryStorage); ok {
+			return retrying.Stats()
+		}
+		wrapper, ok := s.(interface{ Unwrap() storage.TodoStorage })
+		if !ok {
+			return []storage.RetryStats{}
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// authorizeAdmin 校验管理密钥，失败时写入错误响应并返回 false
+func (h *TodoHandler) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.config.AdminKey == "" {
+		writeErrorResponse(w, http.StatusForbidden, "管理接口未启用")
+		return false
+	}
+	key := r.Header.Get(adminKeyHeader)
+	if subtle.ConstantTimeCompare([]byte(key), []byte(h.config.AdminKey)) != 1 {
+		writeErrorResponse(w, http.StatusUnauthorized, "管理密钥无效")
+		return false
+	}
+	return true
+}
+
+// handleReset 处理清空所有待办事项并重置ID序列
+func (h *TodoHandler) handleReset(w http.ResponseWriter, r *http.Request) {
+	cleared, err := h.storage.Clear(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "清空待办事项失败")
+		return
+	}
+
+	h.events.Publish(TodoEvent{Type: EventCleared})
+	writeJSONResponse(w, http.StatusOK, ClearResponse{Cleared: cleared})
+}
+
+// handleBackup 处理导出全部数据（包括备注、历史版本和ID序列）的快照
+func (h *TodoHandler) handleBackup(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.storage.Export(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "导出备份失败")
+		return
+	}
+
+	filename := fmt.Sprintf("todos-backup-%s.json", snapshot.ExportedAt.UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	writeJSONResponse(w, http.StatusOK, snapshot)
+}
+
+// handleRestore 处理用备份快照替换全部数据，替换在存储层尽可能原子地完成
+func (h *TodoHandler) handleRestore(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRestoreSize)
+	var snapshot storage.Snapshot
+	if !decodeJSONBody(w, r, &snapshot) {
+		return
+	}
+	if snapshot.Format == 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "缺少快照格式版本 format")
+		return
+	}
+
+	if err := h.storage.Import(r.Context(), &snapshot); err != nil {
+		writeStorageError(w, err, "恢复备份失败")
+		return
+	}
+
+	h.events.Publish(TodoEvent{Type: EventRestored})
+	writeJSONResponse(w, http.StatusOK, RestoreResponse{Restored: len(snapshot.Todos)})
+}
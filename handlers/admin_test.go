@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"go-todolist/models"
+)
+
+func TestAdminReadOnlyRejectsMutations(t *testing.T) {
+	h := newTestHandler(t, func(c *Config) {
+		c.AdminKey = "secret"
+		c.ReadOnly = true
+	})
+	admin := h.AdminHandler()
+
+	for _, path := range []string{"/api/admin/reset", "/api/admin/restore"} {
+		rec := serve(t, admin, http.MethodPost, path, `{"format": 1}`, adminKeyHeader, "secret")
+		expectStatus(t, rec, http.StatusForbidden)
+		if got := errorMessage(t, rec); got != readOnlyMessage {
+			t.Errorf("%s 错误信息 = %q，期望 %q", path, got, readOnlyMessage)
+		}
+	}
+
+	// 备份只读取数据，只读模式下仍然可用
+	expectStatus(t, serve(t, admin, http.MethodPost, "/api/admin/backup", "", adminKeyHeader, "secret"), http.StatusOK)
+	expectStatus(t, serve(t, admin, http.MethodGet, "/api/admin/latency", "", adminKeyHeader, "secret"), http.StatusOK)
+}
+
+func TestAdminResetAllowedWhenWritable(t *testing.T) {
+	h := newTestHandler(t, func(c *Config) { c.AdminKey = "secret" })
+	mustCreateTitled(t, h, "a", "b")
+
+	rec := serve(t, h.AdminHandler(), http.MethodPost, "/api/admin/reset", "", adminKeyHeader, "secret")
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[ClearResponse](t, rec).Cleared; got != 2 {
+		t.Errorf("cleared = %d，期望 2", got)
+	}
+	if got := decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", "")); len(got) != 0 {
+		t.Errorf("清空后列表 = %v，期望为空", todoIDs(got))
+	}
+	if todo := mustCreateTitled(t, h, "c")[0]; todo.ID != 1 {
+		t.Errorf("清空后新建的ID = %d，期望 1", todo.ID)
+	}
+}
+
+func TestAdminResetRequiresKey(t *testing.T) {
+	h := newTestHandler(t, func(c *Config) { c.AdminKey = "secret" })
+	mustCreateTitled(t, h, "a")
+	admin := h.AdminHandler()
+
+	expectStatus(t, serve(t, admin, http.MethodPost, "/api/admin/reset", ""), http.StatusUnauthorized)
+	expectStatus(t, serve(t, admin, http.MethodPost, "/api/admin/reset", "", adminKeyHeader, "wrong"), http.StatusUnauthorized)
+	expectStatus(t, serve(t, admin, http.MethodDelete, "/api/admin/reset", "", adminKeyHeader, "secret"), http.StatusMethodNotAllowed)
+	// 未配置管理密钥时管理接口不可用
+	expectStatus(t, serve(t, newTestHandler(t).AdminHandler(), http.MethodPost, "/api/admin/reset", ""), http.StatusForbidden)
+
+	if got := decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", "")); len(got) != 1 {
+		t.Errorf("未授权的清空后共 %d 项，期望保留 1 项", len(got))
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"go-todolist/models"
+)
+
+// appPath 服务端渲染页面的路径
+const appPath = "/app"
+
+// appTemplate 服务端渲染的备用页面，在前端脚本无法加载时仍可通过普通表单操作待办事项。
+// html/template 会根据上下文自动转义，标题等用户输入不会被当作 HTML 解析
+var appTemplate = template.Must(template.New("app").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>待办事项</title>
+</head>
+<body>
+<h1>待办事项</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="/app/todos">
+<input name="title" placeholder="新的待办事项" required maxlength="100">
+<input name="description" placeholder="描述（可选）" maxlength="500">
+<button type="submit">添加</button>
+</form>
+<ul>
+{{range .Todos}}<li>
+<form method="post" action="/app/todos/{{.ID}}/toggle" style="display:inline">
+<button type="submit">{{if .Completed}}↩ 撤销{{else}}✓ 完成{{end}}</button>
+</form>
+{{if .Completed}}<s>{{.Title}}</s>{{else}}{{.Title}}{{end}}
+{{with .Description}}<small>{{.}}</small>{{end}}
+<form method="post" action="/app/todos/{{.ID}}/delete" style="display:inline">
+<button type="submit">删除</button>
+</form>
+</li>
+{{else}}<li>暂无待办事项</li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// appPage 渲染页面所需的数据
+type appPage struct {
+	Todos []*models.Todo
+	Error string
+}
+
+// AppHandler 返回处理 /app 下服务端渲染页面的处理器，表单提交后重定向回列表页
+func (h *TodoHandler) AppHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, appPath)
+		if h.config.ReadOnly && isMutatingMethod(r.Method) {
+			http.Error(w, readOnlyMessage, http.StatusForbidden)
+			return
+		}
+		switch {
+		case path == "" || path == "/":
+			if r.Method != http.MethodGet {
+				http.Error(w, "方法不允许", http.StatusMethodNotAllowed)
+				return
+			}
+			h.renderApp(r.Context(), w, http.StatusOK, "")
+		case path == "/todos":
+			if r.Method != http.MethodPost {
+				http.Error(w, "方法不允许", http.StatusMethodNotAllowed)
+				return
+			}
+			h.handleAppCreate(w, r)
+		case strings.HasPrefix(path, "/todos/"):
+			if r.Method != http.MethodPost {
+				http.Error(w, "方法不允许", http.StatusMethodNotAllowed)
+				return
+			}
+			idStr, action, _ := strings.Cut(strings.TrimPrefix(path, "/todos/"), "/")
+			id, err := parseID(idStr)
+			if err != nil {
+				h.renderApp(r.Context(), w, http.StatusBadRequest, err.Error())
+				return
+			}
+			h.handleAppAction(w, r, id, action)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// handleAppCreate 处理页面表单创建待办事项
+func (h *TodoHandler) handleAppCreate(w http.ResponseWriter, r *http.Request) {
+	req := &models.CreateTodoRequest{
+		Title:       strings.TrimSpace(r.PostFormValue("title")),
+		Description: strings.TrimSpace(r.PostFormValue("description")),
+	}
+	if _, err := h.createTodo(r.Context(), req); err != nil {
+		statusCode, message := storageErrorStatus(err, "创建待办事项失败")
+		h.renderApp(r.Context(), w, statusCode, message)
+		return
+	}
+	http.Redirect(w, r, appPath, http.StatusSeeOther)
+}
+
+// handleAppAction 处理页面表单切换完成状态和删除待办事项
+func (h *TodoHandler) handleAppAction(w http.ResponseWriter, r *http.Request, id int, action string) {
+	var err error
+	switch action {
+	case "toggle":
+		var todo *models.Todo
+		todo, err = h.storage.GetByID(r.Context(), id)
+		if err == nil {
+			completed := !todo.Completed
+			_, err = h.updateTodo(r.Context(), id, &models.UpdateTodoRequest{Completed: &completed})
+		}
+	case "delete":
+		err = h.deleteTodo(r.Context(), id)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		statusCode, message := storageErrorStatus(err, "操作失败")
+		h.renderApp(r.Context(), w, statusCode, message)
+		return
+	}
+	http.Redirect(w, r, appPath, http.StatusSeeOther)
+}
+
+// renderApp 渲染待办事项列表页，errMessage 不为空时在页面顶部显示
+func (h *TodoHandler) renderApp(ctx context.Context, w http.ResponseWriter, statusCode int, errMessage string) {
+	todos, err := h.storage.GetAll(ctx)
+	if err != nil {
+		statusCode, errMessage = storageErrorStatus(err, "获取待办事项失败")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(statusCode)
+	appTemplate.Execute(w, appPage{Todos: todos, Error: errMessage})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"go-todolist/models"
+)
+
+func TestAppEscapesTitle(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreate(t, h, `{"title": "<script>alert(\"x\")</script> & co", "description": "<b>粗体</b>"}`)
+	app := h.AppHandler()
+
+	rec := serve(t, app, http.MethodGet, "/app", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; co",
+		"&lt;b&gt;粗体&lt;/b&gt;",
+		`action="/app/todos/1/toggle"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("页面缺少 %q:\n%s", want, body)
+		}
+	}
+	for _, raw := range []string{"<script>", "<b>"} {
+		if strings.Contains(body, raw) {
+			t.Errorf("页面包含未转义的 %q", raw)
+		}
+	}
+}
+
+func TestAppForms(t *testing.T) {
+	h := newTestHandler(t)
+	app := h.AppHandler()
+	form := func(target, body string) *http.Response {
+		rec := serve(t, app, http.MethodPost, target, body, "Content-Type", "application/x-www-form-urlencoded")
+		return rec.Result()
+	}
+
+	resp := form("/app/todos", "title=%E4%B9%B0%E7%89%9B%E5%A5%B6&description=")
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != appPath {
+		t.Fatalf("创建后响应 %d，Location %q，期望 303 跳转到 %s", resp.StatusCode, resp.Header.Get("Location"), appPath)
+	}
+	todos := decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))
+	if len(todos) != 1 || todos[0].Title != "买牛奶" {
+		t.Fatalf("表单创建后 = %+v，期望一项标题为 买牛奶", todos)
+	}
+
+	if resp := form("/app/todos/1/toggle", ""); resp.StatusCode != http.StatusSeeOther {
+		t.Errorf("切换完成状态响应 %d，期望 303", resp.StatusCode)
+	}
+	if todo := decodeResponse[models.Todo](t, serve(t, h, http.MethodGet, "/api/todos/1", "")); !todo.Completed {
+		t.Error("切换后未标记为完成")
+	}
+	if resp := form("/app/todos/1/delete", ""); resp.StatusCode != http.StatusSeeOther {
+		t.Errorf("删除响应 %d，期望 303", resp.StatusCode)
+	}
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/1", ""), http.StatusNotFound)
+
+	// 出错时重新渲染页面并显示错误信息
+	rec := serve(t, app, http.MethodPost, "/app/todos", "title=", "Content-Type", "application/x-www-form-urlencoded")
+	expectStatus(t, rec, http.StatusBadRequest)
+	if !strings.Contains(rec.Body.String(), `<p role="alert">标题不能为空</p>`) {
+		t.Errorf("页面缺少错误提示:\n%s", rec.Body.String())
+	}
+	expectStatus(t, serve(t, app, http.MethodPost, "/app/todos/9/delete", "", "Content-Type", "application/x-www-form-urlencoded"), http.StatusNotFound)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-todolist/models"
+)
+
+// handleGetArchived 处理获取归档的待办事项，支持与列表接口相同的查询参数
+func (h *TodoHandler) handleGetArchived(w http.ResponseWriter, r *http.Request) {
+	query, err := h.parseListQuery(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.options.Archived != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "归档列表不支持 archived 参数")
+		return
+	}
+	archived := true
+	query.options.Archived = &archived
+	h.writeTodoList(w, r, query)
+}
+
+// handleSetArchived 处理归档（POST）或取消归档（DELETE）待办事项
+func (h *TodoHandler) handleSetArchived(w http.ResponseWriter, r *http.Request, id int, archived bool) {
+	todo, err := h.updateTodo(r.Context(), id, &models.UpdateTodoRequest{Archived: &archived})
+	if err != nil {
+		if archived {
+			writeStorageError(w, err, "归档待办事项失败")
+		} else {
+			writeStorageError(w, err, "取消归档失败")
+		}
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, h.todoResponse(todo))
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go-todolist/models"
+)
+
+func TestArchive(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c")
+
+	rec := serve(t, h, http.MethodPost, "/api/todos/2/archive", "")
+	expectStatus(t, rec, http.StatusOK)
+	if todo := decodeResponse[*models.Todo](t, rec); !todo.Archived || todo.ArchivedAt == nil {
+		t.Errorf("归档后 archived=%v archived_at=%v，期望已归档", todo.Archived, todo.ArchivedAt)
+	}
+
+	list := func(target string) []models.ID {
+		t.Helper()
+		rec := serve(t, h, http.MethodGet, target, "")
+		expectStatus(t, rec, http.StatusOK)
+		return todoIDs(decodeResponse[[]*models.Todo](t, rec))
+	}
+	tests := []struct {
+		name   string
+		target string
+		want   []models.ID
+	}{
+		{"默认列表排除归档", "/api/todos", []models.ID{1, 3}},
+		{"归档列表", "/api/todos/archived", []models.ID{2}},
+		{"按 archived 参数过滤", "/api/todos?archived=true", []models.ID{2}},
+		{"显式排除归档", "/api/todos?archived=false&completed=false", []models.ID{1, 3}},
+		{"按ID获取时不排除归档", "/api/todos?ids=1,2", []models.ID{1, 2}},
+	}
+	for _, tt := range tests {
+		if got := list(tt.target); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: 结果 = %v，期望 %v", tt.name, got, tt.want)
+		}
+	}
+
+	rec = serve(t, h, http.MethodDelete, "/api/todos/2/archive", "")
+	expectStatus(t, rec, http.StatusOK)
+	if todo := decodeResponse[*models.Todo](t, rec); todo.Archived || todo.ArchivedAt != nil {
+		t.Errorf("取消归档后 archived=%v archived_at=%v，期望未归档", todo.Archived, todo.ArchivedAt)
+	}
+	if got := list("/api/todos"); !reflect.DeepEqual(got, []models.ID{1, 2, 3}) {
+		t.Errorf("取消归档后列表 = %v，期望 [1 2 3]", got)
+	}
+	if got := list("/api/todos/archived"); len(got) != 0 {
+		t.Errorf("取消归档后归档列表 = %v，期望为空", got)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/archived?archived=false", ""), http.StatusBadRequest)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/archived", ""), http.StatusMethodNotAllowed)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/9/archive", ""), http.StatusNotFound)
+	expectStatus(t, serve(t, h, http.MethodPut, "/api/todos/1/archive", ""), http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// 批量操作中单项的处理结果
+const (
+	BulkStatusOK    = "ok"
+	BulkStatusError = "error"
+)
+
+// BulkItemResult 批量操作中单项的结果，Index 为该项在请求中的位置（从 0 开始）
+type BulkItemResult struct {
+	Index  int           `json:"index"`
+	ID     models.ID     `json:"id,omitempty"`
+	Status string        `json:"status"`
+	Error  string        `json:"error,omitempty"`
+	Todo   *TodoResponse `json:"todo,omitempty"`
+}
+
+// BulkResponse 批量操作的统一响应结构
+type BulkResponse struct {
+	Results []BulkItemResult `json:"results"`
+}
+
+// bulkOK 生成成功项的结果
+func (h *TodoHandler) bulkOK(index int, todo *models.Todo) BulkItemResult {
+	return BulkItemResult{Index: index, ID: todo.ID, Status: BulkStatusOK, Todo: h.todoResponse(todo)}
+}
+
+// bulkError 生成失败项的结果，错误信息与单项接口保持一致
+func bulkError(index int, id models.ID, err error, fallback string) BulkItemResult {
+	_, message := storageErrorStatus(err, fallback)
+	return BulkItemResult{Index: index, ID: id, Status: BulkStatusError, Error: message}
+}
+
+// writeBulkResponse 写入批量操作结果：全部成功时返回 200，存在失败项时返回 207 Multi-Status
+func writeBulkResponse(w http.ResponseWriter, results []BulkItemResult) {
+	statusCode := http.StatusOK
+	for _, result := range results {
+		if result.Status != BulkStatusOK {
+			statusCode = http.StatusMultiStatus
+			break
+		}
+	}
+	writeJSONResponse(w, statusCode, BulkResponse{Results: results})
+}
+
+// checkBatchSize 校验批量请求的条目数不超过 MaxBatchSize，所有批量接口都应在执行前调用
+func (h *TodoHandler) checkBatchSize(n int) error {
+	if n > h.config.MaxBatchSize {
+		return fmt.Errorf("单次批量操作最多 %d 项，实际为 %d 项", h.config.MaxBatchSize, n)
+	}
+	return nil
+}
+
+// handleBatchDue 处理批量设置截止时间，不存在的ID会在对应项的结果中报告错误
+func (h *TodoHandler) handleBatchDue(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchDueRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.checkBatchSize(len(req.IDs)); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	due := req.DueAt(h.config.Clock())
+	var results []BulkItemResult
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		// 事务重试时 fn 会再次执行，每次都从头收集结果
+		results = make([]BulkItemResult, 0, len(req.IDs))
+		for i, id := range req.IDs {
+			todo, err := tx.Update(r.Context(), int(id), &models.UpdateTodoRequest{DueDate: &due})
+			if errors.Is(err, storage.ErrTodoNotFound) {
+				results = append(results, bulkError(i, id, err, "设置截止时间失败"))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			results = append(results, h.bulkOK(i, todo))
+		}
+		return nil
+	})
+	if err != nil {
+		writeStorageError(w, err, "批量设置截止时间失败")
+		return
+	}
+
+	for _, result := range results {
+		if result.Todo != nil {
+			h.publishTodo(EventUpdated, &result.Todo.Todo)
+		}
+	}
+	writeBulkResponse(w, results)
+}
+
+// handleBulkCreate 处理批量创建待办事项。校验失败的项在结果中报告错误，其余项在存储支持事务时于同一事务中创建，
+// 写入出错时全部回滚；开启外部ID唯一性检查时，与已有数据或同批其他项重复的项报告冲突
+func (h *TodoHandler) handleBulkCreate(w http.ResponseWriter, r *http.Request) {
+	var reqs []*models.CreateTodoRequest
+	if !decodeJSONBody(w, r, &reqs) {
+		return
+	}
+	if len(reqs) == 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "请求体必须为非空的待办事项数组")
+		return
+	}
+	if err := h.checkBatchSize(len(reqs)); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results := make([]BulkItemResult, len(reqs))
+	valid := make([]int, 0, len(reqs))
+	for i, req := range reqs {
+		if req == nil {
+			results[i] = bulkError(i, 0, &models.ValidationError{Field: "todo", Message: "待办事项不能为空"}, "创建待办事项失败")
+			continue
+		}
+		if h.config.NormalizeTags {
+			req.Tags = models.NormalizeTags(req.Tags)
+		}
+		if err := req.Validate(); err != nil {
+			results[i] = bulkError(i, 0, err, "创建待办事项失败")
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		for _, i := range valid {
+			err := h.checkExternalID(r.Context(), tx, reqs[i].ExternalID, 0)
+			if errors.Is(err, storage.ErrConflict) {
+				results[i] = bulkError(i, 0, err, "创建待办事项失败")
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			todo, err := tx.Create(r.Context(), reqs[i])
+			if err != nil {
+				return err
+			}
+			results[i] = h.bulkOK(i, todo)
+		}
+		return nil
+	})
+	if err != nil {
+		writeStorageError(w, err, "批量创建待办事项失败")
+		return
+	}
+
+	for _, result := range results {
+		if result.Todo != nil {
+			h.publishTodo(EventCreated, &result.Todo.Todo)
+		}
+	}
+	writeBulkResponse(w, results)
+}
+
+// handleBulkUpdate 处理批量更新，将同一份更新应用到每个ID，存储支持事务时在同一事务中更新；
+// 不存在的ID在对应项的结果中报告错误
+func (h *TodoHandler) handleBulkUpdate(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkUpdateRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Update != nil && h.config.NormalizeTags && req.Update.Tags != nil {
+		tags := models.NormalizeTags(*req.Update.Tags)
+		req.Update.Tags = &tags
+	}
+	if err := req.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.checkBatchSize(len(req.IDs)); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var results []BulkItemResult
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		results = make([]BulkItemResult, 0, len(req.IDs))
+		for i, id := range req.IDs {
+			todo, err := tx.Update(r.Context(), int(id), req.Update)
+			if errors.Is(err, storage.ErrTodoNotFound) {
+				results = append(results, bulkError(i, id, err, "更新待办事项失败"))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			results = append(results, h.bulkOK(i, todo))
+		}
+		return nil
+	})
+	if err != nil {
+		writeStorageError(w, err, "批量更新待办事项失败")
+		return
+	}
+
+	for _, result := range results {
+		if result.Todo != nil {
+			h.publishTodo(EventUpdated, &result.Todo.Todo)
+		}
+	}
+	writeBulkResponse(w, results)
+}
+
+// BulkDeleteResponse 批量删除的响应结构，ID按请求中的顺序排列
+type BulkDeleteResponse struct {
+	Deleted  []models.ID `json:"deleted"`
+	NotFound []models.ID `json:"not_found"`
+}
+
+// bulkDeleteIDs 从 ids 查询参数或 {"ids": [...]} 请求体中读取要删除的ID并去重，失败时写入错误响应并返回 false
+func (h *TodoHandler) bulkDeleteIDs(w http.ResponseWriter, r *http.Request) ([]int, bool) {
+	if v := r.URL.Query().Get("ids"); v != "" {
+		ids, err := h.parseIDList(v)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		return ids, true
+	}
+	if r.ContentLength == 0 {
+		// 不允许不带条件的 DELETE /api/todos 误删全部数据
+		writeErrorResponse(w, http.StatusBadRequest, "需要通过 ids 参数或请求体指定要删除的ID")
+		return nil, false
+	}
+
+	var req models.BulkDeleteRequest
+	if !decodeJSONBody(w, r, &req) {
+		return nil, false
+	}
+	if err := req.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if err := h.checkBatchSize(len(req.IDs)); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	ids := make([]int, 0, len(req.IDs))
+	seen := make(map[models.ID]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, int(id))
+		}
+	}
+	return ids, true
+}
+
+// handleBulkDelete 处理批量删除，存储支持事务时在同一事务中删除；不存在的ID列在 not_found 中，不视为错误
+func (h *TodoHandler) handleBulkDelete(w http.ResponseWriter, r *http.Request) {
+	ids, ok := h.bulkDeleteIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var response BulkDeleteResponse
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		// 事务重试时 fn 会再次执行，每次都从头统计
+		response = BulkDeleteResponse{Deleted: []models.ID{}, NotFound: []models.ID{}}
+		for _, id := range ids {
+			err := tx.Delete(r.Context(), id)
+			if errors.Is(err, storage.ErrTodoNotFound) {
+				response.NotFound = append(response.NotFound, models.ID(id))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			response.Deleted = append(response.Deleted, models.ID(id))
+		}
+		return nil
+	})
+	if err != nil {
+		writeStorageError(w, err, "批量删除待办事项失败")
+		return
+	}
+
+	for _, id := range response.Deleted {
+		h.events.Publish(TodoEvent{Type: EventDeleted, ID: id})
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
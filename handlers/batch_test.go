@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+func TestBatchDue(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want time.Time
+	}{
+		{"相对时长", `{"ids": [1, 3], "due_in": "72h"}`, testNow.Add(72 * time.Hour)},
+		{"相对时长按分钟", `{"ids": [1, 3], "due_in": "90m"}`, testNow.Add(90 * time.Minute)},
+		{"绝对时间", `{"ids": [1, 3], "due_date": "2024-07-01T09:00:00+08:00"}`, time.Date(2024, 7, 1, 1, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t)
+			mustCreateTitled(t, h, "a", "b", "c")
+
+			rec := serve(t, h, http.MethodPut, "/api/todos/batch/due", tt.body)
+			expectStatus(t, rec, http.StatusOK)
+			for _, result := range decodeResponse[BulkResponse](t, rec).Results {
+				if result.Status != BulkStatusOK || result.Todo == nil || !result.Todo.DueDate.Equal(tt.want) {
+					t.Errorf("结果 = %+v，期望截止时间 %v", result, tt.want)
+				}
+			}
+
+			for _, todo := range decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", "")) {
+				switch {
+				case todo.ID == 2 && todo.DueDate != nil:
+					t.Errorf("未列出的待办事项 2 被设置了截止时间 %v", todo.DueDate)
+				case todo.ID != 2 && (todo.DueDate == nil || !todo.DueDate.Equal(tt.want)):
+					t.Errorf("待办事项 %d 的截止时间 = %v，期望 %v", todo.ID, todo.DueDate, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchDueMissingIDs(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b")
+
+	rec := serve(t, h, http.MethodPut, "/api/todos/batch/due", `{"ids": [1, 9, 2], "due_in": "24h"}`)
+	expectStatus(t, rec, http.StatusMultiStatus)
+	results := decodeResponse[BulkResponse](t, rec).Results
+	if len(results) != 3 {
+		t.Fatalf("结果数 = %d，期望 3", len(results))
+	}
+	for i, want := range []string{BulkStatusOK, BulkStatusError, BulkStatusOK} {
+		if results[i].Index != i || results[i].Status != want {
+			t.Errorf("第 %d 项结果 = %+v，期望状态 %s", i, results[i], want)
+		}
+	}
+	if results[1].ID != 9 || results[1].Error != "待办事项未找到" {
+		t.Errorf("不存在的ID结果 = %+v，期望报告 9 未找到", results[1])
+	}
+}
+
+func TestBatchDueValidation(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a")
+
+	for _, body := range []string{
+		`{"ids": [], "due_in": "24h"}`,
+		`{"ids": [1]}`,
+		`{"ids": [1], "due_in": "24h", "due_date": "2024-07-01T00:00:00Z"}`,
+		`{"ids": [1], "due_in": "3 days"}`,
+		`{"ids": [1], "due_in": "-1h"}`,
+		`{"ids": [1], "due_date": "2024-07-01"}`,
+	} {
+		rec := serve(t, h, http.MethodPut, "/api/todos/batch/due", body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s 状态码 = %d，期望 400", body, rec.Code)
+		}
+	}
+	if todo := decodeResponse[models.Todo](t, serve(t, h, http.MethodGet, "/api/todos/1", "")); todo.DueDate != nil {
+		t.Errorf("校验失败后截止时间 = %v，期望未设置", todo.DueDate)
+	}
+}
+
+func TestBatchSizeLimit(t *testing.T) {
+	const limit = 3
+	// 各批量接口的请求体，n 为条目数
+	endpoints := []struct {
+		name, method, target string
+		body                 func(n int) string
+	}{
+		{"批量创建", http.MethodPost, "/api/todos/bulk", func(n int) string {
+			return "[" + strings.Repeat(`{"title": "t"},`, n-1) + `{"title": "t"}]`
+		}},
+		{"批量更新", http.MethodPatch, "/api/todos/bulk", func(n int) string {
+			return `{"ids": ` + idList(n) + `, "update": {"completed": true}}`
+		}},
+		{"批量设置截止时间", http.MethodPut, "/api/todos/batch/due", func(n int) string {
+			return `{"ids": ` + idList(n) + `, "due_in": "24h"}`
+		}},
+		{"手动排序", http.MethodPost, "/api/todos/reorder", func(n int) string {
+			return `{"ids": ` + idList(n) + `}`
+		}},
+		{"批量删除", http.MethodDelete, "/api/todos", func(n int) string {
+			return `{"ids": ` + idList(n) + `}`
+		}},
+		{"CSV 导入", http.MethodPost, "/api/todos/import?format=csv", func(n int) string {
+			return "title\n" + strings.Repeat("t\n", n)
+		}},
+	}
+	for _, tt := range endpoints {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, func(c *Config) { c.MaxBatchSize = limit })
+			mustCreateTitled(t, h, "a", "b", "c", "d")
+
+			rec := serve(t, h, tt.method, tt.target, tt.body(limit+1))
+			expectStatus(t, rec, http.StatusBadRequest)
+			if got, want := errorMessage(t, rec), fmt.Sprintf("单次批量操作最多 %d 项，实际为 %d 项", limit, limit+1); got != want {
+				t.Errorf("错误信息 = %q，期望 %q", got, want)
+			}
+			if got := len(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))); got != 4 {
+				t.Errorf("超出上限被拒绝后共 %d 项，期望保持 4 项", got)
+			}
+
+			if rec := serve(t, h, tt.method, tt.target, tt.body(limit)); rec.Code >= 300 {
+				t.Errorf("恰好 %d 项时状态码 = %d，期望成功，响应: %s", limit, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDefaultBatchSizeLimit(t *testing.T) {
+	h := newTestHandler(t)
+	rec := serve(t, h, http.MethodPut, "/api/todos/batch/due", `{"ids": `+idList(DefaultMaxBatchSize+1)+`, "due_in": "1h"}`)
+	expectStatus(t, rec, http.StatusBadRequest)
+	// 恰好达到默认上限时通过校验，不存在的ID在结果中逐项报告
+	expectStatus(t, serve(t, h, http.MethodPut, "/api/todos/batch/due", `{"ids": `+idList(DefaultMaxBatchSize)+`, "due_in": "1h"}`), http.StatusMultiStatus)
+}
+
+// idList 返回 [1, 2, ..., n] 的 JSON 数组
+func idList(n int) string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i + 1)
+	}
+	return "[" + strings.Join(ids, ", ") + "]"
+}
+
+// bulkStatuses 返回各项结果的状态，并检查 Index 与位置一致
+func bulkStatuses(t *testing.T, results []BulkItemResult) []string {
+	t.Helper()
+	statuses := make([]string, len(results))
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("第 %d 项的 index = %d", i, result.Index)
+		}
+		if (result.Status == BulkStatusError) != (result.Error != "") {
+			t.Errorf("第 %d 项状态 %s 与错误信息 %q 不一致", i, result.Status, result.Error)
+		}
+		statuses[i] = result.Status
+	}
+	return statuses
+}
+
+func TestBulkCreateMixed(t *testing.T) {
+	h := newTestHandler(t)
+	rec := serve(t, h, http.MethodPost, "/api/todos/bulk", `[{"title": "a"}, {"title": ""}, null, {"title": "b", "priority": "urgent"}, {"title": "c", "color": "pink"}]`)
+	expectStatus(t, rec, http.StatusMultiStatus)
+
+	results := decodeResponse[BulkResponse](t, rec).Results
+	want := []string{BulkStatusOK, BulkStatusError, BulkStatusError, BulkStatusOK, BulkStatusError}
+	if got := bulkStatuses(t, results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("各项状态 = %v，期望 %v", got, want)
+	}
+	if results[0].ID != 1 || results[0].Todo == nil || results[3].ID != 2 || results[3].Todo.Priority != models.PriorityUrgent {
+		t.Errorf("成功项 = %+v、%+v，期望依次创建为 1、2", results[0], results[3])
+	}
+	if results[1].Error != "标题不能为空" || results[1].Todo != nil {
+		t.Errorf("校验失败项 = %+v", results[1])
+	}
+	if got := len(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))); got != 2 {
+		t.Errorf("共 %d 项，期望只创建 2 项有效的", got)
+	}
+
+	// 全部成功时返回 200
+	rec = serve(t, h, http.MethodPost, "/api/todos/bulk", `[{"title": "d"}, {"title": "e"}]`)
+	expectStatus(t, rec, http.StatusOK)
+	if got := bulkStatuses(t, decodeResponse[BulkResponse](t, rec).Results); !reflect.DeepEqual(got, []string{BulkStatusOK, BulkStatusOK}) {
+		t.Errorf("各项状态 = %v，期望全部为 ok", got)
+	}
+}
+
+func TestBulkUpdateMixed(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b")
+
+	rec := serve(t, h, http.MethodPatch, "/api/todos/bulk", `{"ids": [2, 7, 1], "update": {"completed": true}}`)
+	expectStatus(t, rec, http.StatusMultiStatus)
+	results := decodeResponse[BulkResponse](t, rec).Results
+	if got := bulkStatuses(t, results); !reflect.DeepEqual(got, []string{BulkStatusOK, BulkStatusError, BulkStatusOK}) {
+		t.Fatalf("各项状态 = %v", got)
+	}
+	if results[1].ID != 7 || results[1].Error != "待办事项未找到" {
+		t.Errorf("不存在的项 = %+v，期望报告 7 未找到", results[1])
+	}
+	for _, result := range []BulkItemResult{results[0], results[2]} {
+		if result.Todo == nil || !result.Todo.Completed {
+			t.Errorf("成功项 = %+v，期望已标记完成", result)
+		}
+	}
+
+	// 更新内容校验失败时整个请求返回 400
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/bulk", `{"ids": [1], "update": {"title": ""}}`), http.StatusBadRequest)
+}
+
+func TestBulkDelete(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c", "d", "e")
+	events := h.events.Subscribe()
+	defer h.events.Unsubscribe(events)
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		want   BulkDeleteResponse
+	}{
+		{"ids 参数", "/api/todos?ids=2,9,4", "", BulkDeleteResponse{Deleted: []models.ID{2, 4}, NotFound: []models.ID{9}}},
+		// 请求体中重复的ID只删除一次，已删除的列在 not_found 中
+		{"请求体", "/api/todos", `{"ids": [3, 3, 2]}`, BulkDeleteResponse{Deleted: []models.ID{3}, NotFound: []models.ID{2}}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, http.MethodDelete, tt.target, tt.body)
+		expectStatus(t, rec, http.StatusOK)
+		if got := decodeResponse[BulkDeleteResponse](t, rec); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: 响应 = %+v，期望 %+v", tt.name, got, tt.want)
+		}
+		if got := drainEvents(events); !reflect.DeepEqual(got, tt.want.Deleted) {
+			t.Errorf("%s: 删除事件 = %v，期望 %v", tt.name, got, tt.want.Deleted)
+		}
+	}
+
+	todos := decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))
+	if got := todoIDs(todos); !reflect.DeepEqual(got, []models.ID{1, 5}) {
+		t.Errorf("剩余 = %v，期望 [1 5]", got)
+	}
+
+	// 不带条件的 DELETE 不会删除全部数据
+	for _, tt := range []struct{ target, body string }{
+		{"/api/todos", ""},
+		{"/api/todos", `{"ids": []}`},
+		{"/api/todos", `{"ids": [0]}`},
+		{"/api/todos?ids=a", ""},
+	} {
+		expectStatus(t, serve(t, h, http.MethodDelete, tt.target, tt.body), http.StatusBadRequest)
+	}
+	if got := len(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))); got != 2 {
+		t.Errorf("错误请求后剩余 %d 项，期望 2", got)
+	}
+}
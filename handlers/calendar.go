@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-todolist/models"
+)
+
+// icalTimeFormat iCalendar 中 UTC 时间的格式
+const icalTimeFormat = "20060102T150405Z"
+
+// icalMaxLineLength iCalendar 单行的最大字节数，超出部分需要折行（RFC 5545 3.1）
+const icalMaxLineLength = 75
+
+// icalPriorities 优先级与 iCalendar PRIORITY 值（1 最高，9 最低）的对应关系
+var icalPriorities = map[models.Priority]int{
+	models.PriorityUrgent: 1,
+	models.PriorityHigh:   3,
+	models.PriorityMedium: 5,
+	models.PriorityLow:    9,
+}
+
+// handleGetCalendar 处理导出 iCalendar 订阅，仅包含设置了截止时间的待办事项
+func (h *TodoHandler) handleGetCalendar(w http.ResponseWriter, r *http.Request) {
+	todos, err := h.storage.GetAll(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "导出日历失败")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="todos.ics"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(renderCalendar(todos, h.config.Clock())))
+}
+
+// renderCalendar 将待办事项渲染为 VCALENDAR，每个有截止时间的待办事项对应一个 VTODO
+func renderCalendar(todos []*models.Todo, now time.Time) string {
+	var b strings.Builder
+	writeLine := func(name, value string) {
+		b.WriteString(foldICalLine(name + ":" + value))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN", "VCALENDAR")
+	writeLine("VERSION", "2.0")
+	writeLine("PRODID", "-//go-todolist//Todos//ZH")
+	writeLine("CALSCALE", "GREGORIAN")
+	for _, todo := range todos {
+		if todo.DueDate == nil {
+			continue
+		}
+
+		writeLine("BEGIN", "VTODO")
+		writeLine("UID", fmt.Sprintf("todo-%d@go-todolist", todo.ID))
+		writeLine("DTSTAMP", now.UTC().Format(icalTimeFormat))
+		writeLine("CREATED", todo.CreatedAt.UTC().Format(icalTimeFormat))
+		writeLine("LAST-MODIFIED", todo.UpdatedAt.UTC().Format(icalTimeFormat))
+		writeLine("DUE", todo.DueDate.UTC().Format(icalTimeFormat))
+		writeLine("SUMMARY", escapeICalText(todo.Title))
+		if todo.Description != "" {
+			writeLine("DESCRIPTION", escapeICalText(todo.Description))
+		}
+		if priority, ok := icalPriorities[todo.Priority]; ok {
+			writeLine("PRIORITY", fmt.Sprint(priority))
+		}
+		if todo.Completed {
+			writeLine("STATUS", "COMPLETED")
+			writeLine("PERCENT-COMPLETE", "100")
+		} else {
+			writeLine("STATUS", "NEEDS-ACTION")
+		}
+		writeLine("END", "VTODO")
+	}
+	writeLine("END", "VCALENDAR")
+
+	return b.String()
+}
+
+// escapeICalText 按 RFC 5545 转义 TEXT 类型的值
+func escapeICalText(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return replacer.Replace(s)
+}
+
+// foldICalLine 将超过 75 字节的行折叠为多行，续行以空格开头，且不会拆分多字节字符
+func foldICalLine(line string) string {
+	if len(line) <= icalMaxLineLength {
+		return line
+	}
+
+	var b strings.Builder
+	limit := icalMaxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// 续行开头的空格占用一个字节
+		limit = icalMaxLineLength - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// parseVTodos 展开折行后解析出每个 VTODO 的属性，属性值按 RFC 5545 反转义
+func parseVTodos(t *testing.T, ics string) []map[string]string {
+	t.Helper()
+	if !strings.HasSuffix(ics, "\r\n") {
+		t.Fatalf("日历未以 CRLF 结尾: %q", ics)
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > icalMaxLineLength {
+			t.Errorf("行长度 %d 超过 %d 字节: %q", len(line), icalMaxLineLength, line)
+		}
+	}
+
+	unescape := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n")
+	var todos []map[string]string
+	var current map[string]string
+	for _, line := range strings.Split(strings.ReplaceAll(ics, "\r\n ", ""), "\r\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch {
+		case line == "BEGIN:VTODO":
+			current = map[string]string{}
+		case line == "END:VTODO":
+			todos = append(todos, current)
+			current = nil
+		case current != nil:
+			current[name] = unescape.Replace(value)
+		}
+	}
+	return todos
+}
+
+func TestGetCalendar(t *testing.T) {
+	h := newTestHandler(t)
+	description := "第一行; 包含, 特殊字符\\\n第二行" + strings.Repeat("很长的描述", 10)
+	mustCreate(t, h, fmt.Sprintf(`{"title": "提交报告, 周五前", "priority": "high", "due_date": "2024-06-20T09:30:00+08:00", "description": %q}`, description))
+	mustCreateTitled(t, h, "没有截止时间")
+	mustCreate(t, h, `{"title": "已完成", "due_date": "2024-06-10T00:00:00Z"}`)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/3", `{"completed": true}`), http.StatusOK)
+
+	rec := serve(t, h, http.MethodGet, "/api/todos/calendar.ics", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "text/calendar; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") || !strings.HasSuffix(body, "END:VCALENDAR\r\n") {
+		t.Errorf("缺少 VCALENDAR 包装: %q", body)
+	}
+
+	todos := parseVTodos(t, body)
+	if len(todos) != 2 {
+		t.Fatalf("VTODO 数量 = %d，期望 2（没有截止时间的不导出）", len(todos))
+	}
+	want := map[string]string{
+		"UID":         "todo-1@go-todolist",
+		"SUMMARY":     "提交报告, 周五前",
+		"DESCRIPTION": description,
+		"DUE":         "20240620T013000Z",
+		"DTSTAMP":     "20240615T120000Z",
+		"PRIORITY":    "3",
+		"STATUS":      "NEEDS-ACTION",
+	}
+	for name, value := range want {
+		if got := todos[0][name]; got != value {
+			t.Errorf("%s = %q，期望 %q", name, got, value)
+		}
+	}
+	if todos[1]["UID"] != "todo-3@go-todolist" || todos[1]["STATUS"] != "COMPLETED" || todos[1]["PERCENT-COMPLETE"] != "100" {
+		t.Errorf("已完成的 VTODO = %v，期望 STATUS:COMPLETED", todos[1])
+	}
+}
+
+func TestFoldICalLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("中", 40)
+	folded := foldICalLine(line)
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > icalMaxLineLength {
+			t.Errorf("折行后长度 %d 超过 %d", len(part), icalMaxLineLength)
+		}
+	}
+	if got := strings.ReplaceAll(folded, "\r\n ", ""); got != line {
+		t.Errorf("展开后 = %q，期望 %q", got, line)
+	}
+}
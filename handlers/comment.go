@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"go-todolist/models"
+)
+
+// handleAddComment 处理为待办事项添加备注
+func (h *TodoHandler) handleAddComment(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.CreateCommentRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comment, err := h.storage.AddComment(r.Context(), id, &req)
+	if err != nil {
+		writeStorageError(w, err, "添加备注失败")
+		return
+	}
+
+	h.publishTodoChanged(r.Context(), id)
+	writeJSONResponse(w, http.StatusCreated, comment)
+}
+
+// handleDeleteComment 处理删除待办事项的备注
+func (h *TodoHandler) handleDeleteComment(w http.ResponseWriter, r *http.Request, id, commentID int) {
+	if err := h.storage.DeleteComment(r.Context(), id, commentID); err != nil {
+		writeStorageError(w, err, "删除备注失败")
+		return
+	}
+
+	h.publishTodoChanged(r.Context(), id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publishTodoChanged 读取待办事项的最新状态并广播更新事件
+func (h *TodoHandler) publishTodoChanged(ctx context.Context, id int) {
+	if todo, err := h.storage.GetByID(ctx, id); err == nil {
+		h.publishTodo(EventUpdated, todo)
+	}
+}
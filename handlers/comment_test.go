@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-todolist/models"
+)
+
+func TestComments(t *testing.T) {
+	h := newTestHandler(t)
+	todo := mustCreateTitled(t, h, "a")[0]
+
+	var added []models.Comment
+	for _, body := range []string{"第一条", "第二条"} {
+		rec := serve(t, h, http.MethodPost, "/api/todos/1/comments", `{"body": "`+body+`"}`)
+		expectStatus(t, rec, http.StatusCreated)
+		comment := decodeResponse[models.Comment](t, rec)
+		if comment.Body != body || comment.CreatedAt.IsZero() {
+			t.Errorf("添加的备注 = %+v，期望内容 %q 且有创建时间", comment, body)
+		}
+		added = append(added, comment)
+	}
+	if added[0].ID == added[1].ID {
+		t.Errorf("两条备注的ID相同: %d", added[0].ID)
+	}
+
+	got := decodeResponse[models.Todo](t, serve(t, h, http.MethodGet, "/api/todos/1", ""))
+	if !reflect.DeepEqual(got.Comments, added) {
+		t.Errorf("待办事项的备注 = %+v，期望 %+v", got.Comments, added)
+	}
+	// 备注不视为对待办事项本身的修改
+	if !got.UpdatedAt.Equal(todo.UpdatedAt) {
+		t.Errorf("添加备注后 updated_at = %v，期望保持 %v", got.UpdatedAt, todo.UpdatedAt)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/1/comments/"+strconv.Itoa(int(added[0].ID)), ""), http.StatusNoContent)
+	got = decodeResponse[models.Todo](t, serve(t, h, http.MethodGet, "/api/todos/1", ""))
+	if !reflect.DeepEqual(got.Comments, added[1:]) {
+		t.Errorf("删除后的备注 = %+v，期望 %+v", got.Comments, added[1:])
+	}
+	if !got.UpdatedAt.Equal(todo.UpdatedAt) {
+		t.Errorf("删除备注后 updated_at = %v，期望保持 %v", got.UpdatedAt, todo.UpdatedAt)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/1/comments/"+strconv.Itoa(int(added[0].ID)), ""), http.StatusNotFound)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/9/comments", `{"body": "x"}`), http.StatusNotFound)
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/9/comments/1", ""), http.StatusNotFound)
+}
+
+func TestCommentValidation(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a")
+
+	limit := models.CurrentLimits().CommentLength
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/1/comments", `{"body": "`+strings.Repeat("x", limit)+`"}`), http.StatusCreated)
+	for _, body := range []string{`{"body": ""}`, `{"body": "   "}`, `{"body": "` + strings.Repeat("x", limit+1) + `"}`} {
+		expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/1/comments", body), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// CompleteResponse 按条件批量完成待办事项的响应结构
+type CompleteResponse struct {
+	Completed int `json:"completed"`
+}
+
+// handleCompleteMatching 将符合过滤条件的未完成待办事项全部标记为完成。
+// 过滤参数与列表接口相同；为避免误操作，没有任何过滤条件时需显式指定 all=true
+func (h *TodoHandler) handleCompleteMatching(w http.ResponseWriter, r *http.Request) {
+	options, filters, err := h.parseListFilters(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !options.HasFilters() && len(filters) == 0 && r.URL.Query().Get("all") != "true" {
+		writeErrorResponse(w, http.StatusBadRequest, "至少需要一个过滤条件，完成全部待办事项请指定 all=true")
+		return
+	}
+
+	completed := true
+	var updated []*models.Todo
+	err = h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		// 事务重试时 fn 会再次执行，每次都从头收集结果
+		updated = nil
+		todos, _, err := storage.List(r.Context(), tx, options)
+		if err != nil {
+			return err
+		}
+		for _, todo := range filterTodos(todos, filters) {
+			if todo.Completed {
+				continue
+			}
+			done, err := tx.Update(r.Context(), int(todo.ID), &models.UpdateTodoRequest{Completed: &completed})
+			if err != nil {
+				return err
+			}
+			updated = append(updated, done)
+		}
+		return nil
+	})
+	if err != nil {
+		writeStorageError(w, err, "批量完成待办事项失败")
+		return
+	}
+
+	for _, todo := range updated {
+		h.publishTodo(EventUpdated, todo)
+	}
+	writeJSONResponse(w, http.StatusOK, CompleteResponse{Completed: len(updated)})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go-todolist/models"
+)
+
+func TestCompleteMatching(t *testing.T) {
+	h := newTestHandler(t)
+	for _, body := range []string{
+		`{"title": "a", "tags": ["project-x"]}`,
+		`{"title": "b", "tags": ["project-y"]}`,
+		`{"title": "c", "tags": ["project-x", "urgent"]}`,
+		`{"title": "d"}`,
+		`{"title": "e", "tags": ["project-x"]}`,
+	} {
+		mustCreate(t, h, body)
+	}
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/5", `{"completed": true}`), http.StatusOK)
+
+	complete := func(target string, want int) {
+		t.Helper()
+		rec := serve(t, h, http.MethodPost, target, "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := decodeResponse[CompleteResponse](t, rec).Completed; got != want {
+			t.Errorf("POST %s 完成了 %d 项，期望 %d", target, got, want)
+		}
+	}
+	pending := func() []models.ID {
+		t.Helper()
+		return todoIDs(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos?completed=false", "")))
+	}
+
+	// 只完成匹配且尚未完成的待办事项
+	complete("/api/todos/complete?tag=project-x", 2)
+	if got := pending(); !reflect.DeepEqual(got, []models.ID{2, 4}) {
+		t.Errorf("未完成 = %v，期望 [2 4]", got)
+	}
+	complete("/api/todos/complete?tag=project-x", 0)
+
+	// 没有过滤条件时拒绝，空值不算过滤条件
+	for _, target := range []string{"/api/todos/complete", "/api/todos/complete?tag=", "/api/todos/complete?all=false"} {
+		rec := serve(t, h, http.MethodPost, target, "")
+		expectStatus(t, rec, http.StatusBadRequest)
+		if got, want := errorMessage(t, rec), "至少需要一个过滤条件，完成全部待办事项请指定 all=true"; got != want {
+			t.Errorf("POST %s 错误信息 = %q，期望 %q", target, got, want)
+		}
+	}
+	if got := pending(); !reflect.DeepEqual(got, []models.ID{2, 4}) {
+		t.Errorf("被拒绝后未完成 = %v，期望 [2 4]", got)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/complete?priority=unknown", ""), http.StatusBadRequest)
+
+	complete("/api/todos/complete?all=true", 2)
+	if got := pending(); len(got) != 0 {
+		t.Errorf("all=true 后未完成 = %v，期望为空", got)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// 默认配置值
+const (
+	DefaultMaxLimit          = 100
+	DefaultCapacitySoftLimit = 10000
+	DefaultMaxBatchSize      = 500
+)
+
+// Config 处理器配置
+type Config struct {
+	// MaxLimit 列表接口 limit 参数允许的最大值，也是游标分页的默认每页数量
+	MaxLimit int
+	// RejectOverLimit 为 true 时超过上限的 limit 返回 400，否则截断为上限
+	RejectOverLimit bool
+	// Clock 返回当前时间，测试时可替换为固定时钟
+	Clock func() time.Time
+	// RandIntn 返回 [0, n) 内的随机整数，测试时可替换为固定种子的随机源
+	RandIntn func(n int) int
+	// Location 计算“今天”等日期边界时默认使用的时区，可被请求的 tz 参数覆盖
+	Location *time.Location
+	// AdminKey 管理接口的访问密钥，为空时管理接口不可用
+	AdminKey string
+	// CapacitySoftLimit 待办事项数量的软上限，仅用于统计接口中的容量提醒
+	CapacitySoftLimit int
+	// NormalizeTags 为 true 时创建和更新的标签会去除首尾空白并转为小写、去重，
+	// 按标签过滤时也忽略大小写；为 false 时保留原始写法
+	NormalizeTags bool
+	// MaxBatchSize 批量接口单次请求允许的最大条目数
+	MaxBatchSize int
+	// StrictQuery 为 true 时列表接口遇到未知的查询参数返回 400，否则忽略
+	StrictQuery bool
+	// UniqueExternalIDs 为 true 时创建和更新会拒绝已被其他待办事项使用的非空外部ID（409）
+	UniqueExternalIDs bool
+	// CreateDedupWindow 大于 0 时，该时间窗口内内容完全相同的创建请求只创建一次，重复请求返回首次创建的待办事项
+	CreateDedupWindow time.Duration
+	// ReadOnly 为 true 时拒绝所有修改数据的请求（403），只提供查询
+	ReadOnly bool
+}
+
+// DefaultConfig 返回默认的处理器配置
+func DefaultConfig() Config {
+	return Config{
+		MaxLimit: DefaultMaxLimit,
+		Clock:    time.Now,
+		RandIntn: rand.IntN,
+		Location: time.Local,
+
+		CapacitySoftLimit: DefaultCapacitySoftLimit,
+		MaxBatchSize:      DefaultMaxBatchSize,
+	}
+}
+
+// withDefaults 为未设置的配置项填充默认值
+func (c Config) withDefaults() Config {
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = DefaultMaxLimit
+	}
+	if c.Clock == nil {
+		c.Clock = time.Now
+	}
+	if c.RandIntn == nil {
+		c.RandIntn = rand.IntN
+	}
+	if c.CapacitySoftLimit <= 0 {
+		c.CapacitySoftLimit = DefaultCapacitySoftLimit
+	}
+	if c.MaxBatchSize <= 0 {
+		c.MaxBatchSize = DefaultMaxBatchSize
+	}
+	if c.Location == nil {
+		c.Location = time.Local
+	}
+	return c
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// CountResponse 数量接口的响应结构
+type CountResponse struct {
+	Count int `json:"count"`
+}
+
+// countQueryParams 数量接口支持的查询参数：列表接口的全部过滤参数、filter 和 tz
+var countQueryParams = func() map[string]bool {
+	params := map[string]bool{"tz": true, "filter": true}
+	for name, field := range listFields {
+		if field.option != nil || field.filter != nil {
+			params[name] = true
+		}
+	}
+	return params
+}()
+
+// handleGetCount 处理统计满足过滤条件的待办事项数量，过滤参数与列表接口相同，同样默认不统计归档的待办事项。
+// 只有存储能执行的条件时由存储统计，不读取待办事项
+func (h *TodoHandler) handleGetCount(w http.ResponseWriter, r *http.Request) {
+	if h.config.StrictQuery {
+		if err := checkQueryParams(r.URL.Query(), countQueryParams); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	options, filters, err := h.parseListFilters(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if options.Archived == nil {
+		// 与列表接口的默认行为一致，X-Total-Count 和本接口的数量相同
+		archived := false
+		options.Archived = &archived
+	}
+
+	var count int
+	if len(filters) == 0 {
+		count, err = storage.Count(r.Context(), h.storage, options)
+	} else {
+		var todos []*models.Todo
+		todos, _, err = storage.List(r.Context(), h.storage, options)
+		count = len(filterTodos(todos, filters))
+	}
+	if err != nil {
+		writeStorageError(w, err, "统计待办事项失败")
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, CountResponse{Count: count})
+}
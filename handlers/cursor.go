@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// listCursor 游标的编码格式，解析后转换为 storage.Keyset
+type listCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        int       `json:"id"`
+}
+
+// CursorResponse 列表接口使用 cursor 参数时的响应结构，NextCursor 为空表示没有更多数据
+type CursorResponse struct {
+	Items      interface{} `json:"items"`
+	Total      int         `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// encodeCursor 生成指向 todo 之后的不透明游标
+func encodeCursor(todo *models.Todo) string {
+	data, _ := json.Marshal(listCursor{CreatedAt: todo.CreatedAt, ID: int(todo.ID)})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseCursor 解析游标，空字符串表示第一页
+func parseCursor(v string) (*storage.Keyset, error) {
+	if v == "" {
+		return &storage.Keyset{}, nil
+	}
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.ID <= 0 {
+		return nil, errors.New("cursor 无效")
+	}
+	return &storage.Keyset{CreatedAt: cursor.CreatedAt, ID: cursor.ID}, nil
+}
+
+// cursorPage 从按 (created_at, id) 升序排列的 todos 中取游标之后的 limit 项，还有更多数据时返回下一页的游标。
+// 与 offset 不同，翻页期间插入或删除的待办事项不会导致重复或遗漏
+func cursorPage(todos []*models.Todo, cursor *storage.Keyset, limit int) ([]*models.Todo, string) {
+	start := len(todos)
+	for i, todo := range todos {
+		if cursor.Precedes(todo) {
+			start = i
+			break
+		}
+	}
+	todos = todos[start:]
+	if limit <= 0 || limit >= len(todos) {
+		return todos, ""
+	}
+	return todos[:limit], encodeCursor(todos[limit-1])
+}
+
+// cursorLink 生成指向下一页的 Link 头，没有下一页时返回空字符串
+func cursorLink(u *url.URL, nextCursor string) string {
+	if nextCursor == "" {
+		return ""
+	}
+	query := u.Query()
+	query.Set("cursor", nextCursor)
+	return fmt.Sprintf(`<%s?%s>; rel="next"`, u.Path, query.Encode())
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// createDeduper 在时间窗口内识别内容相同的重复创建请求（如连点提交），返回首次创建的待办事项
+type createDeduper struct {
+	window time.Duration
+	clock  func() time.Time
+	// mutex 覆盖“检查-创建-记录”全过程，保证并发的相同请求只创建一次
+	mutex  sync.Mutex
+	recent map[[sha256.Size]byte]dedupEntry
+}
+
+// dedupEntry 记录某个请求内容最近一次创建的待办事项
+type dedupEntry struct {
+	id        int
+	createdAt time.Time
+}
+
+// newCreateDeduper 创建去重器，window <= 0 时返回 nil 表示不去重
+func newCreateDeduper(window time.Duration, clock func() time.Time) *createDeduper {
+	if window <= 0 {
+		return nil
+	}
+	return &createDeduper{
+		window: window,
+		clock:  clock,
+		recent: make(map[[sha256.Size]byte]dedupEntry),
+	}
+}
+
+// create 若窗口内已有相同内容的创建请求且该待办事项仍存在，直接返回它（duplicate 为 true）；
+// 否则调用 create 创建并记录。req 应为已规范化并通过校验的请求
+func (d *createDeduper) create(ctx context.Context, s storage.TodoStorage, req *models.CreateTodoRequest, create func() (*models.Todo, error)) (todo *models.Todo, duplicate bool, err error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, false, err
+	}
+	key := sha256.Sum256(data)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.clock()
+	for k, entry := range d.recent {
+		if now.Sub(entry.createdAt) >= d.window {
+			delete(d.recent, k)
+		}
+	}
+
+	if entry, ok := d.recent[key]; ok {
+		todo, err := s.GetByID(ctx, entry.id)
+		if err == nil {
+			return todo, true, nil
+		}
+		if !errors.Is(err, storage.ErrTodoNotFound) {
+			return nil, false, err
+		}
+	}
+
+	todo, err = create()
+	if err != nil {
+		return nil, false, err
+	}
+	d.recent[key] = dedupEntry{id: int(todo.ID), createdAt: now}
+	return todo, false, nil
+}
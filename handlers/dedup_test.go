@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+func TestCreateDedup(t *testing.T) {
+	now := testNow
+	var mu sync.Mutex
+	h := newTestHandler(t, func(c *Config) {
+		c.CreateDedupWindow = 2 * time.Second
+		c.Clock = func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		}
+	})
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+	count := func() int {
+		t.Helper()
+		return len(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", "")))
+	}
+
+	// 窗口内相同内容的请求返回首次创建的待办事项
+	first := mustCreate(t, h, `{"title": "a", "tags": ["x"]}`)
+	if again := mustCreate(t, h, `{"tags": ["x"], "title": "a"}`); again.ID != first.ID {
+		t.Errorf("重复请求创建了 %d，期望返回 %d", again.ID, first.ID)
+	}
+	// 内容不同的请求照常创建
+	if other := mustCreate(t, h, `{"title": "b", "tags": ["x"]}`); other.ID == first.ID {
+		t.Error("不同内容的请求返回了已有的待办事项")
+	}
+	if got := count(); got != 2 {
+		t.Errorf("共 %d 项，期望 2", got)
+	}
+
+	// 窗口过后或首次创建的待办事项被删除后重新创建
+	advance(2 * time.Second)
+	if again := mustCreate(t, h, `{"title": "a", "tags": ["x"]}`); again.ID == first.ID {
+		t.Error("窗口过后的请求返回了已有的待办事项")
+	}
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/3", ""), http.StatusNoContent)
+	if again := mustCreate(t, h, `{"title": "a", "tags": ["x"]}`); again.ID == 3 {
+		t.Error("删除后的请求返回了已删除的待办事项")
+	}
+
+	// 并发的相同请求只创建一次
+	advance(time.Minute)
+	before := count()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(t, h, http.MethodPost, "/api/todos", `{"title": "c"}`)
+		}()
+	}
+	wg.Wait()
+	if got := count(); got != before+1 {
+		t.Errorf("并发创建后共 %d 项，期望 %d", got, before+1)
+	}
+}
+
+func TestCreateDedupDisabled(t *testing.T) {
+	h := newTestHandler(t)
+	first := mustCreate(t, h, `{"title": "a"}`)
+	if again := mustCreate(t, h, `{"title": "a"}`); again.ID == first.ID {
+		t.Error("默认关闭去重时相同请求返回了已有的待办事项")
+	}
+}
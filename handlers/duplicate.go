@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// handleDuplicateTodo 处理复制待办事项，副本使用新的ID和时间戳。
+// 副本与原待办事项标题相同，因此不经过创建去重
+func (h *TodoHandler) handleDuplicateTodo(w http.ResponseWriter, r *http.Request, id int) {
+	source, err := h.storage.GetByID(r.Context(), id)
+	if err != nil {
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+
+	req := source.DuplicateRequest()
+	if err := req.Validate(); err != nil {
+		writeStorageError(w, err, "复制待办事项失败")
+		return
+	}
+	todo, err := h.storeTodo(r.Context(), req)
+	if err != nil {
+		writeStorageError(w, err, "复制待办事项失败")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, h.todoResponse(todo))
+}
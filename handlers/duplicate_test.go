@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go-todolist/models"
+)
+
+func TestDuplicateTodo(t *testing.T) {
+	h := newTestHandler(t)
+	source := mustCreate(t, h, `{"title": "a", "description": "d", "color": "red", "priority": "high",
+		"due_date": "2024-06-20T09:00:00Z", "remind_before": "1h", "estimate_minutes": 60, "spent_minutes": 30,
+		"tags": ["work"], "subtasks": [{"title": "x", "completed": true}, {"title": "y"}],
+		"list_id": "inbox", "external_id": "TICKET-1"}`)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/1/comments", `{"body": "note"}`), http.StatusCreated)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/1/star", ""), http.StatusOK)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", `{"completed": true}`), http.StatusOK)
+
+	rec := serve(t, h, http.MethodPost, "/api/todos/1/duplicate", "")
+	expectStatus(t, rec, http.StatusCreated)
+	copied := decodeResponse[*models.Todo](t, rec)
+
+	// 复制内容、标签、截止时间和子任务
+	if copied.ID != 2 || copied.Title != source.Title || copied.Description != source.Description ||
+		copied.Color != source.Color || copied.Priority != source.Priority || copied.ListID != source.ListID ||
+		copied.RemindBefore != source.RemindBefore || copied.EstimateMinutes != source.EstimateMinutes {
+		t.Errorf("复制的待办事项 = %+v，期望与原待办事项 %+v 内容相同", copied, source)
+	}
+	if copied.DueDate == nil || !copied.DueDate.Equal(*source.DueDate) || !reflect.DeepEqual(copied.Tags, source.Tags) {
+		t.Errorf("复制的 due_date=%v tags=%v，期望 %v %v", copied.DueDate, copied.Tags, source.DueDate, source.Tags)
+	}
+	if want := []models.Subtask{{Title: "x"}, {Title: "y"}}; !reflect.DeepEqual(copied.Subtasks, want) {
+		t.Errorf("复制的子任务 = %+v，期望全部重置为未完成 %+v", copied.Subtasks, want)
+	}
+	// 不复制状态、耗时、备注和外部ID
+	if copied.Completed || copied.Starred || copied.SpentMinutes != 0 || len(copied.Comments) != 0 || copied.ExternalID != "" {
+		t.Errorf("复制的 completed=%v starred=%v spent=%d comments=%d external_id=%q，期望均为默认值",
+			copied.Completed, copied.Starred, copied.SpentMinutes, len(copied.Comments), copied.ExternalID)
+	}
+
+	// 原待办事项保持不变
+	got := decodeResponse[*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos/1", ""))
+	if !got.Completed || !got.Starred || got.ExternalID != "TICKET-1" || len(got.Comments) != 1 {
+		t.Errorf("原待办事项被修改: %+v", got)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/9/duplicate", ""), http.StatusNotFound)
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/1/duplicate", ""), http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// storageErrorStatus 将业务或存储层错误映射为 HTTP 状态码和错误信息，未知错误使用 fallback 信息
+func storageErrorStatus(err error, fallback string) (int, string) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, storage.ErrTodoNotFound):
+		return http.StatusNotFound, "待办事项未找到"
+	case errors.Is(err, storage.ErrCommentNotFound):
+		return http.StatusNotFound, "备注未找到"
+	case errors.Is(err, storage.ErrValidation):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, storage.ErrConflict):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, storage.ErrUnavailable):
+		return http.StatusServiceUnavailable, "存储服务暂时不可用"
+	default:
+		return http.StatusInternalServerError, fallback
+	}
+}
+
+// writeStorageError 根据错误类型写入对应的错误响应
+func writeStorageError(w http.ResponseWriter, err error, fallback string) {
+	statusCode, message := storageErrorStatus(err, fallback)
+	writeErrorResponse(w, statusCode, message)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+func TestStorageErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"校验错误", &models.ValidationError{Field: "title", Message: "标题不能为空"}, http.StatusBadRequest},
+		{"存储校验错误", fmt.Errorf("%w: 标题过长", storage.ErrValidation), http.StatusBadRequest},
+		{"未找到", storage.ErrTodoNotFound, http.StatusNotFound},
+		{"备注未找到", storage.ErrCommentNotFound, http.StatusNotFound},
+		{"冲突", fmt.Errorf("更新失败: %w", storage.ErrConflict), http.StatusConflict},
+		{"不可用", fmt.Errorf("连接数据库失败: %w", storage.ErrUnavailable), http.StatusServiceUnavailable},
+		{"未知错误", errors.New("磁盘已满"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := storageErrorStatus(tt.err, "失败"); got != tt.want {
+				t.Errorf("状态码 = %d，期望 %d", got, tt.want)
+			}
+		})
+	}
+
+	// 不可用时不向客户端暴露底层错误，未知错误使用 fallback 信息
+	if _, msg := storageErrorStatus(fmt.Errorf("dial tcp 10.0.0.1: %w", storage.ErrUnavailable), "失败"); msg != "存储服务暂时不可用" {
+		t.Errorf("不可用错误信息 = %q", msg)
+	}
+	if _, msg := storageErrorStatus(errors.New("磁盘已满"), "获取失败"); msg != "获取失败" {
+		t.Errorf("未知错误信息 = %q，期望 获取失败", msg)
+	}
+}
+
+// failingStorage 所有读取都返回 err 的存储
+type failingStorage struct {
+	storage.TodoStorage
+	err error
+}
+
+func (s failingStorage) GetAll(context.Context) ([]*models.Todo, error) { return nil, s.err }
+
+func (s failingStorage) GetByID(context.Context, int) (*models.Todo, error) { return nil, s.err }
+
+func TestStorageErrorResponses(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: 数据损坏", storage.ErrValidation), http.StatusBadRequest},
+		{storage.ErrConflict, http.StatusConflict},
+		{storage.ErrUnavailable, http.StatusServiceUnavailable},
+		{errors.New("未知错误"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		config := DefaultConfig()
+		config.Clock = func() time.Time { return testNow }
+		h := NewTodoHandlerWithConfig(failingStorage{storage.NewMemoryStorage(), tt.err}, config)
+		for _, target := range []string{"/api/todos/stats", "/api/todos/1"} {
+			rec := serve(t, h, http.MethodGet, target, "")
+			if rec.Code != tt.want {
+				t.Errorf("%v: GET %s 状态码 = %d，期望 %d", tt.err, target, rec.Code, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+)
+
+// contentETag 根据内容的 FNV-1a 64 位哈希生成强 ETag，相同内容在重启后仍得到相同的 ETag
+func contentETag(data []byte) string {
+	hash := fnv.New64a()
+	hash.Write(data)
+	return fmt.Sprintf(`"%016x"`, hash.Sum64())
+}
+
+// etagMatches 判断 If-None-Match 请求头是否匹配 etag，按弱比较处理（忽略 W/ 前缀）
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSONWithETag 写入带 ETag 的 JSON 响应，ETag 由序列化后的内容计算，If-None-Match 匹配时返回 304
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "序列化响应失败")
+		return
+	}
+
+	etag := contentETag(body)
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	// 与 writeJSONResponse 使用的 json.Encoder 输出保持一致
+	w.Write(append(body, '\n'))
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+func TestContentETag(t *testing.T) {
+	etagOf := func(todo models.Todo) string {
+		data, err := json.Marshal(todo)
+		if err != nil {
+			t.Fatalf("序列化失败: %v", err)
+		}
+		return contentETag(data)
+	}
+	created := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	todo := models.Todo{ID: 1, Title: "a", Priority: models.PriorityMedium, Tags: []string{"work"}, CreatedAt: created, UpdatedAt: created}
+	// 独立构造的相同内容得到相同的 ETag
+	same := models.Todo{ID: 1, Title: "a", Priority: models.PriorityMedium, Tags: []string{"work"}, CreatedAt: created, UpdatedAt: created}
+
+	etag := etagOf(todo)
+	if !regexp.MustCompile(`^"[0-9a-f]{16}"$`).MatchString(etag) {
+		t.Errorf("ETag = %s，期望带引号的 16 位十六进制强 ETag", etag)
+	}
+	if got := etagOf(same); got != etag {
+		t.Errorf("相同内容的 ETag = %s 与 %s 不同", got, etag)
+	}
+
+	changed := []func(*models.Todo){
+		func(t *models.Todo) { t.Title = "b" },
+		func(t *models.Todo) { t.Completed = true },
+		func(t *models.Todo) { t.Tags = []string{"home"} },
+		func(t *models.Todo) { t.UpdatedAt = t.UpdatedAt.Add(time.Nanosecond) },
+	}
+	for i, change := range changed {
+		other := todo
+		change(&other)
+		if got := etagOf(other); got == etag {
+			t.Errorf("第 %d 个字段变化后 ETag 仍为 %s", i, got)
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"0123456789abcdef"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{etag, true},
+		{`W/"0123456789abcdef"`, true},
+		{`"other", ` + etag, true},
+		{"*", true},
+		{`"other"`, false},
+		{`"0123456789abcdee"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifNoneMatch, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %t，期望 %t", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
+
+func TestGetTodoETag(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a")
+
+	rec := serve(t, h, http.MethodGet, "/api/todos/1", "")
+	expectStatus(t, rec, http.StatusOK)
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("响应缺少 ETag")
+	}
+	if got := serve(t, h, http.MethodGet, "/api/todos/1", "").Header().Get("ETag"); got != etag {
+		t.Errorf("内容未变时 ETag = %s，期望 %s", got, etag)
+	}
+
+	rec = serve(t, h, http.MethodGet, "/api/todos/1", "", "If-None-Match", etag)
+	expectStatus(t, rec, http.StatusNotModified)
+	if rec.Body.Len() != 0 {
+		t.Errorf("304 响应体 = %q，期望为空", rec.Body.String())
+	}
+
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", `{"title": "b"}`), http.StatusOK)
+	rec = serve(t, h, http.MethodGet, "/api/todos/1", "", "If-None-Match", etag)
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("ETag"); got == etag {
+		t.Errorf("修改后 ETag 仍为 %s", got)
+	}
+
+	// 投影不同字段得到不同的内容和 ETag
+	if got := serve(t, h, http.MethodGet, "/api/todos/1?fields=id", "").Header().Get("ETag"); got == rec.Header().Get("ETag") {
+		t.Error("投影后的响应与完整响应 ETag 相同")
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"sync"
+
+	"go-todolist/models"
+)
+
+// EventType 待办事项变更事件类型
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+	// EventCleared 所有待办事项已被清空，客户端应丢弃本地数据
+	EventCleared EventType = "cleared"
+	// EventRestored 数据已从备份恢复，客户端应重新加载全部数据
+	EventRestored EventType = "restored"
+)
+
+// eventBufferSize 每个订阅者的事件缓冲区大小
+const eventBufferSize = 16
+
+// TodoEvent 表示一次待办事项变更
+type TodoEvent struct {
+	Type EventType     `json:"type"`
+	ID   models.ID     `json:"id"`
+	Todo *TodoResponse `json:"todo,omitempty"`
+}
+
+// EventHub 负责变更事件的订阅与广播，同时跟踪活动的长连接（如 WebSocket）以便关闭时等待其退出
+type EventHub struct {
+	subscribers map[chan TodoEvent]struct{}
+	closed      bool
+	mutex       sync.RWMutex
+	// streams 只在持有 mutex 且未关闭时 Add，保证 Close 之后的 Wait 不会与 Add 并发
+	streams sync.WaitGroup
+}
+
+// NewEventHub 创建新的事件中心
+func NewEventHub() *EventHub {
+	return &EventHub{
+		subscribers: make(map[chan TodoEvent]struct{}),
+	}
+}
+
+// Subscribe 注册订阅者并返回接收事件的通道；事件中心关闭后返回已关闭的通道
+func (h *EventHub) Subscribe() chan TodoEvent {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	ch := make(chan TodoEvent, eventBufferSize)
+	if h.closed {
+		close(ch)
+		return ch
+	}
+	h.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// Unsubscribe 注销订阅者并关闭其通道
+func (h *EventHub) Unsubscribe(ch chan TodoEvent) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, exists := h.subscribers[ch]; exists {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Close 关闭所有订阅者的通道，通知其断开连接；之后的订阅会立即收到关闭的通道
+func (h *EventHub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for ch := range h.subscribers {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// beginStream 登记一个长连接，事件中心已关闭（服务正在关闭）时返回 false，不再接受新的长连接。
+// 返回 true 时调用方需在连接结束后调用 endStream
+func (h *EventHub) beginStream() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return false
+	}
+	h.streams.Add(1)
+	return true
+}
+
+// endStream 注销 beginStream 登记的长连接
+func (h *EventHub) endStream() {
+	h.streams.Done()
+}
+
+// Publish 向所有订阅者广播事件，缓冲区已满的订阅者会丢弃该事件
+func (h *EventHub) Publish(event TodoEvent) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// publishTodo 广播待办事项变更，携带映射后的当前状态副本（含计算字段），以免与后续修改产生竞争
+func (h *TodoHandler) publishTodo(eventType EventType, todo *models.Todo) {
+	h.events.Publish(TodoEvent{Type: eventType, ID: todo.ID, Todo: h.todoResponse(todo)})
+}
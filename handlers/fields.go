@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go-todolist/models"
+)
+
+// todoJSONFields TodoResponse 序列化后的全部字段名（含计算字段），用于校验 fields 参数
+var todoJSONFields = func() map[string]bool {
+	names := jsonFieldNames(reflect.TypeOf(models.Todo{}))
+	maps.Copy(names, jsonFieldNames(reflect.TypeOf(TodoResponse{})))
+	return names
+}()
+
+// parseFields 解析逗号分隔的字段列表并校验字段名，extra 为接口在待办事项字段之外附加的字段
+func parseFields(v string, extra ...string) ([]string, error) {
+	var fields []string
+	for _, part := range strings.Split(v, ",") {
+		field := strings.TrimSpace(part)
+		if field == "" {
+			continue
+		}
+		if !todoJSONFields[field] && !slices.Contains(extra, field) {
+			return nil, fmt.Errorf("未知的字段: %s", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// fieldsParam 解析请求的 fields 参数，格式错误时写入 400 响应并返回 false；未指定时返回 nil
+func fieldsParam(w http.ResponseWriter, r *http.Request, extra ...string) ([]string, bool) {
+	v := r.URL.Query().Get("fields")
+	if v == "" {
+		return nil, true
+	}
+	fields, err := parseFields(v, extra...)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return fields, true
+}
+
+// projectTodos 只保留每个待办事项的指定字段
+func projectTodos(todos []*TodoResponse, fields []string) ([]map[string]json.RawMessage, error) {
+	result := make([]map[string]json.RawMessage, 0, len(todos))
+	for _, todo := range todos {
+		projected, err := projectFields(todo, fields)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, projected)
+	}
+	return result, nil
+}
+
+// projectFields 将值序列化后只保留指定字段，值中不存在的字段（如未设置的可选字段）会被忽略
+func projectFields(v interface{}, fields []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	return pickFields(all, fields), nil
+}
+
+// pickFields 返回 all 中 fields 列出的字段
+func pickFields(all map[string]json.RawMessage, fields []string) map[string]json.RawMessage {
+	projected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected
+}
+
+// projectResponse 按 fields 投影单个对象或对象数组，fields 为空时原样返回
+func projectResponse(data interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(body, []byte("[")) {
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(body, &all); err != nil {
+			return nil, err
+		}
+		return pickFields(all, fields), nil
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, err
+	}
+	projected := make([]map[string]json.RawMessage, len(items))
+	for i, item := range items {
+		projected[i] = pickFields(item, fields)
+	}
+	return projected, nil
+}
+
+// writeProjectedJSON 按 fields 投影 data 后写入 JSON 响应
+func writeProjectedJSON(w http.ResponseWriter, status int, data interface{}, fields []string) {
+	projected, err := projectResponse(data, fields)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "序列化响应失败")
+		return
+	}
+	writeJSONResponse(w, status, projected)
+}
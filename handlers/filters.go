@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-todolist/models"
+	querylang "go-todolist/query"
+	"go-todolist/storage"
+)
+
+// FiltersHandler 返回处理 /api/filters 下保存的过滤器（智能清单）接口的处理器
+func (h *TodoHandler) FiltersHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if h.config.ReadOnly && isMutatingMethod(r.Method) {
+			writeErrorResponse(w, http.StatusForbidden, readOnlyMessage)
+			return
+		}
+
+		filters, ok := h.filterStorage()
+		if !ok {
+			writeErrorResponse(w, http.StatusNotImplemented, "当前存储不支持保存过滤器")
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/filters")
+		if path == "" || path == "/" {
+			switch r.Method {
+			case http.MethodGet:
+				h.handleListFilters(w, r, filters)
+			case http.MethodPost:
+				h.handleCreateFilter(w, r, filters)
+			default:
+				writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			}
+			return
+		}
+
+		// /api/filters/{id} 及 /api/filters/{id}/todos
+		idStr, action, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		id, err := parseID(idStr)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch {
+		case action == "todos":
+			if r.Method != http.MethodGet {
+				writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+				return
+			}
+			h.handleFilterTodos(w, r, filters, id)
+		case action != "":
+			writeErrorResponse(w, http.StatusNotFound, "路径未找到")
+		case r.Method == http.MethodGet:
+			filter, err := filters.GetFilter(r.Context(), id)
+			if err != nil {
+				writeFilterError(w, err, "获取过滤器失败")
+				return
+			}
+			writeJSONResponse(w, http.StatusOK, filter)
+		case r.Method == http.MethodPut:
+			h.handleUpdateFilter(w, r, filters, id)
+		case r.Method == http.MethodDelete:
+			if err := filters.DeleteFilter(r.Context(), id); err != nil {
+				writeFilterError(w, err, "删除过滤器失败")
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+		}
+	})
+}
+
+// filterStorage 依次解开存储装饰器查找支持保存过滤器的存储
+func (h *TodoHandler) filterStorage() (storage.FilterStorage, bool) {
+	s := h.storage
+	for {
+		if filters, ok := s.(storage.FilterStorage); ok {
+			return filters, true
+		}
+		wrapper, ok := s.(interface{ Unwrap() storage.TodoStorage })
+		if !ok {
+			return nil, false
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// writeFilterError 写入过滤器接口的错误响应，过滤器不存在时返回 404
+func writeFilterError(w http.ResponseWriter, err error, fallback string) {
+	if errors.Is(err, storage.ErrFilterNotFound) {
+		writeErrorResponse(w, http.StatusNotFound, "过滤器未找到")
+		return
+	}
+	writeStorageError(w, err, fallback)
+}
+
+// decodeFilterRequest 解码并校验保存过滤器的请求，查询语句有语法错误时返回 400
+func decodeFilterRequest(w http.ResponseWriter, r *http.Request) (*models.SaveFilterRequest, bool) {
+	var req models.SaveFilterRequest
+	if !decodeJSONBody(w, r, &req) {
+		return nil, false
+	}
+	if err := req.Validate(); err != nil {
+		writeStorageError(w, err, "保存过滤器失败")
+		return nil, false
+	}
+	if _, err := querylang.Parse(req.Query); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("query 无效: %v", err))
+		return nil, false
+	}
+	return &req, true
+}
+
+// handleListFilters 处理获取全部保存的过滤器
+func (h *TodoHandler) handleListFilters(w http.ResponseWriter, r *http.Request, filters storage.FilterStorage) {
+	list, err := filters.ListFilters(r.Context())
+	if err != nil {
+		writeFilterError(w, err, "获取过滤器失败")
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, list)
+}
+
+// handleCreateFilter 处理保存新的过滤器
+func (h *TodoHandler) handleCreateFilter(w http.ResponseWriter, r *http.Request, filters storage.FilterStorage) {
+	req, ok := decodeFilterRequest(w, r)
+	if !ok {
+		return
+	}
+	filter, err := filters.CreateFilter(r.Context(), req)
+	if err != nil {
+		writeFilterError(w, err, "保存过滤器失败")
+		return
+	}
+	writeJSONResponse(w, http.StatusCreated, filter)
+}
+
+// handleUpdateFilter 处理替换过滤器的名称和查询语句
+func (h *TodoHandler) handleUpdateFilter(w http.ResponseWriter, r *http.Request, filters storage.FilterStorage, id int) {
+	req, ok := decodeFilterRequest(w, r)
+	if !ok {
+		return
+	}
+	filter, err := filters.UpdateFilter(r.Context(), id, req)
+	if err != nil {
+		writeFilterError(w, err, "保存过滤器失败")
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, filter)
+}
+
+// handleFilterTodos 处理执行保存的过滤器。查询参数与列表接口相同，可以在过滤器的条件之外继续过滤、排序和分页
+func (h *TodoHandler) handleFilterTodos(w http.ResponseWriter, r *http.Request, filters storage.FilterStorage, id int) {
+	filter, err := filters.GetFilter(r.Context(), id)
+	if err != nil {
+		writeFilterError(w, err, "获取过滤器失败")
+		return
+	}
+
+	query, err := h.parseListQuery(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.applyFilterQuery(filter.Query, &query.options); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("过滤器 %s 无法执行: %v", filter.Name, err))
+		return
+	}
+	query.excludeArchived()
+	h.writeTodoList(w, r, query)
+}
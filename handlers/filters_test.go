@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+func TestFiltersCRUD(t *testing.T) {
+	filters := newTestHandler(t).FiltersHandler()
+
+	rec := serve(t, filters, http.MethodPost, "/api/filters", `{"name": " 工作 ", "query": "tag:work"}`)
+	expectStatus(t, rec, http.StatusCreated)
+	created := decodeResponse[*models.SavedFilter](t, rec)
+	if created.ID != 1 || created.Name != "工作" || created.Query != "tag:work" {
+		t.Errorf("创建的过滤器 = %+v", created)
+	}
+	mustCreateFilter := func(body string) {
+		expectStatus(t, serve(t, filters, http.MethodPost, "/api/filters", body), http.StatusCreated)
+	}
+	mustCreateFilter(`{"name": "重要", "query": "priority:high"}`)
+
+	rec = serve(t, filters, http.MethodPut, "/api/filters/2", `{"name": "未完成", "query": "completed:false"}`)
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[*models.SavedFilter](t, rec); got.Name != "未完成" || got.Query != "completed:false" {
+		t.Errorf("更新后的过滤器 = %+v", got)
+	}
+	rec = serve(t, filters, http.MethodGet, "/api/filters/2", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[*models.SavedFilter](t, rec); got.Name != "未完成" {
+		t.Errorf("获取的过滤器名称 = %q，期望 未完成", got.Name)
+	}
+
+	expectStatus(t, serve(t, filters, http.MethodDelete, "/api/filters/1", ""), http.StatusNoContent)
+	rec = serve(t, filters, http.MethodGet, "/api/filters", "")
+	expectStatus(t, rec, http.StatusOK)
+	list := decodeResponse[[]*models.SavedFilter](t, rec)
+	if len(list) != 1 || list[0].ID != 2 {
+		t.Errorf("过滤器列表 = %+v，期望只剩 ID 2", list)
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		want         int
+	}{
+		{"获取已删除的过滤器", http.MethodGet, "/api/filters/1", "", http.StatusNotFound},
+		{"更新不存在的过滤器", http.MethodPut, "/api/filters/9", `{"name": "x", "query": "tag:x"}`, http.StatusNotFound},
+		{"删除不存在的过滤器", http.MethodDelete, "/api/filters/1", "", http.StatusNotFound},
+		{"名称为空", http.MethodPost, "/api/filters", `{"name": " ", "query": "tag:x"}`, http.StatusBadRequest},
+		{"查询语句为空", http.MethodPost, "/api/filters", `{"name": "x", "query": ""}`, http.StatusBadRequest},
+		{"查询语句有语法错误", http.MethodPost, "/api/filters", `{"name": "x", "query": "color:red"}`, http.StatusBadRequest},
+		{"更新为错误的查询语句", http.MethodPut, "/api/filters/2", `{"name": "x", "query": "tag:\"x"}`, http.StatusBadRequest},
+		{"无效的ID", http.MethodGet, "/api/filters/abc", "", http.StatusBadRequest},
+		{"未知的子路径", http.MethodGet, "/api/filters/2/other", "", http.StatusNotFound},
+		{"不支持的方法", http.MethodPatch, "/api/filters/2", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rec := serve(t, filters, tt.method, tt.path, tt.body); rec.Code != tt.want {
+			t.Errorf("%s: 状态码 = %d，期望 %d，响应: %s", tt.name, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+}
+
+func TestFilterTodos(t *testing.T) {
+	h := newTestHandler(t)
+	filters := h.FiltersHandler()
+	mustCreate(t, h, `{"title": "a", "tags": ["work"]}`)
+	mustCreate(t, h, `{"title": "b", "tags": ["work"]}`)
+	mustCreate(t, h, `{"title": "c", "tags": ["home"]}`)
+	mustCreate(t, h, `{"title": "d", "tags": ["work"]}`)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/2", `{"completed": true}`), http.StatusOK)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/4/archive", ""), http.StatusOK)
+	for _, body := range []string{
+		`{"name": "工作", "query": "tag:work"}`,
+		`{"name": "归档的工作", "query": "tag:work archived:true"}`,
+	} {
+		expectStatus(t, serve(t, filters, http.MethodPost, "/api/filters", body), http.StatusCreated)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		want   []models.ID
+	}{
+		// 未指定归档条件时与列表接口一样排除归档的待办事项
+		{"执行过滤器", "/api/filters/1/todos", []models.ID{1, 2}},
+		{"叠加查询参数", "/api/filters/1/todos?completed=false", []models.ID{1}},
+		{"叠加排序", "/api/filters/1/todos?sort=title&order=desc", []models.ID{2, 1}},
+		{"过滤器指定归档条件", "/api/filters/2/todos", []models.ID{4}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, filters, http.MethodGet, tt.target, "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := todoIDs(decodeResponse[[]*models.Todo](t, rec)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: 结果 = %v，期望 %v", tt.name, got, tt.want)
+		}
+	}
+
+	expectStatus(t, serve(t, filters, http.MethodGet, "/api/filters/9/todos", ""), http.StatusNotFound)
+	expectStatus(t, serve(t, filters, http.MethodPost, "/api/filters/1/todos", ""), http.StatusMethodNotAllowed)
+	// 查询参数与过滤器的条件重复时无法执行
+	expectStatus(t, serve(t, filters, http.MethodGet, "/api/filters/1/todos?tag=home", ""), http.StatusBadRequest)
+}
+
+func TestFiltersUnsupported(t *testing.T) {
+	// 只暴露 TodoStorage 方法的存储不支持保存过滤器
+	s := struct{ storage.TodoStorage }{storage.NewMemoryStorage()}
+	h := NewTodoHandlerWithConfig(s, DefaultConfig())
+	expectStatus(t, serve(t, h.FiltersHandler(), http.MethodGet, "/api/filters", ""), http.StatusNotImplemented)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"go-todolist/models"
+)
+
+// defaultFocusSize 专注模式默认返回的待办事项数量
+const defaultFocusSize = 3
+
+// handleGetFocus 处理专注模式，返回排名最靠前的若干未完成待办事项
+func (h *TodoHandler) handleGetFocus(w http.ResponseWriter, r *http.Request) {
+	size := defaultFocusSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeErrorResponse(w, http.StatusBadRequest, "limit必须为正整数")
+			return
+		}
+		size = min(n, h.config.MaxLimit)
+	}
+	fields, ok := fieldsParam(w, r)
+	if !ok {
+		return
+	}
+
+	todos, err := h.storage.GetAll(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+
+	ranked := rankPending(todos, h.config.Clock())
+	if len(ranked) > size {
+		ranked = ranked[:size]
+	}
+	writeProjectedJSON(w, http.StatusOK, h.todoResponses(ranked), fields)
+}
+
+// handleGetNext 处理获取下一个要做的待办事项，没有未完成事项时返回 204
+func (h *TodoHandler) handleGetNext(w http.ResponseWriter, r *http.Request) {
+	fields, ok := fieldsParam(w, r)
+	if !ok {
+		return
+	}
+
+	todos, err := h.storage.GetAll(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+
+	next := nextTodo(todos, h.config.Clock())
+	if next == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeProjectedJSON(w, http.StatusOK, h.todoResponse(next), fields)
+}
+
+// handleGetRandom 处理随机挑选一个未完成且未归档的待办事项，没有这样的事项时返回 204
+func (h *TodoHandler) handleGetRandom(w http.ResponseWriter, r *http.Request) {
+	fields, ok := fieldsParam(w, r)
+	if !ok {
+		return
+	}
+
+	todos, err := h.storage.GetAll(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+
+	pending := make([]*models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if !todo.Completed && !todo.Archived {
+			pending = append(pending, todo)
+		}
+	}
+	if len(pending) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeProjectedJSON(w, http.StatusOK, h.todoResponse(pending[h.config.RandIntn(len(pending))]), fields)
+}
+
+// nextTodo 返回排名第一的未完成待办事项，没有时返回 nil
+func nextTodo(todos []*models.Todo, now time.Time) *models.Todo {
+	ranked := rankPending(todos, now)
+	if len(ranked) == 0 {
+		return nil
+	}
+	return ranked[0]
+}
+
+// rankPending 对未完成的待办事项排序：已逾期优先，其次截止时间早的优先（无截止时间的排最后），
+// 再按优先级从高到低，最后按创建顺序
+func rankPending(todos []*models.Todo, now time.Time) []*models.Todo {
+	pending := make([]*models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if !todo.Completed {
+			pending = append(pending, todo)
+		}
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		a, b := pending[i], pending[j]
+		if aOverdue, bOverdue := a.IsOverdue(now), b.IsOverdue(now); aOverdue != bOverdue {
+			return aOverdue
+		}
+		switch {
+		case a.DueDate != nil && b.DueDate == nil:
+			return true
+		case a.DueDate == nil && b.DueDate != nil:
+			return false
+		case a.DueDate != nil && !a.DueDate.Equal(*b.DueDate):
+			return a.DueDate.Before(*b.DueDate)
+		}
+		if a.Priority.Weight() != b.Priority.Weight() {
+			return a.Priority.Weight() > b.Priority.Weight()
+		}
+		return a.ID < b.ID
+	})
+	return pending
+}
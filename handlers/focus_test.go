@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+func TestRankPending(t *testing.T) {
+	at := func(hours int) *time.Time {
+		due := testNow.Add(time.Duration(hours) * time.Hour)
+		return &due
+	}
+	todos := []*models.Todo{
+		{ID: 1, Priority: models.PriorityUrgent},
+		{ID: 2, Priority: models.PriorityLow, DueDate: at(48)},
+		{ID: 3, Priority: models.PriorityLow, DueDate: at(-1)},
+		{ID: 4, Priority: models.PriorityHigh, DueDate: at(48)},
+		{ID: 5, Priority: models.PriorityUrgent, DueDate: at(-24), Completed: true},
+		{ID: 6, Priority: models.PriorityMedium, DueDate: at(24)},
+		{ID: 7, Priority: models.PriorityLow, DueDate: at(-24)},
+		{ID: 8, Priority: models.PriorityHigh},
+		{ID: 9, Priority: models.PriorityUrgent},
+	}
+
+	// 逾期的按截止时间在前，其次截止时间早的，同一截止时间按优先级，没有截止时间的最后且按优先级、创建顺序
+	want := []models.ID{7, 3, 6, 4, 2, 1, 9, 8}
+	if got := todoIDs(rankPending(todos, testNow)); !reflect.DeepEqual(got, want) {
+		t.Errorf("排序结果 = %v，期望 %v", got, want)
+	}
+	if got := nextTodo(todos, testNow); got == nil || got.ID != 7 {
+		t.Errorf("nextTodo = %+v，期望 7", got)
+	}
+	if got := nextTodo([]*models.Todo{{ID: 1, Completed: true}}, testNow); got != nil {
+		t.Errorf("没有未完成事项时 nextTodo = %+v，期望 nil", got)
+	}
+}
+
+func TestGetNext(t *testing.T) {
+	h := newTestHandler(t)
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/next", ""), http.StatusNoContent)
+
+	mustCreate(t, h, `{"title": "a", "priority": "urgent"}`)
+	mustCreate(t, h, `{"title": "b", "due_date": "2024-06-16T00:00:00Z"}`)
+	mustCreate(t, h, `{"title": "c", "due_date": "2024-06-14T00:00:00Z"}`)
+
+	// focus 与 next 使用同一排序，next 即 focus 的第一项
+	focus := decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos/focus", ""))
+	if got := todoIDs(focus); !reflect.DeepEqual(got, []models.ID{3, 2, 1}) {
+		t.Errorf("focus = %v，期望 [3 2 1]", got)
+	}
+	for _, wantID := range []models.ID{3, 2, 1} {
+		rec := serve(t, h, http.MethodGet, "/api/todos/next", "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := decodeResponse[models.Todo](t, rec).ID; got != wantID {
+			t.Errorf("next = %d，期望 %d", got, wantID)
+		}
+		expectStatus(t, serve(t, h, http.MethodPatch, fmt.Sprintf("/api/todos/%d", wantID), `{"completed": true}`), http.StatusOK)
+	}
+
+	rec := serve(t, h, http.MethodGet, "/api/todos/next", "")
+	expectStatus(t, rec, http.StatusNoContent)
+	if rec.Body.Len() != 0 {
+		t.Errorf("204 响应体 = %q，期望为空", rec.Body.String())
+	}
+}
+
+func TestGetRandom(t *testing.T) {
+	var calls []int
+	pick := 0
+	h := newTestHandler(t, func(c *Config) {
+		c.RandIntn = func(n int) int {
+			calls = append(calls, n)
+			return pick
+		}
+	})
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/random", ""), http.StatusNoContent)
+
+	mustCreateTitled(t, h, "a", "b", "c", "d")
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", `{"completed": true}`), http.StatusOK)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/3/archive", ""), http.StatusOK)
+
+	// 只在未完成且未归档的 2、4 中挑选，随机数决定返回哪一项
+	for i, want := range []models.ID{2, 4} {
+		pick = i
+		rec := serve(t, h, http.MethodGet, "/api/todos/random", "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := decodeResponse[*models.Todo](t, rec).ID; got != want {
+			t.Errorf("随机数为 %d 时返回 %d，期望 %d", i, got, want)
+		}
+	}
+	if !reflect.DeepEqual(calls, []int{2, 2}) {
+		t.Errorf("随机数范围 = %v，期望 [2 2]", calls)
+	}
+
+	// 全部完成时返回 204
+	for _, id := range []string{"2", "4"} {
+		expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/"+id, `{"completed": true}`), http.StatusOK)
+	}
+	rec := serve(t, h, http.MethodGet, "/api/todos/random", "")
+	expectStatus(t, rec, http.StatusNoContent)
+	if rec.Body.Len() != 0 {
+		t.Errorf("204 响应体 = %q，期望为空", rec.Body.String())
+	}
+}
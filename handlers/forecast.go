@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-todolist/models"
+)
+
+// 预测统计窗口的默认和最大天数
+const (
+	defaultForecastDays = 14
+	maxForecastDays     = 365
+)
+
+// Forecast 按近期完成速度估算清空未完成事项所需的时间
+type Forecast struct {
+	WindowDays int     `json:"window_days"`
+	Completed  int     `json:"completed"`
+	RatePerDay float64 `json:"rate_per_day"`
+	Pending    int     `json:"pending"`
+	// DaysToClear 和 ClearDate 在窗口内没有完成记录且仍有未完成事项时为 null，表示无法估算
+	DaysToClear *float64   `json:"days_to_clear"`
+	ClearDate   *time.Time `json:"clear_date"`
+}
+
+// handleGetForecast 处理完成预测，统计窗口由 days 参数指定（默认 14 天）
+func (h *TodoHandler) handleGetForecast(w http.ResponseWriter, r *http.Request) {
+	days := defaultForecastDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxForecastDays {
+			writeErrorResponse(w, http.StatusBadRequest, "days必须为1到365之间的整数")
+			return
+		}
+		days = n
+	}
+
+	todos, err := h.storage.GetAll(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, computeForecast(todos, h.config.Clock(), days))
+}
+
+// computeForecast 统计 [now-days, now] 内的完成数得到每日完成速度，并据此估算清空未完成事项的天数
+func computeForecast(todos []*models.Todo, now time.Time, days int) Forecast {
+	since := now.AddDate(0, 0, -days)
+	forecast := Forecast{WindowDays: days}
+	for _, todo := range todos {
+		if !todo.Completed {
+			forecast.Pending++
+			continue
+		}
+		if todo.CompletedAt != nil && todo.CompletedAt.After(since) && !todo.CompletedAt.After(now) {
+			forecast.Completed++
+		}
+	}
+	forecast.RatePerDay = roundTo(float64(forecast.Completed)/float64(days), 2)
+
+	switch {
+	case forecast.Pending == 0:
+		zero := 0.0
+		forecast.DaysToClear = &zero
+		forecast.ClearDate = &now
+	case forecast.Completed > 0:
+		// 使用未取整的速度计算，避免舍入误差放大
+		remaining := float64(forecast.Pending) * float64(days) / float64(forecast.Completed)
+		daysToClear := roundTo(remaining, 1)
+		clearDate := now.Add(time.Duration(remaining * float64(24*time.Hour)))
+		forecast.DaysToClear = &daysToClear
+		forecast.ClearDate = &clearDate
+	}
+	return forecast
+}
+
+// roundTo 将 v 四舍五入到 digits 位小数
+func roundTo(v float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(v*scale) / scale
+}
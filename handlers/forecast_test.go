@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+func TestComputeForecast(t *testing.T) {
+	daysAgo := func(days float64) *time.Time {
+		at := testNow.Add(-time.Duration(days * float64(24*time.Hour)))
+		return &at
+	}
+	todos := []*models.Todo{
+		{ID: 1, Completed: true, CompletedAt: daysAgo(1)},
+		{ID: 2, Completed: true, CompletedAt: daysAgo(3)},
+		{ID: 3, Completed: true, CompletedAt: daysAgo(6.5)},
+		{ID: 4, Completed: true, CompletedAt: daysAgo(7)},  // 恰在窗口起点，不计入
+		{ID: 5, Completed: true, CompletedAt: daysAgo(30)}, // 窗口之外
+		{ID: 6, Completed: true},                           // 没有完成时间
+		{ID: 7},
+		{ID: 8},
+	}
+
+	// 7 天内完成 3 项，2 项未完成需要 2*7/3 天
+	got := computeForecast(todos, testNow, 7)
+	if got.Completed != 3 || got.Pending != 2 || got.RatePerDay != 0.43 {
+		t.Errorf("预测 = %+v，期望完成 3、未完成 2、速度 0.43", got)
+	}
+	if got.DaysToClear == nil || *got.DaysToClear != 4.7 {
+		t.Fatalf("DaysToClear = %v，期望 4.7", got.DaysToClear)
+	}
+	if want := testNow.Add(time.Duration(14.0 / 3 * float64(24*time.Hour))); got.ClearDate == nil || !got.ClearDate.Equal(want) {
+		t.Errorf("ClearDate = %v，期望 %v", got.ClearDate, want)
+	}
+
+	// 窗口内没有完成记录时无法估算
+	got = computeForecast([]*models.Todo{todos[4], todos[6]}, testNow, 7)
+	if got.Completed != 0 || got.RatePerDay != 0 || got.DaysToClear != nil || got.ClearDate != nil {
+		t.Errorf("没有完成记录时预测 = %+v，期望无法估算", got)
+	}
+
+	// 没有未完成事项时已经清空
+	got = computeForecast(todos[:6], testNow, 7)
+	if got.DaysToClear == nil || *got.DaysToClear != 0 || got.ClearDate == nil || !got.ClearDate.Equal(testNow) {
+		t.Errorf("没有未完成事项时预测 = %+v，期望 0 天", got)
+	}
+}
+
+func TestGetForecast(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a")
+
+	// 没有完成记录时 days_to_clear 和 clear_date 为 null
+	rec := serve(t, h, http.MethodGet, "/api/todos/forecast?days=30", "")
+	expectStatus(t, rec, http.StatusOK)
+	got := decodeResponse[map[string]any](t, rec)
+	if got["window_days"] != 30.0 || got["pending"] != 1.0 || got["rate_per_day"] != 0.0 {
+		t.Errorf("预测 = %v", got)
+	}
+	for _, key := range []string{"days_to_clear", "clear_date"} {
+		if v, ok := got[key]; !ok || v != nil {
+			t.Errorf("%s = %v，期望 null", key, v)
+		}
+	}
+
+	for _, days := range []string{"0", "-1", "366", "abc"} {
+		expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/forecast?days="+days, ""), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultGzipMinSize 默认的压缩阈值，小于该字节数的响应不压缩
+const DefaultGzipMinSize = 1024
+
+// Gzip 对支持 gzip 的客户端压缩响应。响应体达到 minSize 字节后才开始压缩，
+// 较小的响应原样返回；level 为 compress/gzip 的压缩级别
+func Gzip(next http.Handler, minSize, level int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		// 范围请求返回的是原始内容的片段，不进行压缩
+		if r.Method == http.MethodHead || r.Header.Get("Range") != "" || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize, level: level, status: http.StatusOK}
+		defer gw.Close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip 判断客户端是否接受 gzip 编码
+func acceptsGzip(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			if strings.EqualFold(strings.TrimSpace(coding), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter 先缓冲响应体，达到阈值后再决定是否压缩
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+	level   int
+	status  int
+	buf     []byte
+	gz      *gzip.Writer
+	// decided 为 true 表示响应头已写出，之后的数据直接写入 gz 或底层 ResponseWriter
+	decided  bool
+	hijacked bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(statusCode int) {
+	if w.decided {
+		return
+	}
+	w.status = statusCode
+	// 没有响应体的状态码无需等待数据
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified || statusCode < 200 {
+		w.startPlain()
+	}
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minSize {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// startGzip 写出响应头并开始压缩；已由下游设置编码的响应不再压缩
+func (w *gzipResponseWriter) startGzip() error {
+	if w.Header().Get("Content-Encoding") != "" {
+		return w.startPlain()
+	}
+
+	gz, err := gzip.NewWriterLevel(w.ResponseWriter, w.level)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.decided = true
+	w.gz = gz
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	_, err = w.gz.Write(buf)
+	return err
+}
+
+// startPlain 写出响应头和已缓冲的数据，不进行压缩
+func (w *gzipResponseWriter) startPlain() error {
+	w.decided = true
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Close 在请求处理结束后写出剩余数据；未达到阈值的响应原样写出
+func (w *gzipResponseWriter) Close() error {
+	if w.hijacked {
+		return nil
+	}
+	if !w.decided {
+		return w.startPlain()
+	}
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
+
+// Flush 支持流式响应，尚未达到阈值时按未压缩方式写出
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.startPlain()
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 支持 WebSocket 等需要接管连接的场景
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("连接不支持接管")
+	}
+	w.hijacked = true
+	return hijacker.Hijack()
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// DefaultMaxDecompressedBody 默认的解压后请求体大小上限
+const DefaultMaxDecompressedBody = 10 << 20
+
+// DecompressRequest 透明解压 Content-Encoding: gzip 的请求体，解压后的数据超过 maxSize 字节时读取失败，
+// 防止压缩炸弹；不支持的编码返回 415
+func DecompressRequest(next http.Handler, maxSize int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+		case "", "identity":
+			next.ServeHTTP(w, r)
+			return
+		case "gzip":
+		default:
+			writeErrorResponse(w, http.StatusUnsupportedMediaType, "不支持的请求体编码: "+encoding)
+			return
+		}
+
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "无效的 gzip 请求体")
+			return
+		}
+		defer gz.Close()
+
+		r.Body = http.MaxBytesReader(w, gz, maxSize)
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		r.ContentLength = -1
+		next.ServeHTTP(w, r)
+	})
+}
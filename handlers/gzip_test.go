@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-todolist/models"
+)
+
+// gzipHeaderXFL gzip 头中 XFL 字段的偏移，最高压缩级别为 2，最快级别为 4（RFC 1952 2.3.1）
+const gzipHeaderXFL = 8
+
+// serveGzip 经 Gzip 中间件返回大小为 size 的响应体
+func serveGzip(t *testing.T, size, minSize, level int) *httptest.ResponseRecorder {
+	t.Helper()
+	body := strings.Repeat("待办事项 todo ", size/len("待办事项 todo ")+1)[:size]
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		// 分多次写入，验证跨越阈值时缓冲的数据不会丢失
+		for i := 0; i < len(body); i += 100 {
+			w.Write([]byte(body[i:min(i+100, len(body))]))
+		}
+	}), minSize, level)
+	rec := serve(t, handler, http.MethodGet, "/", "", "Accept-Encoding", "gzip")
+	expectStatus(t, rec, http.StatusOK)
+	return rec
+}
+
+func TestGzipThreshold(t *testing.T) {
+	rec := serveGzip(t, 999, 1000, gzip.DefaultCompression)
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("低于阈值的响应 Content-Encoding = %q，期望不压缩", got)
+	}
+	if rec.Body.Len() != 999 {
+		t.Errorf("低于阈值的响应体长度 = %d，期望 999", rec.Body.Len())
+	}
+
+	rec = serveGzip(t, 1000, 1000, gzip.DefaultCompression)
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("达到阈值的响应 Content-Encoding = %q，期望 gzip", got)
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("解压失败: %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil || len(data) != 1000 {
+		t.Errorf("解压后长度 = %d, %v，期望 1000", len(data), err)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q，期望 Accept-Encoding", got)
+	}
+}
+
+func TestGzipLevel(t *testing.T) {
+	tests := []struct {
+		level int
+		xfl   byte
+	}{
+		{gzip.BestSpeed, 4},
+		{gzip.BestCompression, 2},
+	}
+	sizes := map[int]int{}
+	for _, tt := range tests {
+		rec := serveGzip(t, 64<<10, DefaultGzipMinSize, tt.level)
+		body := rec.Body.Bytes()
+		if rec.Header().Get("Content-Encoding") != "gzip" || len(body) <= gzipHeaderXFL {
+			t.Fatalf("级别 %d 的响应未压缩", tt.level)
+		}
+		if body[gzipHeaderXFL] != tt.xfl {
+			t.Errorf("级别 %d 的 gzip 头 XFL = %d，期望 %d", tt.level, body[gzipHeaderXFL], tt.xfl)
+		}
+		sizes[tt.level] = len(body)
+	}
+	if sizes[gzip.BestCompression] > sizes[gzip.BestSpeed] {
+		t.Errorf("最高级别压缩后 %d 字节，大于最快级别的 %d 字节", sizes[gzip.BestCompression], sizes[gzip.BestSpeed])
+	}
+}
+
+func TestGzipSkipsWithoutAcceptEncoding(t *testing.T) {
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), 4096))
+	}), 0, gzip.DefaultCompression)
+	for _, accept := range []string{"", "identity", "gzip;q=0", "br"} {
+		rec := serve(t, handler, http.MethodGet, "/", "", "Accept-Encoding", accept)
+		if got := rec.Header().Get("Content-Encoding"); got != "" || rec.Body.Len() != 4096 {
+			t.Errorf("Accept-Encoding %q 时 Content-Encoding = %q，长度 %d，期望不压缩", accept, got, rec.Body.Len())
+		}
+	}
+}
+
+// gzipString 返回 s 经 gzip 压缩后的内容
+func gzipString(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestDecompressRequest(t *testing.T) {
+	h := newTestHandler(t)
+	handler := DecompressRequest(h, 64<<10)
+
+	rec := serve(t, handler, http.MethodPost, "/api/todos", gzipString(t, `{"title": "压缩的请求体", "tags": ["mobile"]}`), "Content-Encoding", "gzip")
+	expectStatus(t, rec, http.StatusCreated)
+	if todo := decodeResponse[models.Todo](t, rec); todo.Title != "压缩的请求体" || len(todo.Tags) != 1 {
+		t.Errorf("创建的待办事项 = %+v", todo)
+	}
+	rec = serve(t, handler, http.MethodPatch, "/api/todos/1", gzipString(t, `{"completed": true}`), "Content-Encoding", "GZIP")
+	expectStatus(t, rec, http.StatusOK)
+	if !decodeResponse[models.Todo](t, rec).Completed {
+		t.Error("压缩的更新请求未生效")
+	}
+
+	// 未压缩和 identity 编码照常处理
+	expectStatus(t, serve(t, handler, http.MethodPost, "/api/todos", `{"title": "a"}`), http.StatusCreated)
+	expectStatus(t, serve(t, handler, http.MethodPost, "/api/todos", `{"title": "b"}`, "Content-Encoding", "identity"), http.StatusCreated)
+
+	expectStatus(t, serve(t, handler, http.MethodPost, "/api/todos", `{"title": "c"}`, "Content-Encoding", "br"), http.StatusUnsupportedMediaType)
+	expectStatus(t, serve(t, handler, http.MethodPost, "/api/todos", `{"title": "c"}`, "Content-Encoding", "gzip"), http.StatusBadRequest)
+}
+
+func TestDecompressRequestBomb(t *testing.T) {
+	const limit = 64 << 10
+	h := newTestHandler(t)
+	handler := DecompressRequest(h, limit)
+
+	// 约 10MB 的重复数据压缩后只有几 KB
+	bomb := gzipString(t, `{"title": "a", "description": "`+strings.Repeat("0", 10<<20)+`"}`)
+	if len(bomb) >= limit {
+		t.Fatalf("压缩后 %d 字节，测试数据不足以构成压缩炸弹", len(bomb))
+	}
+	rec := serve(t, handler, http.MethodPost, "/api/todos", bomb, "Content-Encoding", "gzip")
+	expectStatus(t, rec, http.StatusRequestEntityTooLarge)
+	if got := len(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))); got != 0 {
+		t.Errorf("解压超出上限后创建了 %d 项", got)
+	}
+
+	// 解压后未超过上限的请求体照常处理
+	expectStatus(t, serve(t, handler, http.MethodPost, "/api/todos", gzipString(t, `{"title": "a", "description": "`+strings.Repeat("0", 400)+`"}`), "Content-Encoding", "gzip"), http.StatusCreated)
+}
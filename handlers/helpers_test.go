@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// testNow 测试使用的固定时间
+var testNow = time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+// newTestHandler 创建使用内存存储的处理器，configure 可以修改默认配置，时钟固定为 testNow
+func newTestHandler(t *testing.T, configure ...func(*Config)) *TodoHandler {
+	t.Helper()
+	config := DefaultConfig()
+	config.Clock = func() time.Time { return testNow }
+	config.Location = time.UTC
+	for _, fn := range configure {
+		fn(&config)
+	}
+	return NewTodoHandlerWithConfig(storage.NewMemoryStorage(), config)
+}
+
+// serve 向 h 发送请求并返回响应，headers 依次为请求头名称和值
+func serve(t *testing.T, h http.Handler, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// expectStatus 检查响应状态码
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("状态码 = %d，期望 %d，响应: %s", rec.Code, want, rec.Body.String())
+	}
+}
+
+// decodeResponse 将响应体解析为 T
+func decodeResponse[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("解析响应失败: %v，响应: %s", err, rec.Body.String())
+	}
+	return v
+}
+
+// errorMessage 返回错误响应中的错误信息
+func errorMessage(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	return decodeResponse[ErrorResponse](t, rec).Error
+}
+
+// mustCreate 通过接口创建待办事项，body 为创建请求的 JSON
+func mustCreate(t *testing.T, h http.Handler, body string) *models.Todo {
+	t.Helper()
+	rec := serve(t, h, http.MethodPost, "/api/todos", body)
+	expectStatus(t, rec, http.StatusCreated)
+	return decodeResponse[*models.Todo](t, rec)
+}
+
+// mustCreateTitled 依次创建给定标题的待办事项
+func mustCreateTitled(t *testing.T, h http.Handler, titles ...string) []*models.Todo {
+	t.Helper()
+	todos := make([]*models.Todo, 0, len(titles))
+	for _, title := range titles {
+		todos = append(todos, mustCreate(t, h, fmt.Sprintf(`{"title": %q}`, title)))
+	}
+	return todos
+}
+
+// todoIDs 返回待办事项的ID列表
+func todoIDs(todos []*models.Todo) []models.ID {
+	ids := make([]models.ID, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
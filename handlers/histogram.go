@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-todolist/models"
+)
+
+// maxHistogramBuckets 单次直方图最多返回的桶数，防止过大的日期范围
+const maxHistogramBuckets = 1000
+
+// HistogramBucket 直方图中的一个时间桶，bucket 为桶起始日期（月粒度为 YYYY-MM）
+type HistogramBucket struct {
+	Bucket string `json:"bucket"`
+	Count  int    `json:"count"`
+}
+
+// histogramFields 直方图支持统计的时间字段
+var histogramFields = map[string]func(todo *models.Todo) *time.Time{
+	"created_at": func(todo *models.Todo) *time.Time {
+		return &todo.CreatedAt
+	},
+	"completed_at": func(todo *models.Todo) *time.Time {
+		return todo.CompletedAt
+	},
+}
+
+// histogramInterval 直方图的分桶粒度
+type histogramInterval struct {
+	// start 返回 t 在 loc 时区所在桶的起始时间
+	start func(t time.Time, loc *time.Location) time.Time
+	// next 返回下一个桶的起始时间
+	next   func(start time.Time) time.Time
+	layout string
+}
+
+// histogramIntervals 支持的分桶粒度，周从周一开始
+var histogramIntervals = map[string]histogramInterval{
+	"day": {
+		start: func(t time.Time, loc *time.Location) time.Time {
+			start, _ := dayRange(t, loc)
+			return start
+		},
+		next:   func(start time.Time) time.Time { return start.AddDate(0, 0, 1) },
+		layout: "2006-01-02",
+	},
+	"week": {
+		start: func(t time.Time, loc *time.Location) time.Time {
+			start, _ := weekRange(t, loc)
+			return start
+		},
+		next:   func(start time.Time) time.Time { return start.AddDate(0, 0, 7) },
+		layout: "2006-01-02",
+	},
+	"month": {
+		start: func(t time.Time, loc *time.Location) time.Time {
+			local := t.In(loc)
+			return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+		},
+		next:   func(start time.Time) time.Time { return start.AddDate(0, 1, 0) },
+		layout: "2006-01",
+	},
+}
+
+// handleGetHistogram 处理按日/周/月统计待办事项数量，桶边界按请求时区计算。
+// from 和 to 为可选的 YYYY-MM-DD 日期（均包含在内），范围内没有数据的桶计数为 0
+func (h *TodoHandler) handleGetHistogram(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	fieldName := query.Get("field")
+	if fieldName == "" {
+		fieldName = "created_at"
+	}
+	field, ok := histogramFields[fieldName]
+	if !ok {
+		writeErrorResponse(w, http.StatusBadRequest, "field 必须为 created_at 或 completed_at")
+		return
+	}
+
+	intervalName := query.Get("interval")
+	if intervalName == "" {
+		intervalName = "day"
+	}
+	interval, ok := histogramIntervals[intervalName]
+	if !ok {
+		writeErrorResponse(w, http.StatusBadRequest, "interval 必须为 day、week 或 month")
+		return
+	}
+
+	loc, err := h.requestLocation(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, err := parseHistogramDate(query.Get("from"), "from", loc)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseHistogramDate(query.Get("to"), "to", loc)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		writeErrorResponse(w, http.StatusBadRequest, "to 不能早于 from")
+		return
+	}
+
+	todos, err := h.storage.GetAll(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+
+	buckets, err := computeHistogram(todos, field, interval, loc, from, to)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, buckets)
+}
+
+// parseHistogramDate 解析 YYYY-MM-DD 格式的日期，空值返回零值时间
+func parseHistogramDate(v, name string, loc *time.Location) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s 必须为 YYYY-MM-DD 格式的日期", name)
+	}
+	return t, nil
+}
+
+// computeHistogram 统计 [from, to] 内各时间桶的数量并按时间升序返回；
+// from 或 to 为零值时使用数据中最早或最晚的桶
+func computeHistogram(todos []*models.Todo, field func(*models.Todo) *time.Time, interval histogramInterval, loc *time.Location, from, to time.Time) ([]HistogramBucket, error) {
+	var end time.Time
+	if !to.IsZero() {
+		// to 当天整天都包含在内
+		_, end = dayRange(to, loc)
+	}
+
+	counts := make(map[time.Time]int)
+	var first, last time.Time
+	for _, todo := range todos {
+		t := field(todo)
+		if t == nil || (!from.IsZero() && t.Before(from)) || (!end.IsZero() && !t.Before(end)) {
+			continue
+		}
+		start := interval.start(*t, loc)
+		counts[start]++
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+
+	if !from.IsZero() {
+		first = interval.start(from, loc)
+	}
+	if !to.IsZero() {
+		last = interval.start(to, loc)
+	}
+	buckets := make([]HistogramBucket, 0)
+	if first.IsZero() || last.IsZero() {
+		return buckets, nil
+	}
+
+	for start := first; !start.After(last); start = interval.next(start) {
+		if len(buckets) >= maxHistogramBuckets {
+			return nil, errors.New("时间范围过大，请缩小 from 和 to 的范围或使用更大的 interval")
+		}
+		buckets = append(buckets, HistogramBucket{Bucket: start.Format(interval.layout), Count: counts[start]})
+	}
+	return buckets, nil
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+func TestComputeHistogram(t *testing.T) {
+	at := func(v string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	completed := func(v string) *time.Time {
+		parsed := at(v)
+		return &parsed
+	}
+	todos := []*models.Todo{
+		{ID: 1, CreatedAt: at("2024-06-01T10:00:00Z"), CompletedAt: completed("2024-06-02T05:00:00Z")},
+		{ID: 2, CreatedAt: at("2024-06-01T23:30:00Z")}, // 上海 06-02
+		{ID: 3, CreatedAt: at("2024-06-03T08:00:00Z"), CompletedAt: completed("2024-06-03T09:00:00Z")},
+		{ID: 4, CreatedAt: at("2024-06-09T12:00:00Z")}, // 周日
+		{ID: 5, CreatedAt: at("2024-06-10T00:00:00Z")}, // 周一零点
+		{ID: 6, CreatedAt: at("2024-07-02T00:00:00Z")},
+	}
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(v string, loc *time.Location) time.Time {
+		parsed, _ := time.ParseInLocation("2006-01-02", v, loc)
+		return parsed
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		interval string
+		loc      *time.Location
+		from, to time.Time
+		want     []HistogramBucket
+	}{
+		{"按日且指定范围", "created_at", "day", time.UTC, day("2024-06-01", time.UTC), day("2024-06-04", time.UTC),
+			[]HistogramBucket{{"2024-06-01", 2}, {"2024-06-02", 0}, {"2024-06-03", 1}, {"2024-06-04", 0}}},
+		{"按周从周一开始", "created_at", "week", time.UTC, time.Time{}, time.Time{},
+			[]HistogramBucket{{"2024-05-27", 2}, {"2024-06-03", 2}, {"2024-06-10", 1}, {"2024-06-17", 0}, {"2024-06-24", 0}, {"2024-07-01", 1}}},
+		{"按月", "created_at", "month", time.UTC, time.Time{}, time.Time{},
+			[]HistogramBucket{{"2024-06", 5}, {"2024-07", 1}}},
+		{"按配置时区分桶", "created_at", "day", shanghai, day("2024-06-01", shanghai), day("2024-06-02", shanghai),
+			[]HistogramBucket{{"2024-06-01", 1}, {"2024-06-02", 1}}},
+		{"按完成时间且跳过未完成", "completed_at", "day", time.UTC, time.Time{}, time.Time{},
+			[]HistogramBucket{{"2024-06-02", 1}, {"2024-06-03", 1}}},
+		{"范围内没有数据", "created_at", "month", time.UTC, time.Time{}, day("2024-05-31", time.UTC),
+			[]HistogramBucket{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := computeHistogram(todos, histogramFields[tt.field], histogramIntervals[tt.interval], tt.loc, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("结果 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := computeHistogram(todos, histogramFields["created_at"], histogramIntervals["day"], time.UTC, day("2020-01-01", time.UTC), day("2024-01-01", time.UTC)); err == nil {
+		t.Error("超过桶数上限时没有返回错误")
+	}
+}
+
+func TestGetHistogram(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b")
+
+	rec := serve(t, h, http.MethodGet, "/api/todos/histogram?interval=month", "")
+	expectStatus(t, rec, http.StatusOK)
+	got := decodeResponse[[]HistogramBucket](t, rec)
+	if len(got) != 1 || got[0].Count != 2 {
+		t.Errorf("结果 = %v，期望一个计数为 2 的桶", got)
+	}
+
+	for _, query := range []string{
+		"field=updated_at",
+		"interval=year",
+		"from=2024-13-01",
+		"to=06/01/2024",
+		"from=2024-06-02&to=2024-06-01",
+		"tz=Mars/Olympus",
+	} {
+		expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/histogram?"+query, ""), http.StatusBadRequest)
+	}
+}
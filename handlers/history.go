@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-todolist/models"
+)
+
+// VersionDiff 某个版本相对上一版本的字段变更
+type VersionDiff struct {
+	Version int                  `json:"version"`
+	Changes []models.FieldChange `json:"changes"`
+}
+
+// serveHistory 处理 /api/todos/{id}/history 及 /api/todos/{id}/history/{version}/diff
+func (h *TodoHandler) serveHistory(w http.ResponseWriter, r *http.Request, id int, sub string) {
+	versions, err := h.storage.History(r.Context(), id)
+	if err != nil {
+		writeStorageError(w, err, "获取历史版本失败")
+		return
+	}
+
+	if sub == "" {
+		writeJSONResponse(w, http.StatusOK, versions)
+		return
+	}
+
+	versionStr, rest, _ := strings.Cut(sub, "/")
+	if rest != "diff" {
+		writeErrorResponse(w, http.StatusNotFound, "路径未找到")
+		return
+	}
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version <= 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "版本号必须为正整数")
+		return
+	}
+
+	diff, ok := diffVersion(versions, version)
+	if !ok {
+		writeErrorResponse(w, http.StatusNotFound, "版本未找到")
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, diff)
+}
+
+// diffVersion 计算指定版本相对上一版本的变更，第 1 版与空状态比较；
+// 版本或其上一版本已不再保留时返回 false
+func diffVersion(versions []models.TodoVersion, version int) (VersionDiff, bool) {
+	for i, v := range versions {
+		if v.Version != version {
+			continue
+		}
+
+		var before models.Todo
+		switch {
+		case i > 0:
+			before = versions[i-1].Todo
+		case version > 1:
+			return VersionDiff{}, false
+		}
+		return VersionDiff{Version: version, Changes: models.DiffTodos(before, v.Todo)}, true
+	}
+	return VersionDiff{}, false
+}
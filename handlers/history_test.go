@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHistoryDiff(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreate(t, h, `{"title": "a", "priority": "low"}`)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", `{"title": "b", "priority": "high", "completed": true}`), http.StatusOK)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", `{"title": "b"}`), http.StatusOK)
+
+	fields := func(diff VersionDiff) []string {
+		names := make([]string, len(diff.Changes))
+		for i, change := range diff.Changes {
+			names[i] = change.Field
+		}
+		return names
+	}
+
+	rec := serve(t, h, http.MethodGet, "/api/todos/1/history/2/diff", "")
+	expectStatus(t, rec, http.StatusOK)
+	diff := decodeResponse[VersionDiff](t, rec)
+	if got := fields(diff); diff.Version != 2 || len(got) < 3 || got[0] != "title" || got[1] != "completed" {
+		t.Errorf("第 2 版变更字段 = %v，期望以 title、completed 开头并包含 priority", got)
+	}
+	for _, change := range diff.Changes {
+		if change.Field == "title" && (change.Before != "a" || change.After != "b") {
+			t.Errorf("title 变更 = %+v，期望 a -> b", change)
+		}
+	}
+
+	// 没有实际变化的更新得到空的变更列表
+	rec = serve(t, h, http.MethodGet, "/api/todos/1/history/3/diff", "")
+	expectStatus(t, rec, http.StatusOK)
+	if diff := decodeResponse[VersionDiff](t, rec); diff.Changes == nil || len(diff.Changes) != 0 {
+		t.Errorf("第 3 版变更 = %+v，期望空列表", diff.Changes)
+	}
+
+	// 第 1 版与空状态比较
+	if got := fields(decodeResponse[VersionDiff](t, serve(t, h, http.MethodGet, "/api/todos/1/history/1/diff", ""))); len(got) == 0 || got[0] != "id" {
+		t.Errorf("第 1 版变更字段 = %v，期望从 id 开始", got)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/1/history/4/diff", ""), http.StatusNotFound)
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/1/history/0/diff", ""), http.StatusBadRequest)
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/1/history/2/patch", ""), http.StatusNotFound)
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/9/history/1/diff", ""), http.StatusNotFound)
+}
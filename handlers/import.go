@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// maxImportSize 导入请求体的最大字节数
+const maxImportSize = 5 << 20
+
+// ImportRowError 导入时某一行的错误，Line 为 CSV 中的行号（从 1 开始，含表头）
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Imported int              `json:"imported"`
+	Todos    []*TodoResponse  `json:"todos"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// csvImportColumns CSV 表头与创建请求字段的对应关系
+var csvImportColumns = map[string]func(req *models.CreateTodoRequest, value string) error{
+	"title": func(req *models.CreateTodoRequest, value string) error {
+		req.Title = value
+		return nil
+	},
+	"description": func(req *models.CreateTodoRequest, value string) error {
+		req.Description = value
+		return nil
+	},
+	"color": func(req *models.CreateTodoRequest, value string) error {
+		req.Color = value
+		return nil
+	},
+	"priority": func(req *models.CreateTodoRequest, value string) error {
+		req.Priority = models.Priority(value)
+		return nil
+	},
+	"due_date": func(req *models.CreateTodoRequest, value string) error {
+		if value == "" {
+			return nil
+		}
+		due, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("due_date 必须为 RFC3339 格式")
+		}
+		req.DueDate = &due
+		return nil
+	},
+	"list_id": func(req *models.CreateTodoRequest, value string) error {
+		req.ListID = value
+		return nil
+	},
+	"estimate_minutes": func(req *models.CreateTodoRequest, value string) error {
+		return parseCSVInt(value, "estimate_minutes", &req.EstimateMinutes)
+	},
+	"spent_minutes": func(req *models.CreateTodoRequest, value string) error {
+		return parseCSVInt(value, "spent_minutes", &req.SpentMinutes)
+	},
+}
+
+// parseCSVInt 解析整数列，空值视为 0
+func parseCSVInt(value, field string, dst *int) error {
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%s 必须为整数", field)
+	}
+	*dst = n
+	return nil
+}
+
+// handleImport 处理批量导入；默认导入所有有效行，atomic=true 时任一行出错则全部不导入
+func (h *TodoHandler) handleImport(w http.ResponseWriter, r *http.Request) {
+	if format := r.URL.Query().Get("format"); format != "csv" {
+		writeErrorResponse(w, http.StatusBadRequest, "不支持的导入格式，目前仅支持 format=csv")
+		return
+	}
+	atomic := r.URL.Query().Get("atomic") == "true"
+
+	requests, lines, rowErrors, err := parseCSVImport(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.checkBatchSize(len(requests) + len(rowErrors)); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := ImportResult{Todos: []*TodoResponse{}, Errors: rowErrors}
+	if atomic && len(rowErrors) > 0 {
+		writeJSONResponse(w, http.StatusBadRequest, result)
+		return
+	}
+
+	var created []*models.Todo
+	create := func(s storage.TodoStorage) error {
+		// 事务重试时 create 会再次执行，每次都从头收集结果
+		created, result.Errors = nil, rowErrors
+		for i, req := range requests {
+			todo, err := s.Create(r.Context(), req)
+			if err != nil {
+				if atomic {
+					return err
+				}
+				result.Errors = append(result.Errors, ImportRowError{Line: lines[i], Error: "创建待办事项失败"})
+				continue
+			}
+			created = append(created, todo)
+		}
+		return nil
+	}
+	if atomic {
+		err = h.withTx(r.Context(), create)
+	} else {
+		err = create(h.storage)
+	}
+	if err != nil {
+		writeStorageError(w, err, "导入待办事项失败")
+		return
+	}
+
+	for _, todo := range created {
+		h.publishTodo(EventCreated, todo)
+	}
+	result.Todos = h.todoResponses(created)
+	result.Imported = len(created)
+	writeJSONResponse(w, http.StatusOK, result)
+}
+
+// parseCSVImport 解析带表头的 CSV，返回通过校验的创建请求及其行号，以及逐行的错误；
+// CSV 本身格式错误时返回 error
+func parseCSVImport(r io.Reader) ([]*models.CreateTodoRequest, []int, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil, errors.New("CSV 内容为空")
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("CSV 格式错误: %v", err)
+	}
+
+	columns := make([]string, len(header))
+	hasTitle := false
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := csvImportColumns[name]; !ok {
+			return nil, nil, nil, fmt.Errorf("未知的列: %s", name)
+		}
+		columns[i] = name
+		hasTitle = hasTitle || name == "title"
+	}
+	if !hasTitle {
+		return nil, nil, nil, errors.New("CSV 缺少 title 列")
+	}
+
+	var (
+		requests  []*models.CreateTodoRequest
+		lines     []int
+		rowErrors = []ImportRowError{}
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("CSV 格式错误: %v", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(columns) {
+			rowErrors = append(rowErrors, ImportRowError{
+				Line:  line,
+				Error: fmt.Sprintf("列数应为 %d，实际为 %d", len(columns), len(record)),
+			})
+			continue
+		}
+
+		req := &models.CreateTodoRequest{}
+		if err := applyCSVRecord(req, columns, record); err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Line: line, Error: err.Error()})
+			continue
+		}
+		requests = append(requests, req)
+		lines = append(lines, line)
+	}
+
+	return requests, lines, rowErrors, nil
+}
+
+// applyCSVRecord 将一行数据填充到创建请求并进行校验
+func applyCSVRecord(req *models.CreateTodoRequest, columns, record []string) error {
+	for i, value := range record {
+		if err := csvImportColumns[columns[i]](req, strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+	return req.Validate()
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+func TestImportCSV(t *testing.T) {
+	h := newTestHandler(t)
+	body := "title,priority,due_date,estimate_minutes\n" +
+		"写周报,high,2024-06-20T09:00:00Z,30\n" +
+		"\"带逗号, 和引号\"\"的标题\",low,,\n"
+	rec := serve(t, h, http.MethodPost, "/api/todos/import?format=csv", body, "Content-Type", "text/csv")
+	expectStatus(t, rec, http.StatusOK)
+	result := decodeResponse[ImportResult](t, rec)
+	if result.Imported != 2 || len(result.Errors) != 0 {
+		t.Fatalf("导入结果 = %+v，期望导入 2 项且没有错误", result)
+	}
+	first, second := result.Todos[0], result.Todos[1]
+	if first.Title != "写周报" || first.Priority != models.PriorityHigh || first.EstimateMinutes != 30 ||
+		first.DueDate == nil || !first.DueDate.Equal(time.Date(2024, 6, 20, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("第一项 = %+v", first)
+	}
+	if second.Title != `带逗号, 和引号"的标题` || second.Priority != models.PriorityLow || second.DueDate != nil {
+		t.Errorf("第二项 = %+v", second)
+	}
+	if got := len(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))); got != 2 {
+		t.Errorf("导入后共 %d 项，期望 2", got)
+	}
+}
+
+func TestImportCSVRowErrors(t *testing.T) {
+	body := "title,priority,estimate_minutes\n" +
+		"a,high,10\n" +
+		"b,critical,\n" +
+		",low,\n" +
+		"c,low,abc\n" +
+		"d,low\n" +
+		"e,,\n"
+	wantErrors := []int{3, 4, 5, 6}
+
+	h := newTestHandler(t)
+	rec := serve(t, h, http.MethodPost, "/api/todos/import?format=csv", body)
+	expectStatus(t, rec, http.StatusOK)
+	result := decodeResponse[ImportResult](t, rec)
+	var lines []int
+	for _, rowErr := range result.Errors {
+		if rowErr.Error == "" {
+			t.Errorf("第 %d 行缺少错误信息", rowErr.Line)
+		}
+		lines = append(lines, rowErr.Line)
+	}
+	if result.Imported != 2 || !reflect.DeepEqual(lines, wantErrors) {
+		t.Errorf("导入 %d 项，错误行 %v，期望导入 2 项，错误行 %v", result.Imported, lines, wantErrors)
+	}
+	if got := []string{result.Todos[0].Title, result.Todos[1].Title}; !reflect.DeepEqual(got, []string{"a", "e"}) {
+		t.Errorf("导入的标题 = %v，期望 [a e]", got)
+	}
+
+	// atomic=true 时任一行出错则全部不导入
+	atomic := newTestHandler(t)
+	rec = serve(t, atomic, http.MethodPost, "/api/todos/import?format=csv&atomic=true", body)
+	expectStatus(t, rec, http.StatusBadRequest)
+	if result := decodeResponse[ImportResult](t, rec); result.Imported != 0 || len(result.Errors) != len(wantErrors) {
+		t.Errorf("原子导入结果 = %+v，期望不导入并报告 %d 个错误", result, len(wantErrors))
+	}
+	if got := len(decodeResponse[[]*models.Todo](t, serve(t, atomic, http.MethodGet, "/api/todos", ""))); got != 0 {
+		t.Errorf("原子导入失败后共 %d 项，期望 0", got)
+	}
+}
+
+func TestImportCSVMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"引号未闭合", "/api/todos/import?format=csv", "title\n\"未闭合\n"},
+		{"字段中出现裸引号", "/api/todos/import?format=csv", "title\na\"b\n"},
+		{"内容为空", "/api/todos/import?format=csv", ""},
+		{"未知的列", "/api/todos/import?format=csv", "title,owner\na,b\n"},
+		{"缺少 title 列", "/api/todos/import?format=csv", "priority\nhigh\n"},
+		{"不支持的格式", "/api/todos/import?format=xml", "title\na\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t)
+			expectStatus(t, serve(t, h, http.MethodPost, tt.target, tt.body), http.StatusBadRequest)
+			if got := len(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))); got != 0 {
+				t.Errorf("格式错误时导入了 %d 项", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencySampleSize 每个路由保留的最近耗时样本数
+const latencySampleSize = 1000
+
+// maxLatencyRoutes 最多统计的路由数，避免大量不同路径导致内存无限增长
+const maxLatencyRoutes = 200
+
+// RouteLatency 单个路由的耗时统计，耗时单位为毫秒
+type RouteLatency struct {
+	Route string  `json:"route"`
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P95   float64 `json:"p95_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// latencySamples 固定容量的环形样本缓冲区
+type latencySamples struct {
+	samples []time.Duration
+	next    int
+}
+
+// LatencyRecorder 按路由记录最近的请求耗时
+type LatencyRecorder struct {
+	routes map[string]*latencySamples
+	mutex  sync.Mutex
+}
+
+// NewLatencyRecorder 创建新的耗时记录器
+func NewLatencyRecorder() *LatencyRecorder {
+	return &LatencyRecorder{
+		routes: make(map[string]*latencySamples),
+	}
+}
+
+// Record 记录一次请求耗时，样本满后覆盖最旧的样本
+func (l *LatencyRecorder) Record(route string, d time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	s, exists := l.routes[route]
+	if !exists {
+		if len(l.routes) >= maxLatencyRoutes {
+			return
+		}
+		s = &latencySamples{samples: make([]time.Duration, 0, latencySampleSize)}
+		l.routes[route] = s
+	}
+
+	if len(s.samples) < latencySampleSize {
+		s.samples = append(s.samples, d)
+		return
+	}
+	s.samples[s.next] = d
+	s.next = (s.next + 1) % latencySampleSize
+}
+
+// Summary 返回各路由的耗时统计，按 p95 从高到低排列
+func (l *LatencyRecorder) Summary() []RouteLatency {
+	l.mutex.Lock()
+	summary := make([]RouteLatency, 0, len(l.routes))
+	for route, s := range l.routes {
+		summary = append(summary, summarizeLatency(route, s.samples))
+	}
+	l.mutex.Unlock()
+
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].P95 != summary[j].P95 {
+			return summary[i].P95 > summary[j].P95
+		}
+		return summary[i].Route < summary[j].Route
+	})
+	return summary
+}
+
+// summarizeLatency 计算一组样本的统计值
+func summarizeLatency(route string, samples []time.Duration) RouteLatency {
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	result := RouteLatency{Route: route, Count: len(sorted)}
+	if len(sorted) == 0 {
+		return result
+	}
+	result.P50 = durationMillis(percentile(sorted, 0.50))
+	result.P95 = durationMillis(percentile(sorted, 0.95))
+	result.Max = durationMillis(sorted[len(sorted)-1])
+	return result
+}
+
+// percentile 按最近秩法取已排序样本的分位数
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// durationMillis 将耗时转换为毫秒
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// latencyRoute 将请求归类为路由，路径中的数字段替换为 {id} 以合并同类请求
+func latencyRoute(r *http.Request) string {
+	segments := strings.Split(r.URL.Path, "/")
+	for i, segment := range segments {
+		if segment != "" && strings.Trim(segment, "0123456789") == "" {
+			segments[i] = "{id}"
+		}
+	}
+	return r.Method + " " + strings.Join(segments, "/")
+}
+
+// RecordLatency 记录每个请求的处理耗时
+func RecordLatency(next http.Handler, recorder *LatencyRecorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		recorder.Record(latencyRoute(r), time.Since(start))
+	})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLatencySummary(t *testing.T) {
+	recorder := NewLatencyRecorder()
+	// 乱序写入 1ms 到 100ms
+	for i := 100; i >= 1; i -= 2 {
+		recorder.Record("GET /api/todos", time.Duration(i)*time.Millisecond)
+	}
+	for i := 1; i <= 100; i += 2 {
+		recorder.Record("GET /api/todos", time.Duration(i)*time.Millisecond)
+	}
+	recorder.Record("GET /api/todos/{id}", 5*time.Millisecond)
+	recorder.Record("POST /api/todos", 1500*time.Microsecond)
+	recorder.Record("POST /api/todos", 2500*time.Microsecond)
+
+	want := []RouteLatency{
+		{Route: "GET /api/todos", Count: 100, P50: 50, P95: 95, Max: 100},
+		{Route: "GET /api/todos/{id}", Count: 1, P50: 5, P95: 5, Max: 5},
+		{Route: "POST /api/todos", Count: 2, P50: 1.5, P95: 2.5, Max: 2.5},
+	}
+	if got := recorder.Summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Summary() = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestLatencyBoundedSamples(t *testing.T) {
+	recorder := NewLatencyRecorder()
+	// 超出容量后只保留最近的 latencySampleSize 个样本，即 501ms 到 1500ms
+	for i := 1; i <= latencySampleSize+500; i++ {
+		recorder.Record("GET /", time.Duration(i)*time.Millisecond)
+	}
+	got := recorder.Summary()[0]
+	if got.Count != latencySampleSize || got.P50 != 1000 || got.P95 != 1450 || got.Max != 1500 {
+		t.Errorf("Summary() = %+v，期望 count=%d p50=1000 p95=1450 max=1500", got, latencySampleSize)
+	}
+
+	// 路由数达到上限后忽略新的路由
+	for i := 0; i < maxLatencyRoutes+10; i++ {
+		recorder.Record(fmt.Sprintf("GET /r%d", i), time.Millisecond)
+	}
+	if got := len(recorder.Summary()); got != maxLatencyRoutes {
+		t.Errorf("统计的路由数 = %d，期望 %d", got, maxLatencyRoutes)
+	}
+}
+
+func TestLatencyRoute(t *testing.T) {
+	tests := []struct {
+		method, target, want string
+	}{
+		{http.MethodGet, "/api/todos", "GET /api/todos"},
+		{http.MethodPatch, "/api/todos/42", "PATCH /api/todos/{id}"},
+		{http.MethodDelete, "/api/todos/42/comments/7", "DELETE /api/todos/{id}/comments/{id}"},
+		{http.MethodGet, "/api/todos/v2", "GET /api/todos/v2"},
+	}
+	for _, tt := range tests {
+		if got := latencyRoute(httptest.NewRequest(tt.method, tt.target, nil)); got != tt.want {
+			t.Errorf("latencyRoute(%s %s) = %q，期望 %q", tt.method, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestAdminLatency(t *testing.T) {
+	h := newTestHandler(t, func(c *Config) { c.AdminKey = "secret" })
+	handler := RecordLatency(h, h.Latency())
+	for i := 0; i < 3; i++ {
+		serve(t, handler, http.MethodGet, fmt.Sprintf("/api/todos/%d", i+1), "")
+	}
+
+	rec := serve(t, h.AdminHandler(), http.MethodGet, "/api/admin/latency", "", adminKeyHeader, "secret")
+	expectStatus(t, rec, http.StatusOK)
+	summary := decodeResponse[[]RouteLatency](t, rec)
+	if len(summary) != 1 || summary[0].Route != "GET /api/todos/{id}" || summary[0].Count != 3 {
+		t.Fatalf("耗时统计 = %+v，期望 GET /api/todos/{id} 共 3 次", summary)
+	}
+	if s := summary[0]; s.P50 < 0 || s.P50 > s.P95 || s.P95 > s.Max {
+		t.Errorf("分位数不满足 0 <= p50 <= p95 <= max: %+v", s)
+	}
+}
@@ -0,0 +1,658 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-todolist/models"
+	querylang "go-todolist/query"
+	"go-todolist/storage"
+)
+
+// todoFilter 列表过滤条件，返回 true 表示保留该待办事项
+type todoFilter func(todo *models.Todo) bool
+
+// todoLess 排序比较函数，a 应排在 b 之前时返回 true
+type todoLess func(a, b *models.Todo) bool
+
+// listField 列表接口中可过滤和/或可排序的字段
+type listField struct {
+	// option 将同名查询参数解析为存储层的查询条件，由存储执行过滤
+	option func(h *TodoHandler, value string, opts *storage.ListOptions) error
+	// filter 将同名查询参数解析为处理器中执行的过滤条件，用于依赖请求时区等存储无法处理的条件。
+	// option 和 filter 都为 nil 表示不可过滤
+	filter func(h *TodoHandler, r *http.Request, value string) (todoFilter, error)
+	// matchEmpty 为 true 时空值也作为过滤条件，否则空值视为未设置
+	matchEmpty bool
+	// less 排序比较函数，为 nil 表示不可排序
+	less todoLess
+}
+
+// listFields 列表接口可过滤、可排序字段的注册表，只有在此登记的字段才能用于过滤和 sort 参数
+var listFields = map[string]listField{
+	"id": {less: func(a, b *models.Todo) bool {
+		return a.ID < b.ID
+	}},
+	"title": {less: func(a, b *models.Todo) bool {
+		return a.Title < b.Title
+	}},
+	"created_at": {less: func(a, b *models.Todo) bool {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}},
+	"updated_at": {less: func(a, b *models.Todo) bool {
+		return a.UpdatedAt.Before(b.UpdatedAt)
+	}},
+	"position": {less: func(a, b *models.Todo) bool {
+		return a.OrderPosition() < b.OrderPosition()
+	}},
+	"priority": {option: priorityOption, less: func(a, b *models.Todo) bool {
+		return a.Priority.Weight() < b.Priority.Weight()
+	}},
+	"due_date": {less: func(a, b *models.Todo) bool {
+		// 没有截止时间的视为最晚
+		if a.DueDate == nil || b.DueDate == nil {
+			return a.DueDate != nil && b.DueDate == nil
+		}
+		return a.DueDate.Before(*b.DueDate)
+	}},
+	"completed":      {option: boolOption("completed", func(opts *storage.ListOptions, v *bool) { opts.Completed = v })},
+	"starred":        {option: boolOption("starred", func(opts *storage.ListOptions, v *bool) { opts.Starred = v })},
+	"archived":       {option: boolOption("archived", func(opts *storage.ListOptions, v *bool) { opts.Archived = v })},
+	"list_id":        {option: listIDOption, matchEmpty: true},
+	"tag":            {option: tagOption},
+	"q":              {option: queryOption},
+	"created_after":  {option: timeOption("created_after", func(opts *storage.ListOptions, t time.Time) { opts.CreatedAfter = t })},
+	"created_before": {option: timeOption("created_before", func(opts *storage.ListOptions, t time.Time) { opts.CreatedBefore = t })},
+	"updated_after":  {option: timeOption("updated_after", func(opts *storage.ListOptions, t time.Time) { opts.UpdatedAfter = t })},
+	"updated_before": {option: timeOption("updated_before", func(opts *storage.ListOptions, t time.Time) { opts.UpdatedBefore = t })},
+	"due_after":      {option: timeOption("due_after", func(opts *storage.ListOptions, t time.Time) { opts.DueAfter = t })},
+	"due_before":     {option: timeOption("due_before", func(opts *storage.ListOptions, t time.Time) { opts.DueBefore = t })},
+	"due":            {filter: dueFilter},
+	"due_on":         {filter: dueOnFilter},
+}
+
+// listFieldNames listFields 中的字段名，按名称排序以保证解析顺序和错误信息稳定
+var listFieldNames = func() []string {
+	names := make([]string, 0, len(listFields))
+	for name := range listFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// sortableFieldNames listFields 中可排序的字段名，按名称排序
+var sortableFieldNames = func() []string {
+	var names []string
+	for _, name := range listFieldNames {
+		if listFields[name].less != nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}()
+
+// listQuery 列表接口的查询参数，按 过滤 -> 排序 -> 分页 -> 投影 的顺序执行
+type listQuery struct {
+	// options 由存储执行的过滤条件，filters 为之后在处理器中执行的过滤条件
+	options storage.ListOptions
+	filters []todoFilter
+	sortBy  string
+	desc    bool
+	limit   int
+	offset  int
+	fields  []string
+	// externalID 非空时通过外部ID索引查找，而不是遍历全部待办事项
+	externalID string
+	// ids 非空时只按ID逐个获取这些待办事项，不存在的ID会被忽略
+	ids []int
+	// asMap 为 true 时以 ID 字符串为键返回对象而不是数组
+	asMap bool
+	// envelope 为 true 时返回带总数的 ListResponse 而不是直接返回列表
+	envelope bool
+	// cursor 不为 nil 时按 (created_at, id) 游标分页，取代 offset，响应为 CursorResponse
+	cursor *storage.Keyset
+	// nextCursor 游标分页时由 page 设置的下一页游标
+	nextCursor string
+}
+
+// ListResponse 列表接口指定 envelope=true 时的响应结构，Items 为数组或（as=map 时）对象
+type ListResponse struct {
+	Items  interface{} `json:"items"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit,omitempty"`
+	Offset int         `json:"offset"`
+}
+
+// listQueryParams 列表接口支持的全部查询参数：注册表中的可过滤字段加上以下控制参数，
+// 严格模式下其余参数会被拒绝
+var listQueryParams = func() map[string]bool {
+	params := map[string]bool{
+		"external_id": true,
+		"ids":         true,
+		"as":          true,
+		"tz":          true,
+		"sort":        true,
+		"order":       true,
+		"limit":       true,
+		"offset":      true,
+		"fields":      true,
+		"envelope":    true,
+		"cursor":      true,
+		"filter":      true,
+	}
+	for name, field := range listFields {
+		if field.option != nil || field.filter != nil {
+			params[name] = true
+		}
+	}
+	return params
+}()
+
+// checkQueryParams 返回第一个（按名称排序）不在 known 中的查询参数对应的错误
+func checkQueryParams(query url.Values, known map[string]bool) error {
+	var unknown []string
+	for key := range query {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("不支持的查询参数: %s", unknown[0])
+}
+
+// parseListQuery 解析并校验列表接口的全部查询参数，严格模式下拒绝未知参数
+func (h *TodoHandler) parseListQuery(r *http.Request) (*listQuery, error) {
+	if h.config.StrictQuery {
+		if err := checkQueryParams(r.URL.Query(), listQueryParams); err != nil {
+			return nil, err
+		}
+	}
+
+	options, filters, err := h.parseListFilters(r)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset, err := h.parsePagination(r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	q := &listQuery{
+		options:    options,
+		filters:    filters,
+		sortBy:     "id",
+		limit:      limit,
+		offset:     offset,
+		externalID: query.Get("external_id"),
+	}
+
+	if q.externalID != "" {
+		// 与 ids 同时指定时外部ID作为过滤条件生效
+		externalID := q.externalID
+		q.filters = append(q.filters, func(todo *models.Todo) bool {
+			return todo.ExternalID == externalID
+		})
+	}
+
+	if v := query.Get("ids"); v != "" {
+		ids, err := h.parseIDList(v)
+		if err != nil {
+			return nil, err
+		}
+		q.ids = ids
+	}
+	switch query.Get("as") {
+	case "", "array":
+	case "map":
+		if q.ids == nil {
+			return nil, errors.New("as=map 需要同时指定 ids 参数")
+		}
+		q.asMap = true
+	default:
+		return nil, errors.New("as 必须为 array 或 map")
+	}
+
+	if v := query.Get("sort"); v != "" {
+		if field, ok := listFields[v]; !ok || field.less == nil {
+			return nil, fmt.Errorf("不支持按 %s 排序，可用的排序字段: %s", v, strings.Join(sortableFieldNames, "、"))
+		}
+		q.sortBy = v
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		q.desc = true
+	default:
+		return nil, errors.New("order 必须为 asc 或 desc")
+	}
+
+	if query.Has("cursor") {
+		if query.Has("offset") {
+			return nil, errors.New("cursor 不能与 offset 同时使用")
+		}
+		if v := query.Get("sort"); (v != "" && v != "created_at") || q.desc {
+			return nil, errors.New("cursor 分页固定按 created_at 升序排列")
+		}
+		cursor, err := parseCursor(query.Get("cursor"))
+		if err != nil {
+			return nil, err
+		}
+		q.cursor = cursor
+		q.sortBy = "created_at"
+		// 游标分页总是分页，未指定 limit 时每页取上限
+		if q.limit == 0 {
+			q.limit = h.config.MaxLimit
+		}
+	}
+
+	switch query.Get("envelope") {
+	case "", "false":
+	case "true":
+		q.envelope = true
+	default:
+		return nil, errors.New("envelope 必须为 true 或 false")
+	}
+
+	if v := query.Get("fields"); v != "" {
+		fields, err := parseFields(v)
+		if err != nil {
+			return nil, err
+		}
+		q.fields = fields
+	}
+
+	return q, nil
+}
+
+// excludeArchived 未指定归档条件时排除归档的待办事项；按ID或外部ID查找时不排除。
+// 需在合并完所有来源（查询参数、filter、保存的过滤器）的条件之后调用，否则会与其中的 archived 条件重复
+func (q *listQuery) excludeArchived() {
+	if q.options.Archived == nil && q.ids == nil && q.externalID == "" {
+		archived := false
+		q.options.Archived = &archived
+	}
+}
+
+// storageSorts 能交给存储执行的排序字段
+var storageSorts = map[string]storage.ListSort{
+	"id":       storage.SortByID,
+	"priority": storage.SortByPriority,
+}
+
+// pagedInStorage 判断能否直接在存储中排序和分页：不按ID或外部ID查找、只有存储执行的过滤条件，
+// 并且按 storageSorts 中的字段排序或使用游标分页
+func (q *listQuery) pagedInStorage() bool {
+	if q.ids != nil || q.externalID != "" || len(q.filters) > 0 {
+		return false
+	}
+	_, ok := storageSorts[q.sortBy]
+	return q.cursor != nil || ok
+}
+
+// storageOptions 返回交给存储执行的条件，能在存储中分页时带上排序和分页参数。
+// 游标分页多取一项，由 cursorPage 判断是否还有下一页
+func (q *listQuery) storageOptions() storage.ListOptions {
+	options := q.options
+	switch {
+	case !q.pagedInStorage():
+	case q.cursor != nil:
+		options.After, options.Limit = q.cursor, q.limit+1
+	default:
+		options.Limit, options.Offset = q.limit, q.offset
+		options.Sort, options.Desc = storageSorts[q.sortBy], q.desc
+	}
+	return options
+}
+
+// page 依次执行处理器中的过滤、排序和分页，同时返回分页前符合条件的总数。
+// todos 需已满足 options 中的条件
+func (q *listQuery) page(todos []*models.Todo) ([]*models.Todo, int) {
+	todos = filterTodos(todos, q.filters)
+	total := len(todos)
+	todos = sortTodos(todos, q.sortBy, q.desc)
+	if q.cursor != nil {
+		todos, q.nextCursor = cursorPage(todos, q.cursor, q.limit)
+		return todos, total
+	}
+	return paginate(todos, q.limit, q.offset), total
+}
+
+// render 对分页后映射为响应结构的待办事项执行投影，并按 as、envelope 和 cursor 参数构造响应
+func (q *listQuery) render(todos []*TodoResponse, total int) (interface{}, error) {
+	result, err := q.project(todos)
+	if err != nil {
+		return nil, err
+	}
+	if q.cursor != nil {
+		return CursorResponse{Items: result, Total: total, NextCursor: q.nextCursor}, nil
+	}
+	if !q.envelope {
+		return result, nil
+	}
+	return ListResponse{Items: result, Total: total, Limit: q.limit, Offset: q.offset}, nil
+}
+
+// project 按 fields 参数投影，as=map 时转换为以ID字符串为键的对象
+func (q *listQuery) project(todos []*TodoResponse) (interface{}, error) {
+	var projected []map[string]json.RawMessage
+	if len(q.fields) > 0 {
+		var err error
+		if projected, err = projectTodos(todos, q.fields); err != nil {
+			return nil, err
+		}
+	}
+	if !q.asMap {
+		if projected != nil {
+			return projected, nil
+		}
+		return todos, nil
+	}
+
+	byID := make(map[string]interface{}, len(todos))
+	for i, todo := range todos {
+		var item interface{} = todo
+		if projected != nil {
+			item = projected[i]
+		}
+		byID[strconv.Itoa(int(todo.ID))] = item
+	}
+	return byID, nil
+}
+
+// parseIDList 解析逗号分隔的ID列表并去重，数量不能超过批量上限
+func (h *TodoHandler) parseIDList(v string) ([]int, error) {
+	parts := strings.Split(v, ",")
+	if len(parts) > h.config.MaxBatchSize {
+		return nil, fmt.Errorf("ids 数量不能超过%d个", h.config.MaxBatchSize)
+	}
+	ids := make([]int, 0, len(parts))
+	seen := make(map[int]bool, len(parts))
+	for _, part := range parts {
+		id, err := parseID(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("ids 中的 %s: %v", part, err)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// parseListFilters 按注册表解析列表接口的过滤参数，分别返回由存储执行和在处理器中执行的过滤条件
+func (h *TodoHandler) parseListFilters(r *http.Request) (storage.ListOptions, []todoFilter, error) {
+	query := r.URL.Query()
+	var (
+		options storage.ListOptions
+		filters []todoFilter
+	)
+
+	for _, name := range listFieldNames {
+		field := listFields[name]
+		if (field.option == nil && field.filter == nil) || !query.Has(name) {
+			continue
+		}
+		v := query.Get(name)
+		if v == "" && !field.matchEmpty {
+			continue
+		}
+		if field.option != nil {
+			if err := field.option(h, v, &options); err != nil {
+				return options, nil, err
+			}
+			continue
+		}
+		filter, err := field.filter(h, r, v)
+		if err != nil {
+			return options, nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	// filter 参数使用查询语言表达多个条件，与单独的过滤参数同时使用时两者都生效，但不能重复设置同一条件
+	if v := query.Get("filter"); v != "" {
+		if err := h.applyFilterQuery(v, &options); err != nil {
+			return options, nil, fmt.Errorf("filter 无效: %w", err)
+		}
+	}
+
+	return options, filters, nil
+}
+
+// applyFilterQuery 将查询语句中的条件合并到 opts，开启标签规范化时标签条件忽略大小写
+func (h *TodoHandler) applyFilterQuery(v string, opts *storage.ListOptions) error {
+	if err := querylang.Apply(v, opts); err != nil {
+		return err
+	}
+	if h.config.NormalizeTags && opts.Tag != "" {
+		opts.Tag = strings.TrimSpace(opts.Tag)
+		opts.IgnoreTagCase = true
+	}
+	return nil
+}
+
+// boolOption 返回按布尔字段过滤的解析函数，set 将解析结果写入查询条件
+func boolOption(name string, set func(opts *storage.ListOptions, v *bool)) func(*TodoHandler, string, *storage.ListOptions) error {
+	return func(_ *TodoHandler, v string, opts *storage.ListOptions) error {
+		want, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%s 必须为 true 或 false", name)
+		}
+		set(opts, &want)
+		return nil
+	}
+}
+
+// timeOption 返回按时间范围过滤的解析函数，值为 RFC3339 格式的时间，set 将解析结果写入查询条件
+func timeOption(name string, set func(opts *storage.ListOptions, t time.Time)) func(*TodoHandler, string, *storage.ListOptions) error {
+	return func(_ *TodoHandler, v string, opts *storage.ListOptions) error {
+		// 查询字符串中未编码的 + 会被解码为空格，如 2024-06-01T00:00:00 08:00
+		t, err := time.Parse(time.RFC3339, strings.Replace(v, " ", "+", 1))
+		if err != nil {
+			return fmt.Errorf("%s 必须为 RFC3339 格式的时间，如 2024-06-01T00:00:00Z", name)
+		}
+		set(opts, t)
+		return nil
+	}
+}
+
+// listIDOption 只保留属于指定清单的待办事项，空值匹配不属于任何清单的
+func listIDOption(_ *TodoHandler, listID string, opts *storage.ListOptions) error {
+	opts.ListID = &listID
+	return nil
+}
+
+// tagOption 只保留包含指定标签的待办事项，开启标签规范化时忽略大小写
+func tagOption(h *TodoHandler, v string, opts *storage.ListOptions) error {
+	if h.config.NormalizeTags {
+		v = strings.TrimSpace(v)
+		opts.IgnoreTagCase = true
+	}
+	opts.Tag = v
+	return nil
+}
+
+// dueFilter 按截止时间快捷范围过滤，范围按请求时区计算
+func dueFilter(h *TodoHandler, r *http.Request, v string) (todoFilter, error) {
+	loc, err := h.requestLocation(r)
+	if err != nil {
+		return nil, err
+	}
+	return dueShortcutFilter(v, h.config.Clock(), loc)
+}
+
+// dueOnFilter 只保留截止时间在指定日期（YYYY-MM-DD）内的待办事项，日期边界按请求时区计算
+func dueOnFilter(h *TodoHandler, r *http.Request, v string) (todoFilter, error) {
+	loc, err := h.requestLocation(r)
+	if err != nil {
+		return nil, err
+	}
+	day, err := time.ParseInLocation("2006-01-02", v, loc)
+	if err != nil {
+		return nil, errors.New("due_on 必须为 YYYY-MM-DD 格式的日期")
+	}
+	start, end := dayRange(day, loc)
+	return dueWithin(start, end), nil
+}
+
+// queryOption 只保留标题或描述中包含关键字（忽略大小写）的待办事项，只有空白的关键字视为未设置
+func queryOption(_ *TodoHandler, v string, opts *storage.ListOptions) error {
+	opts.Query = strings.TrimSpace(v)
+	return nil
+}
+
+// priorityOption 只保留优先级在逗号分隔列表中的待办事项
+func priorityOption(_ *TodoHandler, v string, opts *storage.ListOptions) error {
+	for _, part := range strings.Split(v, ",") {
+		priority, err := models.ParsePriority(strings.TrimSpace(part))
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(opts.Priorities, priority) {
+			opts.Priorities = append(opts.Priorities, priority)
+		}
+	}
+	return nil
+}
+
+// filterTodos 返回满足全部过滤条件的待办事项
+func filterTodos(todos []*models.Todo, filters []todoFilter) []*models.Todo {
+	if len(filters) == 0 {
+		return todos
+	}
+
+	result := make([]*models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		keep := true
+		for _, filter := range filters {
+			if !filter(todo) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			result = append(result, todo)
+		}
+	}
+	return result
+}
+
+// sortTodos 按指定字段排序，字段相同时按ID升序保证结果稳定
+func sortTodos(todos []*models.Todo, field string, desc bool) []*models.Todo {
+	less := listFields[field].less
+	sorted := make([]*models.Todo, len(todos))
+	copy(sorted, todos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// parsePagination 解析 limit 和 offset 查询参数，limit 为 0 表示不分页
+func (h *TodoHandler) parsePagination(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return 0, 0, errors.New("limit必须为正整数")
+		}
+		if n > h.config.MaxLimit {
+			if h.config.RejectOverLimit {
+				return 0, 0, fmt.Errorf("limit不能超过%d", h.config.MaxLimit)
+			}
+			n = h.config.MaxLimit
+		}
+		limit = n
+	}
+
+	offset := 0
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, errors.New("offset必须为非负整数")
+		}
+		offset = n
+	}
+
+	return limit, offset, nil
+}
+
+// paginate 按 offset 和 limit 截取列表
+func paginate(todos []*models.Todo, limit, offset int) []*models.Todo {
+	if offset >= len(todos) {
+		return []*models.Todo{}
+	}
+	todos = todos[offset:]
+	if limit > 0 && limit < len(todos) {
+		todos = todos[:limit]
+	}
+	return todos
+}
+
+// paginationLinks 生成 RFC 8288 Link 头，包含 first、prev、next、last，未分页时返回空字符串。
+// 链接保留请求中的其他查询参数，只替换 offset 和 limit
+func paginationLinks(u *url.URL, limit, offset, total int) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	link := func(rel string, offset int) string {
+		query := u.Query()
+		query.Set("limit", strconv.Itoa(limit))
+		query.Set("offset", strconv.Itoa(offset))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, query.Encode(), rel)
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+	links := []string{link("first", 0)}
+	if offset > 0 {
+		links = append(links, link("prev", max(min(offset-limit, last), 0)))
+	}
+	if offset+limit < total {
+		links = append(links, link("next", offset+limit))
+	}
+	links = append(links, link("last", last))
+	return strings.Join(links, ", ")
+}
+
+// jsonFieldNames 返回结构体类型序列化后的字段名集合
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+func TestListLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		reject     bool
+		target     string
+		wantStatus int
+		wantCount  int
+	}{
+		{"未指定时不分页", false, "/api/todos", http.StatusOK, 5},
+		{"上限内照常返回", false, "/api/todos?limit=2", http.StatusOK, 2},
+		{"超过上限时截断", false, "/api/todos?limit=50", http.StatusOK, 3},
+		{"拒绝模式下超过上限返回 400", true, "/api/todos?limit=50", http.StatusBadRequest, 0},
+		{"拒绝模式下上限内照常返回", true, "/api/todos?limit=3", http.StatusOK, 3},
+		{"按 ids 获取时默认不分页", false, "/api/todos?ids=1,2,3,4,5", http.StatusOK, 5},
+		{"limit 必须为正整数", false, "/api/todos?limit=0", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, func(c *Config) {
+				c.MaxLimit = 3
+				c.RejectOverLimit = tt.reject
+			})
+			mustCreateTitled(t, h, "a", "b", "c", "d", "e")
+
+			rec := serve(t, h, http.MethodGet, tt.target, "")
+			expectStatus(t, rec, tt.wantStatus)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := len(decodeResponse[[]*models.Todo](t, rec)); got != tt.wantCount {
+				t.Errorf("返回 %d 项，期望 %d", got, tt.wantCount)
+			}
+			if got := rec.Header().Get("X-Total-Count"); got != strconv.Itoa(5) {
+				t.Errorf("X-Total-Count = %s，期望 5", got)
+			}
+			// 只有结果被截断时才带 Link 头
+			if link := rec.Header().Get("Link"); (link != "") != (tt.wantCount < 5) {
+				t.Errorf("返回 %d 项时 Link = %q", tt.wantCount, link)
+			}
+		})
+	}
+
+	// 游标分页总是分页，未指定 limit 时每页取上限
+	h := newTestHandler(t, func(c *Config) { c.MaxLimit = 3 })
+	mustCreateTitled(t, h, "a", "b", "c", "d", "e")
+	rec := serve(t, h, http.MethodGet, "/api/todos?cursor=", "")
+	expectStatus(t, rec, http.StatusOK)
+	if page := decodeResponse[struct{ Items []*models.Todo }](t, rec); len(page.Items) != 3 {
+		t.Errorf("游标分页第一页返回 %d 项，期望 3", len(page.Items))
+	}
+}
+
+// TestListPipeline 验证列表参数按 过滤 -> 排序 -> 分页 -> 投影 的顺序组合执行
+func TestListPipeline(t *testing.T) {
+	h := newTestHandler(t)
+	for _, body := range []string{
+		`{"title": "d", "tags": ["work"]}`,
+		`{"title": "b", "tags": ["work"]}`,
+		`{"title": "a", "tags": ["home"]}`,
+		`{"title": "e", "tags": ["work"]}`,
+		`{"title": "c", "tags": ["work"]}`,
+		`{"title": "f", "tags": ["work"]}`,
+	} {
+		mustCreate(t, h, body)
+	}
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/4", `{"completed": true}`), http.StatusOK)
+
+	tests := []struct {
+		name      string
+		query     string
+		want      []map[string]any
+		wantTotal string
+	}{
+		{
+			name:      "全部参数组合",
+			query:     "completed=false&tag=work&sort=title&order=desc&limit=2&offset=1&fields=id,title",
+			want:      []map[string]any{{"id": 1.0, "title": "d"}, {"id": 5.0, "title": "c"}},
+			wantTotal: "4",
+		},
+		{
+			// 先分页后投影，排序字段不必出现在 fields 中
+			name:      "按未投影的字段排序",
+			query:     "tag=work&sort=title&limit=3&fields=id",
+			want:      []map[string]any{{"id": 2.0}, {"id": 5.0}, {"id": 1.0}},
+			wantTotal: "5",
+		},
+		{
+			// 先过滤后分页，offset 作用于过滤后的结果
+			name:      "offset 作用于过滤结果",
+			query:     "completed=true&offset=0&limit=5&fields=title",
+			want:      []map[string]any{{"title": "e"}},
+			wantTotal: "1",
+		},
+		{
+			name:      "offset 超出过滤结果",
+			query:     "tag=home&offset=1&fields=title",
+			want:      []map[string]any{},
+			wantTotal: "1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, h, http.MethodGet, "/api/todos?"+tt.query, "")
+			expectStatus(t, rec, http.StatusOK)
+			got := decodeResponse[[]map[string]any](t, rec)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("结果 = %v，期望 %v", got, tt.want)
+			}
+			if total := rec.Header().Get("X-Total-Count"); total != tt.wantTotal {
+				t.Errorf("X-Total-Count = %s，期望 %s", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestListCursor(t *testing.T) {
+	type cursorPage struct {
+		Items      []models.Todo `json:"items"`
+		Total      int           `json:"total"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c", "d", "e")
+
+	rec := serve(t, h, http.MethodGet, "/api/todos?cursor=&limit=2", "")
+	expectStatus(t, rec, http.StatusOK)
+	first := decodeResponse[cursorPage](t, rec)
+	if got := todoIDs(toPointers(first.Items)); !reflect.DeepEqual(got, []models.ID{1, 2}) || first.Total != 5 || first.NextCursor == "" {
+		t.Fatalf("第一页 = %v，总数 %d，游标 %q", got, first.Total, first.NextCursor)
+	}
+	if link := rec.Header().Get("Link"); link == "" {
+		t.Error("第一页缺少 Link 头")
+	}
+
+	// 翻页期间删除已看过的项、新建待办事项，不会导致重复或遗漏
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/1", ""), http.StatusNoContent)
+	mustCreateTitled(t, h, "f")
+	rec = serve(t, h, http.MethodGet, "/api/todos?limit=2&cursor="+first.NextCursor, "")
+	expectStatus(t, rec, http.StatusOK)
+	second := decodeResponse[cursorPage](t, rec)
+	if got := todoIDs(toPointers(second.Items)); !reflect.DeepEqual(got, []models.ID{3, 4}) || second.NextCursor == "" {
+		t.Fatalf("第二页 = %v，游标 %q", got, second.NextCursor)
+	}
+
+	rec = serve(t, h, http.MethodGet, "/api/todos?limit=2&cursor="+second.NextCursor, "")
+	expectStatus(t, rec, http.StatusOK)
+	last := decodeResponse[cursorPage](t, rec)
+	if got := todoIDs(toPointers(last.Items)); !reflect.DeepEqual(got, []models.ID{5, 6}) || last.NextCursor != "" {
+		t.Fatalf("最后一页 = %v，游标 %q，期望没有下一页", got, last.NextCursor)
+	}
+	if link := rec.Header().Get("Link"); link != "" {
+		t.Errorf("最后一页 Link = %q，期望为空", link)
+	}
+
+	for _, target := range []string{
+		"/api/todos?cursor=abc",
+		"/api/todos?cursor=&offset=1",
+		"/api/todos?cursor=&sort=title",
+		"/api/todos?cursor=&order=desc",
+	} {
+		expectStatus(t, serve(t, h, http.MethodGet, target, ""), http.StatusBadRequest)
+	}
+}
+
+// toPointers 返回指向 todos 中各项的指针
+func toPointers(todos []models.Todo) []*models.Todo {
+	pointers := make([]*models.Todo, len(todos))
+	for i := range todos {
+		pointers[i] = &todos[i]
+	}
+	return pointers
+}
+
+func TestListPriorityFilter(t *testing.T) {
+	h := newTestHandler(t)
+	for _, priority := range []string{"low", "medium", "high", "urgent", "high"} {
+		mustCreate(t, h, `{"title": "t", "priority": "`+priority+`"}`)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []models.ID
+	}{
+		{"单个优先级", "priority=high", []models.ID{3, 5}},
+		{"多个优先级", "priority=high,medium", []models.ID{2, 3, 5}},
+		{"忽略大小写和空白", "priority=URGENT,%20low", []models.ID{1, 4}},
+		{"权重与名称混用", "priority=1,urgent", []models.ID{1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, h, http.MethodGet, "/api/todos?"+tt.query, "")
+			expectStatus(t, rec, http.StatusOK)
+			if got := todoIDs(decodeResponse[[]*models.Todo](t, rec)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("结果 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, query := range []string{"priority=high,critical", "priority=5", "priority=high,,low"} {
+		expectStatus(t, serve(t, h, http.MethodGet, "/api/todos?"+query, ""), http.StatusBadRequest)
+	}
+}
+
+// recordingLister 记录最近一次 List 收到的条件
+type recordingLister struct {
+	*storage.MemoryStorage
+	last storage.ListOptions
+}
+
+func (s *recordingLister) List(ctx context.Context, opts storage.ListOptions) ([]*models.Todo, int, error) {
+	s.last = opts
+	return s.MemoryStorage.List(ctx, opts)
+}
+
+func TestListPrioritySortInStorage(t *testing.T) {
+	s := &recordingLister{MemoryStorage: storage.NewMemoryStorage()}
+	h := NewTodoHandlerWithConfig(s, DefaultConfig())
+	for _, priority := range []string{"low", "medium", "high", "urgent", "high"} {
+		mustCreate(t, h, `{"title": "t", "priority": "`+priority+`"}`)
+	}
+
+	// 按优先级排序时在存储中排序和分页，只读取这一页
+	rec := serve(t, h, http.MethodGet, "/api/todos?sort=priority&order=desc&limit=2&offset=1", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := todoIDs(decodeResponse[[]*models.Todo](t, rec)); !reflect.DeepEqual(got, []models.ID{3, 5}) {
+		t.Errorf("结果 = %v，期望 [3 5]", got)
+	}
+	if got := rec.Header().Get("X-Total-Count"); got != "5" {
+		t.Errorf("X-Total-Count = %q，期望 5", got)
+	}
+	if s.last.Sort != storage.SortByPriority || !s.last.Desc || s.last.Limit != 2 || s.last.Offset != 1 {
+		t.Errorf("存储收到的条件 = %+v，期望按优先级降序取第 2-3 项", s.last)
+	}
+
+	// 存储不支持的排序字段仍读取全部后在处理器中排序
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos?sort=title&limit=2", ""), http.StatusOK)
+	if s.last.Sort != storage.SortByID || s.last.Limit != 0 {
+		t.Errorf("按标题排序时存储收到的条件 = %+v，期望不排序不分页", s.last)
+	}
+}
+
+func TestStrictQuery(t *testing.T) {
+	tests := []struct {
+		target string
+		// wantKey 不为空时严格模式下应拒绝并在错误信息中指出该参数
+		wantKey string
+	}{
+		{"/api/todos?completed=false&sort=title&limit=5&fields=id", ""},
+		{"/api/todos?priority=high&tag=work&tz=UTC&envelope=true", ""},
+		{"/api/todos?completd=false", "completd"},
+		{"/api/todos?sort=title&zeta=1&alpha=2", "alpha"},
+		{"/api/todos/count?completed=true", ""},
+		{"/api/todos/count?limit=5", "limit"},
+		{"/api/todos/count?foo=bar", "foo"},
+	}
+
+	lenient := newTestHandler(t)
+	strict := newTestHandler(t, func(c *Config) { c.StrictQuery = true })
+	for _, h := range []*TodoHandler{lenient, strict} {
+		mustCreate(t, h, `{"title": "a", "tags": ["work"], "priority": "high"}`)
+	}
+
+	for _, tt := range tests {
+		// 默认忽略未知参数
+		expectStatus(t, serve(t, lenient, http.MethodGet, tt.target, ""), http.StatusOK)
+
+		rec := serve(t, strict, http.MethodGet, tt.target, "")
+		if tt.wantKey == "" {
+			expectStatus(t, rec, http.StatusOK)
+			continue
+		}
+		expectStatus(t, rec, http.StatusBadRequest)
+		if got, want := errorMessage(t, rec), "不支持的查询参数: "+tt.wantKey; got != want {
+			t.Errorf("GET %s 错误信息 = %q，期望 %q", tt.target, got, want)
+		}
+	}
+}
+
+func TestListProgress(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreate(t, h, `{"title": "a", "subtasks": [{"title": "x", "completed": true}, {"title": "y"}]}`)
+	mustCreateTitled(t, h, "b", "c")
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/3", `{"completed": true}`), http.StatusOK)
+
+	rec := serve(t, h, http.MethodGet, "/api/todos?fields=id,progress", "")
+	expectStatus(t, rec, http.StatusOK)
+	want := []map[string]any{{"id": 1.0, "progress": 0.5}, {"id": 2.0, "progress": 0.0}, {"id": 3.0, "progress": 1.0}}
+	if got := decodeResponse[[]map[string]any](t, rec); !reflect.DeepEqual(got, want) {
+		t.Errorf("结果 = %v，期望 %v", got, want)
+	}
+}
+
+func TestListLinkHeader(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c", "d", "e")
+
+	link := func(rel string, offset int) string {
+		return `</api/todos?completed=false&limit=2&offset=` + strconv.Itoa(offset) + `>; rel="` + rel + `"`
+	}
+	tests := []struct {
+		name   string
+		offset int
+		want   string
+	}{
+		{"第一页", 0, link("first", 0) + ", " + link("next", 2) + ", " + link("last", 4)},
+		{"中间页", 2, link("first", 0) + ", " + link("prev", 0) + ", " + link("next", 4) + ", " + link("last", 4)},
+		{"最后一页", 4, link("first", 0) + ", " + link("prev", 2) + ", " + link("last", 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, h, http.MethodGet, "/api/todos?completed=false&limit=2&offset="+strconv.Itoa(tt.offset), "")
+			expectStatus(t, rec, http.StatusOK)
+			if got := rec.Header().Get("Link"); got != tt.want {
+				t.Errorf("Link = %q\n期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFieldRegistry(t *testing.T) {
+	h := newTestHandler(t)
+	for _, body := range []string{
+		`{"title": "b", "priority": "low", "due_date": "2024-06-20T00:00:00Z"}`,
+		`{"title": "c", "priority": "urgent"}`,
+		`{"title": "a", "priority": "medium", "due_date": "2024-06-18T00:00:00Z"}`,
+	} {
+		mustCreate(t, h, body)
+	}
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/2", `{"completed": true}`), http.StatusOK)
+
+	// 登记了比较函数的字段可以排序，登记了过滤条件的字段可以过滤
+	tests := []struct {
+		query string
+		want  []models.ID
+	}{
+		{"sort=title", []models.ID{3, 1, 2}},
+		{"sort=priority&order=desc", []models.ID{2, 3, 1}},
+		{"sort=due_date", []models.ID{3, 1, 2}},
+		{"completed=false&sort=title&order=desc", []models.ID{1, 3}},
+		{"priority=urgent", []models.ID{2}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, http.MethodGet, "/api/todos?"+tt.query, "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := todoIDs(decodeResponse[[]*models.Todo](t, rec)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s 结果 = %v，期望 %v", tt.query, got, tt.want)
+		}
+	}
+
+	// 未登记或不可排序的字段被拒绝，错误信息列出可用的排序字段
+	for _, field := range []string{"description", "completed", "tag", "password"} {
+		rec := serve(t, h, http.MethodGet, "/api/todos?sort="+field, "")
+		expectStatus(t, rec, http.StatusBadRequest)
+		want := "不支持按 " + field + " 排序，可用的排序字段: " + strings.Join(sortableFieldNames, "、")
+		if got := errorMessage(t, rec); got != want {
+			t.Errorf("sort=%s 错误信息 = %q，期望 %q", field, got, want)
+		}
+	}
+}
+
+func TestListIDsAsMap(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c")
+
+	// 键为ID字符串，不存在的ID不出现在结果中
+	rec := serve(t, h, http.MethodGet, "/api/todos?ids=1,3,99&as=map", "")
+	expectStatus(t, rec, http.StatusOK)
+	got := decodeResponse[map[string]models.Todo](t, rec)
+	if len(got) != 2 || got["1"].Title != "a" || got["3"].Title != "c" {
+		t.Errorf("结果 = %+v，期望只有键 1 和 3", got)
+	}
+
+	// 与 fields 组合时每项只包含指定字段
+	rec = serve(t, h, http.MethodGet, "/api/todos?ids=2&as=map&fields=title", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got, want := decodeResponse[map[string]map[string]any](t, rec), map[string]map[string]any{"2": {"title": "b"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("结果 = %v，期望 %v", got, want)
+	}
+
+	// 全部不存在时返回空对象
+	rec = serve(t, h, http.MethodGet, "/api/todos?ids=98,99&as=map", "")
+	expectStatus(t, rec, http.StatusOK)
+	if body := strings.TrimSpace(rec.Body.String()); body != "{}" {
+		t.Errorf("响应 = %s，期望 {}", body)
+	}
+
+	for _, target := range []string{"/api/todos?as=map", "/api/todos?ids=1&as=set"} {
+		expectStatus(t, serve(t, h, http.MethodGet, target, ""), http.StatusBadRequest)
+	}
+}
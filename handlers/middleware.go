@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// requestIDHeader 请求关联ID的请求头/响应头
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 客户端传入的请求ID的最大长度
+const maxRequestIDLength = 64
+
+// requestIDKey 请求ID在 context 中的键
+type requestIDKey struct{}
+
+// LimitConcurrency 限制同时处理的请求总数，已满时直接返回 503 并提示稍后重试
+func LimitConcurrency(next http.Handler, max int) http.Handler {
+	semaphore := make(chan struct{}, max)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case semaphore <- struct{}{}:
+		default:
+			w.Header().Set("Retry-After", "1")
+			writeErrorResponse(w, http.StatusServiceUnavailable, "服务器繁忙，请稍后重试")
+			return
+		}
+		// 使用 defer 释放，处理过程中发生 panic 也不会泄漏名额
+		defer func() { <-semaphore }()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ResponseTime 在写入响应头时添加 X-Response-Time 和 Server-Timing，记录从收到请求到开始响应的耗时
+func ResponseTime(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tw := &timingResponseWriter{ResponseWriter: w, start: time.Now()}
+		next.ServeHTTP(tw, r)
+	})
+}
+
+// timingResponseWriter 在响应头写出前注入耗时头
+type timingResponseWriter struct {
+	http.ResponseWriter
+	start       time.Time
+	wroteHeader bool
+}
+
+func (w *timingResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		elapsed := time.Since(w.start)
+		w.Header().Set("X-Response-Time", elapsed.String())
+		w.Header().Set("Server-Timing", fmt.Sprintf("app;dur=%.3f", float64(elapsed)/float64(time.Millisecond)))
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *timingResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 支持流式响应
+func (w *timingResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 支持 WebSocket 等需要接管连接的场景
+func (w *timingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("连接不支持接管")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (w *timingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// RequestID 为每个请求分配关联ID：优先沿用客户端传入的合法 X-Request-ID，否则生成新的ID。
+// ID 会写入响应头和请求 context，错误响应体中也会携带该ID
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext 返回 context 中的请求ID，不存在时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID 生成随机的请求ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// validRequestID 判断客户端传入的请求ID是否可以直接使用，只接受字母、数字、- 和 _
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Logging 记录每个请求的方法、路径、状态码、耗时和请求ID
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		log.Printf("%s %s %d %s request_id=%s", r.Method, r.URL.Path, sw.status, time.Since(start), RequestIDFromContext(r.Context()))
+	})
+}
+
+// statusResponseWriter 记录响应状态码
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 支持流式响应
+func (w *statusResponseWriter) Flush() {
+	w.wroteHeader = true
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 支持 WebSocket 等需要接管连接的场景
+func (w *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("连接不支持接管")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimitConcurrencySaturation(t *testing.T) {
+	const max = 3
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	limited := LimitConcurrency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}), max)
+
+	var wg sync.WaitGroup
+	codes := make([]int, max)
+	for i := 0; i < max; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			limited.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			codes[i] = rec.Code
+		}(i)
+	}
+	// 等待全部名额被占用
+	for i := 0; i < max; i++ {
+		<-entered
+	}
+
+	rec := httptest.NewRecorder()
+	limited.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	expectStatus(t, rec, http.StatusServiceUnavailable)
+	if got := rec.Header().Get("Retry-After"); got == "" {
+		t.Error("繁忙响应缺少 Retry-After 头")
+	}
+
+	close(release)
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("第 %d 个请求状态码 = %d，期望 200", i, code)
+		}
+	}
+
+	// 名额释放后可以再次处理请求
+	go func() { <-entered }()
+	rec = httptest.NewRecorder()
+	limited.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	expectStatus(t, rec, http.StatusOK)
+}
+
+func TestLimitConcurrencyReleasesAfterPanic(t *testing.T) {
+	const max = 2
+	limited := LimitConcurrency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusOK)
+	}), max)
+
+	// panic 次数超过名额数，若名额泄漏之后的请求会被拒绝
+	for i := 0; i < max+1; i++ {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("期望处理器 panic")
+				}
+			}()
+			limited.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+		}()
+	}
+
+	rec := httptest.NewRecorder()
+	limited.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	expectStatus(t, rec, http.StatusOK)
+}
+
+func TestResponseTime(t *testing.T) {
+	const delay = 5 * time.Millisecond
+	tests := []struct {
+		name  string
+		write func(w http.ResponseWriter)
+	}{
+		{"隐式写入响应头", func(w http.ResponseWriter) { w.Write([]byte("ok")) }},
+		{"显式写入响应头", func(w http.ResponseWriter) { w.WriteHeader(http.StatusNoContent) }},
+		{"流式响应", func(w http.ResponseWriter) { w.(http.Flusher).Flush() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timed := ResponseTime(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(delay)
+				tt.write(w)
+			}))
+			rec := httptest.NewRecorder()
+			timed.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			elapsed, err := time.ParseDuration(rec.Header().Get("X-Response-Time"))
+			if err != nil || elapsed < delay {
+				t.Errorf("X-Response-Time = %q（%v），期望不小于 %v 的时长", rec.Header().Get("X-Response-Time"), err, delay)
+			}
+			dur, ok := strings.CutPrefix(rec.Header().Get("Server-Timing"), "app;dur=")
+			if ms, err := strconv.ParseFloat(dur, 64); !ok || err != nil || ms < float64(delay/time.Millisecond) {
+				t.Errorf("Server-Timing = %q，期望 app;dur=<毫秒数>", rec.Header().Get("Server-Timing"))
+			}
+		})
+	}
+}
+
+func TestRequestIDInErrors(t *testing.T) {
+	h := newTestHandler(t, func(c *Config) { c.AdminKey = "secret" })
+	mux := http.NewServeMux()
+	mux.Handle("/api/todos", h)
+	mux.Handle("/api/todos/", h)
+	mux.Handle("/api/admin/", h.AdminHandler())
+	handler := RequestID(ResponseTime(mux))
+
+	tests := []struct {
+		name, method, target, body string
+		want                       int
+	}{
+		{"未找到", http.MethodGet, "/api/todos/9", "", http.StatusNotFound},
+		{"无效的ID", http.MethodGet, "/api/todos/abc", "", http.StatusBadRequest},
+		{"校验失败", http.MethodPost, "/api/todos", `{"title": ""}`, http.StatusBadRequest},
+		{"请求体格式错误", http.MethodPost, "/api/todos", `{`, http.StatusBadRequest},
+		{"管理密钥无效", http.MethodPost, "/api/admin/reset", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, handler, tt.method, tt.target, tt.body)
+			expectStatus(t, rec, tt.want)
+			id := rec.Header().Get(requestIDHeader)
+			if id == "" {
+				t.Fatal("响应缺少请求ID头")
+			}
+			if got := decodeResponse[ErrorResponse](t, rec); got.RequestID != id || got.Error == "" {
+				t.Errorf("错误响应 = %+v，期望 request_id 为 %q", got, id)
+			}
+		})
+	}
+
+	// 沿用客户端传入的合法ID，不合法的ID会被替换
+	rec := serve(t, handler, http.MethodGet, "/api/todos/9", "", requestIDHeader, "client-id_1")
+	if got := decodeResponse[ErrorResponse](t, rec).RequestID; got != "client-id_1" || rec.Header().Get(requestIDHeader) != got {
+		t.Errorf("request_id = %q，响应头 %q，期望均为 client-id_1", got, rec.Header().Get(requestIDHeader))
+	}
+	rec = serve(t, handler, http.MethodGet, "/api/todos/9", "", requestIDHeader, "bad id<script>")
+	if got := decodeResponse[ErrorResponse](t, rec).RequestID; got == "bad id<script>" || got != rec.Header().Get(requestIDHeader) {
+		t.Errorf("不合法的请求ID未被替换: request_id = %q，响应头 %q", got, rec.Header().Get(requestIDHeader))
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// OverdueTodo 逾期待办事项及其逾期时长
+type OverdueTodo struct {
+	*TodoResponse
+	OverdueBy      string `json:"overdue_by"`
+	OverdueSeconds int64  `json:"overdue_seconds"`
+}
+
+// handleGetOverdue 处理获取逾期待办事项，逾期最久的排在最前
+func (h *TodoHandler) handleGetOverdue(w http.ResponseWriter, r *http.Request) {
+	fields, ok := fieldsParam(w, r, "overdue_by", "overdue_seconds")
+	if !ok {
+		return
+	}
+
+	todos, err := h.storage.GetAll(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+	writeProjectedJSON(w, http.StatusOK, overdueTodos(todos, h.config.Clock()), fields)
+}
+
+// overdueTodos 筛选在 now 时刻已逾期的待办事项，并按截止时间升序排列
+func overdueTodos(todos []*models.Todo, now time.Time) []OverdueTodo {
+	result := make([]OverdueTodo, 0)
+	for _, todo := range todos {
+		if !todo.IsOverdue(now) {
+			continue
+		}
+		overdueBy := now.Sub(*todo.DueDate).Truncate(time.Second)
+		result = append(result, OverdueTodo{
+			TodoResponse:   newTodoResponse(todo, now),
+			OverdueBy:      overdueBy.String(),
+			OverdueSeconds: int64(overdueBy / time.Second),
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DueDate.Before(*result[j].DueDate)
+	})
+	return result
+}
+
+// SnoozeResponse 推迟逾期待办事项的响应结构
+type SnoozeResponse struct {
+	Snoozed int `json:"snoozed"`
+}
+
+// handleSnoozeOverdue 处理将所有逾期的未完成待办事项的截止时间推迟指定时长，请求体可省略
+func (h *TodoHandler) handleSnoozeOverdue(w http.ResponseWriter, r *http.Request) {
+	var req models.SnoozeRequest
+	if r.ContentLength != 0 && !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := h.config.Clock()
+	duration := req.SnoozeDuration()
+	var snoozed []*models.Todo
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		// 事务重试时 fn 会再次执行，每次都从头收集结果
+		snoozed = nil
+		todos, err := tx.GetAll(r.Context())
+		if err != nil {
+			return err
+		}
+		for _, todo := range todos {
+			if !todo.IsOverdue(now) {
+				continue
+			}
+			due := todo.DueDate.Add(duration)
+			updated, err := tx.Update(r.Context(), int(todo.ID), &models.UpdateTodoRequest{DueDate: &due})
+			if err != nil {
+				return err
+			}
+			snoozed = append(snoozed, updated)
+		}
+		return nil
+	})
+	if err != nil {
+		writeStorageError(w, err, "推迟逾期待办事项失败")
+		return
+	}
+
+	for _, todo := range snoozed {
+		h.publishTodo(EventUpdated, todo)
+	}
+	writeJSONResponse(w, http.StatusOK, SnoozeResponse{Snoozed: len(snoozed)})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+func TestGetOverdue(t *testing.T) {
+	h := newTestHandler(t)
+	for _, body := range []string{
+		`{"title": "昨天到期", "due_date": "2024-06-14T12:00:00Z"}`,
+		`{"title": "五天前到期", "due_date": "2024-06-10T12:00:00Z"}`,
+		`{"title": "明天到期", "due_date": "2024-06-16T12:00:00Z"}`,
+		`{"title": "已完成", "due_date": "2024-06-01T12:00:00Z"}`,
+		`{"title": "没有截止时间"}`,
+		`{"title": "一秒前到期", "due_date": "2024-06-15T11:59:59Z"}`,
+	} {
+		mustCreate(t, h, body)
+	}
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/4", `{"completed": true}`), http.StatusOK)
+
+	rec := serve(t, h, http.MethodGet, "/api/todos/overdue", "")
+	expectStatus(t, rec, http.StatusOK)
+	type overdue struct {
+		ID             models.ID `json:"id"`
+		OverdueBy      string    `json:"overdue_by"`
+		OverdueSeconds int64     `json:"overdue_seconds"`
+	}
+	want := []overdue{
+		{2, "120h0m0s", 5 * 24 * 3600},
+		{1, "24h0m0s", 24 * 3600},
+		{6, "1s", 1},
+	}
+	if got := decodeResponse[[]overdue](t, rec); !reflect.DeepEqual(got, want) {
+		t.Errorf("逾期列表 = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestSnoozeOverdue(t *testing.T) {
+	h := newTestHandler(t)
+	for _, body := range []string{
+		`{"title": "昨天到期", "due_date": "2024-06-14T12:00:00Z"}`,
+		`{"title": "明天到期", "due_date": "2024-06-16T12:00:00Z"}`,
+		`{"title": "已完成", "due_date": "2024-06-01T12:00:00Z"}`,
+		`{"title": "没有截止时间"}`,
+		`{"title": "一小时前到期", "due_date": "2024-06-15T11:00:00Z"}`,
+	} {
+		mustCreate(t, h, body)
+	}
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/3", `{"completed": true}`), http.StatusOK)
+
+	snooze := func(body string, want int) {
+		t.Helper()
+		rec := serve(t, h, http.MethodPost, "/api/todos/overdue/snooze", body)
+		expectStatus(t, rec, http.StatusOK)
+		if got := decodeResponse[SnoozeResponse](t, rec).Snoozed; got != want {
+			t.Errorf("推迟了 %d 项，期望 %d", got, want)
+		}
+	}
+	dueDates := func() map[models.ID]string {
+		t.Helper()
+		rec := serve(t, h, http.MethodGet, "/api/todos", "")
+		expectStatus(t, rec, http.StatusOK)
+		dates := make(map[models.ID]string)
+		for _, todo := range decodeResponse[[]*models.Todo](t, rec) {
+			if todo.DueDate != nil {
+				dates[todo.ID] = todo.DueDate.UTC().Format(time.RFC3339)
+			}
+		}
+		return dates
+	}
+
+	// 省略请求体时推迟 24 小时，只移动逾期且未完成的待办事项
+	snooze("", 2)
+	want := map[models.ID]string{
+		1: "2024-06-15T12:00:00Z",
+		2: "2024-06-16T12:00:00Z",
+		3: "2024-06-01T12:00:00Z",
+		5: "2024-06-16T11:00:00Z",
+	}
+	if got := dueDates(); !reflect.DeepEqual(got, want) {
+		t.Errorf("截止时间 = %v，期望 %v", got, want)
+	}
+
+	// 截止时间恰好为当前时间的不算逾期，没有逾期项时返回 0
+	snooze(`{"duration": "2h"}`, 0)
+
+	for _, body := range []string{`{"duration": "abc"}`, `{"duration": "-1h"}`, `{"duration": "0s"}`} {
+		expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/overdue/snooze", body), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// webhookTimeout 发送提醒 webhook 的超时时间
+const webhookTimeout = 10 * time.Second
+
+// Reminder 发送给 webhook 的提醒内容
+type Reminder struct {
+	Type     string        `json:"type"`
+	RemindAt time.Time     `json:"remind_at"`
+	Todo     *TodoResponse `json:"todo"`
+}
+
+// ReminderNotifier 发送一条提醒，返回错误时该提醒会在下次扫描时重试
+type ReminderNotifier func(ctx context.Context, reminder Reminder) error
+
+// WebhookNotifier 返回以 JSON POST 到 url 的提醒发送函数，非 2xx 响应视为失败
+func WebhookNotifier(url string) ReminderNotifier {
+	client := &http.Client{Timeout: webhookTimeout}
+	return func(ctx context.Context, reminder Reminder) error {
+		body, err := json.Marshal(reminder)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook 返回 %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// ReminderScanner 定期扫描未完成的待办事项，在到达提醒时间（截止时间减去 remind_before）后发送一次提醒。
+// 已发送的提醒按提醒时间记录在内存中，修改截止时间或提前时长后会重新提醒
+type ReminderScanner struct {
+	storage storage.TodoStorage
+	clock   func() time.Time
+	notify  ReminderNotifier
+	// sent 记录每个待办事项已提醒过的提醒时间
+	sent  map[models.ID]time.Time
+	mutex sync.Mutex
+}
+
+// ReminderScanner 创建使用处理器存储和时钟的提醒扫描器
+func (h *TodoHandler) ReminderScanner(notify ReminderNotifier) *ReminderScanner {
+	return &ReminderScanner{
+		storage: h.storage,
+		clock:   h.config.Clock,
+		notify:  notify,
+		sent:    make(map[models.ID]time.Time),
+	}
+}
+
+// Scan 执行一次扫描并返回成功发送的提醒数
+func (s *ReminderScanner) Scan(ctx context.Context) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	todos, err := s.storage.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.clock()
+	seen := make(map[models.ID]bool, len(todos))
+	sent := 0
+	for _, todo := range todos {
+		seen[todo.ID] = true
+		if todo.Completed {
+			continue
+		}
+		remindAt, ok := todo.RemindAt()
+		if !ok || now.Before(remindAt) {
+			continue
+		}
+		if last, ok := s.sent[todo.ID]; ok && last.Equal(remindAt) {
+			continue
+		}
+
+		if err := s.notify(ctx, Reminder{Type: "reminder", RemindAt: remindAt, Todo: newTodoResponse(todo, now)}); err != nil {
+			log.Printf("发送待办事项 %d 的提醒失败: %v", todo.ID, err)
+			continue
+		}
+		s.sent[todo.ID] = remindAt
+		sent++
+	}
+
+	// 清理已删除的待办事项
+	for id := range s.sent {
+		if !seen[id] {
+			delete(s.sent, id)
+		}
+	}
+	return sent, nil
+}
+
+// Run 每隔 interval 扫描一次，直到 ctx 结束
+func (s *ReminderScanner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Scan(ctx); err != nil {
+			log.Printf("扫描提醒失败: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReminderScanner(t *testing.T) {
+	now := testNow
+	h := newTestHandler(t, func(c *Config) {
+		c.Clock = func() time.Time { return now }
+	})
+	var reminders []Reminder
+	scanner := h.ReminderScanner(func(_ context.Context, r Reminder) error {
+		reminders = append(reminders, r)
+		return nil
+	})
+	scan := func(want int) {
+		t.Helper()
+		got, err := scanner.Scan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s 扫描发送了 %d 条提醒，期望 %d", now.Format(time.RFC3339), got, want)
+		}
+	}
+
+	// 截止 14:00，提前 1 小时，应在 13:00 提醒
+	mustCreate(t, h, `{"title": "开会", "due_date": "2024-06-15T14:00:00Z", "remind_before": "1h"}`)
+	mustCreate(t, h, `{"title": "无截止时间"}`)
+
+	now = testNow.Add(59 * time.Minute)
+	scan(0)
+	now = testNow.Add(time.Hour)
+	scan(1)
+	if len(reminders) != 1 || reminders[0].Todo.Title != "开会" || !reminders[0].RemindAt.Equal(testNow.Add(time.Hour)) {
+		t.Fatalf("提醒 = %+v", reminders)
+	}
+
+	// 同一提醒时间只发送一次
+	now = testNow.Add(90 * time.Minute)
+	scan(0)
+	now = testNow.Add(3 * time.Hour)
+	scan(0)
+
+	// 修改截止时间后重新提醒
+	now = testNow.Add(90 * time.Minute)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", `{"due_date": "2024-06-15T15:00:00Z"}`), http.StatusOK)
+	now = testNow.Add(119 * time.Minute)
+	scan(0)
+	now = testNow.Add(2 * time.Hour)
+	scan(1)
+
+	// 已完成的待办事项不提醒
+	mustCreate(t, h, `{"title": "已完成", "due_date": "2024-06-15T12:00:00Z"}`)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/3", `{"completed": true}`), http.StatusOK)
+	scan(0)
+	if len(reminders) != 2 {
+		t.Errorf("共发送 %d 条提醒，期望 2", len(reminders))
+	}
+}
+
+func TestReminderScannerRetry(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreate(t, h, `{"title": "已到期", "due_date": "2024-06-15T11:00:00Z"}`)
+
+	fail := true
+	calls := 0
+	scanner := h.ReminderScanner(func(context.Context, Reminder) error {
+		calls++
+		if fail {
+			return errors.New("webhook 不可用")
+		}
+		return nil
+	})
+
+	// 发送失败的提醒在下次扫描时重试，成功后不再发送
+	for _, want := range []int{0, 1, 0} {
+		got, err := scanner.Scan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("扫描发送了 %d 条提醒，期望 %d", got, want)
+		}
+		fail = false
+	}
+	if calls != 2 {
+		t.Errorf("发送函数被调用 %d 次，期望 2", calls)
+	}
+}
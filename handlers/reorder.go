@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// ReorderResponse 手动排序的响应结构，Updated 为位置发生变化的待办事项
+type ReorderResponse struct {
+	Updated []*TodoResponse `json:"updated"`
+}
+
+// handleReorder 处理手动排序，可以按给定顺序重排一组待办事项，或将一个待办事项移动到指定位置。
+// 只更新位置需要变化的待办事项，位置间隔用尽时才重新编号全部待办事项
+func (h *TodoHandler) handleReorder(w http.ResponseWriter, r *http.Request) {
+	var req models.ReorderRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.checkBatchSize(len(req.IDs)); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var updated []*models.Todo
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		// 事务重试时 fn 会再次执行，每次都从头收集结果
+		updated = []*models.Todo{}
+		todos, err := tx.GetAll(r.Context())
+		if err != nil {
+			return err
+		}
+		order := sortTodos(todos, "position", false)
+
+		var positions map[models.ID]int64
+		if len(req.IDs) > 0 {
+			positions, err = reorderIDs(order, req.IDs)
+		} else {
+			positions, err = moveTodo(order, req.ID, *req.Index)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, todo := range order {
+			position, ok := positions[todo.ID]
+			if !ok || position == todo.OrderPosition() {
+				continue
+			}
+			moved, err := tx.Update(r.Context(), int(todo.ID), &models.UpdateTodoRequest{Position: &position})
+			if err != nil {
+				return err
+			}
+			updated = append(updated, moved)
+		}
+		return nil
+	})
+	if err != nil {
+		writeStorageError(w, err, "排序失败")
+		return
+	}
+
+	for _, todo := range updated {
+		h.publishTodo(EventUpdated, todo)
+	}
+	writeJSONResponse(w, http.StatusOK, ReorderResponse{Updated: h.todoResponses(updated)})
+}
+
+// reorderIDs 将 ids 中的待办事项按给定顺序放回它们原来占据的位置，其他待办事项保持不动。
+// order 为按位置排列的全部待办事项
+func reorderIDs(order []*models.Todo, ids []models.ID) (map[models.ID]int64, error) {
+	index := make(map[models.ID]int, len(order))
+	for i, todo := range order {
+		index[todo.ID] = i
+	}
+	slots := make([]int, 0, len(ids))
+	for _, id := range ids {
+		i, ok := index[id]
+		if !ok {
+			return nil, storage.ErrTodoNotFound
+		}
+		slots = append(slots, i)
+	}
+	sort.Ints(slots)
+
+	reordered := slices.Clone(order)
+	for k, id := range ids {
+		reordered[slots[k]] = order[index[id]]
+	}
+
+	// 原有位置严格递增时直接复用，否则（存在相同位置）重新编号
+	positions := make(map[models.ID]int64, len(ids))
+	for _, slot := range slots {
+		position := order[slot].OrderPosition()
+		if slot > 0 && position <= order[slot-1].OrderPosition() ||
+			slot < len(order)-1 && position >= order[slot+1].OrderPosition() {
+			return renumber(reordered), nil
+		}
+		positions[reordered[slot].ID] = position
+	}
+	return positions, nil
+}
+
+// moveTodo 将 id 指定的待办事项移动到 index 位置（超出末尾时移到最后），通常只需取前后相邻位置的中间值。
+// 移到最后时以 (最大ID+1)*PositionGap 为上界，保证之后新建的待办事项仍排在后面
+func moveTodo(order []*models.Todo, id models.ID, index int) (map[models.ID]int64, error) {
+	from := slices.IndexFunc(order, func(todo *models.Todo) bool { return todo.ID == id })
+	if from < 0 {
+		return nil, storage.ErrTodoNotFound
+	}
+	todo := order[from]
+	rest := slices.Delete(slices.Clone(order), from, from+1)
+	index = min(index, len(rest))
+	if index == from {
+		return nil, nil
+	}
+
+	var prev, next int64
+	if index > 0 {
+		prev = rest[index-1].OrderPosition()
+	}
+	if index < len(rest) {
+		next = rest[index].OrderPosition()
+	} else {
+		var maxID models.ID
+		for _, t := range order {
+			maxID = max(maxID, t.ID)
+		}
+		next = int64(maxID+1) * models.PositionGap
+	}
+	if next-prev >= 2 {
+		return map[models.ID]int64{todo.ID: prev + (next-prev)/2}, nil
+	}
+	return renumber(slices.Insert(rest, index, todo)), nil
+}
+
+// renumber 按 order 的顺序以 PositionGap 为间隔重新编号全部待办事项
+func renumber(order []*models.Todo) map[models.ID]int64 {
+	positions := make(map[models.ID]int64, len(order))
+	for i, todo := range order {
+		positions[todo.ID] = int64(i+1) * models.PositionGap
+	}
+	return positions
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// positioned 按给定顺序返回待办事项，positions 中为 0 的项使用按ID计算的默认位置
+func positioned(positions ...int64) []*models.Todo {
+	todos := make([]*models.Todo, len(positions))
+	for i, position := range positions {
+		todos[i] = &models.Todo{ID: models.ID(i + 1), Position: position}
+	}
+	return todos
+}
+
+func TestMoveTodo(t *testing.T) {
+	gap := models.PositionGap
+	tests := []struct {
+		name  string
+		order []*models.Todo
+		id    models.ID
+		index int
+		want  map[models.ID]int64
+	}{
+		{"移到开头取零和首项的中间值", positioned(0, 0, 0), 3, 0, map[models.ID]int64{3: gap / 2}},
+		{"移到中间取相邻位置的中间值", positioned(0, 0, 0), 1, 1, map[models.ID]int64{1: 2*gap + gap/2}},
+		{"超出末尾时移到最后", positioned(0, 0, 0), 1, 10, map[models.ID]int64{1: 3*gap + gap/2}},
+		{"位置不变时不更新", positioned(0, 0, 0), 2, 1, nil},
+		{"间隔用尽时重新编号", positioned(100, 101, 0), 3, 1, map[models.ID]int64{1: gap, 3: 2 * gap, 2: 3 * gap}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := moveTodo(tt.order, tt.id, tt.index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("位置 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := moveTodo(positioned(0, 0), 9, 0); !errors.Is(err, storage.ErrTodoNotFound) {
+		t.Errorf("未知ID错误 = %v，期望 %v", err, storage.ErrTodoNotFound)
+	}
+}
+
+func TestReorderIDs(t *testing.T) {
+	gap := models.PositionGap
+	tests := []struct {
+		name  string
+		order []*models.Todo
+		ids   []models.ID
+		want  map[models.ID]int64
+	}{
+		{"复用原有位置，其他项不动", positioned(0, 0, 0, 0), []models.ID{4, 2}, map[models.ID]int64{4: 2 * gap, 2: 4 * gap}},
+		{"顺序不变", positioned(0, 0, 0), []models.ID{1, 2}, map[models.ID]int64{1: gap, 2: 2 * gap}},
+		{"存在相同位置时重新编号", positioned(500, 500, 0), []models.ID{2, 1}, map[models.ID]int64{2: gap, 1: 2 * gap, 3: 3 * gap}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reorderIDs(tt.order, tt.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("位置 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := reorderIDs(positioned(0, 0), []models.ID{2, 9}); !errors.Is(err, storage.ErrTodoNotFound) {
+		t.Errorf("未知ID错误 = %v，期望 %v", err, storage.ErrTodoNotFound)
+	}
+}
+
+func TestReorder(t *testing.T) {
+	h, flaky := newFlakyTxHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c", "d")
+	ordered := func() []models.ID {
+		t.Helper()
+		return todoIDs(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos?sort=position", "")))
+	}
+
+	// 事务重试后每个移动的待办事项只返回一次
+	flaky.failures = 1
+	rec := serve(t, h, http.MethodPost, "/api/todos/reorder", `{"ids": [4, 1]}`)
+	expectStatus(t, rec, http.StatusOK)
+	updated := decodeResponse[struct{ Updated []*models.Todo }](t, rec).Updated
+	if got := todoIDs(updated); !reflect.DeepEqual(got, []models.ID{1, 4}) {
+		t.Errorf("更新的待办事项 = %v，期望 [1 4]", got)
+	}
+	if got := ordered(); !reflect.DeepEqual(got, []models.ID{4, 2, 3, 1}) {
+		t.Errorf("排序后 = %v，期望 [4 2 3 1]", got)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/reorder", `{"id": 3, "index": 0}`), http.StatusOK)
+	if got := ordered(); !reflect.DeepEqual(got, []models.ID{3, 4, 2, 1}) {
+		t.Errorf("移动后 = %v，期望 [3 4 2 1]", got)
+	}
+	// 新建的待办事项排在最后
+	mustCreateTitled(t, h, "e")
+	if got := ordered(); !reflect.DeepEqual(got, []models.ID{3, 4, 2, 1, 5}) {
+		t.Errorf("新建后 = %v，期望 [3 4 2 1 5]", got)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/reorder", `{"id": 9, "index": 0}`), http.StatusNotFound)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/reorder", `{"ids": [1, 9]}`), http.StatusNotFound)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/reorder", `{"ids": [1, 1]}`), http.StatusBadRequest)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"time"
+
+	"go-todolist/models"
+)
+
+// TodoResponse 响应和事件中的待办事项：在存储的字段之外附加计算字段，Position 为实际的排序位置。
+// 计算字段只在这里生成，不会写入存储或导出的数据
+type TodoResponse struct {
+	models.Todo
+	Progress float64 `json:"progress"`
+	Overdue  bool    `json:"overdue"`
+}
+
+// newTodoResponse 将待办事项映射为响应结构，now 为计算 overdue 使用的当前时间；新增计算字段时只需在此添加
+func newTodoResponse(todo *models.Todo, now time.Time) *TodoResponse {
+	if todo == nil {
+		return nil
+	}
+	resp := &TodoResponse{Todo: *todo, Progress: todo.Progress(), Overdue: todo.IsOverdue(now)}
+	resp.Position = todo.OrderPosition()
+	return resp
+}
+
+// todoResponse 使用处理器的时钟映射单个待办事项
+func (h *TodoHandler) todoResponse(todo *models.Todo) *TodoResponse {
+	return newTodoResponse(todo, h.config.Clock())
+}
+
+// todoResponses 使用处理器的时钟映射待办事项列表，同一次响应中的所有事项使用同一时刻
+func (h *TodoHandler) todoResponses(todos []*models.Todo) []*TodoResponse {
+	now := h.config.Clock()
+	result := make([]*TodoResponse, len(todos))
+	for i, todo := range todos {
+		result[i] = newTodoResponse(todo, now)
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// flakyTxStorage 的前 failures 次事务在执行完 fn 之后以 ErrTxConflict 失败并回滚，模拟提交时的并发冲突
+type flakyTxStorage struct {
+	*storage.MemoryStorage
+	failures int
+}
+
+func (s *flakyTxStorage) WithTx(ctx context.Context, fn func(storage.TodoStorage) error) error {
+	return s.MemoryStorage.WithTx(ctx, func(tx storage.TodoStorage) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if s.failures > 0 {
+			s.failures--
+			return storage.ErrTxConflict
+		}
+		return nil
+	})
+}
+
+// newFlakyTxHandler 创建使用重试存储的处理器，其内层存储的下一个事务会失败一次
+func newFlakyTxHandler(t *testing.T) (*TodoHandler, *flakyTxStorage) {
+	t.Helper()
+	flaky := &flakyTxStorage{MemoryStorage: storage.NewMemoryStorage()}
+	config := DefaultConfig()
+	config.Clock = func() time.Time { return testNow }
+	config.Location = time.UTC
+	policy := storage.RetryPolicy{MaxRetries: 3}
+	return NewTodoHandlerWithConfig(storage.NewRetrying(flaky, policy), config), flaky
+}
+
+// drainEvents 返回通道中已缓冲的事件的ID
+func drainEvents(events chan TodoEvent) []models.ID {
+	var ids []models.ID
+	for {
+		select {
+		case event := <-events:
+			ids = append(ids, event.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+// TestRetriedTxResults 验证事务重试时处理器只返回和广播最后一次执行的结果
+func TestRetriedTxResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		completed  []string
+		method     string
+		target     string
+		body       string
+		wantEvents []models.ID
+		check      func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "批量设置截止时间",
+			method: http.MethodPut, target: "/api/todos/batch/due", body: `{"ids": [1, 2], "due_in": "1h"}`,
+			wantEvents: []models.ID{1, 2},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				resp := decodeResponse[BulkResponse](t, rec)
+				if len(resp.Results) != 2 || resp.Results[0].Index != 0 || resp.Results[1].Index != 1 {
+					t.Errorf("结果 = %+v，期望索引 0 和 1 各一项", resp.Results)
+				}
+			},
+		},
+		{
+			name:   "推迟逾期",
+			method: http.MethodPost, target: "/api/todos/overdue/snooze",
+			wantEvents: []models.ID{1, 2},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				resp := decodeResponse[SnoozeResponse](t, rec)
+				if resp.Snoozed != 2 {
+					t.Errorf("推迟了 %d 项，期望 2", resp.Snoozed)
+				}
+			},
+		},
+		{
+			name:      "移动已完成",
+			completed: []string{"1", "3"},
+			method:    http.MethodPost, target: "/api/todos/sweep-completed?list_id=archive",
+			wantEvents: []models.ID{1, 3},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				resp := decodeResponse[SweepResponse](t, rec)
+				if resp.Moved != 2 {
+					t.Errorf("移动了 %d 项，期望 2", resp.Moved)
+				}
+			},
+		},
+		{
+			name:   "按条件完成",
+			method: http.MethodPost, target: "/api/todos/complete?all=true",
+			wantEvents: []models.ID{1, 2, 3},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				resp := decodeResponse[CompleteResponse](t, rec)
+				if resp.Completed != 3 {
+					t.Errorf("完成了 %d 项，期望 3", resp.Completed)
+				}
+			},
+		},
+		{
+			name:   "原子导入",
+			method: http.MethodPost, target: "/api/todos/import?format=csv&atomic=true", body: "title\nx\ny\n",
+			wantEvents: []models.ID{4, 5},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				resp := decodeResponse[ImportResult](t, rec)
+				if resp.Imported != 2 || len(resp.Todos) != 2 || len(resp.Errors) != 0 {
+					t.Errorf("导入结果 = %+v，期望导入 2 项且没有错误", resp)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, flaky := newFlakyTxHandler(t)
+			for _, body := range []string{
+				`{"title": "a", "due_date": "2024-06-14T00:00:00Z"}`,
+				`{"title": "b", "due_date": "2024-06-15T00:00:00Z"}`,
+				`{"title": "c"}`,
+			} {
+				mustCreate(t, h, body)
+			}
+			for _, id := range tt.completed {
+				expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/"+id, `{"completed": true}`), http.StatusOK)
+			}
+
+			events := h.events.Subscribe()
+			defer h.events.Unsubscribe(events)
+			flaky.failures = 1
+			rec := serve(t, h, tt.method, tt.target, tt.body)
+			if rec.Code >= 300 {
+				t.Fatalf("状态码 = %d，响应: %s", rec.Code, rec.Body.String())
+			}
+			if flaky.failures != 0 {
+				t.Fatal("事务没有失败过，测试未覆盖重试")
+			}
+			tt.check(t, rec)
+			if got := drainEvents(events); !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Errorf("广播的事件 = %v，期望 %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
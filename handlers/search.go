@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// 高亮片段中包裹匹配内容的标记
+const (
+	highlightStart = "<<"
+	highlightEnd   = ">>"
+)
+
+// snippetContext 高亮片段中匹配内容前后保留的字符数
+const snippetContext = 20
+
+// Highlight 描述一次匹配的位置，Index 和 Length 均以字符（rune）计
+type Highlight struct {
+	Field   string `json:"field"`
+	Index   int    `json:"index"`
+	Length  int    `json:"length"`
+	Snippet string `json:"snippet"`
+}
+
+// SearchResult 带高亮信息的搜索结果
+type SearchResult struct {
+	*TodoResponse
+	Highlights []Highlight `json:"highlights"`
+}
+
+// handleSearch 处理关键字搜索，highlight=true 时附带匹配位置
+func (h *TodoHandler) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "搜索关键字不能为空")
+		return
+	}
+	fields, ok := fieldsParam(w, r, "highlights")
+	if !ok {
+		return
+	}
+
+	// 由存储按关键字过滤，这里只计算匹配位置
+	todos, _, err := storage.List(r.Context(), h.storage, storage.ListOptions{Query: query})
+	if err != nil {
+		writeStorageError(w, err, "搜索待办事项失败")
+		return
+	}
+
+	results := searchTodos(todos, query, h.config.Clock())
+	if r.URL.Query().Get("highlight") != "true" {
+		plain := make([]*TodoResponse, 0, len(results))
+		for _, result := range results {
+			plain = append(plain, result.TodoResponse)
+		}
+		writeProjectedJSON(w, http.StatusOK, plain, fields)
+		return
+	}
+	writeProjectedJSON(w, http.StatusOK, results, fields)
+}
+
+// searchTodos 返回标题或描述中包含关键字（忽略大小写）的待办事项及匹配位置，now 用于计算结果中的计算字段
+func searchTodos(todos []*models.Todo, query string, now time.Time) []SearchResult {
+	results := make([]SearchResult, 0)
+	for _, todo := range todos {
+		var highlights []Highlight
+		if hl, ok := highlightMatch("title", todo.Title, query); ok {
+			highlights = append(highlights, hl)
+		}
+		if hl, ok := highlightMatch("description", todo.Description, query); ok {
+			highlights = append(highlights, hl)
+		}
+		if len(highlights) > 0 {
+			results = append(results, SearchResult{TodoResponse: newTodoResponse(todo, now), Highlights: highlights})
+		}
+	}
+	return results
+}
+
+// highlightMatch 在 text 中查找 query 的第一次出现（忽略大小写），并生成带标记的片段
+func highlightMatch(field, text, query string) (Highlight, bool) {
+	textRunes := []rune(text)
+	queryRunes := []rune(query)
+	index := indexFoldRunes(textRunes, queryRunes)
+	if index < 0 {
+		return Highlight{}, false
+	}
+
+	end := index + len(queryRunes)
+	start := max(index-snippetContext, 0)
+	stop := min(end+snippetContext, len(textRunes))
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	snippet.WriteString(string(textRunes[start:index]))
+	snippet.WriteString(highlightStart)
+	snippet.WriteString(string(textRunes[index:end]))
+	snippet.WriteString(highlightEnd)
+	snippet.WriteString(string(textRunes[end:stop]))
+	if stop < len(textRunes) {
+		snippet.WriteString("…")
+	}
+
+	return Highlight{
+		Field:   field,
+		Index:   index,
+		Length:  len(queryRunes),
+		Snippet: snippet.String(),
+	}, true
+}
+
+// indexFoldRunes 返回 sub 在 s 中第一次出现的字符下标（忽略大小写），未找到返回 -1
+func indexFoldRunes(s, sub []rune) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	for i := 0; i+len(sub) <= len(s); i++ {
+		matched := true
+		for j, r := range sub {
+			if unicode.ToLower(s[i+j]) != unicode.ToLower(r) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i
+		}
+	}
+	return -1
+}
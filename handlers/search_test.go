@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHighlightMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  Highlight
+		ok    bool
+	}{
+		{
+			name:  "忽略大小写",
+			text:  "Write the Design doc",
+			query: "design",
+			want:  Highlight{Field: "title", Index: 10, Length: 6, Snippet: "Write the <<Design>> doc"},
+			ok:    true,
+		},
+		{
+			name:  "位置按字符计算",
+			text:  "准备周会的演示文稿",
+			query: "周会",
+			want:  Highlight{Field: "title", Index: 2, Length: 2, Snippet: "准备<<周会>>的演示文稿"},
+			ok:    true,
+		},
+		{
+			name:  "长文本截取前后上下文",
+			text:  "0123456789012345678901234567890 match 0123456789012345678901234567890",
+			query: "match",
+			want:  Highlight{Field: "title", Index: 32, Length: 5, Snippet: "…2345678901234567890 <<match>> 0123456789012345678…"},
+			ok:    true,
+		},
+		{name: "没有匹配", text: "buy milk", query: "bread"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := highlightMatch("title", tt.text, tt.query)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("highlightMatch(%q, %q) = %+v, %t，期望 %+v, %t", tt.text, tt.query, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSearchHighlight(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreate(t, h, `{"title": "Review PR", "description": "review the storage PR"}`)
+	mustCreateTitled(t, h, "buy milk")
+
+	type result struct {
+		ID         int         `json:"id"`
+		Highlights []Highlight `json:"highlights"`
+	}
+	rec := serve(t, h, http.MethodGet, "/api/todos/search?q=review&highlight=true", "")
+	expectStatus(t, rec, http.StatusOK)
+	want := []result{{ID: 1, Highlights: []Highlight{
+		{Field: "title", Index: 0, Length: 6, Snippet: "<<Review>> PR"},
+		{Field: "description", Index: 0, Length: 6, Snippet: "<<review>> the storage PR"},
+	}}}
+	if got := decodeResponse[[]result](t, rec); !reflect.DeepEqual(got, want) {
+		t.Errorf("高亮结果 = %+v，期望 %+v", got, want)
+	}
+
+	// 默认返回不带高亮信息的待办事项
+	rec = serve(t, h, http.MethodGet, "/api/todos/search?q=review", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[[]map[string]any](t, rec); len(got) != 1 || got[0]["highlights"] != nil {
+		t.Errorf("默认结果 = %v，期望不含 highlights 的一项", got)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// StaticHandler 静态文件处理器，在文件服务的基础上添加缓存相关响应头
+type StaticHandler struct {
+	root       http.FileSystem
+	fileServer http.Handler
+	maxAge     time.Duration
+}
+
+// NewStaticHandler 创建静态文件处理器，maxAge 为 JS/CSS 等资源的缓存时长
+func NewStaticHandler(dir string, maxAge time.Duration) *StaticHandler {
+	root := http.Dir(dir)
+	return &StaticHandler{
+		root:       root,
+		fileServer: http.FileServer(root),
+		maxAge:     maxAge,
+	}
+}
+
+// ServeHTTP 实现http.Handler接口
+func (h *StaticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + r.URL.Path)
+	if etag, isHTML, ok := h.fileInfo(name); ok {
+		// ETag 需在文件服务写入响应前设置，以便其处理 If-None-Match
+		w.Header().Set("ETag", etag)
+		if isHTML {
+			// 页面需每次校验，确保能及时加载新版本的资源
+			w.Header().Set("Cache-Control", "no-cache")
+		} else {
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+		}
+	}
+	h.fileServer.ServeHTTP(w, r)
+}
+
+// fileInfo 根据文件修改时间和大小生成弱 ETag，目录会解析为其中的 index.html
+func (h *StaticHandler) fileInfo(name string) (string, bool, bool) {
+	f, err := h.root.Open(name)
+	if err != nil {
+		return "", false, false
+	}
+	stat, err := f.Stat()
+	f.Close()
+	if err != nil {
+		return "", false, false
+	}
+
+	if stat.IsDir() {
+		name = path.Join(name, "index.html")
+		f, err = h.root.Open(name)
+		if err != nil {
+			return "", false, false
+		}
+		stat, err = f.Stat()
+		f.Close()
+		if err != nil || stat.IsDir() {
+			return "", false, false
+		}
+	}
+
+	etag := fmt.Sprintf(`W/"%x-%x"`, stat.ModTime().UnixNano(), stat.Size())
+	return etag, strings.HasSuffix(name, ".html"), true
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStaticCacheHeaders(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html": "<html></html>",
+		"app.js":     "console.log(1)",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	static := NewStaticHandler(dir, 10*time.Minute)
+
+	rec := serve(t, static, http.MethodGet, "/app.js", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=600" {
+		t.Errorf("静态资源 Cache-Control = %q，期望 public, max-age=600", got)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" || rec.Header().Get("Last-Modified") == "" {
+		t.Errorf("静态资源缺少 ETag 或 Last-Modified，响应头: %v", rec.Header())
+	}
+	expectStatus(t, serve(t, static, http.MethodGet, "/app.js", "", "If-None-Match", etag), http.StatusNotModified)
+
+	// 页面每次校验，避免引用过期的资源
+	rec = serve(t, static, http.MethodGet, "/", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("页面 Cache-Control = %q，期望 no-cache", got)
+	}
+
+	rec = serve(t, newTestHandler(t), http.MethodGet, "/api/todos", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Cache-Control"); got != "" {
+		t.Errorf("API 响应 Cache-Control = %q，期望不缓存", got)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"go-todolist/models"
+)
+
+// nearLimitRatio 数量达到软上限的该比例时视为接近上限
+const nearLimitRatio = 0.8
+
+// CapacityStats 存储容量统计
+type CapacityStats struct {
+	Count     int     `json:"count"`
+	SoftLimit int     `json:"soft_limit"`
+	Usage     float64 `json:"usage"`
+	NearLimit bool    `json:"near_limit"`
+}
+
+// TodoStats 待办事项统计信息
+type TodoStats struct {
+	Total            int `json:"total"`
+	Completed        int `json:"completed"`
+	Pending          int `json:"pending"`
+	Overdue          int `json:"overdue"`
+	EstimatedMinutes int `json:"estimated_minutes"`
+	SpentMinutes     int `json:"spent_minutes"`
+
+	Capacity CapacityStats `json:"capacity"`
+}
+
+// handleGetStats 处理获取统计信息
+func (h *TodoHandler) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	todos, err := h.storage.GetAll(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "获取统计信息失败")
+		return
+	}
+	stats := computeStats(todos, h.config.Clock())
+	stats.Capacity = computeCapacity(len(todos), h.config.CapacitySoftLimit)
+	writeJSONResponse(w, http.StatusOK, stats)
+}
+
+// computeStats 汇总待办事项的数量与耗时统计
+func computeStats(todos []*models.Todo, now time.Time) TodoStats {
+	stats := TodoStats{Total: len(todos)}
+	for _, todo := range todos {
+		if todo.Completed {
+			stats.Completed++
+		} else {
+			stats.Pending++
+		}
+		if todo.IsOverdue(now) {
+			stats.Overdue++
+		}
+		stats.EstimatedMinutes += todo.EstimateMinutes
+		stats.SpentMinutes += todo.SpentMinutes
+	}
+	return stats
+}
+
+// computeCapacity 计算当前数量相对软上限的使用情况
+func computeCapacity(count, softLimit int) CapacityStats {
+	usage := float64(count) / float64(softLimit)
+	return CapacityStats{
+		Count:     count,
+		SoftLimit: softLimit,
+		Usage:     usage,
+		NearLimit: usage >= nearLimitRatio,
+	}
+}
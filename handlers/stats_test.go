@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestComputeCapacity(t *testing.T) {
+	tests := []struct {
+		count, softLimit int
+		want             bool
+	}{
+		{0, 10, false},
+		{7, 10, false},
+		{8, 10, true},
+		{10, 10, true},
+		{12, 10, true},
+		{7999, 10000, false},
+		{8000, 10000, true},
+	}
+	for _, tt := range tests {
+		got := computeCapacity(tt.count, tt.softLimit)
+		if got.NearLimit != tt.want || got.Count != tt.count || got.SoftLimit != tt.softLimit {
+			t.Errorf("computeCapacity(%d, %d) = %+v，期望 near_limit=%t", tt.count, tt.softLimit, got, tt.want)
+		}
+	}
+}
+
+func TestStatsNearLimit(t *testing.T) {
+	h := newTestHandler(t, func(c *Config) { c.CapacitySoftLimit = 5 })
+
+	// 软上限 5，达到 4 项（80%）时开始提醒
+	for i, want := range []bool{false, false, false, false, true, true} {
+		if i > 0 {
+			mustCreateTitled(t, h, "t")
+		}
+		rec := serve(t, h, http.MethodGet, "/api/todos/stats", "")
+		expectStatus(t, rec, http.StatusOK)
+		capacity := decodeResponse[TodoStats](t, rec).Capacity
+		if capacity.Count != i || capacity.SoftLimit != 5 || capacity.NearLimit != want {
+			t.Errorf("%d 项时 capacity = %+v，期望 near_limit=%t", i, capacity, want)
+		}
+	}
+
+	// 删除后回落到阈值以下
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/1", ""), http.StatusNoContent)
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/2", ""), http.StatusNoContent)
+	if capacity := decodeResponse[TodoStats](t, serve(t, h, http.MethodGet, "/api/todos/stats", "")).Capacity; capacity.NearLimit {
+		t.Errorf("删除后 capacity = %+v，期望 near_limit=false", capacity)
+	}
+}
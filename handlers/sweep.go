@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// SweepResponse 批量移动已完成待办事项的响应结构
+type SweepResponse struct {
+	Moved  int    `json:"moved"`
+	ListID string `json:"list_id"`
+}
+
+// ClearCompletedResponse 清除已完成待办事项的响应结构
+type ClearCompletedResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// handleSweepCompleted 将所有已完成的待办事项移动到 list_id 指定的清单，未完成的保持不变。
+// 清单没有单独的实体，指定新的 list_id 即视为创建该清单
+func (h *TodoHandler) handleSweepCompleted(w http.ResponseWriter, r *http.Request) {
+	listID := r.URL.Query().Get("list_id")
+	if listID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "缺少 list_id 参数")
+		return
+	}
+	if err := models.ValidateListID(listID); err != nil {
+		writeStorageError(w, err, "无效的清单ID")
+		return
+	}
+
+	var moved []*models.Todo
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		// 事务重试时 fn 会再次执行，每次都从头收集结果
+		moved = nil
+		todos, err := tx.GetAll(r.Context())
+		if err != nil {
+			return err
+		}
+		for _, todo := range todos {
+			if !todo.Completed || todo.ListID == listID {
+				continue
+			}
+			updated, err := tx.Update(r.Context(), int(todo.ID), &models.UpdateTodoRequest{ListID: &listID})
+			if err != nil {
+				return err
+			}
+			moved = append(moved, updated)
+		}
+		return nil
+	})
+	if err != nil {
+		writeStorageError(w, err, "移动已完成待办事项失败")
+		return
+	}
+
+	for _, todo := range moved {
+		h.publishTodo(EventUpdated, todo)
+	}
+	writeJSONResponse(w, http.StatusOK, SweepResponse{Moved: len(moved), ListID: listID})
+}
+
+// handleClearCompleted 删除所有已完成的待办事项，存储支持事务时在同一事务中删除。
+// 需要保留时改用 handleSweepCompleted 移动到归档清单
+func (h *TodoHandler) handleClearCompleted(w http.ResponseWriter, r *http.Request) {
+	var deleted []models.ID
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		deleted = nil
+		todos, err := tx.GetAll(r.Context())
+		if err != nil {
+			return err
+		}
+		for _, todo := range todos {
+			if !todo.Completed {
+				continue
+			}
+			if err := tx.Delete(r.Context(), int(todo.ID)); err != nil {
+				return err
+			}
+			deleted = append(deleted, todo.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		writeStorageError(w, err, "清除已完成待办事项失败")
+		return
+	}
+
+	for _, id := range deleted {
+		h.events.Publish(TodoEvent{Type: EventDeleted, ID: id})
+	}
+	writeJSONResponse(w, http.StatusOK, ClearCompletedResponse{Deleted: len(deleted)})
+}
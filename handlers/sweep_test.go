@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+
+	"go-todolist/models"
+)
+
+func TestSweepCompleted(t *testing.T) {
+	h := newTestHandler(t)
+	for _, body := range []string{
+		`{"title": "a", "list_id": "work"}`,
+		`{"title": "b", "list_id": "work"}`,
+		`{"title": "c"}`,
+		`{"title": "d", "list_id": "archive"}`,
+		`{"title": "e"}`,
+	} {
+		mustCreate(t, h, body)
+	}
+	for _, id := range []string{"1", "3", "4"} {
+		expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/"+id, `{"completed": true}`), http.StatusOK)
+	}
+
+	// 已在目标清单中的已完成事项不计入移动数量
+	rec := serve(t, h, http.MethodPost, "/api/todos/sweep-completed?list_id=archive", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[SweepResponse](t, rec); got != (SweepResponse{Moved: 2, ListID: "archive"}) {
+		t.Errorf("响应 = %+v，期望移动 2 项到 archive", got)
+	}
+
+	want := map[models.ID]string{1: "archive", 2: "work", 3: "archive", 4: "archive", 5: ""}
+	todos := decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))
+	if len(todos) != len(want) {
+		t.Fatalf("共 %d 项，期望 %d", len(todos), len(want))
+	}
+	for _, todo := range todos {
+		if todo.ListID != want[todo.ID] {
+			t.Errorf("待办事项 %d 的清单 = %q，期望 %q", todo.ID, todo.ListID, want[todo.ID])
+		}
+	}
+
+	rec = serve(t, h, http.MethodPost, "/api/todos/sweep-completed?list_id=archive", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[SweepResponse](t, rec).Moved; got != 0 {
+		t.Errorf("再次移动 %d 项，期望 0", got)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/sweep-completed", ""), http.StatusBadRequest)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/sweep-completed?list_id=%20archive", ""), http.StatusBadRequest)
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/sweep-completed?list_id=archive", ""), http.StatusMethodNotAllowed)
+}
+
+func TestClearCompleted(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c", "d")
+	for _, id := range []string{"1", "3"} {
+		expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/"+id, `{"completed": true}`), http.StatusOK)
+	}
+	events := h.events.Subscribe()
+	defer h.events.Unsubscribe(events)
+
+	rec := serve(t, h, http.MethodDelete, "/api/todos/completed", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[ClearCompletedResponse](t, rec).Deleted; got != 2 {
+		t.Errorf("删除 %d 项，期望 2", got)
+	}
+	if got := drainEvents(events); !reflect.DeepEqual(got, []models.ID{1, 3}) {
+		t.Errorf("删除事件 = %v，期望 [1 3]", got)
+	}
+
+	todos := decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))
+	if got := todoIDs(todos); !reflect.DeepEqual(got, []models.ID{2, 4}) {
+		t.Errorf("剩余 = %v，期望 [2 4]", got)
+	}
+	// 删除的待办事项进入回收站，可以恢复
+	trash := todoIDs(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos/trash", "")))
+	slices.Sort(trash)
+	if !reflect.DeepEqual(trash, []models.ID{1, 3}) {
+		t.Errorf("回收站 = %v，期望 [1 3]", trash)
+	}
+
+	rec = serve(t, h, http.MethodDelete, "/api/todos/completed", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[ClearCompletedResponse](t, rec).Deleted; got != 0 {
+		t.Errorf("再次删除 %d 项，期望 0", got)
+	}
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/completed", ""), http.StatusMethodNotAllowed)
+}
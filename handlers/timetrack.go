@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// handleLogTime 处理记录耗时，将分钟数累加到已用耗时
+func (h *TodoHandler) handleLogTime(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.LogTimeRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var todo *models.Todo
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		current, err := tx.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		spent := current.SpentMinutes + req.Minutes
+		todo, err = tx.Update(r.Context(), id, &models.UpdateTodoRequest{SpentMinutes: &spent})
+		return err
+	})
+	if err != nil {
+		writeStorageError(w, err, "记录耗时失败")
+		return
+	}
+
+	h.publishTodo(EventUpdated, todo)
+	writeJSONResponse(w, http.StatusOK, h.todoResponse(todo))
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"go-todolist/models"
+)
+
+func TestLogTime(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreate(t, h, `{"title": "a", "estimate_minutes": 60, "spent_minutes": 10}`)
+	mustCreate(t, h, `{"title": "b", "estimate_minutes": 30}`)
+
+	for _, minutes := range []string{"15", "20"} {
+		expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/1/time", `{"minutes": `+minutes+`}`), http.StatusOK)
+	}
+	rec := serve(t, h, http.MethodGet, "/api/todos/1", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[models.Todo](t, rec).SpentMinutes; got != 45 {
+		t.Errorf("已用耗时 = %d，期望 45", got)
+	}
+
+	stats := decodeResponse[TodoStats](t, serve(t, h, http.MethodGet, "/api/todos/stats", ""))
+	if stats.EstimatedMinutes != 90 || stats.SpentMinutes != 45 {
+		t.Errorf("统计的预估/已用耗时 = %d/%d，期望 90/45", stats.EstimatedMinutes, stats.SpentMinutes)
+	}
+
+	for _, minutes := range []string{"0", "-5"} {
+		expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/1/time", `{"minutes": `+minutes+`}`), http.StatusBadRequest)
+	}
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/9/time", `{"minutes": 5}`), http.StatusNotFound)
+}
+
+func TestTimeFieldsNonNegative(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a")
+
+	for _, body := range []string{
+		`{"title": "b", "estimate_minutes": -1}`,
+		`{"title": "b", "spent_minutes": -1}`,
+	} {
+		expectStatus(t, serve(t, h, http.MethodPost, "/api/todos", body), http.StatusBadRequest)
+	}
+	for _, body := range []string{`{"estimate_minutes": -1}`, `{"spent_minutes": -1}`} {
+		expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", body), http.StatusBadRequest)
+	}
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", `{"estimate_minutes": 0, "spent_minutes": 0}`), http.StatusOK)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"go-todolist/models"
+)
+
+// requestLocation 返回请求 tz 参数指定的时区，未指定时使用配置的默认时区
+func (h *TodoHandler) requestLocation(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return h.config.Location, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.New("无效的时区: " + name)
+	}
+	return loc, nil
+}
+
+// dayRange 返回 now 在指定时区所在自然日的起止时间，区间为 [start, end)
+func dayRange(now time.Time, loc *time.Location) (time.Time, time.Time) {
+	local := now.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// weekRange 返回 now 在指定时区所在自然周（周一至周日）的起止时间，区间为 [start, end)
+func weekRange(now time.Time, loc *time.Location) (time.Time, time.Time) {
+	today, _ := dayRange(now, loc)
+	// time.Weekday 以周日为 0，换算为距周一的天数
+	offset := (int(today.Weekday()) + 6) % 7
+	start := today.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// dueShortcutFilter 将 due 参数的快捷值转换为过滤条件，日期边界按 loc 时区计算
+func dueShortcutFilter(shortcut string, now time.Time, loc *time.Location) (todoFilter, error) {
+	var start, end time.Time
+	switch shortcut {
+	case "today":
+		start, end = dayRange(now, loc)
+	case "tomorrow":
+		start, end = dayRange(now.In(loc).AddDate(0, 0, 1), loc)
+	case "this_week":
+		start, end = weekRange(now, loc)
+	case "overdue":
+		return func(todo *models.Todo) bool {
+			return todo.IsOverdue(now)
+		}, nil
+	default:
+		return nil, errors.New("due 必须为 today、tomorrow、this_week 或 overdue")
+	}
+
+	return dueWithin(start, end), nil
+}
+
+// dueWithin 返回截止时间落在 [start, end) 内的过滤条件
+func dueWithin(start, end time.Time) todoFilter {
+	return func(todo *models.Todo) bool {
+		return todo.DueDate != nil && !todo.DueDate.Before(start) && todo.DueDate.Before(end)
+	}
+}
+
+// handleGetToday 处理获取今天到期的未完成待办事项
+func (h *TodoHandler) handleGetToday(w http.ResponseWriter, r *http.Request) {
+	loc, err := h.requestLocation(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fields, ok := fieldsParam(w, r)
+	if !ok {
+		return
+	}
+
+	todos, err := h.storage.GetAll(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+
+	start, end := dayRange(h.config.Clock(), loc)
+	writeProjectedJSON(w, http.StatusOK, h.todoResponses(dueBetween(todos, start, end)), fields)
+}
+
+// dueBetween 返回截止时间位于 [start, end) 区间内的未完成待办事项
+func dueBetween(todos []*models.Todo, start, end time.Time) []*models.Todo {
+	result := make([]*models.Todo, 0)
+	for _, todo := range todos {
+		if todo.Completed || todo.DueDate == nil {
+			continue
+		}
+		if !todo.DueDate.Before(start) && todo.DueDate.Before(end) {
+			result = append(result, todo)
+		}
+	}
+	return result
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+	// 测试不依赖系统时区数据库
+	_ "time/tzdata"
+
+	"go-todolist/models"
+)
+
+func TestDayRange(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		loc       *time.Location
+		wantStart string
+		wantEnd   string
+	}{
+		{"UTC", time.Date(2024, 6, 15, 20, 0, 0, 0, time.UTC), time.UTC, "2024-06-15T00:00:00Z", "2024-06-16T00:00:00Z"},
+		// UTC 晚上八点在上海已是第二天凌晨
+		{"上海跨日", time.Date(2024, 6, 15, 20, 0, 0, 0, time.UTC), shanghai, "2024-06-16T00:00:00+08:00", "2024-06-17T00:00:00+08:00"},
+		{"上海当天零点", time.Date(2024, 6, 15, 16, 0, 0, 0, time.UTC), shanghai, "2024-06-16T00:00:00+08:00", "2024-06-17T00:00:00+08:00"},
+		{"上海前一天最后一秒", time.Date(2024, 6, 15, 15, 59, 59, 0, time.UTC), shanghai, "2024-06-15T00:00:00+08:00", "2024-06-16T00:00:00+08:00"},
+		// 夏令时开始的那天只有 23 小时
+		{"纽约夏令时切换", time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC), newYork, "2024-03-10T00:00:00-05:00", "2024-03-11T00:00:00-04:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := dayRange(tt.now, tt.loc)
+			if got := start.Format(time.RFC3339); got != tt.wantStart {
+				t.Errorf("开始 = %s，期望 %s", got, tt.wantStart)
+			}
+			if got := end.Format(time.RFC3339); got != tt.wantEnd {
+				t.Errorf("结束 = %s，期望 %s", got, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestTodayTimezone(t *testing.T) {
+	// UTC 2024-06-15 20:00，即上海 2024-06-16 04:00
+	now := time.Date(2024, 6, 15, 20, 0, 0, 0, time.UTC)
+	h := newTestHandler(t, func(c *Config) { c.Clock = func() time.Time { return now } })
+	for _, due := range []string{
+		"2024-06-15T10:00:00Z", // UTC 今天，上海昨天
+		"2024-06-15T15:59:59Z", // 上海昨天的最后一秒
+		"2024-06-15T16:00:00Z", // 上海今天零点
+		"2024-06-16T15:59:59Z", // 上海今天的最后一秒
+		"2024-06-16T16:00:00Z", // 上海明天零点
+	} {
+		mustCreate(t, h, `{"title": "t", "due_date": "`+due+`"}`)
+	}
+
+	tests := []struct {
+		target string
+		want   []models.ID
+	}{
+		{"/api/todos/today", []models.ID{1, 2, 3}},
+		{"/api/todos/today?tz=Asia/Shanghai", []models.ID{3, 4}},
+		{"/api/todos?due=today", []models.ID{1, 2, 3}},
+		{"/api/todos?due=today&tz=Asia/Shanghai", []models.ID{3, 4}},
+		{"/api/todos?due=tomorrow&tz=Asia/Shanghai", []models.ID{5}},
+		{"/api/todos?due_on=2024-06-15&tz=Asia/Shanghai", []models.ID{1, 2}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, http.MethodGet, tt.target, "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := todoIDs(decodeResponse[[]*models.Todo](t, rec)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET %s = %v，期望 %v", tt.target, got, tt.want)
+		}
+	}
+
+	// 配置的默认时区可被 tz 参数覆盖
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	configured := newTestHandler(t, func(c *Config) {
+		c.Clock = func() time.Time { return now }
+		c.Location = shanghai
+	})
+	mustCreate(t, configured, `{"title": "t", "due_date": "2024-06-16T10:00:00Z"}`)
+	if got := decodeResponse[[]*models.Todo](t, serve(t, configured, http.MethodGet, "/api/todos/today", "")); len(got) != 1 {
+		t.Errorf("默认时区为上海时今天到期 %d 项，期望 1", len(got))
+	}
+	if got := decodeResponse[[]*models.Todo](t, serve(t, configured, http.MethodGet, "/api/todos/today?tz=UTC", "")); len(got) != 0 {
+		t.Errorf("tz=UTC 时今天到期 %d 项，期望 0", len(got))
+	}
+
+	for _, target := range []string{
+		"/api/todos/today?tz=Mars/Olympus",
+		"/api/todos?due=today&tz=Mars/Olympus",
+		"/api/todos?due_on=2024-06-15&tz=Mars/Olympus",
+	} {
+		expectStatus(t, serve(t, h, http.MethodGet, target, ""), http.StatusBadRequest)
+	}
+}
+
+func TestDueShortcuts(t *testing.T) {
+	// testNow 为 2024-06-15（周六）12:00 UTC，本周为 06-10（周一）至 06-17
+	h := newTestHandler(t)
+	for _, due := range []string{
+		"2024-06-15T18:00:00Z", // 今天
+		"2024-06-15T00:00:00Z", // 今天零点，已逾期
+		"2024-06-16T10:00:00Z", // 明天（周日），仍在本周
+		"2024-06-17T00:00:00Z", // 下周一零点
+		"2024-06-10T00:00:00Z", // 本周一零点，已逾期
+		"2024-06-09T23:59:59Z", // 上周日，已逾期
+	} {
+		mustCreate(t, h, `{"title": "t", "due_date": "`+due+`"}`)
+	}
+	mustCreateTitled(t, h, "没有截止时间")
+	mustCreate(t, h, `{"title": "已完成", "due_date": "2024-06-14T00:00:00Z"}`)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/8", `{"completed": true}`), http.StatusOK)
+
+	tests := []struct {
+		due  string
+		want []models.ID
+	}{
+		{"today", []models.ID{1, 2}},
+		{"tomorrow", []models.ID{3}},
+		{"this_week", []models.ID{1, 2, 3, 5, 8}},
+		// 已完成的不算逾期
+		{"overdue", []models.ID{2, 5, 6}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, http.MethodGet, "/api/todos?due="+tt.due, "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := todoIDs(decodeResponse[[]*models.Todo](t, rec)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("due=%s 结果 = %v，期望 %v", tt.due, got, tt.want)
+		}
+	}
+
+	for _, due := range []string{"yesterday", "next_week", "TODAY"} {
+		rec := serve(t, h, http.MethodGet, "/api/todos?due="+due, "")
+		expectStatus(t, rec, http.StatusBadRequest)
+		if got := errorMessage(t, rec); got != "due 必须为 today、tomorrow、this_week 或 overdue" {
+			t.Errorf("due=%s 错误信息 = %q", due, got)
+		}
+	}
+}
+
+func TestWeekRange(t *testing.T) {
+	tests := []struct {
+		now       time.Time
+		wantStart string
+	}{
+		{time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), "2024-06-10"},
+		{time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC), "2024-06-10"},
+		// 周日属于以周一开始的本周
+		{time.Date(2024, 6, 16, 23, 59, 59, 0, time.UTC), "2024-06-10"},
+		{time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC), "2024-06-17"},
+		// 跨年的一周
+		{time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC), "2024-12-30"},
+	}
+	for _, tt := range tests {
+		start, end := weekRange(tt.now, time.UTC)
+		if got := start.Format("2006-01-02"); got != tt.wantStart || !end.Equal(start.AddDate(0, 0, 7)) {
+			t.Errorf("weekRange(%v) = %v - %v，期望从 %s 开始的 7 天", tt.now, start, end, tt.wantStart)
+		}
+	}
+}
+
+func TestDueOn(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHandler(t, func(c *Config) { c.Location = shanghai })
+	for _, due := range []string{
+		"2024-05-31T15:59:59Z", // 上海 05-31 的最后一秒
+		"2024-05-31T16:00:00Z", // 上海 06-01 零点
+		"2024-06-01T08:00:00Z", // 上海 06-01 16:00，UTC 同一天
+		"2024-06-01T15:59:59Z", // 上海 06-01 的最后一秒
+		"2024-06-01T16:00:00Z", // 上海 06-02 零点，UTC 仍为 06-01
+	} {
+		mustCreate(t, h, `{"title": "t", "due_date": "`+due+`"}`)
+	}
+	mustCreate(t, h, `{"title": "没有截止时间"}`)
+
+	tests := []struct {
+		target string
+		want   []models.ID
+	}{
+		{"/api/todos?due_on=2024-06-01", []models.ID{2, 3, 4}},
+		{"/api/todos?due_on=2024-05-31", []models.ID{1}},
+		{"/api/todos?due_on=2024-06-02", []models.ID{5}},
+		{"/api/todos?due_on=2024-06-03", []models.ID{}},
+		{"/api/todos?due_on=2024-06-01&tz=UTC", []models.ID{3, 4, 5}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, http.MethodGet, tt.target, "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := todoIDs(decodeResponse[[]*models.Todo](t, rec)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET %s = %v，期望 %v", tt.target, got, tt.want)
+		}
+	}
+
+	for _, v := range []string{"2024-6-1", "2024-06-31", "06/01/2024", "2024-06-01T00:00:00Z", "tomorrow"} {
+		rec := serve(t, h, http.MethodGet, "/api/todos?due_on="+v, "")
+		expectStatus(t, rec, http.StatusBadRequest)
+		if got, want := errorMessage(t, rec), "due_on 必须为 YYYY-MM-DD 格式的日期"; got != want {
+			t.Errorf("due_on=%s 错误信息 = %q，期望 %q", v, got, want)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,16 +17,73 @@ import (
 // TodoHandler 处理待办事项相关的HTTP请求
 type TodoHandler struct {
 	storage storage.TodoStorage
+	events  *EventHub
+	config  Config
+	latency *LatencyRecorder
+	// dedup 为 nil 时不对创建请求去重
+	dedup *createDeduper
 }
 
-// NewTodoHandler 创建新的待办事项处理器
+// NewTodoHandler 使用默认配置创建新的待办事项处理器
 func NewTodoHandler(storage storage.TodoStorage) *TodoHandler {
-	return &TodoHandler{storage: storage}
+	return NewTodoHandlerWithConfig(storage, DefaultConfig())
 }
 
+// NewTodoHandlerWithConfig 使用指定配置创建新的待办事项处理器
+func NewTodoHandlerWithConfig(storage storage.TodoStorage, config Config) *TodoHandler {
+	config = config.withDefaults()
+	return &TodoHandler{
+		storage: storage,
+		events:  NewEventHub(),
+		latency: NewLatencyRecorder(),
+		dedup:   newCreateDeduper(config.CreateDedupWindow, config.Clock),
+		config:  config,
+	}
+}
+
+// Events 返回处理器的事件中心
+func (h *TodoHandler) Events() *EventHub {
+	return h.events
+}
+
+// Latency 返回处理器的请求耗时记录器，供 RecordLatency 中间件写入
+func (h *TodoHandler) Latency() *LatencyRecorder {
+	return h.latency
+}
+
+// Shutdown 通知所有长连接断开，并等待其退出或 ctx 结束；之后新的长连接请求返回 503。
+// http.Server.Shutdown 不会等待已被接管的连接，因此需要与其配合调用
+func (h *TodoHandler) Shutdown(ctx context.Context) error {
+	h.events.Close()
+
+	done := make(chan struct{})
+	go func() {
+		h.events.streams.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// maxTodoID 允许的最大ID值，超出该范围的ID直接视为无效请求
+const maxTodoID = math.MaxInt32
+
 // ErrorResponse 错误响应结构
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// DeleteResponse 幂等删除的响应结构
+type DeleteResponse struct {
+	Deleted        bool      `json:"deleted"`
+	ID             models.ID `json:"id"`
+	AlreadyDeleted bool      `json:"already_deleted,omitempty"`
 }
 
 // writeJSONResponse 写入JSON响应
@@ -32,17 +93,44 @@ func writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{})
 	json.NewEncoder(w).Encode(data)
 }
 
-// writeErrorResponse 写入错误响应
+// writeErrorResponse 写入错误响应，携带 RequestID 中间件写入响应头的请求ID
 func writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	writeJSONResponse(w, statusCode, ErrorResponse{Error: message})
+	writeJSONResponse(w, statusCode, ErrorResponse{
+		Error:     message,
+		RequestID: w.Header().Get(requestIDHeader),
+	})
+}
+
+// parseID 解析路径中的ID，要求为 1 到 maxTodoID 之间的正整数
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) && numErr.Err == strconv.ErrRange {
+			return 0, errors.New("ID超出有效范围")
+		}
+		return 0, errors.New("无效的ID格式")
+	}
+	if id <= 0 {
+		return 0, errors.New("ID必须为正整数")
+	}
+	if id > maxTodoID {
+		return 0, errors.New("ID超出有效范围")
+	}
+	return id, nil
+}
+
+// setCORSHeaders 设置允许跨域访问 API 的响应头
+func setCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Encoding, X-Request-ID, If-None-Match")
+	w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, ETag, Link, X-Total-Count")
 }
 
 // ServeHTTP 实现http.Handler接口
 func (h *TodoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// 设置CORS头
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	setCORSHeaders(w)
 
 	// 处理预检请求
 	if r.Method == http.MethodOptions {
@@ -50,9 +138,15 @@ func (h *TodoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 只读模式下统一拒绝修改请求
+	if h.config.ReadOnly && isMutatingMethod(r.Method) {
+		writeErrorResponse(w, http.StatusForbidden, readOnlyMessage)
+		return
+	}
+
 	// 解析路径
 	path := strings.TrimPrefix(r.URL.Path, "/api/todos")
-	
+
 	switch {
 	case path == "" || path == "/":
 		// /api/todos
@@ -61,15 +155,174 @@ func (h *TodoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			h.handleGetTodos(w, r)
 		case http.MethodPost:
 			h.handleCreateTodo(w, r)
+		case http.MethodDelete:
+			h.handleBulkDelete(w, r)
 		default:
 			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
 		}
+	case path == "/import":
+		// /api/todos/import
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleImport(w, r)
+	case path == "/bulk":
+		// /api/todos/bulk
+		switch r.Method {
+		case http.MethodPost:
+			h.handleBulkCreate(w, r)
+		case http.MethodPatch:
+			h.handleBulkUpdate(w, r)
+		default:
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+		}
+	case path == "/batch/due":
+		// /api/todos/batch/due
+		if r.Method != http.MethodPut {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleBatchDue(w, r)
+	case path == "/overdue/snooze":
+		// /api/todos/overdue/snooze
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleSnoozeOverdue(w, r)
+	case path == "/complete":
+		// /api/todos/complete
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleCompleteMatching(w, r)
+	case path == "/sweep-completed":
+		// /api/todos/sweep-completed
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleSweepCompleted(w, r)
+	case path == "/completed":
+		// /api/todos/completed
+		if r.Method != http.MethodDelete {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleClearCompleted(w, r)
+	case path == "/reorder":
+		// /api/todos/reorder
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleReorder(w, r)
+	case path == "/archived":
+		// /api/todos/archived
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetArchived(w, r)
+	case path == "/trash":
+		// /api/todos/trash
+		h.serveTrash(w, r)
+	case path == "/count":
+		// /api/todos/count
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetCount(w, r)
+	case path == "/search":
+		// /api/todos/search
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleSearch(w, r)
+	case path == "/calendar.ics":
+		// /api/todos/calendar.ics
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetCalendar(w, r)
+	case path == "/stats":
+		// /api/todos/stats
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetStats(w, r)
+	case path == "/focus":
+		// /api/todos/focus
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetFocus(w, r)
+	case path == "/next":
+		// /api/todos/next
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetNext(w, r)
+	case path == "/histogram":
+		// /api/todos/histogram
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetHistogram(w, r)
+	case path == "/forecast":
+		// /api/todos/forecast
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetForecast(w, r)
+	case path == "/random":
+		// /api/todos/random
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetRandom(w, r)
+	case path == "/today":
+		// /api/todos/today
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetToday(w, r)
+	case path == "/overdue":
+		// /api/todos/overdue
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleGetOverdue(w, r)
+	case path == "/ws":
+		// /api/todos/ws
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleWebSocket(w, r)
 	case strings.HasPrefix(path, "/"):
-		// /api/todos/{id}
-		idStr := strings.TrimPrefix(path, "/")
-		id, err := strconv.Atoi(idStr)
+		// /api/todos/{id} 及其子资源 /api/todos/{id}/{action}
+		idStr, action, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		id, err := parseID(idStr)
 		if err != nil {
-			writeErrorResponse(w, http.StatusBadRequest, "无效的ID格式")
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if action != "" {
+			h.serveTodoAction(w, r, id, action)
 			return
 		}
 
@@ -77,6 +330,8 @@ func (h *TodoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		case http.MethodGet:
 			h.handleGetTodo(w, r, id)
 		case http.MethodPut:
+			h.handleReplaceTodo(w, r, id)
+		case http.MethodPatch:
 			h.handleUpdateTodo(w, r, id)
 		case http.MethodDelete:
 			h.handleDeleteTodo(w, r, id)
@@ -88,84 +343,405 @@ func (h *TodoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleGetTodos 处理获取所有待办事项
+// serveTodoAction 处理单个待办事项的子资源请求
+func (h *TodoHandler) serveTodoAction(w http.ResponseWriter, r *http.Request, id int, action string) {
+	action, sub, _ := strings.Cut(action, "/")
+	switch {
+	case action == "comments" && sub == "":
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleAddComment(w, r, id)
+	case action == "comments":
+		commentID, err := parseID(sub)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if r.Method != http.MethodDelete {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleDeleteComment(w, r, id, commentID)
+	case action == "star" && sub == "":
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleToggleStar(w, r, id)
+	case action == "time" && sub == "":
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleLogTime(w, r, id)
+	case action == "restore" && sub == "":
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleRestoreTodo(w, r, id)
+	case action == "duplicate" && sub == "":
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.handleDuplicateTodo(w, r, id)
+	case action == "archive" && sub == "":
+		switch r.Method {
+		case http.MethodPost:
+			h.handleSetArchived(w, r, id, true)
+		case http.MethodDelete:
+			h.handleSetArchived(w, r, id, false)
+		default:
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+		}
+	case action == "history":
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+			return
+		}
+		h.serveHistory(w, r, id, sub)
+	default:
+		writeErrorResponse(w, http.StatusNotFound, "路径未找到")
+	}
+}
+
+// handleGetTodos 处理获取所有待办事项，支持过滤、排序、分页和字段投影；过滤尽量由存储执行，按ID升序时在存储中分页
 func (h *TodoHandler) handleGetTodos(w http.ResponseWriter, r *http.Request) {
-	todos, err := h.storage.GetAll()
+	query, err := h.parseListQuery(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.excludeArchived()
+	h.writeTodoList(w, r, query)
+}
+
+// writeTodoList 按解析后的列表参数获取待办事项并写入响应
+func (h *TodoHandler) writeTodoList(w http.ResponseWriter, r *http.Request, query *listQuery) {
+	var (
+		err   error
+		todos []*models.Todo
+		total int
+	)
+	switch {
+	case query.ids != nil:
+		todos, err = h.getTodosByIDs(r.Context(), query.ids)
+		todos = query.options.Filter(todos)
+	case query.externalID != "":
+		todos, err = h.storage.FindByExternalID(r.Context(), query.externalID)
+		todos = query.options.Filter(todos)
+	default:
+		todos, total, err = storage.List(r.Context(), h.storage, query.storageOptions())
+	}
+	if err != nil {
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+	switch {
+	case !query.pagedInStorage():
+		todos, total = query.page(todos)
+	case query.cursor != nil:
+		todos, query.nextCursor = cursorPage(todos, query.cursor, query.limit)
+	}
+
+	result, err := query.render(h.todoResponses(todos), total)
 	if err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, "获取待办事项失败")
 		return
 	}
-	writeJSONResponse(w, http.StatusOK, todos)
+	links := paginationLinks(r.URL, query.limit, query.offset, total)
+	if query.cursor != nil {
+		links = cursorLink(r.URL, query.nextCursor)
+	}
+	if links != "" {
+		w.Header().Set("Link", links)
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeJSONWithETag(w, r, result)
+}
+
+// getTodosByIDs 按ID逐个获取待办事项，跳过不存在的ID
+func (h *TodoHandler) getTodosByIDs(ctx context.Context, ids []int) ([]*models.Todo, error) {
+	todos := make([]*models.Todo, 0, len(ids))
+	for _, id := range ids {
+		todo, err := h.storage.GetByID(ctx, id)
+		if errors.Is(err, storage.ErrTodoNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
 }
 
 // handleGetTodo 处理获取单个待办事项
 func (h *TodoHandler) handleGetTodo(w http.ResponseWriter, r *http.Request, id int) {
-	todo, err := h.storage.GetByID(id)
-	if err == storage.ErrTodoNotFound {
-		writeErrorResponse(w, http.StatusNotFound, "待办事项未找到")
+	fields, ok := fieldsParam(w, r)
+	if !ok {
 		return
 	}
+
+	todo, err := h.storage.GetByID(r.Context(), id)
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "获取待办事项失败")
+		writeStorageError(w, err, "获取待办事项失败")
+		return
+	}
+	data, err := projectResponse(h.todoResponse(todo), fields)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "序列化响应失败")
 		return
 	}
-	writeJSONResponse(w, http.StatusOK, todo)
+	writeJSONWithETag(w, r, data)
 }
 
 // handleCreateTodo 处理创建待办事项
 func (h *TodoHandler) handleCreateTodo(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateTodoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErrorResponse(w, http.StatusBadRequest, "无效的JSON格式")
-		return
-	}
-
-	if err := req.Validate(); err != nil {
-		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
-	todo, err := h.storage.Create(&req)
+	todo, err := h.createTodo(r.Context(), &req)
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "创建待办事项失败")
+		writeStorageError(w, err, "创建待办事项失败")
 		return
 	}
 
-	writeJSONResponse(w, http.StatusCreated, todo)
+	writeJSONResponse(w, http.StatusCreated, h.todoResponse(todo))
 }
 
-// handleUpdateTodo 处理更新待办事项
+// handleUpdateTodo 处理部分更新待办事项，只修改请求体中出现的字段
 func (h *TodoHandler) handleUpdateTodo(w http.ResponseWriter, r *http.Request, id int) {
 	var req models.UpdateTodoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErrorResponse(w, http.StatusBadRequest, "无效的JSON格式")
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	todo, err := h.updateTodo(r.Context(), id, &req)
+	if err != nil {
+		writeStorageError(w, err, "更新待办事项失败")
 		return
 	}
 
-	todo, err := h.storage.Update(id, &req)
-	if err == storage.ErrTodoNotFound {
-		writeErrorResponse(w, http.StatusNotFound, "待办事项未找到")
+	writeJSONResponse(w, http.StatusOK, h.todoResponse(todo))
+}
+
+// handleReplaceTodo 处理整体替换待办事项，请求体与创建接口相同并可设置 completed、starred，
+// 未提供的字段恢复为默认值；只修改部分字段应使用 PATCH
+func (h *TodoHandler) handleReplaceTodo(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.ReplaceTodoRequest
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
+	if h.config.NormalizeTags {
+		req.Tags = models.NormalizeTags(req.Tags)
+	}
+	if err := req.Validate(); err != nil {
+		writeStorageError(w, err, "更新待办事项失败")
+		return
+	}
+
+	todo, err := h.updateTodo(r.Context(), id, req.UpdateRequest())
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "更新待办事项失败")
+		writeStorageError(w, err, "更新待办事项失败")
 		return
 	}
 
-	writeJSONResponse(w, http.StatusOK, todo)
+	writeJSONResponse(w, http.StatusOK, h.todoResponse(todo))
 }
 
 // handleDeleteTodo 处理删除待办事项
 func (h *TodoHandler) handleDeleteTodo(w http.ResponseWriter, r *http.Request, id int) {
-	err := h.storage.Delete(id)
-	if err == storage.ErrTodoNotFound {
-		writeErrorResponse(w, http.StatusNotFound, "待办事项未找到")
+	idempotent := r.URL.Query().Get("idempotent") == "true"
+
+	err := h.deleteTodo(r.Context(), id)
+	if idempotent && errors.Is(err, storage.ErrTodoNotFound) {
+		// 幂等模式下不存在的ID视为已删除
+		writeJSONResponse(w, http.StatusOK, DeleteResponse{Deleted: true, ID: models.ID(id), AlreadyDeleted: true})
 		return
 	}
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "删除待办事项失败")
+		writeStorageError(w, err, "删除待办事项失败")
 		return
 	}
 
+	if idempotent {
+		writeJSONResponse(w, http.StatusOK, DeleteResponse{Deleted: true, ID: models.ID(id)})
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// readOnlyMessage 只读模式下拒绝修改时返回的错误信息
+const readOnlyMessage = "只读模式下不允许修改数据"
+
+// isMutatingMethod 判断请求方法是否会修改数据
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// createTodo 验证并创建待办事项，成功后广播变更事件。开启创建去重时，窗口内的重复请求直接返回首次创建的待办事项
+func (h *TodoHandler) createTodo(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	if h.config.NormalizeTags {
+		req.Tags = models.NormalizeTags(req.Tags)
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if h.dedup == nil {
+		return h.storeTodo(ctx, req)
+	}
+	todo, _, err := h.dedup.create(ctx, h.storage, req, func() (*models.Todo, error) {
+		return h.storeTodo(ctx, req)
+	})
+	return todo, err
+}
+
+// storeTodo 将已校验的请求写入存储并广播创建事件，需要时在事务中检查外部ID唯一性
+func (h *TodoHandler) storeTodo(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	var todo *models.Todo
+	create := func(s storage.TodoStorage) error {
+		if err := h.checkExternalID(ctx, s, req.ExternalID, 0); err != nil {
+			return err
+		}
+		var err error
+		todo, err = s.Create(ctx, req)
+		return err
+	}
+	var err error
+	if h.config.UniqueExternalIDs && req.ExternalID != "" {
+		// 检查与创建需在同一事务中完成，避免并发请求写入重复的外部ID
+		err = h.withTx(ctx, create)
+	} else {
+		err = create(h.storage)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h.publishTodo(EventCreated, todo)
+	return todo, nil
+}
+
+// updateTodo 验证并更新待办事项，成功后广播变更事件
+func (h *TodoHandler) updateTodo(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	if h.config.NormalizeTags && req.Tags != nil {
+		tags := models.NormalizeTags(*req.Tags)
+		req.Tags = &tags
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var todo *models.Todo
+	update := func(s storage.TodoStorage) error {
+		if req.ExternalID != nil {
+			if err := h.checkExternalID(ctx, s, *req.ExternalID, id); err != nil {
+				return err
+			}
+		}
+		var err error
+		todo, err = s.Update(ctx, id, req)
+		return err
+	}
+	var err error
+	if h.config.UniqueExternalIDs && req.ExternalID != nil && *req.ExternalID != "" {
+		err = h.withTx(ctx, update)
+	} else {
+		err = update(h.storage)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h.publishTodo(EventUpdated, todo)
+	return todo, nil
+}
+
+// checkExternalID 在开启外部ID唯一性校验时，检查 externalID 是否已被 selfID 以外的待办事项使用
+func (h *TodoHandler) checkExternalID(ctx context.Context, s storage.TodoStorage, externalID string, selfID int) error {
+	if !h.config.UniqueExternalIDs || externalID == "" {
+		return nil
+	}
+	todos, err := s.FindByExternalID(ctx, externalID)
+	if err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		if int(todo.ID) != selfID {
+			return fmt.Errorf("%w: 外部ID %s 已被待办事项 %d 使用", storage.ErrConflict, externalID, todo.ID)
+		}
+	}
+	return nil
+}
+
+// deleteTodo 删除待办事项，成功后广播变更事件
+func (h *TodoHandler) deleteTodo(ctx context.Context, id int) error {
+	if err := h.storage.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	h.events.Publish(TodoEvent{Type: EventDeleted, ID: models.ID(id)})
+	return nil
+}
+
+// withTx 在存储支持事务时以事务方式执行 fn，否则直接执行
+func (h *TodoHandler) withTx(ctx context.Context, fn func(storage.TodoStorage) error) error {
+	if tx, ok := h.storage.(storage.Transactional); ok {
+		return tx.WithTx(ctx, fn)
+	}
+	return fn(h.storage)
+}
+
+// handleToggleStar 处理切换待办事项的星标状态，不影响完成状态
+func (h *TodoHandler) handleToggleStar(w http.ResponseWriter, r *http.Request, id int) {
+	var todo *models.Todo
+	err := h.withTx(r.Context(), func(tx storage.TodoStorage) error {
+		current, err := tx.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		starred := !current.Starred
+		todo, err = tx.Update(r.Context(), id, &models.UpdateTodoRequest{Starred: &starred})
+		return err
+	})
+	if err != nil {
+		writeStorageError(w, err, "切换星标失败")
+		return
+	}
+
+	h.publishTodo(EventUpdated, todo)
+	writeJSONResponse(w, http.StatusOK, h.todoResponse(todo))
+}
+
+// decodeJSONBody 解析 JSON 请求体，失败时写入错误响应并返回 false；
+// 请求体超过大小限制时返回 413，其他解析错误返回 400
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeErrorResponse(w, http.StatusRequestEntityTooLarge, "请求体过大")
+		return false
+	}
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		writeErrorResponse(w, http.StatusBadRequest, validationErr.Message)
+		return false
+	}
+	writeErrorResponse(w, http.StatusBadRequest, "无效的JSON格式")
+	return false
+}
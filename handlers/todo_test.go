@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	writable := newTestHandler(t)
+	mustCreateTitled(t, writable, "a")
+	expectStatus(t, serve(t, writable, http.MethodGet, "/api/todos", ""), http.StatusOK)
+	expectStatus(t, serve(t, writable, http.MethodPatch, "/api/todos/1", `{"completed": true}`), http.StatusOK)
+	expectStatus(t, serve(t, writable, http.MethodDelete, "/api/todos/1", ""), http.StatusNoContent)
+
+	h := newTestHandler(t, func(c *Config) { c.ReadOnly = true })
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos", ""), http.StatusOK)
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		rec := serve(t, h, method, "/api/todos", `{"title": "a"}`)
+		expectStatus(t, rec, http.StatusForbidden)
+		if got := errorMessage(t, rec); got != readOnlyMessage {
+			t.Errorf("%s 错误信息 = %q，期望 %q", method, got, readOnlyMessage)
+		}
+	}
+}
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr string
+	}{
+		{"1", 1, ""},
+		{"2147483647", 2147483647, ""},
+		{"0", 0, "ID必须为正整数"},
+		{"-5", 0, "ID必须为正整数"},
+		{"2147483648", 0, "ID超出有效范围"},
+		{"99999999999999999999", 0, "ID超出有效范围"},
+		{"abc", 0, "无效的ID格式"},
+		{"1a", 0, "无效的ID格式"},
+		{"", 0, "无效的ID格式"},
+	}
+	for _, tt := range tests {
+		got, err := parseID(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("parseID(%q) 错误 = %v，期望 %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseID(%q) = %d, %v，期望 %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestTodoIDPath(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a")
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/api/todos/1", http.StatusOK},
+		{"/api/todos/1/", http.StatusOK},
+		{"/api/todos/0", http.StatusBadRequest},
+		{"/api/todos/-1", http.StatusBadRequest},
+		{"/api/todos/9223372036854775808", http.StatusBadRequest},
+		{"/api/todos/abc", http.StatusBadRequest},
+		{"/api/todos/2", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, http.MethodGet, tt.target, "")
+		if rec.Code != tt.want {
+			t.Errorf("GET %s 状态码 = %d，期望 %d", tt.target, rec.Code, tt.want)
+		}
+	}
+}
+
+// TestConcurrentRequests 多个 goroutine 同时读写同一个待办事项，需配合 go test -race 运行
+func TestConcurrentRequests(t *testing.T) {
+	h := newTestHandler(t)
+	todo := mustCreate(t, h, `{"title": "shared", "tags": ["a"]}`)
+	target := fmt.Sprintf("/api/todos/%d", todo.ID)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(3)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				body := fmt.Sprintf(`{"title": "t%d-%d", "tags": ["a", "w%d"], "completed": %t}`, w, i, w, i%2 == 0)
+				if rec := serve(t, h, http.MethodPatch, target, body); rec.Code != http.StatusOK {
+					t.Errorf("PATCH 状态码 = %d", rec.Code)
+					return
+				}
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if rec := serve(t, h, http.MethodGet, target, ""); rec.Code != http.StatusOK {
+					t.Errorf("GET 状态码 = %d", rec.Code)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if rec := serve(t, h, http.MethodGet, "/api/todos?tag=a&sort=title", ""); rec.Code != http.StatusOK {
+					t.Errorf("列表状态码 = %d", rec.Code)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := decodeResponse[models.Todo](t, serve(t, h, http.MethodGet, target, ""))
+	if len(got.Tags) != 2 || got.Tags[0] != "a" {
+		t.Errorf("并发更新后标签 = %v，期望 [a wN]", got.Tags)
+	}
+}
+
+func TestRestoreTodo(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b")
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/1", ""), http.StatusNoContent)
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/1", ""), http.StatusNotFound)
+
+	rec := serve(t, h, http.MethodPost, "/api/todos/1/restore", "")
+	expectStatus(t, rec, http.StatusOK)
+	if todo := decodeResponse[models.Todo](t, rec); todo.ID != 1 || todo.DeletedAt != nil {
+		t.Errorf("恢复的待办事项 = %+v，期望没有删除时间的 1", todo)
+	}
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/1", ""), http.StatusOK)
+
+	// 未被删除、已永久删除返回 409，从未存在返回 404
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/1/restore", ""), http.StatusConflict)
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/2", ""), http.StatusNoContent)
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/trash", ""), http.StatusOK)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/2/restore", ""), http.StatusConflict)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/99/restore", ""), http.StatusNotFound)
+}
+
+func TestTodoColor(t *testing.T) {
+	h := newTestHandler(t)
+	todo := mustCreate(t, h, `{"title": "a", "color": "#FF8800"}`)
+	if todo.Color != "#FF8800" {
+		t.Errorf("创建后颜色 = %q，期望 #FF8800", todo.Color)
+	}
+
+	rec := serve(t, h, http.MethodPatch, "/api/todos/1", `{"color": "green"}`)
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[models.Todo](t, rec).Color; got != "green" {
+		t.Errorf("更新后颜色 = %q，期望 green", got)
+	}
+	// 未提供颜色的更新保留原值
+	rec = serve(t, h, http.MethodPatch, "/api/todos/1", `{"title": "b"}`)
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[models.Todo](t, rec).Color; got != "green" {
+		t.Errorf("未提供颜色时颜色 = %q，期望保留 green", got)
+	}
+
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos", `{"title": "c", "color": "#12345"}`), http.StatusBadRequest)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", `{"color": "pink"}`), http.StatusBadRequest)
+}
+
+func TestDeleteTodoModes(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b")
+
+	// 默认模式：204 空响应体，重复删除返回 404
+	rec := serve(t, h, http.MethodDelete, "/api/todos/1", "")
+	expectStatus(t, rec, http.StatusNoContent)
+	if rec.Body.Len() != 0 {
+		t.Errorf("204 响应体 = %q，期望为空", rec.Body.String())
+	}
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/1", ""), http.StatusNotFound)
+
+	// 幂等模式：200 带确认信息，重复删除和不存在的ID同样返回 200
+	tests := []struct {
+		target string
+		want   DeleteResponse
+	}{
+		{"/api/todos/2?idempotent=true", DeleteResponse{Deleted: true, ID: 2}},
+		{"/api/todos/2?idempotent=true", DeleteResponse{Deleted: true, ID: 2, AlreadyDeleted: true}},
+		{"/api/todos/99?idempotent=true", DeleteResponse{Deleted: true, ID: 99, AlreadyDeleted: true}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, http.MethodDelete, tt.target, "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := decodeResponse[DeleteResponse](t, rec); got != tt.want {
+			t.Errorf("DELETE %s = %+v，期望 %+v", tt.target, got, tt.want)
+		}
+	}
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/2", ""), http.StatusNotFound)
+
+	// 幂等模式只放宽不存在的情况，无效的ID仍返回 400
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos/abc?idempotent=true", ""), http.StatusBadRequest)
+}
+
+func TestToggleStar(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c")
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/2", `{"completed": true, "archived": true}`), http.StatusOK)
+
+	for _, want := range []bool{true, false, true} {
+		rec := serve(t, h, http.MethodPost, "/api/todos/2/star", "")
+		expectStatus(t, rec, http.StatusOK)
+		todo := decodeResponse[models.Todo](t, rec)
+		if todo.Starred != want {
+			t.Errorf("切换后 starred = %t，期望 %t", todo.Starred, want)
+		}
+		// 星标不影响完成和归档状态
+		if !todo.Completed || !todo.Archived {
+			t.Errorf("切换星标后 completed = %t，archived = %t，期望保持 true", todo.Completed, todo.Archived)
+		}
+	}
+
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/9/star", ""), http.StatusNotFound)
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos/1/star", ""), http.StatusMethodNotAllowed)
+}
+
+func TestStarredFilter(t *testing.T) {
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c")
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/1/star", ""), http.StatusOK)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/3", `{"starred": true}`), http.StatusOK)
+
+	tests := []struct {
+		query string
+		want  []models.ID
+	}{
+		{"starred=true", []models.ID{1, 3}},
+		{"starred=false", []models.ID{2}},
+		{"", []models.ID{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, http.MethodGet, "/api/todos?"+tt.query, "")
+		expectStatus(t, rec, http.StatusOK)
+		if got := todoIDs(decodeResponse[[]*models.Todo](t, rec)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET /api/todos?%s = %v，期望 %v", tt.query, got, tt.want)
+		}
+	}
+	expectStatus(t, serve(t, h, http.MethodGet, "/api/todos?starred=maybe", ""), http.StatusBadRequest)
+}
+
+func TestStringIDs(t *testing.T) {
+	models.SetStringIDs(true)
+	t.Cleanup(func() { models.SetStringIDs(false) })
+	h := newTestHandler(t)
+	mustCreateTitled(t, h, "a", "b", "c")
+
+	rec := serve(t, h, http.MethodPost, "/api/todos/1/comments", `{"body": "note"}`)
+	expectStatus(t, rec, http.StatusCreated)
+	if got := decodeResponse[map[string]any](t, rec)["id"]; got != "1" {
+		t.Errorf("备注 id = %#v，期望字符串 \"1\"", got)
+	}
+	todo := decodeResponse[map[string]any](t, serve(t, h, http.MethodGet, "/api/todos/1", ""))
+	if todo["id"] != "1" {
+		t.Errorf("待办事项 id = %#v，期望字符串 \"1\"", todo["id"])
+	}
+
+	// 输入同时接受数字和字符串形式的ID
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos", `{"ids": ["1", 2]}`), http.StatusOK)
+	if got := todoIDs(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos", ""))); !reflect.DeepEqual(got, []models.ID{3}) {
+		t.Errorf("批量删除后剩余 %v，期望 [3]", got)
+	}
+	expectStatus(t, serve(t, h, http.MethodDelete, "/api/todos", `{"ids": ["x"]}`), http.StatusBadRequest)
+}
+
+func TestNormalizeTags(t *testing.T) {
+	h := newTestHandler(t, func(c *Config) { c.NormalizeTags = true })
+	todo := mustCreate(t, h, `{"title": "a", "tags": ["Work", " work ", "Home"]}`)
+	if !reflect.DeepEqual(todo.Tags, []string{"work", "home"}) {
+		t.Errorf("创建后标签 = %q，期望 [work home]", todo.Tags)
+	}
+	rec := serve(t, h, http.MethodPatch, "/api/todos/1", `{"tags": ["HOME", "Work", "work"]}`)
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[models.Todo](t, rec).Tags; !reflect.DeepEqual(got, []string{"home", "work"}) {
+		t.Errorf("更新后标签 = %q，期望 [home work]", got)
+	}
+	mustCreate(t, h, `{"title": "b", "tags": ["WORK"]}`)
+	mustCreate(t, h, `{"title": "c", "tags": ["personal"]}`)
+
+	// 过滤时忽略大小写
+	for _, tag := range []string{"work", "Work", "%20WORK"} {
+		got := todoIDs(decodeResponse[[]*models.Todo](t, serve(t, h, http.MethodGet, "/api/todos?tag="+tag, "")))
+		if !reflect.DeepEqual(got, []models.ID{1, 2}) {
+			t.Errorf("tag=%s 结果 = %v，期望 [1 2]", tag, got)
+		}
+	}
+
+	// 关闭时保留原始写法，过滤区分大小写
+	raw := newTestHandler(t)
+	todo = mustCreate(t, raw, `{"title": "a", "tags": ["Work", "work"]}`)
+	if !reflect.DeepEqual(todo.Tags, []string{"Work", "work"}) {
+		t.Errorf("未开启规范化时标签 = %q，期望保留 [Work work]", todo.Tags)
+	}
+	mustCreate(t, raw, `{"title": "b", "tags": ["Work"]}`)
+	if got := todoIDs(decodeResponse[[]*models.Todo](t, serve(t, raw, http.MethodGet, "/api/todos?tag=Work", ""))); !reflect.DeepEqual(got, []models.ID{1, 2}) {
+		t.Errorf("tag=Work 结果 = %v，期望 [1 2]", got)
+	}
+	if got := todoIDs(decodeResponse[[]*models.Todo](t, serve(t, raw, http.MethodGet, "/api/todos?tag=WORK", ""))); len(got) != 0 {
+		t.Errorf("tag=WORK 结果 = %v，期望为空", got)
+	}
+}
+
+func TestExternalID(t *testing.T) {
+	h := newTestHandler(t)
+	todo := mustCreate(t, h, `{"title": "a", "external_id": "TICKET-42"}`)
+	if todo.ExternalID != "TICKET-42" {
+		t.Errorf("创建后外部ID = %q，期望 TICKET-42", todo.ExternalID)
+	}
+	mustCreate(t, h, `{"title": "b", "external_id": "TICKET-7"}`)
+	mustCreate(t, h, `{"title": "c"}`)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/3", `{"external_id": "TICKET-42"}`), http.StatusOK)
+
+	lookup := func(h http.Handler, externalID string) []models.ID {
+		t.Helper()
+		rec := serve(t, h, http.MethodGet, "/api/todos?external_id="+externalID, "")
+		expectStatus(t, rec, http.StatusOK)
+		return todoIDs(decodeResponse[[]*models.Todo](t, rec))
+	}
+	if got := lookup(h, "TICKET-42"); !reflect.DeepEqual(got, []models.ID{1, 3}) {
+		t.Errorf("external_id=TICKET-42 结果 = %v，期望 [1 3]", got)
+	}
+	if got := lookup(h, "TICKET-99"); len(got) != 0 {
+		t.Errorf("external_id=TICKET-99 结果 = %v，期望为空", got)
+	}
+
+	// 开启唯一性校验时，创建和更新都不能使用其他待办事项的外部ID
+	unique := newTestHandler(t, func(c *Config) { c.UniqueExternalIDs = true })
+	mustCreate(t, unique, `{"title": "a", "external_id": "TICKET-42"}`)
+	mustCreate(t, unique, `{"title": "b"}`)
+	expectStatus(t, serve(t, unique, http.MethodPost, "/api/todos", `{"title": "c", "external_id": "TICKET-42"}`), http.StatusConflict)
+	expectStatus(t, serve(t, unique, http.MethodPatch, "/api/todos/2", `{"external_id": "TICKET-42"}`), http.StatusConflict)
+	expectStatus(t, serve(t, unique, http.MethodPatch, "/api/todos/1", `{"external_id": "TICKET-42"}`), http.StatusOK)
+	if got := lookup(unique, "TICKET-42"); !reflect.DeepEqual(got, []models.ID{1}) {
+		t.Errorf("external_id=TICKET-42 结果 = %v，期望 [1]", got)
+	}
+}
+
+func TestComputedFields(t *testing.T) {
+	h := newTestHandler(t)
+	type computed struct {
+		ID       models.ID `json:"id"`
+		Overdue  bool      `json:"overdue"`
+		Progress float64   `json:"progress"`
+		Position int64     `json:"position"`
+	}
+
+	// 创建的响应已包含计算字段，无需再次获取；overdue 按处理器的时钟计算
+	rec := serve(t, h, http.MethodPost, "/api/todos", `{"title": "a", "due_date": "2024-06-15T11:00:00Z", "subtasks": [{"title": "x", "completed": true}, {"title": "y"}]}`)
+	expectStatus(t, rec, http.StatusCreated)
+	if got, want := decodeResponse[computed](t, rec), (computed{1, true, 0.5, 1024}); got != want {
+		t.Errorf("创建响应 = %+v，期望 %+v", got, want)
+	}
+	rec = serve(t, h, http.MethodPost, "/api/todos", `{"title": "b", "due_date": "2024-06-15T13:00:00Z"}`)
+	expectStatus(t, rec, http.StatusCreated)
+	if got, want := decodeResponse[computed](t, rec), (computed{2, false, 0, 2048}); got != want {
+		t.Errorf("创建响应 = %+v，期望 %+v", got, want)
+	}
+
+	// 获取、更新和列表响应使用同样的映射
+	rec = serve(t, h, http.MethodGet, "/api/todos/1", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got, want := decodeResponse[computed](t, rec), (computed{1, true, 0.5, 1024}); got != want {
+		t.Errorf("获取响应 = %+v，期望 %+v", got, want)
+	}
+	rec = serve(t, h, http.MethodPatch, "/api/todos/1", `{"completed": true}`)
+	expectStatus(t, rec, http.StatusOK)
+	if got, want := decodeResponse[computed](t, rec), (computed{1, false, 0.5, 1024}); got != want {
+		t.Errorf("更新响应 = %+v，期望 %+v", got, want)
+	}
+	rec = serve(t, h, http.MethodGet, "/api/todos", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got, want := decodeResponse[[]computed](t, rec), []computed{{1, false, 0.5, 1024}, {2, false, 0, 2048}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("列表响应 = %+v，期望 %+v", got, want)
+	}
+
+	// 批量操作和事件同样经过映射，结果与 /overdue 的判断一致
+	events := h.events.Subscribe()
+	defer h.events.Unsubscribe(events)
+	rec = serve(t, h, http.MethodPatch, "/api/todos/bulk", `{"ids": [2], "update": {"due_date": "2024-06-15T11:30:00Z"}}`)
+	expectStatus(t, rec, http.StatusOK)
+	results := decodeResponse[struct{ Results []struct{ Todo computed } }](t, rec).Results
+	if want := (computed{2, true, 0, 2048}); len(results) != 1 || results[0].Todo != want {
+		t.Errorf("批量更新响应 = %+v，期望 %+v", results, want)
+	}
+	if event := <-events; event.Todo == nil || !event.Todo.Overdue || event.Todo.Position != 2048 {
+		t.Errorf("更新事件 = %+v，期望携带 overdue 和 position", event.Todo)
+	}
+	rec = serve(t, h, http.MethodGet, "/api/todos/overdue", "")
+	expectStatus(t, rec, http.StatusOK)
+	if got, want := decodeResponse[[]computed](t, rec), []computed{{2, true, 0, 2048}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("逾期列表 = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestStrictJSONPriority(t *testing.T) {
+	models.SetStrictJSON(true)
+	t.Cleanup(func() { models.SetStrictJSON(false) })
+	h := newTestHandler(t)
+
+	// 严格模式只关闭宽松写法，priority 的权重 1-4 仍然可用
+	if todo := mustCreate(t, h, `{"title": "a", "priority": 3}`); todo.Priority != models.PriorityHigh {
+		t.Errorf("创建后 priority = %q，期望 high", todo.Priority)
+	}
+	rec := serve(t, h, http.MethodPatch, "/api/todos/1", `{"priority": 1}`)
+	expectStatus(t, rec, http.StatusOK)
+	if got := decodeResponse[*models.Todo](t, rec).Priority; got != models.PriorityLow {
+		t.Errorf("更新后 priority = %q，期望 low", got)
+	}
+	expectStatus(t, serve(t, h, http.MethodPut, "/api/todos/1", `{"title": "a", "priority": 4}`), http.StatusOK)
+	expectStatus(t, serve(t, h, http.MethodPatch, "/api/todos/1", `{"completed": "true"}`), http.StatusBadRequest)
+}
+
+func TestReplaceAndPatchTodo(t *testing.T) {
+	h := newTestHandler(t)
+	full := `{"title": "a", "description": "d", "priority": "high", "due_date": "2024-06-20T09:00:00Z", "tags": ["work"]}`
+	mustCreate(t, h, full)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/1/star", ""), http.StatusOK)
+	mustCreate(t, h, full)
+	expectStatus(t, serve(t, h, http.MethodPost, "/api/todos/2/star", ""), http.StatusOK)
+
+	// PUT 整体替换，未提供的字段恢复为默认值
+	rec := serve(t, h, http.MethodPut, "/api/todos/1", `{"title": "b"}`)
+	expectStatus(t, rec, http.StatusOK)
+	replaced := decodeResponse[*models.Todo](t, rec)
+	if replaced.Title != "b" || replaced.Description != "" || replaced.Priority != models.PriorityMedium {
+		t.Errorf("替换后 title=%q description=%q priority=%q，期望 b、空、medium", replaced.Title, replaced.Description, replaced.Priority)
+	}
+	if replaced.DueDate != nil || len(replaced.Tags) != 0 || replaced.Starred {
+		t.Errorf("替换后 due_date=%v tags=%v starred=%v，期望全部恢复为默认值", replaced.DueDate, replaced.Tags, replaced.Starred)
+	}
+	// PUT 同样校验必填字段
+	expectStatus(t, serve(t, h, http.MethodPut, "/api/todos/1", `{"description": "x"}`), http.StatusBadRequest)
+
+	// PATCH 只修改请求中提供的字段
+	rec = serve(t, h, http.MethodPatch, "/api/todos/2", `{"title": "b"}`)
+	expectStatus(t, rec, http.StatusOK)
+	patched := decodeResponse[*models.Todo](t, rec)
+	if patched.Title != "b" || patched.Description != "d" || patched.Priority != models.PriorityHigh {
+		t.Errorf("部分更新后 title=%q description=%q priority=%q，期望 b、d、high", patched.Title, patched.Description, patched.Priority)
+	}
+	due := time.Date(2024, 6, 20, 9, 0, 0, 0, time.UTC)
+	if patched.DueDate == nil || !patched.DueDate.Equal(due) || !reflect.DeepEqual(patched.Tags, []string{"work"}) || !patched.Starred {
+		t.Errorf("部分更新后 due_date=%v tags=%v starred=%v，期望保持不变", patched.DueDate, patched.Tags, patched.Starred)
+	}
+
+	// PATCH 中 due_date 为 null 时清除截止时间，其他字段不变
+	rec = serve(t, h, http.MethodPatch, "/api/todos/2", `{"due_date": null}`)
+	expectStatus(t, rec, http.StatusOK)
+	cleared := decodeResponse[*models.Todo](t, rec)
+	if cleared.DueDate != nil || cleared.Title != "b" || !cleared.Starred {
+		t.Errorf("清除截止时间后 due_date=%v title=%q starred=%v，期望 nil、b、true", cleared.DueDate, cleared.Title, cleared.Starred)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go-todolist/storage"
+)
+
+// PurgeTrashResponse 清理回收站的响应结构
+type PurgeTrashResponse struct {
+	Purged int `json:"purged"`
+}
+
+// trashStorage 依次解开存储装饰器查找支持回收站的存储
+func (h *TodoHandler) trashStorage() (storage.TrashStorage, bool) {
+	s := h.storage
+	for {
+		if trash, ok := s.(storage.TrashStorage); ok {
+			return trash, true
+		}
+		wrapper, ok := s.(interface{ Unwrap() storage.TodoStorage })
+		if !ok {
+			return nil, false
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// invalidateCaches 清空存储装饰器链上的缓存，绕过装饰器直接修改内部存储后调用
+func (h *TodoHandler) invalidateCaches() {
+	s := h.storage
+	for {
+		if cache, ok := s.(interface{ Invalidate() }); ok {
+			cache.Invalidate()
+		}
+		wrapper, ok := s.(interface{ Unwrap() storage.TodoStorage })
+		if !ok {
+			return
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// serveTrash 处理 /api/todos/trash：GET 列出回收站，DELETE 清理回收站
+func (h *TodoHandler) serveTrash(w http.ResponseWriter, r *http.Request) {
+	trash, ok := h.trashStorage()
+	if !ok {
+		writeErrorResponse(w, http.StatusNotImplemented, "当前存储不支持回收站")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.handleGetTrash(w, r, trash)
+	case http.MethodDelete:
+		h.handlePurgeTrash(w, r, trash)
+	default:
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "方法不允许")
+	}
+}
+
+// handleGetTrash 处理获取回收站中的待办事项，最近删除的排在最前
+func (h *TodoHandler) handleGetTrash(w http.ResponseWriter, r *http.Request, trash storage.TrashStorage) {
+	fields, ok := fieldsParam(w, r)
+	if !ok {
+		return
+	}
+	todos, err := trash.Trash(r.Context())
+	if err != nil {
+		writeStorageError(w, err, "获取回收站失败")
+		return
+	}
+	writeProjectedJSON(w, http.StatusOK, h.todoResponses(todos), fields)
+}
+
+// handlePurgeTrash 处理永久删除回收站中的待办事项，指定 older_than 时只删除移入回收站超过该时长的
+func (h *TodoHandler) handlePurgeTrash(w http.ResponseWriter, r *http.Request, trash storage.TrashStorage) {
+	var before time.Time
+	if v := r.URL.Query().Get("older_than"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			writeErrorResponse(w, http.StatusBadRequest, "older_than 必须为正的时长，如 720h")
+			return
+		}
+		before = h.config.Clock().Add(-d)
+	}
+
+	purged, err := trash.PurgeTrash(r.Context(), before)
+	if err != nil {
+		writeStorageError(w, err, "清理回收站失败")
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, PurgeTrashResponse{Purged: purged})
+}
+
+// handleRestoreTodo 处理将待办事项从回收站恢复，已被永久删除的ID返回 409。
+// 恢复的待办事项对客户端而言重新出现，因此广播创建事件
+func (h *TodoHandler) handleRestoreTodo(w http.ResponseWriter, r *http.Request, id int) {
+	trash, ok := h.trashStorage()
+	if !ok {
+		writeErrorResponse(w, http.StatusNotImplemented, "当前存储不支持回收站")
+		return
+	}
+	if err := h.checkRestoreExternalID(r.Context(), trash, id); err != nil {
+		writeStorageError(w, err, "恢复待办事项失败")
+		return
+	}
+
+	todo, err := trash.Restore(r.Context(), id)
+	if err != nil {
+		writeStorageError(w, err, "恢复待办事项失败")
+		return
+	}
+	h.invalidateCaches()
+
+	h.publishTodo(EventCreated, todo)
+	writeJSONResponse(w, http.StatusOK, h.todoResponse(todo))
+}
+
+// checkRestoreExternalID 开启外部ID唯一性校验时，检查回收站中的待办事项的外部ID在删除后是否已被其他待办事项占用
+func (h *TodoHandler) checkRestoreExternalID(ctx context.Context, trash storage.TrashStorage, id int) error {
+	if !h.config.UniqueExternalIDs {
+		return nil
+	}
+	todos, err := trash.Trash(ctx)
+	if err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		if int(todo.ID) == id {
+			return h.checkExternalID(ctx, h.storage, todo.ExternalID, id)
+		}
+	}
+	return nil
+}
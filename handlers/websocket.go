@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"go-todolist/models"
+)
+
+// websocketGUID 握手时用于计算 Sec-WebSocket-Accept 的固定值（RFC 6455）
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketMessageSize 单条客户端消息的最大字节数
+const maxWebSocketMessageSize = 1 << 20
+
+// WebSocket 帧操作码
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+var errWebSocketMessageTooLarge = errors.New("消息过大")
+
+// wsCloseGoingAway 服务端关闭时发送的关闭状态码（RFC 6455 7.4.1）
+const wsCloseGoingAway = 1001
+
+// wsConn 表示一个已完成握手的 WebSocket 连接
+type wsConn struct {
+	conn       net.Conn
+	reader     *bufio.Reader
+	writeMutex sync.Mutex
+}
+
+// checkWebSocketHandshake 校验 WebSocket 握手请求头
+func checkWebSocketHandshake(r *http.Request) error {
+	if !headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return errors.New("缺少 WebSocket 升级请求头")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return errors.New("不支持的 WebSocket 版本")
+	}
+	if r.Header.Get("Sec-WebSocket-Key") == "" {
+		return errors.New("缺少 Sec-WebSocket-Key")
+	}
+	return nil
+}
+
+// upgradeWebSocket 将已通过校验的 HTTP 连接升级为 WebSocket 连接
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("连接不支持升级")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, reader: rw.Reader}, nil
+}
+
+// headerContainsToken 判断逗号分隔的请求头中是否包含指定值（忽略大小写）
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readFrame 读取一个数据帧，返回 fin 标志、操作码和解除掩码后的负载
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if !masked {
+		return false, 0, nil, errors.New("客户端帧必须使用掩码")
+	}
+	if length > maxWebSocketMessageSize {
+		return false, 0, nil, errWebSocketMessageTooLarge
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// readMessage 读取一条完整的数据消息，期间自动处理 ping/pong/close 控制帧
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			c.writeFrame(wsOpClose, payload)
+			return nil, io.EOF
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			message = append(message, payload...)
+			if len(message) > maxWebSocketMessageSize {
+				return nil, errWebSocketMessageTooLarge
+			}
+		default:
+			return nil, errors.New("未知的帧类型")
+		}
+
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// writeFrame 写入一个不带掩码的服务端帧
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	header := []byte{0x80 | opcode}
+	length := len(payload)
+	switch {
+	case length < 126:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// writeJSON 以文本帧写入 JSON 消息
+func (c *wsConn) writeJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(wsOpText, data)
+}
+
+// writeClose 发送带状态码的关闭帧
+func (c *wsConn) writeClose(code uint16) error {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, code)
+	return c.writeFrame(wsOpClose, payload)
+}
+
+// Close 关闭底层连接
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
+
+// WebSocketMessage 客户端通过 WebSocket 发送的变更请求
+type WebSocketMessage struct {
+	Action string          `json:"action"`
+	Ref    string          `json:"ref,omitempty"`
+	ID     models.ID       `json:"id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// WebSocketAck 服务端对客户端变更请求的确认
+type WebSocketAck struct {
+	Type  string        `json:"type"`
+	Ref   string        `json:"ref,omitempty"`
+	OK    bool          `json:"ok"`
+	Error string        `json:"error,omitempty"`
+	Todo  *TodoResponse `json:"todo,omitempty"`
+}
+
+// handleWebSocket 处理 WebSocket 双向同步连接
+func (h *TodoHandler) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if err := checkWebSocketHandshake(r); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 接管连接前登记，服务开始关闭后不再接受新连接
+	if !h.events.beginStream() {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "服务正在关闭")
+		return
+	}
+	defer h.events.endStream()
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+
+	events := h.events.Subscribe()
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		h.events.Unsubscribe(events)
+		conn.Close()
+	}()
+
+	// 推送服务端变更事件；事件通道被关闭说明服务正在关闭，通知客户端后断开连接
+	go func() {
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					conn.writeClose(wsCloseGoingAway)
+					conn.Close()
+					return
+				}
+				if err := conn.writeJSON(event); err != nil {
+					conn.Close()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// 处理客户端变更请求
+	for {
+		data, err := conn.readMessage()
+		if err != nil {
+			return
+		}
+
+		var msg WebSocketMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			conn.writeJSON(WebSocketAck{Type: "ack", OK: false, Error: "无效的JSON格式"})
+			continue
+		}
+		if err := conn.writeJSON(h.applyWebSocketMessage(r.Context(), &msg)); err != nil {
+			return
+		}
+	}
+}
+
+// applyWebSocketMessage 执行客户端请求的变更并生成确认消息
+func (h *TodoHandler) applyWebSocketMessage(ctx context.Context, msg *WebSocketMessage) WebSocketAck {
+	ack := WebSocketAck{Type: "ack", Ref: msg.Ref}
+	if h.config.ReadOnly {
+		ack.Error = readOnlyMessage
+		return ack
+	}
+
+	var (
+		todo *models.Todo
+		err  error
+	)
+	switch msg.Action {
+	case "create":
+		var req models.CreateTodoRequest
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			ack.Error = "无效的JSON格式"
+			return ack
+		}
+		todo, err = h.createTodo(ctx, &req)
+	case "update":
+		var req models.UpdateTodoRequest
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			ack.Error = "无效的JSON格式"
+			return ack
+		}
+		todo, err = h.updateTodo(ctx, int(msg.ID), &req)
+	case "delete":
+		err = h.deleteTodo(ctx, int(msg.ID))
+	default:
+		ack.Error = "不支持的操作"
+		return ack
+	}
+
+	if err != nil {
+		_, ack.Error = storageErrorStatus(err, "操作失败")
+		return ack
+	}
+	ack.OK = true
+	ack.Todo = h.todoResponse(todo)
+	return ack
+}
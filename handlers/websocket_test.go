@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsTestClient 测试用的最小 WebSocket 客户端
+type wsTestClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialWebSocket 连接测试服务器上的 /api/todos/ws 并完成 RFC 6455 握手
+func dialWebSocket(t *testing.T, server *httptest.Server) *wsTestClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/todos/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("发送握手请求失败: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		t.Fatalf("读取握手响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("握手状态码 = %d，期望 101", resp.StatusCode)
+	}
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), websocketAccept(key); got != want {
+		t.Fatalf("Sec-WebSocket-Accept = %q，期望 %q", got, want)
+	}
+	return &wsTestClient{conn: conn, reader: reader}
+}
+
+// websocketAccept 按 RFC 6455 计算握手响应的 Sec-WebSocket-Accept
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// send 以带掩码的文本帧发送 JSON 消息
+func (c *wsTestClient) send(t *testing.T, v any) {
+	t.Helper()
+	payload, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := []byte{0x80 | wsOpText}
+	switch {
+	case len(payload) < 126:
+		header = append(header, 0x80|byte(len(payload)))
+	default:
+		header = append(header, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	header = append(header, mask...)
+	masked := make([]byte, len(payload))
+	for i, b := range payload {
+		masked[i] = b ^ mask[i%4]
+	}
+	if _, err := c.conn.Write(append(header, masked...)); err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+}
+
+// readFrame 读取一个服务端帧，返回操作码和负载
+func (c *wsTestClient) readFrame(t *testing.T) (byte, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		t.Fatalf("读取帧失败: %v", err)
+	}
+	if header[1]&0x80 != 0 {
+		t.Fatal("服务端帧不应使用掩码")
+	}
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.reader, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.reader, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		t.Fatalf("读取帧负载失败: %v", err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+// readJSON 读取一个文本帧并解析为 map，便于同时处理事件和确认消息
+func (c *wsTestClient) readJSON(t *testing.T) map[string]any {
+	t.Helper()
+	opcode, payload := c.readFrame(t)
+	if opcode != wsOpText {
+		t.Fatalf("操作码 = %#x，期望文本帧，负载: %q", opcode, payload)
+	}
+	var v map[string]any
+	if err := json.Unmarshal(payload, &v); err != nil {
+		t.Fatalf("解析消息失败: %v", err)
+	}
+	return v
+}
+
+func TestWebSocketShutdownClosesActiveStreams(t *testing.T) {
+	h := newTestHandler(t)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	client := dialWebSocket(t, server)
+	// 确认连接已登记：发送一条消息并等待确认
+	client.send(t, WebSocketMessage{Action: "noop", Ref: "ping"})
+	if ack := client.readJSON(t); ack["ref"] != "ping" {
+		t.Fatalf("确认消息 = %v", ack)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown 返回错误: %v", err)
+	}
+
+	opcode, payload := client.readFrame(t)
+	if opcode != wsOpClose {
+		t.Fatalf("操作码 = %#x，期望关闭帧", opcode)
+	}
+	if code := binary.BigEndian.Uint16(payload); code != wsCloseGoingAway {
+		t.Errorf("关闭状态码 = %d，期望 %d", code, wsCloseGoingAway)
+	}
+
+	// 关闭开始后拒绝新的长连接
+	rec := serve(t, h, http.MethodGet, "/api/todos/ws", "",
+		"Connection", "Upgrade", "Upgrade", "websocket", "Sec-WebSocket-Version", "13", "Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	expectStatus(t, rec, http.StatusServiceUnavailable)
+}
+
+// readAckAndEvent 读取一次变更产生的确认消息和广播事件，两者由不同的 goroutine 写入，顺序不固定
+func (c *wsTestClient) readAckAndEvent(t *testing.T) (ack, event map[string]any) {
+	t.Helper()
+	for ack == nil || event == nil {
+		msg := c.readJSON(t)
+		if msg["type"] == "ack" {
+			ack = msg
+		} else {
+			event = msg
+		}
+	}
+	return ack, event
+}
+
+func TestWebSocketMutationsAreAcknowledgedAndBroadcast(t *testing.T) {
+	h := newTestHandler(t)
+	server := httptest.NewServer(h)
+	defer server.Close()
+	client := dialWebSocket(t, server)
+
+	client.send(t, map[string]any{"action": "create", "ref": "c1", "data": map[string]any{"title": "来自 WebSocket"}})
+	ack, event := client.readAckAndEvent(t)
+	if ack["ok"] != true || ack["ref"] != "c1" {
+		t.Fatalf("创建确认 = %v", ack)
+	}
+	if event["type"] != string(EventCreated) || event["todo"].(map[string]any)["title"] != "来自 WebSocket" {
+		t.Fatalf("创建事件 = %v", event)
+	}
+	id := event["id"]
+
+	client.send(t, map[string]any{"action": "update", "ref": "u1", "id": id, "data": map[string]any{"completed": true}})
+	ack, event = client.readAckAndEvent(t)
+	if ack["ok"] != true || event["type"] != string(EventUpdated) || event["todo"].(map[string]any)["completed"] != true {
+		t.Fatalf("更新确认 = %v，事件 = %v", ack, event)
+	}
+
+	client.send(t, map[string]any{"action": "delete", "ref": "d1", "id": id})
+	ack, event = client.readAckAndEvent(t)
+	if ack["ok"] != true || event["type"] != string(EventDeleted) || event["id"] != id {
+		t.Fatalf("删除确认 = %v，事件 = %v", ack, event)
+	}
+
+	// 校验失败的变更只返回失败确认，不广播事件
+	client.send(t, map[string]any{"action": "create", "ref": "bad", "data": map[string]any{"title": ""}})
+	if ack := client.readJSON(t); ack["type"] != "ack" || ack["ok"] != false || ack["error"] == "" {
+		t.Fatalf("失败确认 = %v", ack)
+	}
+}
+
+func TestWebSocketReceivesRESTChanges(t *testing.T) {
+	h := newTestHandler(t)
+	server := httptest.NewServer(h)
+	defer server.Close()
+	client := dialWebSocket(t, server)
+	// 等待连接完成订阅后再通过 REST 修改
+	client.send(t, WebSocketMessage{Action: "noop", Ref: "ready"})
+	client.readJSON(t)
+
+	todo := mustCreate(t, h, `{"title": "REST 创建"}`)
+	if event := client.readJSON(t); event["type"] != string(EventCreated) || event["todo"].(map[string]any)["title"] != "REST 创建" {
+		t.Fatalf("创建事件 = %v", event)
+	}
+	expectStatus(t, serve(t, h, http.MethodDelete, fmt.Sprintf("/api/todos/%d", todo.ID), ""), http.StatusNoContent)
+	if event := client.readJSON(t); event["type"] != string(EventDeleted) {
+		t.Fatalf("删除事件 = %v", event)
+	}
+}
+
+func TestWebSocketRejectsInvalidHandshake(t *testing.T) {
+	h := newTestHandler(t)
+	rec := serve(t, h, http.MethodGet, "/api/todos/ws", "")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
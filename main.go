@@ -1,21 +1,50 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"go-todolist/handlers"
+	"go-todolist/models"
 	"go-todolist/storage"
 )
 
 func main() {
+	storageDriver := flag.String("storage-driver", envString("STORAGE_DRIVER", "memory"),
+		"存储驱动："+strings.Join(storage.Drivers(), "、"))
+	storageDSN := flag.String("storage-dsn", os.Getenv("STORAGE_DSN"), "存储 DSN，格式由驱动决定")
+	migrate := flag.Bool("migrate", false, "执行 SQL 存储的数据库迁移，输出当前和目标版本后退出")
+	flag.Parse()
+
+	if *migrate {
+		runMigrate(*storageDriver, *storageDSN)
+		return
+	}
+
+	// 注册自定义校验规则
+	registerValidationRules()
+	models.SetLimits(loadLimits())
+
+	// ID_FORMAT=string 时 ID 在 JSON 中序列化为字符串
+	models.SetStringIDs(os.Getenv("ID_FORMAT") == "string")
+	// STRICT_JSON=true 时请求中的布尔值和时间只接受标准 JSON 写法
+	models.SetStrictJSON(os.Getenv("STRICT_JSON") == "true")
+
 	// 创建存储实例
-	todoStorage := storage.NewMemoryStorage()
+	todoStorage := openStorage(*storageDriver, *storageDSN)
 
 	// 创建处理器
-	todoHandler := handlers.NewTodoHandler(todoStorage)
+	todoHandler := handlers.NewTodoHandlerWithConfig(todoStorage, loadHandlerConfig())
 
 	// 设置路由
 	mux := http.NewServeMux()
@@ -23,10 +52,28 @@ func main() {
 	// API 路由
 	mux.Handle("/api/todos", todoHandler)
 	mux.Handle("/api/todos/", todoHandler)
+	mux.Handle("/api/admin/", todoHandler.AdminHandler())
+	mux.Handle("/api/filters", todoHandler.FiltersHandler())
+	mux.Handle("/api/filters/", todoHandler.FiltersHandler())
+
+	// 服务端渲染的备用页面
+	mux.Handle("/app", todoHandler.AppHandler())
+	mux.Handle("/app/", todoHandler.AppHandler())
 
 	// 静态文件服务
-	fileServer := http.FileServer(http.Dir("./static/"))
-	mux.Handle("/", fileServer)
+	staticMaxAge := time.Duration(envInt("STATIC_MAX_AGE", 3600)) * time.Second
+	mux.Handle("/", handlers.NewStaticHandler("./static/", staticMaxAge))
+
+	// 中间件
+	var handler http.Handler = handlers.ResponseTime(mux)
+	handler = handlers.Gzip(handler, envInt("GZIP_MIN_SIZE", handlers.DefaultGzipMinSize), gzipLevel())
+	handler = handlers.DecompressRequest(handler, int64(envInt("MAX_DECOMPRESSED_BODY", handlers.DefaultMaxDecompressedBody)))
+	if n := envInt("MAX_CONCURRENT_REQUESTS", 0); n > 0 {
+		handler = handlers.LimitConcurrency(handler, n)
+	}
+	handler = handlers.RecordLatency(handler, todoHandler.Latency())
+	handler = handlers.Logging(handler)
+	handler = handlers.RequestID(handler)
 
 	// 获取端口号
 	port := os.Getenv("PORT")
@@ -41,5 +88,39 @@ func main() {
 	fmt.Printf("🔗 API 地址: http://localhost%s/api/todos\n", addr)
 	fmt.Printf("⏹️  按 Ctrl+C 停止服务器\n\n")
 
-	log.Fatal(http.ListenAndServe(addr, mux))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 提醒扫描：配置了 webhook 时定期发送到期提醒
+	if url := os.Getenv("REMINDER_WEBHOOK_URL"); url != "" {
+		interval := time.Duration(envInt("REMINDER_INTERVAL", 60)) * time.Second
+		go todoHandler.ReminderScanner(handlers.WebhookNotifier(url)).Run(ctx, interval)
+	}
+
+	server := &http.Server{Addr: addr, Handler: handler}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// 优雅关闭：停止接收新请求，通知长连接断开，并在超时前等待其退出
+	<-ctx.Done()
+
+	fmt.Printf("\n⏳ 正在关闭服务器...\n")
+	shutdownTimeout := time.Duration(envInt("SHUTDOWN_TIMEOUT", 10)) * time.Second
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := todoHandler.Shutdown(shutdownCtx); err != nil {
+		log.Printf("等待长连接关闭超时: %v", err)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("服务器关闭失败: %v", err)
+	}
+	if closer, ok := todoStorage.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("关闭存储失败: %v", err)
+		}
+	}
 }
@@ -0,0 +1,154 @@
+// Package migrations 管理 SQL 存储的数据库结构版本。
+// 每种数据库一个目录，迁移文件命名为 <版本号>_<名称>.sql（如 0001_init.sql），版本号从 1 开始递增；
+// 已应用的版本记录在 schema_migrations 表中
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sqlite/*.sql postgres/*.sql mysql/*.sql
+var files embed.FS
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+// fileNamePattern 迁移文件名格式，名称限定为小写字母、数字和下划线，可直接写入 SQL
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+
+// createTableSQL 记录已应用版本的表，使用各数据库通用的类型
+const createTableSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at VARCHAR(40) NOT NULL
+)`
+
+// Load 读取 dialect（sqlite、postgres 或 mysql）的全部迁移，按版本升序排列
+func Load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("没有 %s 的迁移文件: %w", dialect, err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("迁移文件名 %s 格式无效，应为 <版本号>_<名称>.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		data, err := files.ReadFile(dialect + "/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{
+			Version:    version,
+			Name:       match[2],
+			Statements: splitStatements(string(data)),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("%s 的迁移版本不连续：期望 %d，实际 %d", dialect, i+1, m.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// splitStatements 去掉 -- 注释行后按分号拆分语句
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	var statements []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// Target 返回 dialect 的最新版本
+func Target(dialect string) (int, error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return 0, err
+	}
+	return len(migrations), nil
+}
+
+// Current 返回数据库当前的版本，尚未应用任何迁移时为 0
+func Current(db *sql.DB) (int, error) {
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// Up 依次应用未执行的迁移，每个版本在单独的事务中执行，返回迁移前后的版本。
+// MySQL 的 DDL 会隐式提交事务，失败时可能需要手动清理
+func Up(db *sql.DB, dialect string) (from, to int, err error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return 0, 0, err
+	}
+	from, err = Current(db)
+	if err != nil {
+		return 0, 0, err
+	}
+	if from > len(migrations) {
+		return from, from, fmt.Errorf("数据库版本 %d 高于程序支持的版本 %d，请升级程序", from, len(migrations))
+	}
+
+	to = from
+	for _, m := range migrations[from:] {
+		if err := apply(db, m); err != nil {
+			return from, to, fmt.Errorf("应用迁移 %04d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		to = m.Version
+	}
+	return from, to, nil
+}
+
+// apply 在事务中执行一个迁移并记录版本
+func apply(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.Statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	// 版本号和名称均已校验格式，直接写入 SQL 以避免不同数据库占位符的差异
+	record := fmt.Sprintf("INSERT INTO schema_migrations (version, name, applied_at) VALUES (%d, '%s', '%s')",
+		m.Version, m.Name, time.Now().UTC().Format(time.RFC3339))
+	if _, err := tx.Exec(record); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,23 @@
+-- 待办事项、备注和历史版本。MySQL 不支持 CREATE INDEX IF NOT EXISTS，索引在建表语句中声明
+CREATE TABLE IF NOT EXISTS todos (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	external_id VARCHAR(255) NOT NULL DEFAULT '',
+	data LONGTEXT NOT NULL,
+	INDEX idx_todos_external_id (external_id)
+) DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS comments (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	todo_id BIGINT NOT NULL,
+	body TEXT NOT NULL,
+	created_at VARCHAR(40) NOT NULL,
+	INDEX idx_comments_todo_id (todo_id)
+) DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS todo_versions (
+	todo_id BIGINT NOT NULL,
+	version INT NOT NULL,
+	recorded_at VARCHAR(40) NOT NULL,
+	data LONGTEXT NOT NULL,
+	PRIMARY KEY (todo_id, version)
+) DEFAULT CHARSET = utf8mb4;
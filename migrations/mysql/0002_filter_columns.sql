@@ -0,0 +1,9 @@
+-- 完成状态和优先级单独成列，列表过滤在数据库中执行
+ALTER TABLE todos
+	ADD COLUMN completed BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN priority VARCHAR(20) NOT NULL DEFAULT '',
+	ADD INDEX idx_todos_completed (completed);
+
+UPDATE todos SET
+	completed = COALESCE(JSON_UNQUOTE(JSON_EXTRACT(data, '$.completed')) = 'true', FALSE),
+	priority = COALESCE(JSON_UNQUOTE(JSON_EXTRACT(data, '$.priority')), '');
@@ -0,0 +1,12 @@
+-- 标题和描述单独成列，关键字搜索在数据库中执行。MySQL 的 TEXT 列不能有默认值
+ALTER TABLE todos
+	ADD COLUMN title TEXT NULL,
+	ADD COLUMN description TEXT NULL;
+
+UPDATE todos SET
+	title = COALESCE(JSON_UNQUOTE(JSON_EXTRACT(data, '$.title')), ''),
+	description = COALESCE(JSON_UNQUOTE(JSON_EXTRACT(data, '$.description')), '');
+
+ALTER TABLE todos
+	MODIFY COLUMN title TEXT NOT NULL,
+	MODIFY COLUMN description TEXT NOT NULL;
@@ -0,0 +1,8 @@
+-- 保存的过滤器（智能清单）
+CREATE TABLE IF NOT EXISTS saved_filters (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	query TEXT NOT NULL,
+	created_at VARCHAR(40) NOT NULL,
+	updated_at VARCHAR(40) NOT NULL
+) DEFAULT CHARSET = utf8mb4;
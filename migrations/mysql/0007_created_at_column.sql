@@ -0,0 +1,8 @@
+-- 创建时间单独成列（UTC 纳秒时间戳），游标分页按 (created_at, id) 在数据库中排序和定位。
+-- 已有数据从 JSON 回填，精度为微秒级
+ALTER TABLE todos
+	ADD COLUMN created_at BIGINT NOT NULL DEFAULT 0,
+	ADD INDEX idx_todos_created_at_id (created_at, id);
+
+UPDATE todos SET
+	created_at = COALESCE(CAST(UNIX_TIMESTAMP(CAST(JSON_UNQUOTE(JSON_EXTRACT(data, '$.created_at')) AS DATETIME(6))) * 1000000 AS SIGNED) * 1000, 0);
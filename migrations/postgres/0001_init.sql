@@ -0,0 +1,25 @@
+-- 待办事项、备注和历史版本
+CREATE TABLE IF NOT EXISTS todos (
+	id BIGSERIAL PRIMARY KEY,
+	external_id TEXT NOT NULL DEFAULT '',
+	data TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_todos_external_id ON todos (external_id);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id BIGSERIAL PRIMARY KEY,
+	todo_id BIGINT NOT NULL,
+	body TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_todo_id ON comments (todo_id);
+
+CREATE TABLE IF NOT EXISTS todo_versions (
+	todo_id BIGINT NOT NULL,
+	version INTEGER NOT NULL,
+	recorded_at TEXT NOT NULL,
+	data TEXT NOT NULL,
+	PRIMARY KEY (todo_id, version)
+);
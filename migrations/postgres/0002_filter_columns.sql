@@ -0,0 +1,9 @@
+-- 完成状态和优先级单独成列，列表过滤在数据库中执行
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS completed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT '';
+
+UPDATE todos SET
+	completed = COALESCE((data::jsonb ->> 'completed')::boolean, FALSE),
+	priority = COALESCE(data::jsonb ->> 'priority', '');
+
+CREATE INDEX IF NOT EXISTS idx_todos_completed ON todos (completed);
@@ -0,0 +1,7 @@
+-- 标题和描述单独成列，关键字搜索在数据库中执行
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS title TEXT NOT NULL DEFAULT '';
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+
+UPDATE todos SET
+	title = COALESCE(data::jsonb ->> 'title', ''),
+	description = COALESCE(data::jsonb ->> 'description', '');
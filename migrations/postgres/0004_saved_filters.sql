@@ -0,0 +1,8 @@
+-- 保存的过滤器（智能清单）
+CREATE TABLE IF NOT EXISTS saved_filters (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	query TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
@@ -0,0 +1,8 @@
+-- 创建时间单独成列（UTC 纳秒时间戳），游标分页按 (created_at, id) 在数据库中排序和定位。
+-- 已有数据从 JSON 回填，精度为微秒级
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS created_at BIGINT NOT NULL DEFAULT 0;
+
+UPDATE todos SET
+	created_at = COALESCE((EXTRACT(EPOCH FROM (data::jsonb ->> 'created_at')::timestamptz) * 1000000)::BIGINT * 1000, 0);
+
+CREATE INDEX IF NOT EXISTS idx_todos_created_at_id ON todos (created_at, id);
@@ -0,0 +1,9 @@
+-- 完成状态和优先级单独成列，列表过滤在数据库中执行
+ALTER TABLE todos ADD COLUMN completed INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE todos ADD COLUMN priority TEXT NOT NULL DEFAULT '';
+
+UPDATE todos SET
+	completed = COALESCE(json_extract(data, '$.completed'), 0),
+	priority = COALESCE(json_extract(data, '$.priority'), '');
+
+CREATE INDEX IF NOT EXISTS idx_todos_completed ON todos (completed);
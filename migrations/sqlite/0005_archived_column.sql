@@ -0,0 +1,4 @@
+-- 归档状态单独成列，默认列表排除归档的待办事项时在数据库中过滤
+ALTER TABLE todos ADD COLUMN archived INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_todos_archived ON todos (archived);
@@ -0,0 +1,4 @@
+-- 软删除：移入回收站的待办事项记录删除时间（UTC 纳秒时间戳），未删除时为 NULL
+ALTER TABLE todos ADD COLUMN deleted_at INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_todos_deleted_at ON todos (deleted_at);
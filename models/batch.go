@@ -0,0 +1,148 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// BatchDueRequest 表示批量设置截止时间的请求结构，DueIn 与 DueDate 必须且只能设置一个
+type BatchDueRequest struct {
+	IDs     []ID       `json:"ids"`
+	DueIn   string     `json:"due_in,omitempty"`
+	DueDate *time.Time `json:"due_date,omitempty"`
+}
+
+// Validate 验证批量设置截止时间请求的有效性
+func (req *BatchDueRequest) Validate() error {
+	if len(req.IDs) == 0 {
+		return &ValidationError{Field: "ids", Message: "ids 不能为空"}
+	}
+	if (req.DueIn == "") == (req.DueDate == nil) {
+		return &ValidationError{Field: "due_in", Message: "due_in 和 due_date 必须且只能设置一个"}
+	}
+	if req.DueIn != "" {
+		d, err := time.ParseDuration(req.DueIn)
+		if err != nil {
+			return &ValidationError{Field: "due_in", Message: "due_in 必须为有效的时长，如 72h 或 90m"}
+		}
+		if d <= 0 {
+			return &ValidationError{Field: "due_in", Message: "due_in 必须为正数"}
+		}
+	}
+	return nil
+}
+
+// DueAt 返回请求对应的截止时间，相对时长以 now 为起点；调用前需先通过 Validate
+func (req *BatchDueRequest) DueAt(now time.Time) time.Time {
+	if req.DueDate != nil {
+		return *req.DueDate
+	}
+	d, _ := time.ParseDuration(req.DueIn)
+	return now.Add(d)
+}
+
+// BulkDeleteRequest 表示批量删除的请求结构
+type BulkDeleteRequest struct {
+	IDs []ID `json:"ids"`
+}
+
+// Validate 验证批量删除请求的有效性
+func (req *BulkDeleteRequest) Validate() error {
+	if len(req.IDs) == 0 {
+		return &ValidationError{Field: "ids", Message: "ids 不能为空"}
+	}
+	for _, id := range req.IDs {
+		if id <= 0 {
+			return &ValidationError{Field: "ids", Message: "ids 中的ID必须为正整数"}
+		}
+	}
+	return nil
+}
+
+// BulkUpdateRequest 表示批量更新的请求结构，Update 中设置的字段应用到 IDs 中的每个待办事项
+type BulkUpdateRequest struct {
+	IDs    []ID               `json:"ids"`
+	Update *UpdateTodoRequest `json:"update"`
+}
+
+// Validate 验证批量更新请求的有效性
+func (req *BulkUpdateRequest) Validate() error {
+	if len(req.IDs) == 0 {
+		return &ValidationError{Field: "ids", Message: "ids 不能为空"}
+	}
+	if req.Update == nil {
+		return &ValidationError{Field: "update", Message: "update 不能为空"}
+	}
+	if req.Update.ExternalID != nil {
+		// 外部ID标识单个外部条目，不能同时设置到多个待办事项上
+		return &ValidationError{Field: "external_id", Message: "批量更新不能设置外部ID"}
+	}
+	return req.Update.Validate()
+}
+
+// DefaultSnoozeDuration 未指定时长时的默认推迟时长
+const DefaultSnoozeDuration = 24 * time.Hour
+
+// SnoozeRequest 表示推迟逾期待办事项的请求结构，Duration 为空时推迟 24 小时
+type SnoozeRequest struct {
+	Duration string `json:"duration,omitempty"`
+}
+
+// Validate 验证推迟请求的有效性
+func (req *SnoozeRequest) Validate() error {
+	if req.Duration == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return &ValidationError{Field: "duration", Message: "duration 必须为有效的时长，如 24h"}
+	}
+	if d <= 0 {
+		return &ValidationError{Field: "duration", Message: "duration 必须为正数"}
+	}
+	return nil
+}
+
+// SnoozeDuration 返回推迟时长；调用前需先通过 Validate
+func (req *SnoozeRequest) SnoozeDuration() time.Duration {
+	if req.Duration == "" {
+		return DefaultSnoozeDuration
+	}
+	d, _ := time.ParseDuration(req.Duration)
+	return d
+}
+
+// ReorderRequest 表示手动排序的请求结构，二选一：IDs 按给定顺序重排这些待办事项，
+// 或将 ID 指定的待办事项移动到 Index 位置
+type ReorderRequest struct {
+	IDs   []ID `json:"ids,omitempty"`
+	ID    ID   `json:"id,omitempty"`
+	Index *int `json:"index,omitempty"`
+}
+
+// Validate 验证排序请求的有效性
+func (req *ReorderRequest) Validate() error {
+	if len(req.IDs) > 0 {
+		if req.ID != 0 || req.Index != nil {
+			return &ValidationError{Field: "ids", Message: "ids 不能与 id、index 同时使用"}
+		}
+		seen := make(map[ID]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			if id <= 0 {
+				return &ValidationError{Field: "ids", Message: "ids 中的ID必须为正整数"}
+			}
+			if seen[id] {
+				return &ValidationError{Field: "ids", Message: fmt.Sprintf("ids 中的ID %d 重复", id)}
+			}
+			seen[id] = true
+		}
+		return nil
+	}
+	if req.ID <= 0 {
+		return &ValidationError{Field: "id", Message: "需要指定 ids，或指定 id 和 index"}
+	}
+	if req.Index == nil || *req.Index < 0 {
+		return &ValidationError{Field: "index", Message: "index 必须为非负整数"}
+	}
+	return nil
+}
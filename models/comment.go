@@ -0,0 +1,30 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Comment 表示待办事项下的一条备注
+type Comment struct {
+	ID        ID        `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateCommentRequest 表示添加备注的请求结构
+type CreateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+// Validate 验证添加备注请求的有效性
+func (req *CreateCommentRequest) Validate() error {
+	if strings.TrimSpace(req.Body) == "" {
+		return &ValidationError{Field: "body", Message: "备注内容不能为空"}
+	}
+	if limit := CurrentLimits().CommentLength; len(req.Body) > limit {
+		return &ValidationError{Field: "body", Message: fmt.Sprintf("备注长度不能超过%d个字符", limit)}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxFilterQueryLength 保存的查询语句的最大长度（字节）
+const maxFilterQueryLength = 1000
+
+// SavedFilter 表示保存的过滤器（智能清单），Query 使用列表接口 filter 参数的查询语言
+type SavedFilter struct {
+	ID        ID        `json:"id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SaveFilterRequest 表示创建或替换保存的过滤器的请求结构
+type SaveFilterRequest struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// Validate 验证保存过滤器请求的有效性，查询语句的语法由调用方另行校验
+func (req *SaveFilterRequest) Validate() error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Name == "" {
+		return &ValidationError{Field: "name", Message: "名称不能为空"}
+	}
+	if limit := CurrentLimits().TitleLength; len(req.Name) > limit {
+		return &ValidationError{Field: "name", Message: fmt.Sprintf("名称长度不能超过%d个字符", limit)}
+	}
+	if req.Query == "" {
+		return &ValidationError{Field: "query", Message: "查询语句不能为空"}
+	}
+	if len(req.Query) > maxFilterQueryLength {
+		return &ValidationError{Field: "query", Message: fmt.Sprintf("查询语句长度不能超过%d个字符", maxFilterQueryLength)}
+	}
+	return nil
+}
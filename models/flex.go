@@ -0,0 +1,246 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// strictJSON 为 true 时请求只接受标准的 JSON 布尔值和 RFC3339 时间
+var strictJSON atomic.Bool
+
+// SetStrictJSON 设置是否关闭宽松解码，默认宽松
+func SetStrictJSON(enabled bool) {
+	strictJSON.Store(enabled)
+}
+
+// flexTimeLayouts 宽松模式下接受的时间格式，不带时区的按 UTC 解释
+var flexTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// decodeFlexBool 解析宽松的布尔值：true/false、"true"/"false" 等 strconv.ParseBool 接受的字符串，以及数字 1/0
+func decodeFlexBool(field string, raw json.RawMessage) (*bool, error) {
+	if raw == nil || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	var v bool
+	switch {
+	case bytes.Equal(raw, []byte("true")), bytes.Equal(raw, []byte("1")):
+		v = true
+	case bytes.Equal(raw, []byte("false")), bytes.Equal(raw, []byte("0")):
+		v = false
+	case raw[0] == '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		parsed, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, &ValidationError{Field: field, Message: field + " 必须为布尔值，如 true、false、\"true\" 或 1"}
+		}
+		v = parsed
+	default:
+		return nil, &ValidationError{Field: field, Message: field + " 必须为布尔值，如 true、false、\"true\" 或 1"}
+	}
+	return &v, nil
+}
+
+// decodeFlexTime 解析宽松的时间：flexTimeLayouts 中的字符串格式，或以秒为单位的 Unix 时间戳
+func decodeFlexTime(field string, raw json.RawMessage) (*time.Time, error) {
+	if raw == nil || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	message := field + " 必须为 RFC3339、YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS 格式的时间，或 Unix 秒级时间戳"
+	if raw[0] != '"' {
+		seconds, err := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil {
+			return nil, &ValidationError{Field: field, Message: message}
+		}
+		t := time.Unix(seconds, 0).UTC()
+		return &t, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	s = strings.TrimSpace(s)
+	for _, layout := range flexTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, &ValidationError{Field: field, Message: message}
+}
+
+// decodeFlexPriority 解析宽松的优先级：忽略大小写的名称，或数字、字符串形式的权重 1-4，空字符串视为未设置
+func decodeFlexPriority(field string, raw json.RawMessage) (*Priority, error) {
+	if raw == nil || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	v := string(raw)
+	if raw[0] == '"' {
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+	}
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	priority, err := ParsePriority(v)
+	if err != nil {
+		return nil, &ValidationError{Field: field, Message: field + " 必须为 low、medium、high、urgent 或权重 1-4"}
+	}
+	return &priority, nil
+}
+
+// decodeStrictTime 按标准方式解码 RFC3339 时间，供严格模式使用
+func decodeStrictTime(raw json.RawMessage) (*time.Time, error) {
+	var t *time.Time
+	if raw != nil {
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// UnmarshalJSON priority 在两种模式下都接受名称或权重；宽松模式下 due_date 接受多种时间格式，严格模式下只接受 RFC3339
+func (req *CreateTodoRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateTodoRequest
+	aux := struct {
+		*plain
+		Priority json.RawMessage `json:"priority"`
+		DueDate  json.RawMessage `json:"due_date"`
+	}{plain: (*plain)(req)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	priority, err := decodeFlexPriority("priority", aux.Priority)
+	if err != nil {
+		return err
+	}
+	if priority != nil {
+		req.Priority = *priority
+	}
+	if strictJSON.Load() {
+		req.DueDate, err = decodeStrictTime(aux.DueDate)
+	} else {
+		req.DueDate, err = decodeFlexTime("due_date", aux.DueDate)
+	}
+	return err
+}
+
+// UnmarshalJSON priority 在两种模式下都接受名称或权重，due_date 为 null 时清除截止时间；宽松模式下 completed、archived、starred
+// 接受多种布尔写法，due_date 接受多种时间格式；严格模式下其余字段按标准方式解码
+func (req *UpdateTodoRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateTodoRequest
+	if strictJSON.Load() {
+		aux := struct {
+			*plain
+			Priority json.RawMessage `json:"priority"`
+			DueDate  json.RawMessage `json:"due_date"`
+		}{plain: (*plain)(req)}
+		if err := json.Unmarshal(data, &aux); err != nil {
+			return err
+		}
+		var err error
+		if req.Priority, err = decodeFlexPriority("priority", aux.Priority); err != nil {
+			return err
+		}
+		if req.DueDate, err = decodeStrictTime(aux.DueDate); err != nil {
+			return err
+		}
+		req.ClearDueDate = bytes.Equal(aux.DueDate, []byte("null"))
+		return nil
+	}
+
+	aux := struct {
+		*plain
+		Completed json.RawMessage `json:"completed"`
+		Archived  json.RawMessage `json:"archived"`
+		Starred   json.RawMessage `json:"starred"`
+		Priority  json.RawMessage `json:"priority"`
+		DueDate   json.RawMessage `json:"due_date"`
+	}{plain: (*plain)(req)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if req.Completed, err = decodeFlexBool("completed", aux.Completed); err != nil {
+		return err
+	}
+	if req.Archived, err = decodeFlexBool("archived", aux.Archived); err != nil {
+		return err
+	}
+	if req.Starred, err = decodeFlexBool("starred", aux.Starred); err != nil {
+		return err
+	}
+	if req.Priority, err = decodeFlexPriority("priority", aux.Priority); err != nil {
+		return err
+	}
+	if req.DueDate, err = decodeFlexTime("due_date", aux.DueDate); err != nil {
+		return err
+	}
+	req.ClearDueDate = bytes.Equal(aux.DueDate, []byte("null"))
+	return nil
+}
+
+// UnmarshalJSON 创建请求的字段按 CreateTodoRequest 的规则解码；宽松模式下 completed、archived、starred 接受多种布尔写法
+func (req *ReplaceTodoRequest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &req.CreateTodoRequest); err != nil {
+		return err
+	}
+	if strictJSON.Load() {
+		state := struct {
+			Completed bool `json:"completed"`
+			Archived  bool `json:"archived"`
+			Starred   bool `json:"starred"`
+		}{}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return err
+		}
+		req.Completed, req.Archived, req.Starred = state.Completed, state.Archived, state.Starred
+		return nil
+	}
+
+	aux := struct {
+		Completed json.RawMessage `json:"completed"`
+		Archived  json.RawMessage `json:"archived"`
+		Starred   json.RawMessage `json:"starred"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	completed, err := decodeFlexBool("completed", aux.Completed)
+	if err != nil {
+		return err
+	}
+	archived, err := decodeFlexBool("archived", aux.Archived)
+	if err != nil {
+		return err
+	}
+	starred, err := decodeFlexBool("starred", aux.Starred)
+	if err != nil {
+		return err
+	}
+	req.Completed = completed != nil && *completed
+	req.Archived = archived != nil && *archived
+	req.Starred = starred != nil && *starred
+	return nil
+}
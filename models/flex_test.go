@@ -0,0 +1,142 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlexBool(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`"true"`, true},
+		{`"FALSE"`, false},
+		{`" true "`, true},
+		{`1`, true},
+		{`0`, false},
+		{`"1"`, true},
+		{`"0"`, false},
+	}
+	for _, tt := range tests {
+		var req UpdateTodoRequest
+		if err := json.Unmarshal([]byte(`{"completed": `+tt.raw+`}`), &req); err != nil {
+			t.Errorf("completed=%s 返回错误 %v", tt.raw, err)
+			continue
+		}
+		if req.Completed == nil || *req.Completed != tt.want {
+			t.Errorf("completed=%s 解析为 %v，期望 %v", tt.raw, req.Completed, tt.want)
+		}
+	}
+
+	var req UpdateTodoRequest
+	if err := json.Unmarshal([]byte(`{"starred": null}`), &req); err != nil || req.Starred != nil {
+		t.Errorf("starred=null 解析为 %v, %v，期望未设置", req.Starred, err)
+	}
+	for _, raw := range []string{`"yes"`, `2`, `"maybe"`, `[]`} {
+		var req UpdateTodoRequest
+		err := json.Unmarshal([]byte(`{"archived": `+raw+`}`), &req)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "archived" {
+			t.Errorf("archived=%s 返回 %v，期望 archived 字段的 ValidationError", raw, err)
+		}
+	}
+}
+
+func TestFlexTime(t *testing.T) {
+	want := time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{`"2024-06-01T09:30:00Z"`, want},
+		{`"2024-06-01T17:30:00+08:00"`, want},
+		{`"2024-06-01 09:30:00"`, want},
+		{`"2024-06-01T09:30"`, want},
+		{`"2024-06-01"`, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{`1717234200`, want},
+	}
+	for _, tt := range tests {
+		var req CreateTodoRequest
+		if err := json.Unmarshal([]byte(`{"title": "a", "due_date": `+tt.raw+`}`), &req); err != nil {
+			t.Errorf("due_date=%s 返回错误 %v", tt.raw, err)
+			continue
+		}
+		if req.DueDate == nil || !req.DueDate.Equal(tt.want) {
+			t.Errorf("due_date=%s 解析为 %v，期望 %v", tt.raw, req.DueDate, tt.want)
+		}
+	}
+
+	for _, raw := range []string{`"06/01/2024"`, `"tomorrow"`, `1.5`, `true`} {
+		var req CreateTodoRequest
+		err := json.Unmarshal([]byte(`{"title": "a", "due_date": `+raw+`}`), &req)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "due_date" {
+			t.Errorf("due_date=%s 返回 %v，期望 due_date 字段的 ValidationError", raw, err)
+		}
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	SetStrictJSON(true)
+	t.Cleanup(func() { SetStrictJSON(false) })
+
+	var update UpdateTodoRequest
+	if err := json.Unmarshal([]byte(`{"completed": true}`), &update); err != nil || update.Completed == nil || !*update.Completed {
+		t.Errorf("严格模式下 completed=true 解析为 %v, %v", update.Completed, err)
+	}
+	for _, body := range []string{`{"completed": "true"}`, `{"completed": 1}`} {
+		if err := json.Unmarshal([]byte(body), &UpdateTodoRequest{}); err == nil {
+			t.Errorf("严格模式下 %s 没有返回错误", body)
+		}
+	}
+	for _, body := range []string{`{"title": "a", "due_date": "2024-06-01"}`, `{"title": "a", "due_date": 1717234200}`} {
+		if err := json.Unmarshal([]byte(body), &CreateTodoRequest{}); err == nil {
+			t.Errorf("严格模式下 %s 没有返回错误", body)
+		}
+	}
+
+	// 权重是 priority 的正式取值，严格模式下同样接受
+	var create CreateTodoRequest
+	if err := json.Unmarshal([]byte(`{"title": "a", "priority": 2, "due_date": "2024-06-01T09:30:00Z"}`), &create); err != nil || create.Priority != PriorityMedium || create.DueDate == nil {
+		t.Errorf("严格模式下创建请求解析为 priority=%q due_date=%v, %v", create.Priority, create.DueDate, err)
+	}
+	update = UpdateTodoRequest{}
+	if err := json.Unmarshal([]byte(`{"priority": 4}`), &update); err != nil || update.Priority == nil || *update.Priority != PriorityUrgent {
+		t.Errorf("严格模式下 priority=4 解析为 %v, %v", update.Priority, err)
+	}
+	var validationErr *ValidationError
+	if err := json.Unmarshal([]byte(`{"priority": 5}`), &UpdateTodoRequest{}); !errors.As(err, &validationErr) || validationErr.Field != "priority" {
+		t.Errorf("严格模式下 priority=5 返回 %v，期望 priority 字段的 ValidationError", err)
+	}
+}
+
+func TestUpdateClearDueDate(t *testing.T) {
+	tests := []struct {
+		body  string
+		clear bool
+		set   bool
+	}{
+		{`{"due_date": null}`, true, false},
+		{`{"title": "a"}`, false, false},
+		{`{"due_date": "2024-06-01T09:30:00Z"}`, false, true},
+	}
+	t.Cleanup(func() { SetStrictJSON(false) })
+
+	for _, strict := range []bool{false, true} {
+		SetStrictJSON(strict)
+		for _, tt := range tests {
+			var req UpdateTodoRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("strict=%v 解析 %s 失败: %v", strict, tt.body, err)
+			}
+			if req.ClearDueDate != tt.clear || (req.DueDate != nil) != tt.set {
+				t.Errorf("strict=%v %s 解析为 clear=%v due_date=%v，期望 clear=%v", strict, tt.body, req.ClearDueDate, req.DueDate, tt.clear)
+			}
+		}
+	}
+}
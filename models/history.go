@@ -0,0 +1,63 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TodoVersion 待办事项在某次创建或更新后的状态，Version 从 1 开始递增
+type TodoVersion struct {
+	Version    int       `json:"version"`
+	Todo       Todo      `json:"todo"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// FieldChange 单个字段的变更，Field 为 JSON 字段名
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// diffIgnoredFields 比较版本时忽略的字段：updated_at 每次更新都会变化，备注不属于版本内容
+var diffIgnoredFields = map[string]bool{
+	"updated_at": true,
+	"comments":   true,
+}
+
+// DiffTodos 比较两个状态，按字段声明顺序返回发生变化的字段，没有变化时返回空切片。
+// 字段按 JSON 序列化结果比较，因此时间只比较时刻，不受单调时钟读数影响
+func DiffTodos(before, after Todo) []FieldChange {
+	changes := []FieldChange{}
+	beforeValue := reflect.ValueOf(before)
+	afterValue := reflect.ValueOf(after)
+	todoType := beforeValue.Type()
+
+	for i := 0; i < todoType.NumField(); i++ {
+		name, _, _ := strings.Cut(todoType.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || diffIgnoredFields[name] {
+			continue
+		}
+
+		b := beforeValue.Field(i).Interface()
+		a := afterValue.Field(i).Interface()
+		if jsonEqual(b, a) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, Before: b, After: a})
+	}
+	return changes
+}
+
+// jsonEqual 判断两个值序列化后是否相同
+func jsonEqual(a, b interface{}) bool {
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return bytes.Equal(aData, bData)
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffTodos(t *testing.T) {
+	created := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	due := created.Add(24 * time.Hour)
+	before := Todo{
+		ID:        1,
+		Title:     "写周报",
+		Priority:  PriorityMedium,
+		Tags:      []string{"work"},
+		Comments:  []Comment{},
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+
+	after := before
+	after.Title = "写月报"
+	after.Completed = true
+	after.Priority = PriorityHigh
+	after.Tags = []string{"work", "report"}
+	after.DueDate = &due
+	// updated_at 和备注的变化不计入差异
+	after.UpdatedAt = created.Add(time.Hour)
+	after.Comments = []Comment{{ID: 1, Body: "note"}}
+
+	want := []FieldChange{
+		{Field: "title", Before: "写周报", After: "写月报"},
+		{Field: "completed", Before: false, After: true},
+		{Field: "priority", Before: PriorityMedium, After: PriorityHigh},
+		{Field: "due_date", Before: (*time.Time)(nil), After: &due},
+		{Field: "tags", Before: []string{"work"}, After: []string{"work", "report"}},
+	}
+	if got := DiffTodos(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffTodos() = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestDiffTodosNoChange(t *testing.T) {
+	now := time.Now()
+	before := Todo{ID: 1, Title: "a", Tags: []string{}, CreatedAt: now}
+	after := before
+	// 去掉单调时钟读数后仍是同一时刻
+	after.CreatedAt = now.Round(0)
+	after.Tags = []string{}
+	after.UpdatedAt = now.Add(time.Minute)
+
+	got := DiffTodos(before, after)
+	if got == nil || len(got) != 0 {
+		t.Errorf("DiffTodos() = %#v，期望非 nil 的空切片", got)
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync/atomic"
+)
+
+// stringIDs 为 true 时 ID 序列化为 JSON 字符串，避免 JavaScript 客户端丢失大整数精度
+var stringIDs atomic.Bool
+
+// SetStringIDs 设置 ID 是否序列化为字符串，默认序列化为数字
+func SetStringIDs(enabled bool) {
+	stringIDs.Store(enabled)
+}
+
+// ID 待办事项与备注的标识，输出格式由 SetStringIDs 决定，输入同时接受数字和数字字符串
+type ID int
+
+// MarshalJSON 按当前设置将 ID 序列化为数字或字符串
+func (id ID) MarshalJSON() ([]byte, error) {
+	s := strconv.Itoa(int(id))
+	if stringIDs.Load() {
+		return []byte(strconv.Quote(s)), nil
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalJSON 接受数字（如 1）或数字字符串（如 "1"）
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		return errors.New("ID 必须为整数或整数字符串")
+	}
+	*id = ID(n)
+	return nil
+}
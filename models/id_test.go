@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIDMarshalJSON(t *testing.T) {
+	t.Cleanup(func() { SetStringIDs(false) })
+	comment := Comment{ID: 9007199254740993}
+
+	tests := []struct {
+		stringIDs bool
+		want      string
+	}{
+		{false, `{"id":9007199254740993,`},
+		{true, `{"id":"9007199254740993",`},
+	}
+	for _, tt := range tests {
+		SetStringIDs(tt.stringIDs)
+		data, err := json.Marshal(comment)
+		if err != nil {
+			t.Fatalf("序列化失败: %v", err)
+		}
+		if got := string(data[:len(tt.want)]); got != tt.want {
+			t.Errorf("stringIDs=%t 时序列化为 %s，期望以 %s 开头", tt.stringIDs, data, tt.want)
+		}
+	}
+}
+
+func TestIDUnmarshalJSON(t *testing.T) {
+	t.Cleanup(func() { SetStringIDs(false) })
+	// 输入格式与输出设置无关，两种设置下都接受数字和数字字符串
+	for _, stringIDs := range []bool{false, true} {
+		SetStringIDs(stringIDs)
+		var req BulkDeleteRequest
+		if err := json.Unmarshal([]byte(`{"ids": [1, "2", "9007199254740993"]}`), &req); err != nil {
+			t.Fatalf("stringIDs=%t 时解析失败: %v", stringIDs, err)
+		}
+		if len(req.IDs) != 3 || req.IDs[0] != 1 || req.IDs[1] != 2 || req.IDs[2] != 9007199254740993 {
+			t.Errorf("stringIDs=%t 时解析结果 = %v", stringIDs, req.IDs)
+		}
+	}
+
+	var id ID = 7
+	if err := json.Unmarshal([]byte(`null`), &id); err != nil || id != 7 {
+		t.Errorf("解析 null = %d, %v，期望保持原值", id, err)
+	}
+	for _, input := range []string{`"abc"`, `1.5`, `"1.5"`, `true`, `""`} {
+		if err := json.Unmarshal([]byte(input), &id); err == nil {
+			t.Errorf("解析 %s 未返回错误", input)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"sync/atomic"
+)
+
+// Limits 各字段的长度与数量上限，长度按字节计算；零值或负值的项使用默认值
+type Limits struct {
+	TitleLength        int
+	DescriptionLength  int
+	TagCount           int
+	TagLength          int
+	SubtaskCount       int
+	SubtaskTitleLength int
+	CommentLength      int
+	ListIDLength       int
+	ExternalIDLength   int
+}
+
+// DefaultLimits 返回默认的字段上限
+func DefaultLimits() Limits {
+	return Limits{
+		TitleLength:        100,
+		DescriptionLength:  500,
+		TagCount:           10,
+		TagLength:          30,
+		SubtaskCount:       50,
+		SubtaskTitleLength: 100,
+		CommentLength:      1000,
+		ListIDLength:       50,
+		ExternalIDLength:   100,
+	}
+}
+
+// withDefaults 为未设置的上限填充默认值
+func (l Limits) withDefaults() Limits {
+	defaults := DefaultLimits()
+	fill := func(v *int, def int) {
+		if *v <= 0 {
+			*v = def
+		}
+	}
+	fill(&l.TitleLength, defaults.TitleLength)
+	fill(&l.DescriptionLength, defaults.DescriptionLength)
+	fill(&l.TagCount, defaults.TagCount)
+	fill(&l.TagLength, defaults.TagLength)
+	fill(&l.SubtaskCount, defaults.SubtaskCount)
+	fill(&l.SubtaskTitleLength, defaults.SubtaskTitleLength)
+	fill(&l.CommentLength, defaults.CommentLength)
+	fill(&l.ListIDLength, defaults.ListIDLength)
+	fill(&l.ExternalIDLength, defaults.ExternalIDLength)
+	return l
+}
+
+// limits 当前生效的字段上限
+var limits atomic.Pointer[Limits]
+
+func init() {
+	SetLimits(Limits{})
+}
+
+// SetLimits 设置校验使用的字段上限，应在启动时调用
+func SetLimits(l Limits) {
+	l = l.withDefaults()
+	limits.Store(&l)
+}
+
+// CurrentLimits 返回当前生效的字段上限
+func CurrentLimits() Limits {
+	return *limits.Load()
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// setTestLimits 在测试期间使用 l 作为字段上限，测试结束后恢复默认值
+func setTestLimits(t *testing.T, l Limits) {
+	t.Helper()
+	SetLimits(l)
+	t.Cleanup(func() { SetLimits(Limits{}) })
+}
+
+func TestSetLimitsDefaults(t *testing.T) {
+	setTestLimits(t, Limits{TitleLength: 10, TagCount: -1})
+	got := CurrentLimits()
+	want := DefaultLimits()
+	want.TitleLength = 10
+	if got != want {
+		t.Errorf("生效的上限 = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestCustomLimits(t *testing.T) {
+	setTestLimits(t, Limits{TitleLength: 5, DescriptionLength: 8, TagCount: 2, TagLength: 3})
+
+	tests := []struct {
+		name  string
+		req   CreateTodoRequest
+		field string
+	}{
+		{"标题在上限内", CreateTodoRequest{Title: "12345"}, ""},
+		{"标题超过上限", CreateTodoRequest{Title: "123456"}, "title"},
+		{"描述在上限内", CreateTodoRequest{Title: "a", Description: "12345678"}, ""},
+		{"描述超过上限", CreateTodoRequest{Title: "a", Description: "123456789"}, "description"},
+		{"标签数量在上限内", CreateTodoRequest{Title: "a", Tags: []string{"a", "b"}}, ""},
+		{"标签数量超过上限", CreateTodoRequest{Title: "a", Tags: []string{"a", "b", "c"}}, "tags"},
+		{"标签长度超过上限", CreateTodoRequest{Title: "a", Tags: []string{"abcd"}}, "tags"},
+		{"默认上限内的长标题被拒绝", CreateTodoRequest{Title: strings.Repeat("a", 50)}, "title"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("返回错误 %v，期望有效", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != tt.field {
+				t.Errorf("返回 %v，期望 %s 字段的 ValidationError", err, tt.field)
+			}
+		})
+	}
+
+	// 更新请求使用同样的上限
+	title := "123456"
+	if err := (&UpdateTodoRequest{Title: &title}).Validate(); err == nil {
+		t.Error("更新标题超过上限时没有返回错误")
+	}
+}
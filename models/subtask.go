@@ -0,0 +1,47 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Subtask 表示待办事项下的一个子任务
+type Subtask struct {
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+}
+
+// validateSubtasks 验证子任务数量及每个子任务的标题
+func validateSubtasks(subtasks []Subtask) error {
+	limits := CurrentLimits()
+	if len(subtasks) > limits.SubtaskCount {
+		return &ValidationError{Field: "subtasks", Message: fmt.Sprintf("子任务数量不能超过%d个", limits.SubtaskCount)}
+	}
+	for _, subtask := range subtasks {
+		if strings.TrimSpace(subtask.Title) == "" {
+			return &ValidationError{Field: "subtasks", Message: "子任务标题不能为空"}
+		}
+		if len(subtask.Title) > limits.SubtaskTitleLength {
+			return &ValidationError{Field: "subtasks", Message: fmt.Sprintf("子任务标题长度不能超过%d个字符", limits.SubtaskTitleLength)}
+		}
+	}
+	return nil
+}
+
+// Progress 返回已完成子任务的比例（0 到 1）；没有子任务时与 Completed 一致
+func (t *Todo) Progress() float64 {
+	if len(t.Subtasks) == 0 {
+		if t.Completed {
+			return 1
+		}
+		return 0
+	}
+
+	done := 0
+	for _, subtask := range t.Subtasks {
+		if subtask.Completed {
+			done++
+		}
+	}
+	return float64(done) / float64(len(t.Subtasks))
+}
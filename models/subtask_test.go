@@ -0,0 +1,26 @@
+package models
+
+import "testing"
+
+func TestProgress(t *testing.T) {
+	tests := []struct {
+		name string
+		todo Todo
+		want float64
+	}{
+		{"部分子任务完成", Todo{Subtasks: []Subtask{{Title: "a", Completed: true}, {Title: "b"}, {Title: "c", Completed: true}, {Title: "d"}}}, 0.5},
+		{"一个子任务完成", Todo{Subtasks: []Subtask{{Title: "a", Completed: true}, {Title: "b"}, {Title: "c"}}}, 1.0 / 3},
+		{"子任务全部完成", Todo{Subtasks: []Subtask{{Title: "a", Completed: true}}}, 1},
+		// 子任务进度不受待办事项本身完成状态影响
+		{"已完成但子任务未完成", Todo{Completed: true, Subtasks: []Subtask{{Title: "a"}}}, 0},
+		{"没有子任务且未完成", Todo{}, 0},
+		{"没有子任务且已完成", Todo{Completed: true}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.todo.Progress(); got != tt.want {
+				t.Errorf("Progress() = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,46 +1,447 @@
 package models
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Priority 表示待办事项的优先级
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// IsValid 判断优先级是否为预设值之一
+func (p Priority) IsValid() bool {
+	switch p {
+	case PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+// Weight 返回优先级的权重，数值越大越紧急，未知优先级为 0
+func (p Priority) Weight() int {
+	switch p {
+	case PriorityUrgent:
+		return 4
+	case PriorityHigh:
+		return 3
+	case PriorityMedium:
+		return 2
+	case PriorityLow:
+		return 1
+	}
+	return 0
+}
+
+// Priorities 按权重升序排列的全部优先级
+var Priorities = []Priority{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// ParsePriority 解析优先级名称（忽略大小写）或权重 1-4
+func ParsePriority(v string) (Priority, error) {
+	if n, err := strconv.Atoi(v); err == nil {
+		if n < 1 || n > len(Priorities) {
+			return "", fmt.Errorf("优先级权重必须在 1 到 %d 之间", len(Priorities))
+		}
+		return Priorities[n-1], nil
+	}
+	priority := Priority(strings.ToLower(v))
+	if !priority.IsValid() {
+		return "", fmt.Errorf("无效的优先级: %s", v)
+	}
+	return priority, nil
+}
+
 // Todo 表示待办事项的数据模型
 type Todo struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Completed   bool      `json:"completed"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              ID         `json:"id"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	Completed       bool       `json:"completed"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	Archived        bool       `json:"archived"`
+	ArchivedAt      *time.Time `json:"archived_at,omitempty"`
+	Starred         bool       `json:"starred"`
+	ListID          string     `json:"list_id,omitempty"`
+	Position        int64      `json:"position"`
+	ExternalID      string     `json:"external_id,omitempty"`
+	Color           string     `json:"color"`
+	Priority        Priority   `json:"priority"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	RemindBefore    string     `json:"remind_before,omitempty"`
+	EstimateMinutes int        `json:"estimate_minutes"`
+	SpentMinutes    int        `json:"spent_minutes"`
+	Tags            []string   `json:"tags"`
+	Subtasks        []Subtask  `json:"subtasks"`
+	Comments        []Comment  `json:"comments"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	// DeletedAt 移入回收站的时间，只有回收站中的待办事项才有
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // CreateTodoRequest 表示创建待办事项的请求结构
 type CreateTodoRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	Color           string     `json:"color"`
+	Priority        Priority   `json:"priority"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	RemindBefore    string     `json:"remind_before,omitempty"`
+	EstimateMinutes int        `json:"estimate_minutes"`
+	SpentMinutes    int        `json:"spent_minutes"`
+	Tags            []string   `json:"tags"`
+	Subtasks        []Subtask  `json:"subtasks"`
+	ListID          string     `json:"list_id,omitempty"`
+	ExternalID      string     `json:"external_id,omitempty"`
 }
 
 // UpdateTodoRequest 表示更新待办事项的请求结构
 type UpdateTodoRequest struct {
-	Title       *string `json:"title,omitempty"`
-	Description *string `json:"description,omitempty"`
-	Completed   *bool   `json:"completed,omitempty"`
+	Title           *string    `json:"title,omitempty"`
+	Description     *string    `json:"description,omitempty"`
+	Completed       *bool      `json:"completed,omitempty"`
+	Archived        *bool      `json:"archived,omitempty"`
+	Starred         *bool      `json:"starred,omitempty"`
+	Color           *string    `json:"color,omitempty"`
+	Priority        *Priority  `json:"priority,omitempty"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	RemindBefore    *string    `json:"remind_before,omitempty"`
+	EstimateMinutes *int       `json:"estimate_minutes,omitempty"`
+	SpentMinutes    *int       `json:"spent_minutes,omitempty"`
+	Tags            *[]string  `json:"tags,omitempty"`
+	Subtasks        *[]Subtask `json:"subtasks,omitempty"`
+	ListID          *string    `json:"list_id,omitempty"`
+	ExternalID      *string    `json:"external_id,omitempty"`
+	// ClearDueDate 为 true 时清除截止时间，JSON 中 due_date 为 null 或整体替换时未提供截止时间时设置
+	ClearDueDate bool `json:"-"`
+	// Position 只由排序接口设置，不从 JSON 读取
+	Position *int64 `json:"-"`
+}
+
+// ReplaceTodoRequest 表示整体替换待办事项的请求结构，未提供的字段恢复为创建时的默认值
+type ReplaceTodoRequest struct {
+	CreateTodoRequest
+	Completed bool `json:"completed"`
+	Archived  bool `json:"archived"`
+	Starred   bool `json:"starred"`
+}
+
+// UpdateRequest 将替换请求转换为设置了全部字段的更新请求；调用前需先通过 Validate
+func (req *ReplaceTodoRequest) UpdateRequest() *UpdateTodoRequest {
+	priority := req.Priority
+	if priority == "" {
+		priority = PriorityMedium
+	}
+	tags := append([]string{}, req.Tags...)
+	subtasks := append([]Subtask{}, req.Subtasks...)
+	return &UpdateTodoRequest{
+		Title:           &req.Title,
+		Description:     &req.Description,
+		Completed:       &req.Completed,
+		Archived:        &req.Archived,
+		Starred:         &req.Starred,
+		Color:           &req.Color,
+		Priority:        &priority,
+		DueDate:         req.DueDate,
+		RemindBefore:    &req.RemindBefore,
+		EstimateMinutes: &req.EstimateMinutes,
+		SpentMinutes:    &req.SpentMinutes,
+		Tags:            &tags,
+		Subtasks:        &subtasks,
+		ListID:          &req.ListID,
+		ExternalID:      &req.ExternalID,
+		ClearDueDate:    req.DueDate == nil,
+	}
+}
+
+// DuplicateRequest 返回复制该待办事项所用的创建请求：复制内容、标签、子任务（重置为未完成）和截止时间，
+// 不复制完成、星标、归档状态，已用耗时、备注和外部ID
+func (t *Todo) DuplicateRequest() *CreateTodoRequest {
+	subtasks := make([]Subtask, len(t.Subtasks))
+	for i, subtask := range t.Subtasks {
+		subtasks[i] = Subtask{Title: subtask.Title}
+	}
+	var dueDate *time.Time
+	if t.DueDate != nil {
+		due := *t.DueDate
+		dueDate = &due
+	}
+	return &CreateTodoRequest{
+		Title:           t.Title,
+		Description:     t.Description,
+		Color:           t.Color,
+		Priority:        t.Priority,
+		DueDate:         dueDate,
+		RemindBefore:    t.RemindBefore,
+		EstimateMinutes: t.EstimateMinutes,
+		Tags:            append([]string{}, t.Tags...),
+		Subtasks:        subtasks,
+		ListID:          t.ListID,
+	}
+}
+
+// LogTimeRequest 表示记录耗时的请求结构
+type LogTimeRequest struct {
+	Minutes int `json:"minutes"`
 }
 
+// NamedColors 允许使用的颜色名称
+var NamedColors = []string{"red", "orange", "yellow", "green", "blue", "purple", "gray"}
+
 // Validate 验证创建请求的有效性
 func (req *CreateTodoRequest) Validate() error {
-	if req.Title == "" {
+	if err := validateTitle(req.Title); err != nil {
+		return err
+	}
+	if err := validateDescription(req.Description); err != nil {
+		return err
+	}
+	if err := validateColor(req.Color); err != nil {
+		return err
+	}
+	if req.Priority != "" && !req.Priority.IsValid() {
+		return &ValidationError{Field: "priority", Message: "优先级必须为 low、medium、high 或 urgent"}
+	}
+	if req.EstimateMinutes < 0 {
+		return &ValidationError{Field: "estimate_minutes", Message: "预估耗时不能为负数"}
+	}
+	if req.SpentMinutes < 0 {
+		return &ValidationError{Field: "spent_minutes", Message: "已用耗时不能为负数"}
+	}
+	if err := validateTags(req.Tags); err != nil {
+		return err
+	}
+	if err := validateRemindBefore(req.RemindBefore); err != nil {
+		return err
+	}
+	if err := validateSubtasks(req.Subtasks); err != nil {
+		return err
+	}
+	if err := ValidateListID(req.ListID); err != nil {
+		return err
+	}
+	if err := validateExternalID(req.ExternalID); err != nil {
+		return err
+	}
+	return runCreateRules(req)
+}
+
+// Validate 验证更新请求中已设置字段的有效性
+func (req *UpdateTodoRequest) Validate() error {
+	if req.Title != nil {
+		if err := validateTitle(*req.Title); err != nil {
+			return err
+		}
+	}
+	if req.Description != nil {
+		if err := validateDescription(*req.Description); err != nil {
+			return err
+		}
+	}
+	if req.Color != nil {
+		if err := validateColor(*req.Color); err != nil {
+			return err
+		}
+	}
+	if req.Priority != nil && !req.Priority.IsValid() {
+		return &ValidationError{Field: "priority", Message: "优先级必须为 low、medium、high 或 urgent"}
+	}
+	if req.EstimateMinutes != nil && *req.EstimateMinutes < 0 {
+		return &ValidationError{Field: "estimate_minutes", Message: "预估耗时不能为负数"}
+	}
+	if req.SpentMinutes != nil && *req.SpentMinutes < 0 {
+		return &ValidationError{Field: "spent_minutes", Message: "已用耗时不能为负数"}
+	}
+	if req.Tags != nil {
+		if err := validateTags(*req.Tags); err != nil {
+			return err
+		}
+	}
+	if req.RemindBefore != nil {
+		if err := validateRemindBefore(*req.RemindBefore); err != nil {
+			return err
+		}
+	}
+	if req.Subtasks != nil {
+		if err := validateSubtasks(*req.Subtasks); err != nil {
+			return err
+		}
+	}
+	if req.ListID != nil {
+		if err := ValidateListID(*req.ListID); err != nil {
+			return err
+		}
+	}
+	if req.ExternalID != nil {
+		if err := validateExternalID(*req.ExternalID); err != nil {
+			return err
+		}
+	}
+	return runUpdateRules(req)
+}
+
+// Validate 验证记录耗时请求的有效性
+func (req *LogTimeRequest) Validate() error {
+	if req.Minutes <= 0 {
+		return &ValidationError{Field: "minutes", Message: "记录的耗时必须为正整数"}
+	}
+	return nil
+}
+
+// validateTitle 验证标题非空且不超过长度上限
+func validateTitle(title string) error {
+	if title == "" {
 		return &ValidationError{Field: "title", Message: "标题不能为空"}
 	}
-	if len(req.Title) > 100 {
-		return &ValidationError{Field: "title", Message: "标题长度不能超过100个字符"}
+	if limit := CurrentLimits().TitleLength; len(title) > limit {
+		return &ValidationError{Field: "title", Message: fmt.Sprintf("标题长度不能超过%d个字符", limit)}
+	}
+	return nil
+}
+
+// validateDescription 验证描述不超过长度上限
+func validateDescription(description string) error {
+	if limit := CurrentLimits().DescriptionLength; len(description) > limit {
+		return &ValidationError{Field: "description", Message: fmt.Sprintf("描述长度不能超过%d个字符", limit)}
+	}
+	return nil
+}
+
+// validateTags 验证标签数量及每个标签的长度
+func validateTags(tags []string) error {
+	limits := CurrentLimits()
+	if len(tags) > limits.TagCount {
+		return &ValidationError{Field: "tags", Message: fmt.Sprintf("标签数量不能超过%d个", limits.TagCount)}
+	}
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			return &ValidationError{Field: "tags", Message: "标签不能为空"}
+		}
+		if len(tag) > limits.TagLength {
+			return &ValidationError{Field: "tags", Message: fmt.Sprintf("标签长度不能超过%d个字符", limits.TagLength)}
+		}
+	}
+	return nil
+}
+
+// ValidateListID 验证清单ID，空值表示不属于任何清单
+func ValidateListID(listID string) error {
+	if limit := CurrentLimits().ListIDLength; len(listID) > limit {
+		return &ValidationError{Field: "list_id", Message: fmt.Sprintf("清单ID长度不能超过%d个字符", limit)}
+	}
+	if listID != "" && strings.TrimSpace(listID) != listID {
+		return &ValidationError{Field: "list_id", Message: "清单ID不能以空白开头或结尾"}
+	}
+	return nil
+}
+
+// NormalizeTags 将标签去除首尾空白并转为小写，去掉重复项，保留首次出现的顺序
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// validateExternalID 验证外部系统ID的长度
+func validateExternalID(externalID string) error {
+	if limit := CurrentLimits().ExternalIDLength; len(externalID) > limit {
+		return &ValidationError{Field: "external_id", Message: fmt.Sprintf("外部ID长度不能超过%d个字符", limit)}
+	}
+	return nil
+}
+
+// HasTag 判断待办事项是否包含指定标签
+func (t *Todo) HasTag(tag string) bool {
+	for _, existing := range t.Tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// validateColor 验证颜色为空、#RRGGBB 格式或预设的颜色名称
+func validateColor(color string) error {
+	if color == "" || isHexColor(color) {
+		return nil
+	}
+	for _, name := range NamedColors {
+		if strings.EqualFold(color, name) {
+			return nil
+		}
+	}
+	return &ValidationError{Field: "color", Message: "颜色必须为 #RRGGBB 格式或预设的颜色名称"}
+}
+
+// isHexColor 判断字符串是否为 #RRGGBB 格式
+func isHexColor(color string) bool {
+	if len(color) != 7 || color[0] != '#' {
+		return false
+	}
+	for _, c := range color[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
 	}
-	if len(req.Description) > 500 {
-		return &ValidationError{Field: "description", Message: "描述长度不能超过500个字符"}
+	return true
+}
+
+// PositionGap 排序位置的间隔。从未手动排序过的待办事项位置为 ID*PositionGap，新建的待办事项因此排在最后
+const PositionGap int64 = 1024
+
+// OrderPosition 返回待办事项的排序位置，Position 为 0（从未手动排序过）时按ID计算
+func (t *Todo) OrderPosition() int64 {
+	if t.Position != 0 {
+		return t.Position
+	}
+	return int64(t.ID) * PositionGap
+}
+
+// IsOverdue 判断待办事项在给定时间是否已逾期（未完成且截止时间已过）
+func (t *Todo) IsOverdue(now time.Time) bool {
+	return !t.Completed && t.DueDate != nil && t.DueDate.Before(now)
+}
+
+// validateRemindBefore 验证提前提醒时长为空或非负的有效时长
+func validateRemindBefore(remindBefore string) error {
+	if remindBefore == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(remindBefore)
+	if err != nil {
+		return &ValidationError{Field: "remind_before", Message: "提前提醒时长必须为有效的时长，如 1h 或 30m"}
+	}
+	if d < 0 {
+		return &ValidationError{Field: "remind_before", Message: "提前提醒时长不能为负数"}
 	}
 	return nil
 }
 
+// RemindAt 返回应发送提醒的时间（截止时间减去提前提醒时长），未设置截止时间时返回 false
+func (t *Todo) RemindAt() (time.Time, bool) {
+	if t.DueDate == nil {
+		return time.Time{}, false
+	}
+	before, _ := time.ParseDuration(t.RemindBefore)
+	return t.DueDate.Add(-before), true
+}
+
 // ValidationError 表示验证错误
 type ValidationError struct {
 	Field   string `json:"field"`
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateColor(t *testing.T) {
+	tests := []struct {
+		color string
+		valid bool
+	}{
+		{"", true},
+		{"#1a2B3c", true},
+		{"#FFFFFF", true},
+		{"red", true},
+		{"Blue", true},
+		{"#12345", false},
+		{"#1234567", false},
+		{"#GGGGGG", false},
+		{"123456", false},
+		{"pink", false},
+	}
+	for _, tt := range tests {
+		create := &CreateTodoRequest{Title: "a", Color: tt.color}
+		color := tt.color
+		update := &UpdateTodoRequest{Color: &color}
+		for name, err := range map[string]error{"创建": create.Validate(), "更新": update.Validate()} {
+			if tt.valid {
+				if err != nil {
+					t.Errorf("%s颜色 %q 返回错误 %v，期望有效", name, tt.color, err)
+				}
+				continue
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != "color" {
+				t.Errorf("%s颜色 %q 返回 %v，期望 color 字段的 ValidationError", name, tt.color, err)
+			}
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		in, want []string
+	}{
+		{[]string{"Work", "work", " WORK "}, []string{"work"}},
+		{[]string{"Home", "Work", "home"}, []string{"home", "work"}},
+		{[]string{"紧急", "紧急"}, []string{"紧急"}},
+		{nil, []string{}},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTags(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NormalizeTags(%q) = %q，期望 %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"sync"
+)
+
+// CreateRule 自定义的创建请求校验规则
+type CreateRule func(req *CreateTodoRequest) error
+
+// UpdateRule 自定义的更新请求校验规则，只需检查请求中已设置的字段
+type UpdateRule func(req *UpdateTodoRequest) error
+
+var (
+	createRules []CreateRule
+	updateRules []UpdateRule
+	rulesMutex  sync.RWMutex
+)
+
+// RegisterCreateRule 注册创建请求的自定义校验规则，应在启动时调用；规则在内置校验通过后按注册顺序执行
+func RegisterCreateRule(rule CreateRule) {
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	createRules = append(createRules, rule)
+}
+
+// RegisterUpdateRule 注册更新请求的自定义校验规则，应在启动时调用；规则在内置校验通过后按注册顺序执行
+func RegisterUpdateRule(rule UpdateRule) {
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	updateRules = append(updateRules, rule)
+}
+
+// ResetRules 清除所有已注册的自定义校验规则
+func ResetRules() {
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	createRules = nil
+	updateRules = nil
+}
+
+// runCreateRules 依次执行自定义创建规则
+func runCreateRules(req *CreateTodoRequest) error {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+	for _, rule := range createRules {
+		if err := rule(req); err != nil {
+			return asValidationError(err)
+		}
+	}
+	return nil
+}
+
+// runUpdateRules 依次执行自定义更新规则
+func runUpdateRules(req *UpdateTodoRequest) error {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+	for _, rule := range updateRules {
+		if err := rule(req); err != nil {
+			return asValidationError(err)
+		}
+	}
+	return nil
+}
+
+// asValidationError 将规则返回的普通错误包装为 ValidationError
+func asValidationError(err error) error {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr
+	}
+	return &ValidationError{Message: err.Error()}
+}
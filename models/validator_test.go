@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// ticketTitleRule 要求标题以工单号开头
+func ticketTitleRule(title string) error {
+	if !strings.HasPrefix(title, "PROJ-") {
+		return &ValidationError{Field: "title", Message: "标题必须以工单号 PROJ- 开头"}
+	}
+	return nil
+}
+
+func TestCustomRules(t *testing.T) {
+	t.Cleanup(ResetRules)
+	RegisterCreateRule(func(req *CreateTodoRequest) error { return ticketTitleRule(req.Title) })
+	RegisterUpdateRule(func(req *UpdateTodoRequest) error {
+		if req.Title == nil {
+			return nil
+		}
+		return ticketTitleRule(*req.Title)
+	})
+
+	tests := []struct {
+		name    string
+		req     *CreateTodoRequest
+		wantErr string
+	}{
+		{"通过全部规则", &CreateTodoRequest{Title: "PROJ-1 修复登录"}, ""},
+		{"自定义规则拒绝", &CreateTodoRequest{Title: "修复登录"}, "标题必须以工单号 PROJ- 开头"},
+		// 内置校验先执行，自定义规则不会掩盖内置错误
+		{"内置规则先执行", &CreateTodoRequest{Title: ""}, "标题不能为空"},
+		{"内置规则检查其他字段", &CreateTodoRequest{Title: "PROJ-1", Color: "pink"}, "颜色必须为 #RRGGBB 格式或预设的颜色名称"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v，期望通过", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Message != tt.wantErr {
+				t.Errorf("Validate() = %v，期望 ValidationError %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	title := "修复登录"
+	if err := (&UpdateTodoRequest{Title: &title}).Validate(); err == nil {
+		t.Error("更新规则未拒绝不带工单号的标题")
+	}
+	completed := true
+	if err := (&UpdateTodoRequest{Completed: &completed}).Validate(); err != nil {
+		t.Errorf("未设置标题的更新 = %v，期望通过", err)
+	}
+
+	ResetRules()
+	if err := (&CreateTodoRequest{Title: "修复登录"}).Validate(); err != nil {
+		t.Errorf("清除规则后 Validate() = %v，期望通过", err)
+	}
+}
+
+func TestCustomRulePlainError(t *testing.T) {
+	t.Cleanup(ResetRules)
+	var calls []string
+	RegisterCreateRule(func(*CreateTodoRequest) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	RegisterCreateRule(func(*CreateTodoRequest) error {
+		calls = append(calls, "second")
+		return errors.New("不允许在周末创建")
+	})
+	RegisterCreateRule(func(*CreateTodoRequest) error {
+		calls = append(calls, "third")
+		return nil
+	})
+
+	// 普通错误包装为 ValidationError，之后的规则不再执行
+	err := (&CreateTodoRequest{Title: "a"}).Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Message != "不允许在周末创建" {
+		t.Errorf("Validate() = %#v，期望包装为 ValidationError", err)
+	}
+	if got := strings.Join(calls, ","); got != "first,second" {
+		t.Errorf("执行的规则 = %s，期望 first,second", got)
+	}
+}
@@ -0,0 +1,290 @@
+// Package query 解析待办事项的查询语言，将其转换为存储层的查询条件。
+//
+// 查询由空白分隔的条件组成，各条件同时满足才匹配：
+//
+//	completed:false priority>=high tag:work created>=2024-06-01 "design doc"
+//
+// 支持的条件：
+//   - completed:BOOL、starred:BOOL、archived:BOOL
+//   - list:ID，list:"" 匹配不属于任何清单的待办事项
+//   - tag:NAME
+//   - priority:P1,P2、priority>P、priority>=P、priority<P、priority<=P，P 为 low、medium、high、urgent 或其权重 1-4
+//   - created、updated、due 后跟 : > >= < <=，值为 YYYY-MM-DD（UTC 自然日）或 RFC3339 时间；created:DATE 匹配当天
+//   - 其他不含冒号和比较符的词或双引号括起的短语作为关键字，在标题和描述中匹配，最多一个
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// dateLayout 只有日期时的格式，按 UTC 自然日解释
+const dateLayout = "2006-01-02"
+
+// operators 支持的比较符，较长的排在前面以便优先匹配
+var operators = []string{">=", "<=", ":", ">", "<", "="}
+
+// Parse 解析查询语句，返回对应的查询条件
+func Parse(s string) (storage.ListOptions, error) {
+	var opts storage.ListOptions
+	err := Apply(s, &opts)
+	return opts, err
+}
+
+// Apply 解析查询语句并写入 opts。语句中的条件与 opts 中已设置的条件重复时返回错误，
+// 便于与其他来源（如查询参数）的条件合并
+func Apply(s string, opts *storage.ListOptions) error {
+	terms, err := tokenize(s)
+	if err != nil {
+		return err
+	}
+	for _, term := range terms {
+		if err := applyTerm(term, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// term 一个查询条件，field 为空时 value 是关键字
+type term struct {
+	field string
+	op    string
+	value string
+}
+
+// tokenize 将查询语句按空白拆分为条件，双引号内的空白不拆分
+func tokenize(s string) ([]term, error) {
+	var (
+		terms   []term
+		current strings.Builder
+		quoted  bool
+		inToken bool
+		// phrase 当前词以引号开头，整个词作为关键字而不拆分字段（如 "a:b"）
+		phrase bool
+	)
+	flush := func() {
+		switch {
+		case phrase:
+			terms = append(terms, term{value: current.String()})
+		case inToken:
+			terms = append(terms, parseTerm(current.String()))
+		}
+		current.Reset()
+		inToken, phrase = false, false
+	}
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			if !inToken {
+				phrase = true
+			}
+			quoted = !quoted
+			inToken = true
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("查询语句中的引号未闭合")
+	}
+	flush()
+	return terms, nil
+}
+
+// parseTerm 将一个词拆分为字段、比较符和值，不以字段名开头的词作为关键字
+func parseTerm(token string) term {
+	for i, r := range token {
+		if !unicode.IsLetter(r) && r != '_' {
+			for _, op := range operators {
+				if i > 0 && strings.HasPrefix(token[i:], op) {
+					return term{field: strings.ToLower(token[:i]), op: op, value: token[i+len(op):]}
+				}
+			}
+			break
+		}
+	}
+	return term{value: token}
+}
+
+// applyTerm 将单个条件写入 opts
+func applyTerm(t term, opts *storage.ListOptions) error {
+	switch t.field {
+	case "":
+		if opts.Query != "" {
+			return errors.New("只能包含一个关键字，多个词请用双引号括起来")
+		}
+		opts.Query = strings.TrimSpace(t.value)
+	case "completed":
+		return applyBool(t, &opts.Completed)
+	case "starred":
+		return applyBool(t, &opts.Starred)
+	case "archived":
+		return applyBool(t, &opts.Archived)
+	case "list":
+		if err := requireEqual(t); err != nil {
+			return err
+		}
+		if opts.ListID != nil {
+			return duplicate(t)
+		}
+		listID := t.value
+		opts.ListID = &listID
+	case "tag":
+		if err := requireEqual(t); err != nil {
+			return err
+		}
+		if opts.Tag != "" {
+			return duplicate(t)
+		}
+		if t.value == "" {
+			return errors.New("tag 的值不能为空")
+		}
+		opts.Tag = t.value
+	case "priority":
+		if len(opts.Priorities) > 0 {
+			return duplicate(t)
+		}
+		matched, err := matchPriorities(t)
+		if err != nil {
+			return err
+		}
+		opts.Priorities = matched
+	case "created":
+		return applyTime(t, &opts.CreatedAfter, &opts.CreatedBefore)
+	case "updated":
+		return applyTime(t, &opts.UpdatedAfter, &opts.UpdatedBefore)
+	case "due":
+		return applyTime(t, &opts.DueAfter, &opts.DueBefore)
+	default:
+		return fmt.Errorf("未知的查询字段: %s，可用的字段: completed、starred、archived、list、tag、priority、created、updated、due", t.field)
+	}
+	return nil
+}
+
+// duplicate 返回条件重复的错误
+func duplicate(t term) error {
+	return fmt.Errorf("%s 条件重复", t.field)
+}
+
+// requireEqual 检查条件只使用 : 或 = 比较
+func requireEqual(t term) error {
+	if t.op != ":" && t.op != "=" {
+		return fmt.Errorf("%s 只支持 : 比较", t.field)
+	}
+	return nil
+}
+
+// applyBool 解析布尔条件
+func applyBool(t term, target **bool) error {
+	if err := requireEqual(t); err != nil {
+		return err
+	}
+	if *target != nil {
+		return duplicate(t)
+	}
+	v, err := strconv.ParseBool(t.value)
+	if err != nil {
+		return fmt.Errorf("%s 必须为 true 或 false", t.field)
+	}
+	*target = &v
+	return nil
+}
+
+// matchPriorities 返回满足优先级条件的全部优先级，按权重升序排列
+func matchPriorities(t term) ([]models.Priority, error) {
+	if t.op == ":" || t.op == "=" {
+		var matched []models.Priority
+		for _, part := range strings.Split(t.value, ",") {
+			priority, err := models.ParsePriority(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(matched, priority) {
+				matched = append(matched, priority)
+			}
+		}
+		return matched, nil
+	}
+
+	bound, err := models.ParsePriority(t.value)
+	if err != nil {
+		return nil, err
+	}
+	var matched []models.Priority
+	for _, priority := range models.Priorities {
+		diff := priority.Weight() - bound.Weight()
+		if (t.op == ">" && diff > 0) || (t.op == ">=" && diff >= 0) ||
+			(t.op == "<" && diff < 0) || (t.op == "<=" && diff <= 0) {
+			matched = append(matched, priority)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("没有满足 priority%s%s 的优先级", t.op, t.value)
+	}
+	return matched, nil
+}
+
+// applyTime 解析时间条件。ListOptions 的时间边界不含端点，这里将各比较符换算为不含端点的区间：
+// 值为日期时代表当天 [start, end)，值为时间时代表该时刻
+func applyTime(t term, after, before *time.Time) error {
+	start, end, err := parseTimeValue(t)
+	if err != nil {
+		return err
+	}
+
+	setAfter := func(v time.Time) error {
+		if !after.IsZero() {
+			return duplicate(t)
+		}
+		*after = v
+		return nil
+	}
+	setBefore := func(v time.Time) error {
+		if !before.IsZero() {
+			return duplicate(t)
+		}
+		*before = v
+		return nil
+	}
+
+	switch t.op {
+	case ":", "=":
+		if err := setAfter(start.Add(-time.Nanosecond)); err != nil {
+			return err
+		}
+		return setBefore(end)
+	case ">":
+		return setAfter(end.Add(-time.Nanosecond))
+	case ">=":
+		return setAfter(start.Add(-time.Nanosecond))
+	case "<":
+		return setBefore(start)
+	default: // "<="
+		return setBefore(end)
+	}
+}
+
+// parseTimeValue 解析时间条件的值，返回其代表的区间 [start, end)；值为时间时区间只包含该时刻
+func parseTimeValue(t term) (time.Time, time.Time, error) {
+	if day, err := time.Parse(dateLayout, t.value); err == nil {
+		return day, day.AddDate(0, 0, 1), nil
+	}
+	moment, err := time.Parse(time.RFC3339, t.value)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s 的值必须为 YYYY-MM-DD 格式的日期或 RFC3339 格式的时间", t.field)
+	}
+	return moment, moment.Add(time.Nanosecond), nil
+}
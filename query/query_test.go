@@ -0,0 +1,132 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+	"go-todolist/storage"
+)
+
+// utc 返回 UTC 时间，nanos 为附加的纳秒偏移
+func utc(year int, month time.Month, day, hour int, nanos time.Duration) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC).Add(nanos)
+}
+
+// inUTC 将 opts 中的时间统一转换为 UTC，便于与期望值比较
+func inUTC(opts storage.ListOptions) storage.ListOptions {
+	for _, t := range []*time.Time{&opts.CreatedAfter, &opts.CreatedBefore, &opts.UpdatedAfter, &opts.UpdatedBefore, &opts.DueAfter, &opts.DueBefore} {
+		if !t.IsZero() {
+			*t = t.UTC()
+		}
+	}
+	return opts
+}
+
+func TestParse(t *testing.T) {
+	yes, no := true, false
+	work, none := "work", ""
+	tests := []struct {
+		query string
+		want  storage.ListOptions
+	}{
+		{"", storage.ListOptions{}},
+		{"completed:false", storage.ListOptions{Completed: &no}},
+		{"Completed=true", storage.ListOptions{Completed: &yes}},
+		{"starred:1", storage.ListOptions{Starred: &yes}},
+		{"archived:false", storage.ListOptions{Archived: &no}},
+		{"list:work", storage.ListOptions{ListID: &work}},
+		{`list:""`, storage.ListOptions{ListID: &none}},
+		{"tag:work", storage.ListOptions{Tag: "work"}},
+
+		{"priority:high,low,high", storage.ListOptions{Priorities: []models.Priority{models.PriorityHigh, models.PriorityLow}}},
+		{"priority>=high", storage.ListOptions{Priorities: []models.Priority{models.PriorityHigh, models.PriorityUrgent}}},
+		{"priority>2", storage.ListOptions{Priorities: []models.Priority{models.PriorityHigh, models.PriorityUrgent}}},
+		{"priority<medium", storage.ListOptions{Priorities: []models.Priority{models.PriorityLow}}},
+		{"priority<=medium", storage.ListOptions{Priorities: []models.Priority{models.PriorityLow, models.PriorityMedium}}},
+
+		// 日期代表 UTC 当天 [start, end)，换算为不含端点的边界
+		{"created:2024-06-01", storage.ListOptions{CreatedAfter: utc(2024, 6, 1, 0, -1), CreatedBefore: utc(2024, 6, 2, 0, 0)}},
+		{"created>2024-06-01", storage.ListOptions{CreatedAfter: utc(2024, 6, 2, 0, -1)}},
+		{"created>=2024-06-01", storage.ListOptions{CreatedAfter: utc(2024, 6, 1, 0, -1)}},
+		{"updated<2024-06-01", storage.ListOptions{UpdatedBefore: utc(2024, 6, 1, 0, 0)}},
+		{"updated<=2024-06-01", storage.ListOptions{UpdatedBefore: utc(2024, 6, 2, 0, 0)}},
+		{"created>=2024-06-01 created<2024-07-01", storage.ListOptions{CreatedAfter: utc(2024, 6, 1, 0, -1), CreatedBefore: utc(2024, 7, 1, 0, 0)}},
+		// 时间只代表该时刻
+		{"due:2024-06-01T12:00:00Z", storage.ListOptions{DueAfter: utc(2024, 6, 1, 12, -1), DueBefore: utc(2024, 6, 1, 12, 1)}},
+		{"due>2024-06-01T12:00:00+08:00", storage.ListOptions{DueAfter: utc(2024, 6, 1, 4, 0)}},
+		{"due>=2024-06-01T12:00:00Z", storage.ListOptions{DueAfter: utc(2024, 6, 1, 12, -1)}},
+		{"due<2024-06-01T12:00:00Z", storage.ListOptions{DueBefore: utc(2024, 6, 1, 12, 0)}},
+		{"due<=2024-06-01T12:00:00Z", storage.ListOptions{DueBefore: utc(2024, 6, 1, 12, 1)}},
+
+		{"design", storage.ListOptions{Query: "design"}},
+		{`"design doc"`, storage.ListOptions{Query: "design doc"}},
+		{`"tag:work"`, storage.ListOptions{Query: "tag:work"}},
+		{"  completed:true \t tag:a  ", storage.ListOptions{Completed: &yes, Tag: "a"}},
+		{`completed:false priority>=high tag:work created>=2024-06-01 "design doc"`, storage.ListOptions{
+			Completed:    &no,
+			Priorities:   []models.Priority{models.PriorityHigh, models.PriorityUrgent},
+			Tag:          "work",
+			CreatedAfter: utc(2024, 6, 1, 0, -1),
+			Query:        "design doc",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got = inUTC(got); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v\n期望 %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`"design doc`, "引号未闭合"},
+		{`tag:work "design`, "引号未闭合"},
+		{"design doc", "只能包含一个关键字"},
+		{"color:red", "未知的查询字段: color"},
+		{"completed:true completed:false", "completed 条件重复"},
+		{"completed>true", "completed 只支持 : 比较"},
+		{"completed:maybe", "completed 必须为 true 或 false"},
+		{"list>work", "list 只支持 : 比较"},
+		{"list:a list:b", "list 条件重复"},
+		{"tag:", "tag 的值不能为空"},
+		{"tag:a tag:b", "tag 条件重复"},
+		{"priority:critical", "critical"},
+		{"priority>urgent", "没有满足 priority>urgent 的优先级"},
+		{"priority:high priority:low", "priority 条件重复"},
+		{"created:2024-06-01 created>2024-01-01", "created 条件重复"},
+		{"updated<2024-06-01 updated<=2024-07-01", "updated 条件重复"},
+		{"due:tomorrow", "due 的值必须为 YYYY-MM-DD 格式的日期或 RFC3339 格式的时间"},
+		{"due>2024-06-01T12:00:00", "due 的值必须为"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) 错误 = %v，期望包含 %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+func TestApplyMerges(t *testing.T) {
+	opts := storage.ListOptions{Tag: "work"}
+	if err := Apply("completed:false", &opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Tag != "work" || opts.Completed == nil || *opts.Completed {
+		t.Errorf("合并后 = %+v，期望保留 tag 并设置 completed", opts)
+	}
+	if err := Apply("tag:home", &opts); err == nil || err.Error() != "tag 条件重复" {
+		t.Errorf("与已有条件重复时错误 = %v，期望 tag 条件重复", err)
+	}
+}
@@ -121,6 +121,11 @@ async function apiCallWithoutGlobalLoading(url, options = {}) {
   }
 }
 
+// 比较两个ID，服务端以 ID_FORMAT=string 运行时 ID 为字符串
+function sameId(a, b) {
+  return String(a) === String(b)
+}
+
 // 加载所有待办事项
 async function loadTodos() {
   try {
@@ -195,11 +200,11 @@ async function handleAddTodo(e) {
 
 // 切换完成状态
 async function toggleTodo(id) {
-  const todo = todos.find((t) => t.id === id)
+  const todo = todos.find((t) => sameId(t.id, id))
   if (!todo) return
 
   // 立即更新 UI，提供即时反馈
-  const index = todos.findIndex((t) => t.id === id)
+  const index = todos.findIndex((t) => sameId(t.id, id))
   const originalTodo = { ...todos[index] }
   todos[index].completed = !todos[index].completed
   renderTodos()
@@ -207,7 +212,7 @@ async function toggleTodo(id) {
 
   try {
     const updatedTodo = await apiCallWithoutGlobalLoading(`${API_BASE}/${id}`, {
-      method: 'PUT',
+      method: 'PATCH',
       body: JSON.stringify({ completed: !originalTodo.completed }),
     })
 
@@ -233,13 +238,13 @@ async function deleteTodo(id) {
   }
 
   // 找到要删除的待办事项
-  const todoIndex = todos.findIndex((t) => t.id === id)
+  const todoIndex = todos.findIndex((t) => sameId(t.id, id))
   if (todoIndex === -1) return
 
   const deletedTodo = todos[todoIndex]
 
   // 立即从 UI 中移除，提供即时反馈
-  todos = todos.filter((t) => t.id !== id)
+  todos = todos.filter((t) => !sameId(t.id, id))
   renderTodos()
   updateStats()
 
@@ -260,7 +265,7 @@ async function deleteTodo(id) {
 
 // 打开编辑模态框
 function openEditModal(id) {
-  const todo = todos.find((t) => t.id === id)
+  const todo = todos.find((t) => sameId(t.id, id))
   if (!todo) return
 
   editingTodoId = id
@@ -318,12 +323,12 @@ async function handleEditTodo(e) {
     submitBtn.innerHTML = '<span class="btn-spinner"></span>保存中...'
 
     const updatedTodo = await apiCallWithoutGlobalLoading(`${API_BASE}/${editingTodoId}`, {
-      method: 'PUT',
+      method: 'PATCH',
       body: JSON.stringify({ title, description, completed }),
     })
 
     // 更新本地数据
-    const index = todos.findIndex((t) => t.id === editingTodoId)
+    const index = todos.findIndex((t) => sameId(t.id, editingTodoId))
     todos[index] = updatedTodo
 
     renderTodos()
@@ -341,7 +346,7 @@ async function handleEditTodo(e) {
 
 // 打开编辑模态框
 function openEditModal(id) {
-  const todo = todos.find((t) => t.id === id)
+  const todo = todos.find((t) => sameId(t.id, id))
   if (!todo) return
 
   editingTodoId = id
@@ -377,11 +382,11 @@ async function handleEditTodo(e) {
 
   try {
     const updatedTodo = await apiCall(`${API_BASE}/${editingTodoId}`, {
-      method: 'PUT',
+      method: 'PATCH',
       body: JSON.stringify({ title, description, completed }),
     })
 
-    const index = todos.findIndex((t) => t.id === editingTodoId)
+    const index = todos.findIndex((t) => sameId(t.id, editingTodoId))
     todos[index] = updatedTodo
     renderTodos()
     updateStats()
@@ -0,0 +1,655 @@
+//go:build badger
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"go-todolist/models"
+)
+
+// Badger 中使用的键前缀，前缀后接大端序的ID以保证按ID有序
+var (
+	badgerTodoPrefix    = []byte("todo/")
+	badgerTrashPrefix   = []byte("trash/")
+	badgerVersionPrefix = []byte("version/")
+	badgerTodoSeqKey    = []byte("seq/todo")
+	badgerCommentSeqKey = []byte("seq/comment")
+)
+
+// badgerMaxRetries 写事务冲突时的最大重试次数，超过后返回 ErrTxConflict
+const badgerMaxRetries = 5
+
+// BadgerStorage 基于 BadgerDB 的本地存储，LSM 结构适合写入频繁的场景。
+// 回收站中的待办事项保存在 trash/ 前缀下，后台定期回收值日志（value log）中的过期数据
+type BadgerStorage struct {
+	db   *badger.DB
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenBadger 打开（不存在时创建）dir 目录下的 Badger 数据库，gcInterval 大于 0 时定期回收值日志
+func OpenBadger(dir string, gcInterval time.Duration) (*BadgerStorage, error) {
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		return nil, fmt.Errorf("打开 Badger 数据库失败: %w", err)
+	}
+
+	s := &BadgerStorage{db: db, stop: make(chan struct{}), done: make(chan struct{})}
+	if gcInterval > 0 {
+		go s.runGC(gcInterval)
+	} else {
+		close(s.done)
+	}
+	return s, nil
+}
+
+// runGC 每隔 interval 回收一次值日志，直到 Close
+func (s *BadgerStorage) runGC(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			// 每次调用最多回收一个文件，持续调用直到没有可回收的文件
+			for {
+				err := s.db.RunValueLogGC(0.5)
+				if err == nil {
+					continue
+				}
+				if !errors.Is(err, badger.ErrNoRewrite) {
+					log.Printf("Badger 值日志回收失败: %v", err)
+				}
+				break
+			}
+		}
+	}
+}
+
+// Close 停止后台回收并关闭数据库
+func (s *BadgerStorage) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.db.Close()
+}
+
+func (s *BadgerStorage) view(fn func(t *badgerTx) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		return fn(&badgerTx{txn: txn})
+	})
+}
+
+// update 在读写事务中执行 fn，与其他事务冲突时重试
+func (s *BadgerStorage) update(fn func(t *badgerTx) error) error {
+	for range badgerMaxRetries {
+		err := s.db.Update(func(txn *badger.Txn) error {
+			return fn(&badgerTx{txn: txn})
+		})
+		if errors.Is(err, badger.ErrConflict) {
+			continue
+		}
+		return err
+	}
+	return ErrTxConflict
+}
+
+// WithTx 在一个读写事务中执行 fn，fn 返回错误时全部回滚
+func (s *BadgerStorage) WithTx(ctx context.Context, fn func(TodoStorage) error) error {
+	return s.update(func(t *badgerTx) error {
+		return fn(t)
+	})
+}
+
+// BeginTx 开启读写事务，提交时与其他事务冲突返回 ErrTxConflict，调用方可以重试整个事务
+func (s *BadgerStorage) BeginTx(ctx context.Context) (Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &badgerBeginTx{badgerTx: &badgerTx{txn: s.db.NewTransaction(true)}, ctx: ctx}, nil
+}
+
+// badgerBeginTx BeginTx 返回的事务
+type badgerBeginTx struct {
+	*badgerTx
+	ctx context.Context
+}
+
+func (t *badgerBeginTx) Commit() error {
+	if err := t.ctx.Err(); err != nil {
+		t.txn.Discard()
+		return err
+	}
+	err := t.txn.Commit()
+	switch {
+	case errors.Is(err, badger.ErrConflict):
+		return ErrTxConflict
+	case errors.Is(err, badger.ErrDiscardedTxn):
+		return ErrTxDone
+	}
+	return err
+}
+
+func (t *badgerBeginTx) Rollback() error {
+	t.txn.Discard()
+	return nil
+}
+
+func (s *BadgerStorage) GetAll(ctx context.Context) (todos []*models.Todo, err error) {
+	err = s.view(func(t *badgerTx) error {
+		todos, err = t.GetAll(ctx)
+		return err
+	})
+	return todos, err
+}
+
+func (s *BadgerStorage) GetByID(ctx context.Context, id int) (todo *models.Todo, err error) {
+	err = s.view(func(t *badgerTx) error {
+		todo, err = t.GetByID(ctx, id)
+		return err
+	})
+	return todo, err
+}
+
+func (s *BadgerStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (todo *models.Todo, err error) {
+	err = s.update(func(t *badgerTx) error {
+		todo, err = t.Create(ctx, req)
+		return err
+	})
+	return todo, err
+}
+
+func (s *BadgerStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (todo *models.Todo, err error) {
+	err = s.update(func(t *badgerTx) error {
+		todo, err = t.Update(ctx, id, req)
+		return err
+	})
+	return todo, err
+}
+
+func (s *BadgerStorage) Delete(ctx context.Context, id int) error {
+	return s.update(func(t *badgerTx) error {
+		return t.Delete(ctx, id)
+	})
+}
+
+func (s *BadgerStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (comment *models.Comment, err error) {
+	err = s.update(func(t *badgerTx) error {
+		comment, err = t.AddComment(ctx, todoID, req)
+		return err
+	})
+	return comment, err
+}
+
+func (s *BadgerStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	return s.update(func(t *badgerTx) error {
+		return t.DeleteComment(ctx, todoID, commentID)
+	})
+}
+
+func (s *BadgerStorage) Trash(ctx context.Context) (todos []*models.Todo, err error) {
+	err = s.view(func(t *badgerTx) error {
+		todos, err = t.Trash(ctx)
+		return err
+	})
+	return todos, err
+}
+
+func (s *BadgerStorage) PurgeTrash(ctx context.Context, before time.Time) (purged int, err error) {
+	err = s.update(func(t *badgerTx) error {
+		purged, err = t.PurgeTrash(ctx, before)
+		return err
+	})
+	return purged, err
+}
+
+func (s *BadgerStorage) Restore(ctx context.Context, id int) (todo *models.Todo, err error) {
+	err = s.update(func(t *badgerTx) error {
+		todo, err = t.Restore(ctx, id)
+		return err
+	})
+	return todo, err
+}
+
+func (s *BadgerStorage) Clear(ctx context.Context) (count int, err error) {
+	err = s.update(func(t *badgerTx) error {
+		count, err = t.Clear(ctx)
+		return err
+	})
+	return count, err
+}
+
+func (s *BadgerStorage) History(ctx context.Context, id int) (versions []models.TodoVersion, err error) {
+	err = s.view(func(t *badgerTx) error {
+		versions, err = t.History(ctx, id)
+		return err
+	})
+	return versions, err
+}
+
+func (s *BadgerStorage) FindByExternalID(ctx context.Context, externalID string) (todos []*models.Todo, err error) {
+	err = s.view(func(t *badgerTx) error {
+		todos, err = t.FindByExternalID(ctx, externalID)
+		return err
+	})
+	return todos, err
+}
+
+func (s *BadgerStorage) Export(ctx context.Context) (snapshot *Snapshot, err error) {
+	err = s.view(func(t *badgerTx) error {
+		snapshot, err = t.Export(ctx)
+		return err
+	})
+	return snapshot, err
+}
+
+func (s *BadgerStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	return s.update(func(t *badgerTx) error {
+		return t.Import(ctx, snapshot)
+	})
+}
+
+// badgerTx 在一个 Badger 事务内操作数据，实现 TodoStorage 供 WithTx 使用
+type badgerTx struct {
+	txn *badger.Txn
+}
+
+// badgerKey 拼接前缀与大端序的ID
+func badgerKey(prefix []byte, id int) []byte {
+	key := make([]byte, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], uint64(id))
+	return key
+}
+
+// get 读取 key 的值，不存在时返回 nil
+func (t *badgerTx) get(key []byte) ([]byte, error) {
+	item, err := t.txn.Get(key)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+// sequence 返回 key 对应计数器的当前值
+func (t *badgerTx) sequence(key []byte) (int, error) {
+	current, err := t.get(key)
+	if err != nil || current == nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint64(current)), nil
+}
+
+// setSequence 设置 key 对应的计数器
+func (t *badgerTx) setSequence(key []byte, value int) error {
+	encoded := make([]byte, 8)
+	binary.BigEndian.PutUint64(encoded, uint64(value))
+	return t.txn.Set(key, encoded)
+}
+
+// nextSequence 递增并返回 key 对应的计数器
+func (t *badgerTx) nextSequence(key []byte) (int, error) {
+	current, err := t.sequence(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := t.setSequence(key, current+1); err != nil {
+		return 0, err
+	}
+	return current + 1, nil
+}
+
+func (t *badgerTx) put(todo *models.Todo) error {
+	return t.putIn(badgerTodoPrefix, todo)
+}
+
+// putIn 将待办事项写入 prefix 前缀下
+func (t *badgerTx) putIn(prefix []byte, todo *models.Todo) error {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+	return t.txn.Set(badgerKey(prefix, int(todo.ID)), data)
+}
+
+func (t *badgerTx) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	return t.all(badgerTodoPrefix)
+}
+
+// all 按ID升序返回 prefix 前缀下的待办事项
+func (t *badgerTx) all(prefix []byte) ([]*models.Todo, error) {
+	it := t.txn.NewIterator(badger.IteratorOptions{Prefix: prefix, PrefetchValues: true, PrefetchSize: 100})
+	defer it.Close()
+
+	todos := make([]*models.Todo, 0)
+	for it.Rewind(); it.Valid(); it.Next() {
+		var todo models.Todo
+		err := it.Item().Value(func(v []byte) error {
+			return json.Unmarshal(v, &todo)
+		})
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, &todo)
+	}
+	return todos, nil
+}
+
+func (t *badgerTx) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	return t.load(badgerTodoPrefix, id)
+}
+
+// load 返回 prefix 前缀下的待办事项
+func (t *badgerTx) load(prefix []byte, id int) (*models.Todo, error) {
+	data, err := t.get(badgerKey(prefix, id))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrTodoNotFound
+	}
+	var todo models.Todo
+	if err := json.Unmarshal(data, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (t *badgerTx) FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error) {
+	todos, err := t.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]*models.Todo, 0)
+	for _, todo := range todos {
+		if todo.ExternalID == externalID {
+			matched = append(matched, todo)
+		}
+	}
+	return matched, nil
+}
+
+func (t *badgerTx) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	id, err := t.nextSequence(badgerTodoSeqKey)
+	if err != nil {
+		return nil, err
+	}
+	todo := newTodo(id, req, time.Now())
+	if err := t.put(todo); err != nil {
+		return nil, err
+	}
+	if err := t.recordVersion(todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (t *badgerTx) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	todo, err := t.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	applyUpdate(todo, req, time.Now())
+	if err := t.put(todo); err != nil {
+		return nil, err
+	}
+	if err := t.recordVersion(todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// Delete 将待办事项移入回收站并删除其历史版本
+func (t *badgerTx) Delete(ctx context.Context, id int) error {
+	todo, err := t.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	todo.DeletedAt = &now
+	if err := t.putIn(badgerTrashPrefix, todo); err != nil {
+		return err
+	}
+	if err := t.txn.Delete(badgerKey(badgerTodoPrefix, id)); err != nil {
+		return err
+	}
+	return t.txn.Delete(badgerKey(badgerVersionPrefix, id))
+}
+
+func (t *badgerTx) Trash(ctx context.Context) ([]*models.Todo, error) {
+	todos, err := t.all(badgerTrashPrefix)
+	if err != nil {
+		return nil, err
+	}
+	sortTrash(todos)
+	return todos, nil
+}
+
+func (t *badgerTx) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	todos, err := t.all(badgerTrashPrefix)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, todo := range todos {
+		if !before.IsZero() && !todo.DeletedAt.Before(before) {
+			continue
+		}
+		if err := t.txn.Delete(badgerKey(badgerTrashPrefix, int(todo.ID))); err != nil {
+			return 0, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func (t *badgerTx) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	todo, err := t.load(badgerTrashPrefix, id)
+	if errors.Is(err, ErrTodoNotFound) {
+		live, err := t.get(badgerKey(badgerTodoPrefix, id))
+		if err != nil {
+			return nil, err
+		}
+		lastID, err := t.sequence(badgerTodoSeqKey)
+		if err != nil {
+			return nil, err
+		}
+		return nil, restoreError(id, live != nil, lastID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	todo.DeletedAt = nil
+	todo.UpdatedAt = time.Now()
+	if err := t.txn.Delete(badgerKey(badgerTrashPrefix, id)); err != nil {
+		return nil, err
+	}
+	if err := t.put(todo); err != nil {
+		return nil, err
+	}
+	if err := t.recordVersion(todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (t *badgerTx) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	todo, err := t.GetByID(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := t.nextSequence(badgerCommentSeqKey)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := models.Comment{ID: models.ID(id), Body: req.Body, CreatedAt: time.Now()}
+	todo.Comments = append(todo.Comments, comment)
+	if err := t.put(todo); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (t *badgerTx) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	todo, err := t.GetByID(ctx, todoID)
+	if err != nil {
+		return err
+	}
+	for i, comment := range todo.Comments {
+		if comment.ID == models.ID(commentID) {
+			todo.Comments = append(todo.Comments[:i], todo.Comments[i+1:]...)
+			return t.put(todo)
+		}
+	}
+	return ErrCommentNotFound
+}
+
+// deletePrefix 删除所有以 prefix 开头的键，返回删除的数量
+func (t *badgerTx) deletePrefix(prefix []byte) (int, error) {
+	it := t.txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+	var keys [][]byte
+	for it.Rewind(); it.Valid(); it.Next() {
+		keys = append(keys, it.Item().KeyCopy(nil))
+	}
+	it.Close()
+
+	for _, key := range keys {
+		if err := t.txn.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// Clear 在同一事务中删除全部数据并重置ID序列；数据量很大时可能超出单个事务的大小限制
+func (t *badgerTx) Clear(ctx context.Context) (int, error) {
+	count, err := t.deletePrefix(badgerTodoPrefix)
+	if err != nil {
+		return 0, err
+	}
+	for _, prefix := range [][]byte{badgerTrashPrefix, badgerVersionPrefix} {
+		if _, err := t.deletePrefix(prefix); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := t.deletePrefix([]byte("seq/")); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (t *badgerTx) History(ctx context.Context, id int) ([]models.TodoVersion, error) {
+	if _, err := t.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return t.versions(id)
+}
+
+func (t *badgerTx) versions(id int) ([]models.TodoVersion, error) {
+	versions := []models.TodoVersion{}
+	data, err := t.get(badgerKey(badgerVersionPrefix, id))
+	if err != nil || data == nil {
+		return versions, err
+	}
+	err = json.Unmarshal(data, &versions)
+	return versions, err
+}
+
+// recordVersion 追加新版本，超出保留数量时丢弃最旧的版本
+func (t *badgerTx) recordVersion(todo *models.Todo) error {
+	versions, err := t.versions(int(todo.ID))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(appendVersion(versions, todo))
+	if err != nil {
+		return err
+	}
+	return t.txn.Set(badgerKey(badgerVersionPrefix, int(todo.ID)), data)
+}
+
+func (t *badgerTx) Export(ctx context.Context) (*Snapshot, error) {
+	todos, err := t.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lastID, err := t.sequence(badgerTodoSeqKey)
+	if err != nil {
+		return nil, err
+	}
+	lastCommentID, err := t.sequence(badgerCommentSeqKey)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Format:        SnapshotFormat,
+		ExportedAt:    time.Now(),
+		NextID:        lastID + 1,
+		NextCommentID: lastCommentID + 1,
+		Todos:         todos,
+		History:       make(map[int][]models.TodoVersion, len(todos)),
+	}
+	if snapshot.Trash, err = t.all(badgerTrashPrefix); err != nil {
+		return nil, err
+	}
+	for _, todo := range todos {
+		if snapshot.History[int(todo.ID)], err = t.versions(int(todo.ID)); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, snapshot.normalize()
+}
+
+// Import 在同一事务中替换全部数据；数据量很大时可能超出单个事务的大小限制
+func (t *badgerTx) Import(ctx context.Context, snapshot *Snapshot) error {
+	if err := snapshot.normalize(); err != nil {
+		return err
+	}
+	if _, err := t.Clear(ctx); err != nil {
+		return err
+	}
+
+	for _, todo := range snapshot.Todos {
+		if err := t.put(todo); err != nil {
+			return err
+		}
+		versions := snapshot.History[int(todo.ID)]
+		if len(versions) == 0 {
+			continue
+		}
+		data, err := json.Marshal(versions)
+		if err != nil {
+			return err
+		}
+		if err := t.txn.Set(badgerKey(badgerVersionPrefix, int(todo.ID)), data); err != nil {
+			return err
+		}
+	}
+	for _, todo := range snapshot.Trash {
+		if err := t.putIn(badgerTrashPrefix, todo); err != nil {
+			return err
+		}
+	}
+	if err := t.setSequence(badgerTodoSeqKey, snapshot.NextID-1); err != nil {
+		return err
+	}
+	return t.setSequence(badgerCommentSeqKey, snapshot.NextCommentID-1)
+}
@@ -0,0 +1,18 @@
+//go:build !badger
+
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// BadgerStorage 未带 badger 构建标签时的占位类型，实际实现见 badger.go
+type BadgerStorage struct {
+	TodoStorage
+}
+
+// OpenBadger 未带 badger 构建标签时不可用
+func OpenBadger(dir string, gcInterval time.Duration) (*BadgerStorage, error) {
+	return nil, errors.New("Badger 存储需要使用 -tags badger 构建")
+}
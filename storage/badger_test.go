@@ -0,0 +1,32 @@
+//go:build badger
+
+package storage
+
+import "testing"
+
+// openTestBadger 在临时目录中打开 Badger 存储，不启动后台回收
+func openTestBadger(tb testing.TB) *BadgerStorage {
+	tb.Helper()
+	s, err := OpenBadger(tb.TempDir(), 0)
+	if err != nil {
+		tb.Fatalf("打开 Badger 存储失败: %v", err)
+	}
+	tb.Cleanup(func() { s.Close() })
+	return s
+}
+
+func init() {
+	benchBackends = append(benchBackends, benchBackend{"badger", func(b *testing.B) TodoStorage { return openTestBadger(b) }})
+}
+
+func TestBadgerTrash(t *testing.T) {
+	testTrash(t, openTestBadger(t))
+}
+
+func TestBadgerRestore(t *testing.T) {
+	testRestore(t, openTestBadger(t))
+}
+
+func TestBadgerClear(t *testing.T) {
+	testClear(t, openTestBadger(t))
+}
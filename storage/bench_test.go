@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+// benchBackend 参与对比基准测试的存储
+type benchBackend struct {
+	name string
+	open func(b *testing.B) TodoStorage
+}
+
+// benchBackends 参与对比基准测试的存储，带构建标签的存储在各自的测试文件中追加，例如
+// go test -tags 'badger bbolt' -run '^$' -bench 'Create|GetAll' ./storage
+var benchBackends = []benchBackend{
+	{"memory", func(*testing.B) TodoStorage { return NewMemoryStorage() }},
+}
+
+// benchSnapshot 生成包含 n 个待办事项的快照，用一次导入填充存储，避免逐条创建拖慢准备阶段
+func benchSnapshot(n int) *Snapshot {
+	now := time.Now()
+	snapshot := &Snapshot{Todos: make([]*models.Todo, n)}
+	for i := range snapshot.Todos {
+		snapshot.Todos[i] = &models.Todo{
+			ID:        models.ID(i + 1),
+			Title:     fmt.Sprintf("todo %d", i),
+			Tags:      []string{"work"},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+	return snapshot
+}
+
+func BenchmarkCreate(b *testing.B) {
+	ctx := context.Background()
+	req := &models.CreateTodoRequest{Title: "bench", Description: "benchmark todo", Tags: []string{"work"}}
+	for _, backend := range benchBackends {
+		b.Run(backend.name, func(b *testing.B) {
+			s := backend.open(b)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Create(ctx, req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetAll(b *testing.B) {
+	ctx := context.Background()
+	for _, backend := range benchBackends {
+		for _, n := range []int{100, 10_000} {
+			b.Run(fmt.Sprintf("%s/n=%d", backend.name, n), func(b *testing.B) {
+				s := backend.open(b)
+				if err := s.Import(ctx, benchSnapshot(n)); err != nil {
+					b.Fatalf("填充数据失败: %v", err)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					todos, err := s.GetAll(ctx)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if len(todos) != n {
+						b.Fatalf("返回 %d 项，期望 %d 项", len(todos), n)
+					}
+				}
+			})
+		}
+	}
+}
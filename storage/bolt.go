@@ -0,0 +1,568 @@
+//go:build bbolt
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"go-todolist/models"
+)
+
+// bbolt 中使用的桶
+var (
+	boltTodosBucket     = []byte("todos")
+	boltTrashBucket     = []byte("trash")
+	boltVersionsBucket  = []byte("versions")
+	boltSequencesBucket = []byte("sequences")
+)
+
+// boltBuckets 数据库中的全部桶
+var boltBuckets = [][]byte{boltTodosBucket, boltTrashBucket, boltVersionsBucket, boltSequencesBucket}
+
+// sequences 桶中的计数器键
+var (
+	boltTodoSeqKey    = []byte("todo")
+	boltCommentSeqKey = []byte("comment")
+)
+
+// BoltStorage 基于 bbolt 的本地文件存储。待办事项（含备注）以 JSON 保存在 todos 桶中，
+// 键为大端序的ID以保证按ID有序；回收站中的待办事项以相同的键保存在 trash 桶；
+// 历史版本保存在 versions 桶；ID序列保存在独立的 sequences 桶
+type BoltStorage struct {
+	db *bolt.DB
+}
+
+// OpenBolt 打开（不存在时创建）path 指定的 bbolt 数据库文件
+func OpenBolt(path string) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("打开 bbolt 数据库失败: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range boltBuckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化 bbolt 数据库失败: %w", err)
+	}
+	return &BoltStorage{db: db}, nil
+}
+
+// Close 关闭数据库文件
+func (s *BoltStorage) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltStorage) view(fn func(t *boltTx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (s *BoltStorage) update(fn func(t *boltTx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+// WithTx 在一个读写事务中执行 fn，fn 返回错误时全部回滚
+func (s *BoltStorage) WithTx(ctx context.Context, fn func(TodoStorage) error) error {
+	return s.update(func(t *boltTx) error {
+		return fn(t)
+	})
+}
+
+// BeginTx 开启读写事务。bbolt 同一时间只允许一个读写事务，提交或回滚之前其他写操作都会阻塞
+func (s *BoltStorage) BeginTx(ctx context.Context) (Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	return &boltBeginTx{boltTx: &boltTx{tx: tx}, ctx: ctx}, nil
+}
+
+// boltBeginTx BeginTx 返回的事务
+type boltBeginTx struct {
+	*boltTx
+	ctx context.Context
+}
+
+func (t *boltBeginTx) Commit() error {
+	if err := t.ctx.Err(); err != nil {
+		t.tx.Rollback()
+		return err
+	}
+	err := t.tx.Commit()
+	if errors.Is(err, bolt.ErrTxClosed) {
+		return ErrTxDone
+	}
+	return err
+}
+
+func (t *boltBeginTx) Rollback() error {
+	err := t.tx.Rollback()
+	if errors.Is(err, bolt.ErrTxClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *BoltStorage) GetAll(ctx context.Context) (todos []*models.Todo, err error) {
+	err = s.view(func(t *boltTx) error {
+		todos, err = t.GetAll(ctx)
+		return err
+	})
+	return todos, err
+}
+
+func (s *BoltStorage) GetByID(ctx context.Context, id int) (todo *models.Todo, err error) {
+	err = s.view(func(t *boltTx) error {
+		todo, err = t.GetByID(ctx, id)
+		return err
+	})
+	return todo, err
+}
+
+func (s *BoltStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (todo *models.Todo, err error) {
+	err = s.update(func(t *boltTx) error {
+		todo, err = t.Create(ctx, req)
+		return err
+	})
+	return todo, err
+}
+
+func (s *BoltStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (todo *models.Todo, err error) {
+	err = s.update(func(t *boltTx) error {
+		todo, err = t.Update(ctx, id, req)
+		return err
+	})
+	return todo, err
+}
+
+func (s *BoltStorage) Delete(ctx context.Context, id int) error {
+	return s.update(func(t *boltTx) error {
+		return t.Delete(ctx, id)
+	})
+}
+
+func (s *BoltStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (comment *models.Comment, err error) {
+	err = s.update(func(t *boltTx) error {
+		comment, err = t.AddComment(ctx, todoID, req)
+		return err
+	})
+	return comment, err
+}
+
+func (s *BoltStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	return s.update(func(t *boltTx) error {
+		return t.DeleteComment(ctx, todoID, commentID)
+	})
+}
+
+func (s *BoltStorage) Trash(ctx context.Context) (todos []*models.Todo, err error) {
+	err = s.view(func(t *boltTx) error {
+		todos, err = t.Trash(ctx)
+		return err
+	})
+	return todos, err
+}
+
+func (s *BoltStorage) PurgeTrash(ctx context.Context, before time.Time) (purged int, err error) {
+	err = s.update(func(t *boltTx) error {
+		purged, err = t.PurgeTrash(ctx, before)
+		return err
+	})
+	return purged, err
+}
+
+func (s *BoltStorage) Restore(ctx context.Context, id int) (todo *models.Todo, err error) {
+	err = s.update(func(t *boltTx) error {
+		todo, err = t.Restore(ctx, id)
+		return err
+	})
+	return todo, err
+}
+
+func (s *BoltStorage) Clear(ctx context.Context) (count int, err error) {
+	err = s.update(func(t *boltTx) error {
+		count, err = t.Clear(ctx)
+		return err
+	})
+	return count, err
+}
+
+func (s *BoltStorage) History(ctx context.Context, id int) (versions []models.TodoVersion, err error) {
+	err = s.view(func(t *boltTx) error {
+		versions, err = t.History(ctx, id)
+		return err
+	})
+	return versions, err
+}
+
+func (s *BoltStorage) FindByExternalID(ctx context.Context, externalID string) (todos []*models.Todo, err error) {
+	err = s.view(func(t *boltTx) error {
+		todos, err = t.FindByExternalID(ctx, externalID)
+		return err
+	})
+	return todos, err
+}
+
+func (s *BoltStorage) Export(ctx context.Context) (snapshot *Snapshot, err error) {
+	err = s.view(func(t *boltTx) error {
+		snapshot, err = t.Export(ctx)
+		return err
+	})
+	return snapshot, err
+}
+
+func (s *BoltStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	return s.update(func(t *boltTx) error {
+		return t.Import(ctx, snapshot)
+	})
+}
+
+// boltTx 在一个 bbolt 事务内操作数据，实现 TodoStorage 供 WithTx 使用
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+// boltKey 将ID编码为大端序键，使遍历顺序与ID顺序一致
+func boltKey(id int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(id))
+	return key
+}
+
+// sequence 返回 sequences 桶中 key 对应计数器的当前值
+func (t *boltTx) sequence(key []byte) int {
+	if v := t.tx.Bucket(boltSequencesBucket).Get(key); v != nil {
+		return int(binary.BigEndian.Uint64(v))
+	}
+	return 0
+}
+
+// setSequence 设置 sequences 桶中 key 对应的计数器
+func (t *boltTx) setSequence(key []byte, value int) error {
+	encoded := make([]byte, 8)
+	binary.BigEndian.PutUint64(encoded, uint64(value))
+	return t.tx.Bucket(boltSequencesBucket).Put(key, encoded)
+}
+
+// nextSequence 递增并返回 sequences 桶中 key 对应的计数器
+func (t *boltTx) nextSequence(key []byte) (int, error) {
+	next := t.sequence(key) + 1
+	if err := t.setSequence(key, next); err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+func (t *boltTx) put(todo *models.Todo) error {
+	return t.putIn(boltTodosBucket, todo)
+}
+
+// putIn 将待办事项写入 bucket 指定的桶
+func (t *boltTx) putIn(bucket []byte, todo *models.Todo) error {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+	return t.tx.Bucket(bucket).Put(boltKey(int(todo.ID)), data)
+}
+
+func (t *boltTx) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	return t.all(boltTodosBucket)
+}
+
+// all 按ID升序返回 bucket 指定的桶中的待办事项
+func (t *boltTx) all(bucket []byte) ([]*models.Todo, error) {
+	todos := make([]*models.Todo, 0)
+	err := t.tx.Bucket(bucket).ForEach(func(_, v []byte) error {
+		var todo models.Todo
+		if err := json.Unmarshal(v, &todo); err != nil {
+			return err
+		}
+		todos = append(todos, &todo)
+		return nil
+	})
+	return todos, err
+}
+
+func (t *boltTx) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	return t.get(boltTodosBucket, id)
+}
+
+// get 返回 bucket 指定的桶中的待办事项
+func (t *boltTx) get(bucket []byte, id int) (*models.Todo, error) {
+	data := t.tx.Bucket(bucket).Get(boltKey(id))
+	if data == nil {
+		return nil, ErrTodoNotFound
+	}
+	var todo models.Todo
+	if err := json.Unmarshal(data, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (t *boltTx) FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error) {
+	todos, err := t.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]*models.Todo, 0)
+	for _, todo := range todos {
+		if todo.ExternalID == externalID {
+			matched = append(matched, todo)
+		}
+	}
+	return matched, nil
+}
+
+func (t *boltTx) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	id, err := t.nextSequence(boltTodoSeqKey)
+	if err != nil {
+		return nil, err
+	}
+	todo := newTodo(id, req, time.Now())
+	if err := t.put(todo); err != nil {
+		return nil, err
+	}
+	if err := t.recordVersion(todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (t *boltTx) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	todo, err := t.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	applyUpdate(todo, req, time.Now())
+	if err := t.put(todo); err != nil {
+		return nil, err
+	}
+	if err := t.recordVersion(todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// Delete 将待办事项移入回收站并删除其历史版本
+func (t *boltTx) Delete(ctx context.Context, id int) error {
+	todo, err := t.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	todo.DeletedAt = &now
+	if err := t.putIn(boltTrashBucket, todo); err != nil {
+		return err
+	}
+	if err := t.tx.Bucket(boltTodosBucket).Delete(boltKey(id)); err != nil {
+		return err
+	}
+	return t.tx.Bucket(boltVersionsBucket).Delete(boltKey(id))
+}
+
+func (t *boltTx) Trash(ctx context.Context) ([]*models.Todo, error) {
+	todos, err := t.all(boltTrashBucket)
+	if err != nil {
+		return nil, err
+	}
+	sortTrash(todos)
+	return todos, nil
+}
+
+func (t *boltTx) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	todos, err := t.all(boltTrashBucket)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, todo := range todos {
+		if !before.IsZero() && !todo.DeletedAt.Before(before) {
+			continue
+		}
+		if err := t.tx.Bucket(boltTrashBucket).Delete(boltKey(int(todo.ID))); err != nil {
+			return 0, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func (t *boltTx) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	todo, err := t.get(boltTrashBucket, id)
+	if errors.Is(err, ErrTodoNotFound) {
+		live := t.tx.Bucket(boltTodosBucket).Get(boltKey(id)) != nil
+		return nil, restoreError(id, live, t.sequence(boltTodoSeqKey))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	todo.DeletedAt = nil
+	todo.UpdatedAt = time.Now()
+	if err := t.tx.Bucket(boltTrashBucket).Delete(boltKey(id)); err != nil {
+		return nil, err
+	}
+	if err := t.put(todo); err != nil {
+		return nil, err
+	}
+	if err := t.recordVersion(todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (t *boltTx) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	todo, err := t.GetByID(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := t.nextSequence(boltCommentSeqKey)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := models.Comment{ID: models.ID(id), Body: req.Body, CreatedAt: time.Now()}
+	todo.Comments = append(todo.Comments, comment)
+	if err := t.put(todo); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (t *boltTx) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	todo, err := t.GetByID(ctx, todoID)
+	if err != nil {
+		return err
+	}
+	for i, comment := range todo.Comments {
+		if comment.ID == models.ID(commentID) {
+			todo.Comments = append(todo.Comments[:i], todo.Comments[i+1:]...)
+			return t.put(todo)
+		}
+	}
+	return ErrCommentNotFound
+}
+
+func (t *boltTx) Clear(ctx context.Context) (int, error) {
+	count := t.tx.Bucket(boltTodosBucket).Stats().KeyN
+	for _, name := range boltBuckets {
+		if err := t.tx.DeleteBucket(name); err != nil {
+			return 0, err
+		}
+		if _, err := t.tx.CreateBucket(name); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+func (t *boltTx) History(ctx context.Context, id int) ([]models.TodoVersion, error) {
+	if t.tx.Bucket(boltTodosBucket).Get(boltKey(id)) == nil {
+		return nil, ErrTodoNotFound
+	}
+	return t.versions(id)
+}
+
+func (t *boltTx) versions(id int) ([]models.TodoVersion, error) {
+	versions := []models.TodoVersion{}
+	data := t.tx.Bucket(boltVersionsBucket).Get(boltKey(id))
+	if data == nil {
+		return versions, nil
+	}
+	err := json.Unmarshal(data, &versions)
+	return versions, err
+}
+
+// recordVersion 追加新版本，超出保留数量时丢弃最旧的版本
+func (t *boltTx) recordVersion(todo *models.Todo) error {
+	versions, err := t.versions(int(todo.ID))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(appendVersion(versions, todo))
+	if err != nil {
+		return err
+	}
+	return t.tx.Bucket(boltVersionsBucket).Put(boltKey(int(todo.ID)), data)
+}
+
+func (t *boltTx) Export(ctx context.Context) (*Snapshot, error) {
+	todos, err := t.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{
+		Format:        SnapshotFormat,
+		ExportedAt:    time.Now(),
+		NextID:        t.sequence(boltTodoSeqKey) + 1,
+		NextCommentID: t.sequence(boltCommentSeqKey) + 1,
+		Todos:         todos,
+		History:       make(map[int][]models.TodoVersion, len(todos)),
+	}
+	if snapshot.Trash, err = t.all(boltTrashBucket); err != nil {
+		return nil, err
+	}
+	for _, todo := range todos {
+		if snapshot.History[int(todo.ID)], err = t.versions(int(todo.ID)); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, snapshot.normalize()
+}
+
+func (t *boltTx) Import(ctx context.Context, snapshot *Snapshot) error {
+	if err := snapshot.normalize(); err != nil {
+		return err
+	}
+	if _, err := t.Clear(ctx); err != nil {
+		return err
+	}
+
+	for _, todo := range snapshot.Todos {
+		if err := t.put(todo); err != nil {
+			return err
+		}
+		versions := snapshot.History[int(todo.ID)]
+		if len(versions) == 0 {
+			continue
+		}
+		data, err := json.Marshal(versions)
+		if err != nil {
+			return err
+		}
+		if err := t.tx.Bucket(boltVersionsBucket).Put(boltKey(int(todo.ID)), data); err != nil {
+			return err
+		}
+	}
+	for _, todo := range snapshot.Trash {
+		if err := t.putIn(boltTrashBucket, todo); err != nil {
+			return err
+		}
+	}
+	if err := t.setSequence(boltTodoSeqKey, snapshot.NextID-1); err != nil {
+		return err
+	}
+	return t.setSequence(boltCommentSeqKey, snapshot.NextCommentID-1)
+}
@@ -0,0 +1,15 @@
+//go:build !bbolt
+
+package storage
+
+import "errors"
+
+// BoltStorage 未带 bbolt 构建标签时的占位类型，实际实现见 bolt.go
+type BoltStorage struct {
+	TodoStorage
+}
+
+// OpenBolt 未带 bbolt 构建标签时不可用
+func OpenBolt(path string) (*BoltStorage, error) {
+	return nil, errors.New("bbolt 存储需要使用 -tags bbolt 构建")
+}
@@ -0,0 +1,35 @@
+//go:build bbolt
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// openTestBolt 在临时目录中打开 bbolt 存储
+func openTestBolt(tb testing.TB) *BoltStorage {
+	tb.Helper()
+	s, err := OpenBolt(filepath.Join(tb.TempDir(), "todos.db"))
+	if err != nil {
+		tb.Fatalf("打开 bbolt 存储失败: %v", err)
+	}
+	tb.Cleanup(func() { s.Close() })
+	return s
+}
+
+func init() {
+	benchBackends = append(benchBackends, benchBackend{"bbolt", func(b *testing.B) TodoStorage { return openTestBolt(b) }})
+}
+
+func TestBoltTrash(t *testing.T) {
+	testTrash(t, openTestBolt(t))
+}
+
+func TestBoltRestore(t *testing.T) {
+	testRestore(t, openTestBolt(t))
+}
+
+func TestBoltClear(t *testing.T) {
+	testClear(t, openTestBolt(t))
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go-todolist/models"
+)
+
+// CachedStorage 在任意存储外缓存 GetAll 和 GetByID 的结果，任何写操作都会清空缓存。
+// 缓存只在本进程内失效，多个实例共享同一数据库时，其他实例的修改最多在 ttl 之后可见
+type CachedStorage struct {
+	TodoStorage
+
+	ttl time.Duration
+
+	mutex sync.Mutex
+	// generation 每次清空缓存时递增，读取期间发生过写入时不写回缓存，避免缓存旧数据
+	generation uint64
+	all        *cachedTodos
+	byID       map[int]cachedTodo
+}
+
+type cachedTodos struct {
+	todos   []*models.Todo
+	expires time.Time
+}
+
+type cachedTodo struct {
+	todo    *models.Todo
+	expires time.Time
+}
+
+// NewCached 创建缓存存储，ttl 为缓存的有效期，不大于 0 时缓存只在写入时失效
+func NewCached(inner TodoStorage, ttl time.Duration) *CachedStorage {
+	return &CachedStorage{
+		TodoStorage: inner,
+		ttl:         ttl,
+		byID:        make(map[int]cachedTodo),
+	}
+}
+
+// expired 判断在 expires 过期的缓存是否已失效
+func (s *CachedStorage) expired(expires time.Time) bool {
+	return s.ttl > 0 && time.Now().After(expires)
+}
+
+// invalidate 清空缓存
+func (s *CachedStorage) invalidate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.generation++
+	s.all = nil
+	s.byID = make(map[int]cachedTodo)
+}
+
+// Invalidate 清空缓存，供绕过缓存直接修改内部存储（如从回收站恢复）的调用方使用
+func (s *CachedStorage) Invalidate() {
+	s.invalidate()
+}
+
+// GetAll 获取所有待办事项，缓存未命中时从内层存储读取。返回的待办事项都是副本
+func (s *CachedStorage) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	s.mutex.Lock()
+	if s.all != nil && !s.expired(s.all.expires) {
+		todos := cloneTodos(s.all.todos)
+		s.mutex.Unlock()
+		return todos, nil
+	}
+	generation := s.generation
+	s.mutex.Unlock()
+
+	todos, err := s.TodoStorage.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.generation == generation {
+		s.all = &cachedTodos{todos: cloneTodos(todos), expires: time.Now().Add(s.ttl)}
+	}
+	return todos, nil
+}
+
+// GetByID 根据ID获取待办事项，缓存未命中时从内层存储读取。返回的待办事项是副本
+func (s *CachedStorage) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	s.mutex.Lock()
+	if cached, ok := s.byID[id]; ok && !s.expired(cached.expires) {
+		todo := cloneTodo(cached.todo)
+		s.mutex.Unlock()
+		return todo, nil
+	}
+	generation := s.generation
+	s.mutex.Unlock()
+
+	todo, err := s.TodoStorage.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.generation == generation {
+		s.byID[id] = cachedTodo{todo: cloneTodo(todo), expires: time.Now().Add(s.ttl)}
+	}
+	return todo, nil
+}
+
+// 写操作无论成功与否都清空缓存：非原子的存储在出错时可能已经写入了部分数据
+
+func (s *CachedStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	defer s.invalidate()
+	return s.TodoStorage.Create(ctx, req)
+}
+
+func (s *CachedStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	defer s.invalidate()
+	return s.TodoStorage.Update(ctx, id, req)
+}
+
+func (s *CachedStorage) Delete(ctx context.Context, id int) error {
+	defer s.invalidate()
+	return s.TodoStorage.Delete(ctx, id)
+}
+
+func (s *CachedStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	defer s.invalidate()
+	return s.TodoStorage.AddComment(ctx, todoID, req)
+}
+
+func (s *CachedStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	defer s.invalidate()
+	return s.TodoStorage.DeleteComment(ctx, todoID, commentID)
+}
+
+func (s *CachedStorage) Clear(ctx context.Context) (int, error) {
+	defer s.invalidate()
+	return s.TodoStorage.Clear(ctx)
+}
+
+func (s *CachedStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	defer s.invalidate()
+	return s.TodoStorage.Import(ctx, snapshot)
+}
+
+// WithTx 在内层存储的事务中执行 fn，事务内的读写不经过缓存，结束后清空缓存。
+// 内层存储不支持事务时直接执行 fn
+func (s *CachedStorage) WithTx(ctx context.Context, fn func(TodoStorage) error) error {
+	inner, ok := s.TodoStorage.(Transactional)
+	if !ok {
+		return fn(s)
+	}
+	defer s.invalidate()
+	return inner.WithTx(ctx, fn)
+}
+
+// BeginTx 开启内层存储的显式事务，提交后清空缓存
+func (s *CachedStorage) BeginTx(ctx context.Context) (Tx, error) {
+	inner, ok := s.TodoStorage.(TxBeginner)
+	if !ok {
+		return nil, fmt.Errorf("%w: 存储不支持显式事务", ErrValidation)
+	}
+	tx, err := inner.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &commitHookTx{Tx: tx, commit: func(tx Tx) error {
+		defer s.invalidate()
+		return tx.Commit()
+	}}, nil
+}
+
+// Unwrap 返回内层存储
+func (s *CachedStorage) Unwrap() TodoStorage {
+	return s.TodoStorage
+}
+
+// Close 关闭内层存储
+func (s *CachedStorage) Close() error {
+	if closer, ok := s.TodoStorage.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// cloneTodos 深拷贝待办事项列表
+func cloneTodos(todos []*models.Todo) []*models.Todo {
+	copied := make([]*models.Todo, len(todos))
+	for i, todo := range todos {
+		copied[i] = cloneTodo(todo)
+	}
+	return copied
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+// countingStorage 记录 GetAll 和 GetByID 读取内层存储的次数
+type countingStorage struct {
+	*MemoryStorage
+	reads int
+}
+
+func (s *countingStorage) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	s.reads++
+	return s.MemoryStorage.GetAll(ctx)
+}
+
+func (s *countingStorage) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	s.reads++
+	return s.MemoryStorage.GetByID(ctx, id)
+}
+
+// newCountingCache 创建包含两个待办事项的计数存储及其缓存
+func newCountingCache(t *testing.T, ttl time.Duration) (*CachedStorage, *countingStorage) {
+	t.Helper()
+	inner := &countingStorage{MemoryStorage: NewMemoryStorage()}
+	for _, title := range []string{"a", "b"} {
+		if _, err := inner.MemoryStorage.Create(context.Background(), &models.CreateTodoRequest{Title: title, Tags: []string{"x"}}); err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+	}
+	return NewCached(inner, ttl), inner
+}
+
+// readBoth 依次读取全部待办事项和 ID 为 1 的待办事项
+func readBoth(t *testing.T, s TodoStorage) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := s.GetAll(ctx); err != nil {
+		t.Fatalf("GetAll 失败: %v", err)
+	}
+	if _, err := s.GetByID(ctx, 1); err != nil {
+		t.Fatalf("GetByID 失败: %v", err)
+	}
+}
+
+func TestCachedInvalidation(t *testing.T) {
+	ctx := context.Background()
+	title := "changed"
+	tests := []struct {
+		name  string
+		write func(s *CachedStorage) error
+	}{
+		{"Create", func(s *CachedStorage) error {
+			_, err := s.Create(ctx, &models.CreateTodoRequest{Title: "c"})
+			return err
+		}},
+		{"Update", func(s *CachedStorage) error {
+			_, err := s.Update(ctx, 1, &models.UpdateTodoRequest{Title: &title})
+			return err
+		}},
+		{"Delete", func(s *CachedStorage) error { return s.Delete(ctx, 2) }},
+		{"失败的 Update", func(s *CachedStorage) error {
+			_, err := s.Update(ctx, 9, &models.UpdateTodoRequest{Title: &title})
+			if !errors.Is(err, ErrTodoNotFound) {
+				return err
+			}
+			return nil
+		}},
+		{"WithTx", func(s *CachedStorage) error {
+			return s.WithTx(ctx, func(tx TodoStorage) error {
+				_, err := tx.Update(ctx, 1, &models.UpdateTodoRequest{Title: &title})
+				return err
+			})
+		}},
+		{"BeginTx", func(s *CachedStorage) error {
+			tx, err := s.BeginTx(ctx)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+			if _, err := tx.Update(ctx, 1, &models.UpdateTodoRequest{Title: &title}); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, inner := newCountingCache(t, 0)
+			readBoth(t, s)
+			readBoth(t, s)
+			if inner.reads != 2 {
+				t.Fatalf("重复读取后内层读取 %d 次，期望 2", inner.reads)
+			}
+			if err := tt.write(s); err != nil {
+				t.Fatalf("写入失败: %v", err)
+			}
+			readBoth(t, s)
+			if inner.reads != 4 {
+				t.Errorf("写入后内层读取 %d 次，期望缓存已清空并重新读取", inner.reads)
+			}
+		})
+	}
+}
+
+func TestCachedSeesWrites(t *testing.T) {
+	s, _ := newCountingCache(t, 0)
+	ctx := context.Background()
+	readBoth(t, s)
+
+	title := "changed"
+	if err := s.WithTx(ctx, func(tx TodoStorage) error {
+		_, err := tx.Update(ctx, 1, &models.UpdateTodoRequest{Title: &title})
+		return err
+	}); err != nil {
+		t.Fatalf("事务失败: %v", err)
+	}
+	if todo, _ := s.GetByID(ctx, 1); todo.Title != title {
+		t.Errorf("事务提交后标题 = %q，期望 %q", todo.Title, title)
+	}
+	if todos, _ := s.GetAll(ctx); todos[0].Title != title {
+		t.Errorf("事务提交后列表中的标题 = %q，期望 %q", todos[0].Title, title)
+	}
+}
+
+func TestCachedTTL(t *testing.T) {
+	s, inner := newCountingCache(t, 20*time.Millisecond)
+	readBoth(t, s)
+	readBoth(t, s)
+	if inner.reads != 2 {
+		t.Fatalf("有效期内内层读取 %d 次，期望 2", inner.reads)
+	}
+	time.Sleep(30 * time.Millisecond)
+	readBoth(t, s)
+	if inner.reads != 4 {
+		t.Errorf("过期后内层读取 %d 次，期望 4", inner.reads)
+	}
+}
+
+func TestCachedDefensiveCopies(t *testing.T) {
+	s, _ := newCountingCache(t, 0)
+	ctx := context.Background()
+
+	// 未命中和命中时返回的都是副本，修改它们不影响缓存
+	for range 2 {
+		todos, _ := s.GetAll(ctx)
+		todos[0].Title = "changed"
+		todos[0].Tags[0] = "changed"
+		todo, _ := s.GetByID(ctx, 2)
+		todo.Title = "changed"
+		todo.Tags[0] = "changed"
+	}
+
+	todos, _ := s.GetAll(ctx)
+	if todos[0].Title != "a" || todos[0].Tags[0] != "x" {
+		t.Errorf("列表中的待办事项 = %q %v，期望 a [x]", todos[0].Title, todos[0].Tags)
+	}
+	todo, _ := s.GetByID(ctx, 2)
+	if todo.Title != "b" || todo.Tags[0] != "x" {
+		t.Errorf("待办事项 = %q %v，期望 b [x]", todo.Title, todo.Tags)
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"go-todolist/models"
+)
+
+func TestClear(t *testing.T) {
+	for _, backend := range trashBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			testClear(t, backend.open(t))
+		})
+	}
+}
+
+// testClear 检查清空后列表和回收站为空，且新建的待办事项与备注从ID 1 开始，供各存储的测试共用
+func testClear(t *testing.T, s trashTestStorage) {
+	ctx := context.Background()
+	for _, title := range []string{"a", "b", "c"} {
+		if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: title}); err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+	}
+	if _, err := s.AddComment(ctx, 2, &models.CreateCommentRequest{Body: "note"}); err != nil {
+		t.Fatalf("添加备注失败: %v", err)
+	}
+	if err := s.Delete(ctx, 3); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+
+	// 返回的数量不含回收站
+	if cleared, err := s.Clear(ctx); err != nil || cleared != 2 {
+		t.Fatalf("Clear() = %d, %v，期望 2", cleared, err)
+	}
+	if todos := mustGetAll(t, s); len(todos) != 0 {
+		t.Errorf("清空后仍有 %d 项", len(todos))
+	}
+	if trash, err := s.Trash(ctx); err != nil || len(trash) != 0 {
+		t.Errorf("清空后回收站 = %d 项, %v，期望为空", len(trash), err)
+	}
+	if _, err := s.GetByID(ctx, 1); err != ErrTodoNotFound {
+		t.Errorf("清空后 GetByID(1) 错误 = %v，期望 ErrTodoNotFound", err)
+	}
+
+	todo, err := s.Create(ctx, &models.CreateTodoRequest{Title: "fresh"})
+	if err != nil {
+		t.Fatalf("清空后创建失败: %v", err)
+	}
+	if todo.ID != 1 {
+		t.Errorf("清空后新建的ID = %d，期望 1", todo.ID)
+	}
+	comment, err := s.AddComment(ctx, 1, &models.CreateCommentRequest{Body: "note"})
+	if err != nil || comment.ID != 1 {
+		t.Errorf("清空后新建备注 = %+v, %v，期望ID为 1", comment, err)
+	}
+	if history, err := s.History(ctx, 1); err != nil || len(history) != 1 {
+		t.Errorf("清空后新建的历史 = %d 条, %v，期望 1", len(history), err)
+	}
+
+	if cleared, err := s.Clear(ctx); err != nil || cleared != 1 {
+		t.Errorf("再次 Clear() = %d, %v，期望 1", cleared, err)
+	}
+	if cleared, err := s.Clear(ctx); err != nil || cleared != 0 {
+		t.Errorf("空存储 Clear() = %d, %v，期望 0", cleared, err)
+	}
+}
@@ -0,0 +1,9 @@
+package storage
+
+// DynamoOptions DynamoDB 存储的配置，凭证和区域按 AWS SDK 的默认方式读取（环境变量、配置文件或 IAM 角色）
+type DynamoOptions struct {
+	// Table 表名，不存在时自动以按需计费模式创建
+	Table string
+	// Endpoint 自定义服务地址，用于 DynamoDB Local 等本地环境，为空时使用 AWS 默认地址
+	Endpoint string
+}
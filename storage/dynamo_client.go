@@ -0,0 +1,661 @@
+//go:build dynamodb
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go-todolist/models"
+)
+
+// dynamoTimeout 单次 DynamoDB 操作的超时时间，建表等待另见 dynamoCreateTimeout
+const (
+	dynamoTimeout       = 10 * time.Second
+	dynamoCreateTimeout = 2 * time.Minute
+)
+
+// 表中的主键前缀：待办事项为 todo#<id>，回收站中的待办事项为 trash#<id>，ID计数器为 counter#<name>
+const (
+	dynamoTodoPrefix    = "todo#"
+	dynamoTrashPrefix   = "trash#"
+	dynamoCounterPrefix = "counter#"
+)
+
+// DynamoStorage 基于 DynamoDB 的存储，适合 Lambda、Fargate 等无状态部署。
+// 所有数据保存在一张以 pk 为分区键的表中；更新以读取到的 data 为条件写入，并发修改时返回 ErrTxConflict
+type DynamoStorage struct {
+	client *dynamodb.Client
+	table  string
+}
+
+// OpenDynamo 连接 DynamoDB，表不存在时创建并等待其可用
+func OpenDynamo(opts DynamoOptions) (*DynamoStorage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dynamoCreateTimeout)
+	defer cancel()
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("加载 AWS 配置失败: %w", err)
+	}
+	client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+		}
+	})
+
+	s := &DynamoStorage{client: client, table: opts.Table}
+	if err := s.ensureTable(ctx); err != nil {
+		return nil, fmt.Errorf("初始化 DynamoDB 表失败: %w", err)
+	}
+	return s, nil
+}
+
+// ensureTable 表不存在时以按需计费模式创建
+func (s *DynamoStorage) ensureTable(ctx context.Context) error {
+	_, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)})
+	var notFound *types.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		return err
+	}
+
+	_, err = s.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String(s.table),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		return err
+	}
+	waiter := dynamodb.NewTableExistsWaiter(s.client)
+	return waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)}, dynamoCreateTimeout)
+}
+
+// dynamoKey 待办事项 id 的主键
+func dynamoKey(id int) map[string]types.AttributeValue {
+	return dynamoPrefixKey(dynamoTodoPrefix, id)
+}
+
+// dynamoPrefixKey 以 prefix 为前缀的主键
+func dynamoPrefixKey(prefix string, id int) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: prefix + strconv.Itoa(id)},
+	}
+}
+
+// nextSequence 原子地递增并返回计数器 name
+func (s *DynamoStorage) nextSequence(ctx context.Context, name string) (int, error) {
+	out, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: dynamoCounterPrefix + name},
+		},
+		UpdateExpression:          aws.String("ADD seq :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":one": &types.AttributeValueMemberN{Value: "1"}},
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+	seq, ok := out.Attributes["seq"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("计数器 %s 的值无效", name)
+	}
+	return strconv.Atoi(seq.Value)
+}
+
+// encodeDynamoItem 将待办事项及其历史版本转换为表中的条目
+func encodeDynamoItem(todo *models.Todo, versions []models.TodoVersion) (map[string]types.AttributeValue, error) {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return nil, err
+	}
+	encodedVersions, err := json.Marshal(versions)
+	if err != nil {
+		return nil, err
+	}
+	item := dynamoKey(int(todo.ID))
+	item["external_id"] = &types.AttributeValueMemberS{Value: todo.ExternalID}
+	item["data"] = &types.AttributeValueMemberS{Value: string(data)}
+	item["versions"] = &types.AttributeValueMemberS{Value: string(encodedVersions)}
+	return item, nil
+}
+
+// dynamoString 读取条目中的字符串属性
+func dynamoString(item map[string]types.AttributeValue, name string) string {
+	if v, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+// decodeDynamoItem 从条目还原待办事项及其历史版本
+func decodeDynamoItem(item map[string]types.AttributeValue) (*models.Todo, []models.TodoVersion, error) {
+	var todo models.Todo
+	if err := json.Unmarshal([]byte(dynamoString(item, "data")), &todo); err != nil {
+		return nil, nil, err
+	}
+	versions := []models.TodoVersion{}
+	if raw := dynamoString(item, "versions"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &versions); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &todo, versions, nil
+}
+
+// get 读取条目，不存在时返回 ErrTodoNotFound
+func (s *DynamoStorage) get(ctx context.Context, id int) (map[string]types.AttributeValue, error) {
+	return s.getKey(ctx, dynamoKey(id))
+}
+
+// getKey 读取主键为 key 的条目，不存在时返回 ErrTodoNotFound
+func (s *DynamoStorage) getKey(ctx context.Context, key map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, ErrTodoNotFound
+	}
+	return out.Item, nil
+}
+
+// modify 读取、修改并以读取到的 data 为条件写回待办事项，条件不满足时返回 ErrTxConflict
+func (s *DynamoStorage) modify(ctx context.Context, id int, record bool, fn func(ctx context.Context, todo *models.Todo) error) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	item, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	todo, versions, err := decodeDynamoItem(item)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(ctx, todo); err != nil {
+		return nil, err
+	}
+	if record {
+		versions = appendVersion(versions, todo)
+	}
+
+	updated, err := encodeDynamoItem(todo, versions)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(s.table),
+		Item:                      updated,
+		ConditionExpression:       aws.String("#data = :old"),
+		ExpressionAttributeNames:  map[string]string{"#data": "data"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":old": item["data"]},
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return nil, ErrTxConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// scanTodos 分页扫描全表，返回按ID升序排列的待办事项
+func (s *DynamoStorage) scanTodos(ctx context.Context) ([]*models.Todo, error) {
+	return s.scanPrefix(ctx, dynamoTodoPrefix)
+}
+
+// scanPrefix 分页扫描全表，返回主键以 prefix 开头、按ID升序排列的待办事项
+func (s *DynamoStorage) scanPrefix(ctx context.Context, prefix string) ([]*models.Todo, error) {
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:                 aws.String(s.table),
+		FilterExpression:          aws.String("begins_with(pk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":prefix": &types.AttributeValueMemberS{Value: prefix}},
+		ConsistentRead:            aws.Bool(true),
+	})
+
+	todos := make([]*models.Todo, 0)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			todo, _, err := decodeDynamoItem(item)
+			if err != nil {
+				return nil, err
+			}
+			todos = append(todos, todo)
+		}
+	}
+	// Scan 不保证顺序
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ID < todos[j].ID })
+	return todos, nil
+}
+
+func (s *DynamoStorage) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+	return s.scanTodos(ctx)
+}
+
+func (s *DynamoStorage) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	item, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	todo, _, err := decodeDynamoItem(item)
+	return todo, err
+}
+
+func (s *DynamoStorage) FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error) {
+	todos, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]*models.Todo, 0)
+	for _, todo := range todos {
+		if todo.ExternalID == externalID {
+			matched = append(matched, todo)
+		}
+	}
+	return matched, nil
+}
+
+func (s *DynamoStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	id, err := s.nextSequence(ctx, "todo")
+	if err != nil {
+		return nil, err
+	}
+	todo := newTodo(id, req, time.Now())
+	item, err := encodeDynamoItem(todo, appendVersion(nil, todo))
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return nil, ErrTxConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *DynamoStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	return s.modify(ctx, id, true, func(_ context.Context, todo *models.Todo) error {
+		applyUpdate(todo, req, time.Now())
+		return nil
+	})
+}
+
+// encodeDynamoTrashItem 将回收站中的待办事项转换为表中的条目，不保留历史版本
+func encodeDynamoTrashItem(todo *models.Todo) (map[string]types.AttributeValue, error) {
+	item, err := encodeDynamoItem(todo, []models.TodoVersion{})
+	if err != nil {
+		return nil, err
+	}
+	item["pk"] = dynamoPrefixKey(dynamoTrashPrefix, int(todo.ID))["pk"]
+	return item, nil
+}
+
+// transactWrite 在一个事务中写入 items，任一条件不满足时返回 ErrTxConflict
+func (s *DynamoStorage) transactWrite(ctx context.Context, items ...types.TransactWriteItem) error {
+	_, err := s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		return ErrTxConflict
+	}
+	return err
+}
+
+// Delete 在一个事务中删除待办事项（含历史版本）并写入回收站，期间被其他实例修改时返回 ErrTxConflict
+func (s *DynamoStorage) Delete(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	item, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	todo, _, err := decodeDynamoItem(item)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	todo.DeletedAt = &now
+	trashed, err := encodeDynamoTrashItem(todo)
+	if err != nil {
+		return err
+	}
+
+	return s.transactWrite(ctx,
+		types.TransactWriteItem{Delete: &types.Delete{
+			TableName:                 aws.String(s.table),
+			Key:                       dynamoKey(id),
+			ConditionExpression:       aws.String("#data = :old"),
+			ExpressionAttributeNames:  map[string]string{"#data": "data"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":old": item["data"]},
+		}},
+		types.TransactWriteItem{Put: &types.Put{TableName: aws.String(s.table), Item: trashed}},
+	)
+}
+
+// Trash 返回回收站中的待办事项，最近删除的排在最前
+func (s *DynamoStorage) Trash(ctx context.Context) ([]*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	todos, err := s.scanPrefix(ctx, dynamoTrashPrefix)
+	if err != nil {
+		return nil, err
+	}
+	sortTrash(todos)
+	return todos, nil
+}
+
+// PurgeTrash 永久删除回收站中删除时间早于 before 的待办事项，before 为零值时清空回收站
+func (s *DynamoStorage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	todos, err := s.scanPrefix(ctx, dynamoTrashPrefix)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, todo := range todos {
+		if !before.IsZero() && !todo.DeletedAt.Before(before) {
+			continue
+		}
+		_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(s.table),
+			Key:       dynamoPrefixKey(dynamoTrashPrefix, int(todo.ID)),
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// Restore 在一个事务中将待办事项从回收站移回并记录新的历史版本
+func (s *DynamoStorage) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	item, err := s.getKey(ctx, dynamoPrefixKey(dynamoTrashPrefix, id))
+	if errors.Is(err, ErrTodoNotFound) {
+		_, getErr := s.get(ctx, id)
+		if getErr != nil && !errors.Is(getErr, ErrTodoNotFound) {
+			return nil, getErr
+		}
+		lastID, err := s.sequenceValue(ctx, "todo")
+		if err != nil {
+			return nil, err
+		}
+		return nil, restoreError(id, getErr == nil, lastID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	todo, _, err := decodeDynamoItem(item)
+	if err != nil {
+		return nil, err
+	}
+	todo.DeletedAt = nil
+	todo.UpdatedAt = time.Now()
+	restored, err := encodeDynamoItem(todo, appendVersion(nil, todo))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactWrite(ctx,
+		types.TransactWriteItem{Delete: &types.Delete{
+			TableName:           aws.String(s.table),
+			Key:                 dynamoPrefixKey(dynamoTrashPrefix, id),
+			ConditionExpression: aws.String("attribute_exists(pk)"),
+		}},
+		types.TransactWriteItem{Put: &types.Put{
+			TableName:           aws.String(s.table),
+			Item:                restored,
+			ConditionExpression: aws.String("attribute_not_exists(pk)"),
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// sequenceValue 返回计数器 name 的当前值，不存在时为 0
+func (s *DynamoStorage) sequenceValue(ctx context.Context, name string) (int, error) {
+	item, err := s.getKey(ctx, map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: dynamoCounterPrefix + name},
+	})
+	if errors.Is(err, ErrTodoNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	seq, ok := item["seq"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("计数器 %s 的值无效", name)
+	}
+	return strconv.Atoi(seq.Value)
+}
+
+func (s *DynamoStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	var comment models.Comment
+	_, err := s.modify(ctx, todoID, false, func(ctx context.Context, todo *models.Todo) error {
+		id, err := s.nextSequence(ctx, "comment")
+		if err != nil {
+			return err
+		}
+		comment = models.Comment{ID: models.ID(id), Body: req.Body, CreatedAt: time.Now()}
+		todo.Comments = append(todo.Comments, comment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (s *DynamoStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	_, err := s.modify(ctx, todoID, false, func(_ context.Context, todo *models.Todo) error {
+		for i, comment := range todo.Comments {
+			if comment.ID == models.ID(commentID) {
+				todo.Comments = append(todo.Comments[:i], todo.Comments[i+1:]...)
+				return nil
+			}
+		}
+		return ErrCommentNotFound
+	})
+	return err
+}
+
+func (s *DynamoStorage) Clear(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	todos, err := s.scanTodos(ctx)
+	if err != nil {
+		return 0, err
+	}
+	trash, err := s.scanPrefix(ctx, dynamoTrashPrefix)
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]map[string]types.AttributeValue, 0, len(todos)+len(trash)+2)
+	for _, todo := range todos {
+		keys = append(keys, dynamoKey(int(todo.ID)))
+	}
+	for _, todo := range trash {
+		keys = append(keys, dynamoPrefixKey(dynamoTrashPrefix, int(todo.ID)))
+	}
+	for _, name := range []string{"todo", "comment"} {
+		keys = append(keys, map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: dynamoCounterPrefix + name},
+		})
+	}
+	for _, key := range keys {
+		_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{TableName: aws.String(s.table), Key: key})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(todos), nil
+}
+
+func (s *DynamoStorage) History(ctx context.Context, id int) ([]models.TodoVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	item, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	_, versions, err := decodeDynamoItem(item)
+	return versions, err
+}
+
+// Export 扫描整张表导出待办事项、历史版本和计数器。Scan 不是一致性快照，导出期间的修改可能只被部分包含
+func (s *DynamoStorage) Export(ctx context.Context) (*Snapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	snapshot := &Snapshot{
+		Format:     SnapshotFormat,
+		ExportedAt: time.Now(),
+		Todos:      []*models.Todo{},
+		History:    make(map[int][]models.TodoVersion),
+	}
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:      aws.String(s.table),
+		ConsistentRead: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			pk := dynamoString(item, "pk")
+			if name, ok := strings.CutPrefix(pk, dynamoCounterPrefix); ok {
+				seq, ok := item["seq"].(*types.AttributeValueMemberN)
+				if !ok {
+					continue
+				}
+				value, err := strconv.Atoi(seq.Value)
+				if err != nil {
+					return nil, fmt.Errorf("计数器 %s 的值无效: %w", name, err)
+				}
+				switch name {
+				case "todo":
+					snapshot.NextID = value + 1
+				case "comment":
+					snapshot.NextCommentID = value + 1
+				}
+				continue
+			}
+			inTrash := strings.HasPrefix(pk, dynamoTrashPrefix)
+			if !inTrash && !strings.HasPrefix(pk, dynamoTodoPrefix) {
+				continue
+			}
+			todo, versions, err := decodeDynamoItem(item)
+			if err != nil {
+				return nil, err
+			}
+			if inTrash {
+				snapshot.Trash = append(snapshot.Trash, todo)
+				continue
+			}
+			snapshot.Todos = append(snapshot.Todos, todo)
+			snapshot.History[int(todo.ID)] = versions
+		}
+	}
+	return snapshot, snapshot.normalize()
+}
+
+// Import 清空表后逐条写入快照。DynamoDB 单个事务最多 100 个条目，因此导入不是原子的，中途失败时数据可能不完整
+func (s *DynamoStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	if err := snapshot.normalize(); err != nil {
+		return err
+	}
+	if _, err := s.Clear(ctx); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, dynamoTimeout)
+	defer cancel()
+
+	for _, todo := range snapshot.Todos {
+		item, err := encodeDynamoItem(todo, snapshot.History[int(todo.ID)])
+		if err != nil {
+			return err
+		}
+		_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(s.table), Item: item})
+		if err != nil {
+			return err
+		}
+	}
+	for _, todo := range snapshot.Trash {
+		item, err := encodeDynamoTrashItem(todo)
+		if err != nil {
+			return err
+		}
+		_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(s.table), Item: item})
+		if err != nil {
+			return err
+		}
+	}
+	for name, value := range map[string]int{"todo": snapshot.NextID - 1, "comment": snapshot.NextCommentID - 1} {
+		_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(s.table),
+			Item: map[string]types.AttributeValue{
+				"pk":  &types.AttributeValueMemberS{Value: dynamoCounterPrefix + name},
+				"seq": &types.AttributeValueMemberN{Value: strconv.Itoa(value)},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !dynamodb
+
+package storage
+
+import "errors"
+
+// DynamoStorage 未带 dynamodb 构建标签时的占位类型，实际实现见 dynamo_client.go
+type DynamoStorage struct {
+	TodoStorage
+}
+
+// OpenDynamo 未带 dynamodb 构建标签时不可用
+func OpenDynamo(opts DynamoOptions) (*DynamoStorage, error) {
+	return nil, errors.New("DynamoDB 存储需要使用 -tags dynamodb 构建")
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EtcdOptions etcd 存储的配置
+type EtcdOptions struct {
+	// Endpoints etcd 集群地址，如 localhost:2379
+	Endpoints []string
+	// Prefix 所有键的前缀，默认 /todolist/
+	Prefix string
+	// DialTimeout 连接超时，默认 5 秒
+	DialTimeout time.Duration
+}
+
+// withDefaults 填充未设置的前缀和超时
+func (o EtcdOptions) withDefaults() EtcdOptions {
+	if o.Prefix == "" {
+		o.Prefix = "/todolist/"
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = 5 * time.Second
+	}
+	return o
+}
+
+// etcd 中使用的键，均带有 EtcdOptions.Prefix 前缀：
+//
+//	todos/<id>     待办事项 JSON（含备注），ID补零到20位使按键排序即按ID排序
+//	versions/<id>  历史版本 JSON，总是与待办事项在同一事务中写入
+//	trash/<id>     回收站中的待办事项 JSON（含备注和删除时间），ID补零方式与 todos/ 相同
+//	seq/<name>     ID计数器
+func (o EtcdOptions) todosPrefix() string       { return o.Prefix + "todos/" }
+func (o EtcdOptions) trashPrefix() string       { return o.Prefix + "trash/" }
+func (o EtcdOptions) versionsPrefix() string    { return o.Prefix + "versions/" }
+func (o EtcdOptions) seqPrefix() string         { return o.Prefix + "seq/" }
+func (o EtcdOptions) todoKey(id int) string     { return fmt.Sprintf("%s%020d", o.todosPrefix(), id) }
+func (o EtcdOptions) trashKey(id int) string    { return fmt.Sprintf("%s%020d", o.trashPrefix(), id) }
+func (o EtcdOptions) versionsKey(id int) string { return o.versionsPrefix() + strconv.Itoa(id) }
+func (o EtcdOptions) seqKey(name string) string { return o.seqPrefix() + name }
@@ -0,0 +1,556 @@
+//go:build etcd
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"go-todolist/models"
+)
+
+const (
+	// etcdTimeout 单次 etcd 操作的超时时间
+	etcdTimeout = 10 * time.Second
+	// etcdMaxRetries 事务比较失败（被其他实例并发修改）时的最大重试次数，超过后返回 ErrTxConflict
+	etcdMaxRetries = 5
+)
+
+// EtcdStorage 基于 etcd 的存储，多个副本共享强一致的数据。
+// ID 分配和修改都通过比较 ModRevision 的事务完成，并发修改时自动重试
+type EtcdStorage struct {
+	client *clientv3.Client
+	opts   EtcdOptions
+}
+
+// OpenEtcd 连接 etcd 集群并检查连通性
+func OpenEtcd(opts EtcdOptions) (*EtcdStorage, error) {
+	opts = opts.withDefaults()
+	client, err := clientv3.New(clientv3.Config{Endpoints: opts.Endpoints, DialTimeout: opts.DialTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("连接 etcd 失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.DialTimeout)
+	defer cancel()
+	if _, err := client.Get(ctx, opts.seqKey("todo")); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("连接 etcd 失败: %w", err)
+	}
+	return &EtcdStorage{client: client, opts: opts}, nil
+}
+
+// Close 关闭连接
+func (s *EtcdStorage) Close() error {
+	return s.client.Close()
+}
+
+// Watch 监听待办事项的变化直到 ctx 取消，fn 收到变化的ID和最新内容，删除时 todo 为 nil。
+// 供实时推送等需要感知其他副本修改的功能使用
+func (s *EtcdStorage) Watch(ctx context.Context, fn func(id int, todo *models.Todo)) error {
+	prefix := s.opts.todosPrefix()
+	for resp := range s.client.Watch(ctx, prefix, clientv3.WithPrefix()) {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+		for _, ev := range resp.Events {
+			id, err := strconv.Atoi(strings.TrimPrefix(string(ev.Kv.Key), prefix))
+			if err != nil {
+				continue
+			}
+			if ev.Type == clientv3.EventTypeDelete {
+				fn(id, nil)
+				continue
+			}
+			var todo models.Todo
+			if err := json.Unmarshal(ev.Kv.Value, &todo); err != nil {
+				continue
+			}
+			fn(id, &todo)
+		}
+	}
+	return ctx.Err()
+}
+
+// sequence 读取计数器 name，返回下一个值以及提交时需要的比较条件和写入操作
+func (s *EtcdStorage) sequence(ctx context.Context, name string) (int, clientv3.Cmp, clientv3.Op, error) {
+	key := s.opts.seqKey(name)
+	resp, err := s.client.Get(ctx, key)
+	if err != nil {
+		return 0, clientv3.Cmp{}, clientv3.Op{}, err
+	}
+
+	current, revision := 0, int64(0)
+	if len(resp.Kvs) > 0 {
+		current, err = strconv.Atoi(string(resp.Kvs[0].Value))
+		if err != nil {
+			return 0, clientv3.Cmp{}, clientv3.Op{}, fmt.Errorf("计数器 %s 的值无效: %w", name, err)
+		}
+		revision = resp.Kvs[0].ModRevision
+	}
+	next := current + 1
+	return next,
+		clientv3.Compare(clientv3.ModRevision(key), "=", revision),
+		clientv3.OpPut(key, strconv.Itoa(next)),
+		nil
+}
+
+// etcdRecord 读取到的待办事项及其版本号
+type etcdRecord struct {
+	todo     *models.Todo
+	versions []models.TodoVersion
+	revision int64
+}
+
+// load 在同一快照中读取待办事项和历史版本，不存在时返回 ErrTodoNotFound
+func (s *EtcdStorage) load(ctx context.Context, id int) (*etcdRecord, error) {
+	resp, err := s.client.Txn(ctx).Then(
+		clientv3.OpGet(s.opts.todoKey(id)),
+		clientv3.OpGet(s.opts.versionsKey(id)),
+	).Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	todoKvs := resp.Responses[0].GetResponseRange().Kvs
+	if len(todoKvs) == 0 {
+		return nil, ErrTodoNotFound
+	}
+	record := &etcdRecord{todo: &models.Todo{}, versions: []models.TodoVersion{}, revision: todoKvs[0].ModRevision}
+	if err := json.Unmarshal(todoKvs[0].Value, record.todo); err != nil {
+		return nil, err
+	}
+	if versionKvs := resp.Responses[1].GetResponseRange().Kvs; len(versionKvs) > 0 {
+		if err := json.Unmarshal(versionKvs[0].Value, &record.versions); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+// putOps 写入待办事项及其历史版本的操作
+func (s *EtcdStorage) putOps(todo *models.Todo, versions []models.TodoVersion) ([]clientv3.Op, error) {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return nil, err
+	}
+	encodedVersions, err := json.Marshal(versions)
+	if err != nil {
+		return nil, err
+	}
+	return []clientv3.Op{
+		clientv3.OpPut(s.opts.todoKey(int(todo.ID)), string(data)),
+		clientv3.OpPut(s.opts.versionsKey(int(todo.ID)), string(encodedVersions)),
+	}, nil
+}
+
+// modify 读取、修改并写回待办事项，待办事项在此期间被修改时重试。
+// fn 可返回额外的比较条件和写入操作，与待办事项在同一事务中提交
+func (s *EtcdStorage) modify(ctx context.Context, id int, record bool, fn func(ctx context.Context, todo *models.Todo) ([]clientv3.Cmp, []clientv3.Op, error)) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	for range etcdMaxRetries {
+		current, err := s.load(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		cmps, ops, err := fn(ctx, current.todo)
+		if err != nil {
+			return nil, err
+		}
+		if record {
+			current.versions = appendVersion(current.versions, current.todo)
+		}
+
+		puts, err := s.putOps(current.todo, current.versions)
+		if err != nil {
+			return nil, err
+		}
+		cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(s.opts.todoKey(id)), "=", current.revision))
+		resp, err := s.client.Txn(ctx).If(cmps...).Then(append(ops, puts...)...).Commit()
+		if err != nil {
+			return nil, err
+		}
+		if resp.Succeeded {
+			return current.todo, nil
+		}
+	}
+	return nil, ErrTxConflict
+}
+
+func (s *EtcdStorage) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	return s.getPrefix(ctx, s.opts.todosPrefix())
+}
+
+// getPrefix 按ID升序返回 prefix 下的待办事项
+func (s *EtcdStorage) getPrefix(ctx context.Context, prefix string) ([]*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	resp, err := s.client.Get(ctx, prefix,
+		clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+	return decodeEtcdTodos(resp.Kvs)
+}
+
+// decodeEtcdTodos 解析键值对中的待办事项
+func decodeEtcdTodos(kvs []*mvccpb.KeyValue) ([]*models.Todo, error) {
+	todos := make([]*models.Todo, 0, len(kvs))
+	for _, kv := range kvs {
+		var todo models.Todo
+		if err := json.Unmarshal(kv.Value, &todo); err != nil {
+			return nil, err
+		}
+		todos = append(todos, &todo)
+	}
+	return todos, nil
+}
+
+func (s *EtcdStorage) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	resp, err := s.client.Get(ctx, s.opts.todoKey(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, ErrTodoNotFound
+	}
+	var todo models.Todo
+	if err := json.Unmarshal(resp.Kvs[0].Value, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (s *EtcdStorage) FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error) {
+	todos, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]*models.Todo, 0)
+	for _, todo := range todos {
+		if todo.ExternalID == externalID {
+			matched = append(matched, todo)
+		}
+	}
+	return matched, nil
+}
+
+func (s *EtcdStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	for range etcdMaxRetries {
+		id, cmp, seqOp, err := s.sequence(ctx, "todo")
+		if err != nil {
+			return nil, err
+		}
+		todo := newTodo(id, req, time.Now())
+		puts, err := s.putOps(todo, appendVersion(nil, todo))
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.client.Txn(ctx).If(cmp).Then(append(puts, seqOp)...).Commit()
+		if err != nil {
+			return nil, err
+		}
+		if resp.Succeeded {
+			return todo, nil
+		}
+	}
+	return nil, ErrTxConflict
+}
+
+func (s *EtcdStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	return s.modify(ctx, id, true, func(_ context.Context, todo *models.Todo) ([]clientv3.Cmp, []clientv3.Op, error) {
+		applyUpdate(todo, req, time.Now())
+		return nil, nil, nil
+	})
+}
+
+// Delete 在一个事务中删除待办事项及其历史版本并写入回收站，待办事项在此期间被修改时重试
+func (s *EtcdStorage) Delete(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	key := s.opts.todoKey(id)
+	for range etcdMaxRetries {
+		current, err := s.load(ctx, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		current.todo.DeletedAt = &now
+		data, err := json.Marshal(current.todo)
+		if err != nil {
+			return err
+		}
+
+		resp, err := s.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", current.revision)).
+			Then(
+				clientv3.OpDelete(key),
+				clientv3.OpDelete(s.opts.versionsKey(id)),
+				clientv3.OpPut(s.opts.trashKey(id), string(data)),
+			).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			return nil
+		}
+	}
+	return ErrTxConflict
+}
+
+// Trash 返回回收站中的待办事项，最近删除的排在最前
+func (s *EtcdStorage) Trash(ctx context.Context) ([]*models.Todo, error) {
+	todos, err := s.getPrefix(ctx, s.opts.trashPrefix())
+	if err != nil {
+		return nil, err
+	}
+	sortTrash(todos)
+	return todos, nil
+}
+
+// PurgeTrash 永久删除回收站中删除时间早于 before 的待办事项，before 为零值时清空回收站
+func (s *EtcdStorage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	if before.IsZero() {
+		resp, err := s.client.Delete(ctx, s.opts.trashPrefix(), clientv3.WithPrefix())
+		if err != nil {
+			return 0, err
+		}
+		return int(resp.Deleted), nil
+	}
+
+	todos, err := s.getPrefix(ctx, s.opts.trashPrefix())
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, todo := range todos {
+		if !todo.DeletedAt.Before(before) {
+			continue
+		}
+		resp, err := s.client.Delete(ctx, s.opts.trashKey(int(todo.ID)))
+		if err != nil {
+			return purged, err
+		}
+		purged += int(resp.Deleted)
+	}
+	return purged, nil
+}
+
+// Restore 在一个事务中将待办事项从回收站移回并记录新的历史版本，回收站在此期间被修改时重试
+func (s *EtcdStorage) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	trashKey, todoKey := s.opts.trashKey(id), s.opts.todoKey(id)
+	for range etcdMaxRetries {
+		resp, err := s.client.Txn(ctx).Then(
+			clientv3.OpGet(trashKey),
+			clientv3.OpGet(todoKey, clientv3.WithCountOnly()),
+			clientv3.OpGet(s.opts.seqKey("todo")),
+		).Commit()
+		if err != nil {
+			return nil, err
+		}
+		trashKvs := resp.Responses[0].GetResponseRange().Kvs
+		if len(trashKvs) == 0 {
+			lastID := 0
+			if kvs := resp.Responses[2].GetResponseRange().Kvs; len(kvs) > 0 {
+				lastID, _ = strconv.Atoi(string(kvs[0].Value))
+			}
+			return nil, restoreError(id, resp.Responses[1].GetResponseRange().Count > 0, lastID)
+		}
+
+		var todo models.Todo
+		if err := json.Unmarshal(trashKvs[0].Value, &todo); err != nil {
+			return nil, err
+		}
+		todo.DeletedAt = nil
+		todo.UpdatedAt = time.Now()
+		puts, err := s.putOps(&todo, appendVersion(nil, &todo))
+		if err != nil {
+			return nil, err
+		}
+		txn, err := s.client.Txn(ctx).
+			If(
+				clientv3.Compare(clientv3.ModRevision(trashKey), "=", trashKvs[0].ModRevision),
+				clientv3.Compare(clientv3.Version(todoKey), "=", 0),
+			).
+			Then(append(puts, clientv3.OpDelete(trashKey))...).
+			Commit()
+		if err != nil {
+			return nil, err
+		}
+		if txn.Succeeded {
+			return &todo, nil
+		}
+	}
+	return nil, ErrTxConflict
+}
+
+func (s *EtcdStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	var comment models.Comment
+	_, err := s.modify(ctx, todoID, false, func(ctx context.Context, todo *models.Todo) ([]clientv3.Cmp, []clientv3.Op, error) {
+		id, cmp, seqOp, err := s.sequence(ctx, "comment")
+		if err != nil {
+			return nil, nil, err
+		}
+		comment = models.Comment{ID: models.ID(id), Body: req.Body, CreatedAt: time.Now()}
+		todo.Comments = append(todo.Comments, comment)
+		return []clientv3.Cmp{cmp}, []clientv3.Op{seqOp}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (s *EtcdStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	_, err := s.modify(ctx, todoID, false, func(_ context.Context, todo *models.Todo) ([]clientv3.Cmp, []clientv3.Op, error) {
+		for i, comment := range todo.Comments {
+			if comment.ID == models.ID(commentID) {
+				todo.Comments = append(todo.Comments[:i], todo.Comments[i+1:]...)
+				return nil, nil, nil
+			}
+		}
+		return nil, nil, ErrCommentNotFound
+	})
+	return err
+}
+
+func (s *EtcdStorage) Clear(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	resp, err := s.client.Txn(ctx).Then(
+		clientv3.OpDelete(s.opts.todosPrefix(), clientv3.WithPrefix()),
+		clientv3.OpDelete(s.opts.versionsPrefix(), clientv3.WithPrefix()),
+		clientv3.OpDelete(s.opts.trashPrefix(), clientv3.WithPrefix()),
+		clientv3.OpDelete(s.opts.seqPrefix(), clientv3.WithPrefix()),
+	).Commit()
+	if err != nil {
+		return 0, err
+	}
+	return int(resp.Responses[0].GetResponseDeleteRange().Deleted), nil
+}
+
+func (s *EtcdStorage) History(ctx context.Context, id int) ([]models.TodoVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	record, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return record.versions, nil
+}
+
+// Export 在同一修订版本上读取全部数据
+func (s *EtcdStorage) Export(ctx context.Context) (*Snapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	resp, err := s.client.Txn(ctx).Then(
+		clientv3.OpGet(s.opts.todosPrefix(), clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)),
+		clientv3.OpGet(s.opts.versionsPrefix(), clientv3.WithPrefix()),
+		clientv3.OpGet(s.opts.seqKey("todo")),
+		clientv3.OpGet(s.opts.seqKey("comment")),
+		clientv3.OpGet(s.opts.trashPrefix(), clientv3.WithPrefix()),
+	).Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Format:     SnapshotFormat,
+		ExportedAt: time.Now(),
+		History:    make(map[int][]models.TodoVersion),
+	}
+	if snapshot.Todos, err = decodeEtcdTodos(resp.Responses[0].GetResponseRange().Kvs); err != nil {
+		return nil, err
+	}
+	for _, kv := range resp.Responses[1].GetResponseRange().Kvs {
+		id, err := strconv.Atoi(strings.TrimPrefix(string(kv.Key), s.opts.versionsPrefix()))
+		if err != nil {
+			continue
+		}
+		var versions []models.TodoVersion
+		if err := json.Unmarshal(kv.Value, &versions); err != nil {
+			return nil, err
+		}
+		snapshot.History[id] = versions
+	}
+	if kvs := resp.Responses[2].GetResponseRange().Kvs; len(kvs) > 0 {
+		snapshot.NextID, _ = strconv.Atoi(string(kvs[0].Value))
+		snapshot.NextID++
+	}
+	if kvs := resp.Responses[3].GetResponseRange().Kvs; len(kvs) > 0 {
+		snapshot.NextCommentID, _ = strconv.Atoi(string(kvs[0].Value))
+		snapshot.NextCommentID++
+	}
+	if snapshot.Trash, err = decodeEtcdTodos(resp.Responses[4].GetResponseRange().Kvs); err != nil {
+		return nil, err
+	}
+	return snapshot, snapshot.normalize()
+}
+
+// Import 在一个事务中删除现有数据并写入快照。
+// etcd 默认限制单个事务最多 128 个操作（--max-txn-ops），数据较多时需调大该限制
+func (s *EtcdStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	if err := snapshot.normalize(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	ops := []clientv3.Op{
+		clientv3.OpDelete(s.opts.todosPrefix(), clientv3.WithPrefix()),
+		clientv3.OpDelete(s.opts.versionsPrefix(), clientv3.WithPrefix()),
+		clientv3.OpDelete(s.opts.trashPrefix(), clientv3.WithPrefix()),
+		clientv3.OpDelete(s.opts.seqPrefix(), clientv3.WithPrefix()),
+	}
+	for _, todo := range snapshot.Todos {
+		versions := snapshot.History[int(todo.ID)]
+		if versions == nil {
+			versions = []models.TodoVersion{}
+		}
+		puts, err := s.putOps(todo, versions)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, puts...)
+	}
+	for _, todo := range snapshot.Trash {
+		data, err := json.Marshal(todo)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, clientv3.OpPut(s.opts.trashKey(int(todo.ID)), string(data)))
+	}
+	ops = append(ops,
+		clientv3.OpPut(s.opts.seqKey("todo"), strconv.Itoa(snapshot.NextID-1)),
+		clientv3.OpPut(s.opts.seqKey("comment"), strconv.Itoa(snapshot.NextCommentID-1)),
+	)
+	_, err := s.client.Txn(ctx).Then(ops...).Commit()
+	return err
+}
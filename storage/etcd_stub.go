@@ -0,0 +1,15 @@
+//go:build !etcd
+
+package storage
+
+import "errors"
+
+// EtcdStorage 未带 etcd 构建标签时的占位类型，实际实现见 etcd_client.go
+type EtcdStorage struct {
+	TodoStorage
+}
+
+// OpenEtcd 未带 etcd 构建标签时不可用
+func OpenEtcd(opts EtcdOptions) (*EtcdStorage, error) {
+	return nil, errors.New("etcd 存储需要使用 -tags etcd 构建")
+}
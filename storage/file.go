@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go-todolist/models"
+)
+
+// FileStorage 基于 JSON 文件的持久化存储。数据保存在内存中，启动时从文件加载，
+// 修改后在 flushDelay 内合并写回文件
+type FileStorage struct {
+	*MemoryStorage
+
+	path       string
+	flushDelay time.Duration
+
+	// flushMutex 串行化写文件；timer 非空表示已安排延迟写入，由 timerMutex 保护
+	flushMutex sync.Mutex
+	timerMutex sync.Mutex
+	timer      *time.Timer
+}
+
+// OpenFile 从 path 加载数据，文件不存在时从空数据开始。
+// flushDelay 为 0 时每次修改都立即写入文件
+func OpenFile(path string, flushDelay time.Duration) (*FileStorage, error) {
+	s := &FileStorage{
+		MemoryStorage: NewMemoryStorage(),
+		path:          path,
+		flushDelay:    flushDelay,
+	}
+
+	snapshot, err := readSnapshotFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return s, nil
+	}
+	if err := s.MemoryStorage.Import(context.Background(), snapshot); err != nil {
+		return nil, fmt.Errorf("加载数据文件失败: %w", err)
+	}
+	return s, nil
+}
+
+// Flush 立即将当前数据写入文件
+func (s *FileStorage) Flush() error {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+
+	snapshot, err := s.MemoryStorage.Export(context.Background())
+	if err != nil {
+		return err
+	}
+	return writeSnapshotFile(s.path, snapshot)
+}
+
+// scheduleFlush 安排一次延迟写入，flushDelay 内的多次修改只写一次文件
+func (s *FileStorage) scheduleFlush() {
+	if s.flushDelay <= 0 {
+		if err := s.Flush(); err != nil {
+			log.Printf("保存数据文件失败: %v", err)
+		}
+		return
+	}
+
+	s.timerMutex.Lock()
+	defer s.timerMutex.Unlock()
+	if s.timer != nil {
+		return
+	}
+	s.timer = time.AfterFunc(s.flushDelay, func() {
+		s.timerMutex.Lock()
+		s.timer = nil
+		s.timerMutex.Unlock()
+		if err := s.Flush(); err != nil {
+			log.Printf("保存数据文件失败: %v", err)
+		}
+	})
+}
+
+// Close 取消待执行的延迟写入并立即写入文件
+func (s *FileStorage) Close() error {
+	s.timerMutex.Lock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.timerMutex.Unlock()
+	return s.Flush()
+}
+
+func (s *FileStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	todo, err := s.MemoryStorage.Create(ctx, req)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return todo, err
+}
+
+func (s *FileStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	todo, err := s.MemoryStorage.Update(ctx, id, req)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return todo, err
+}
+
+func (s *FileStorage) Delete(ctx context.Context, id int) error {
+	err := s.MemoryStorage.Delete(ctx, id)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return err
+}
+
+func (s *FileStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	comment, err := s.MemoryStorage.AddComment(ctx, todoID, req)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return comment, err
+}
+
+func (s *FileStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	err := s.MemoryStorage.DeleteComment(ctx, todoID, commentID)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return err
+}
+
+func (s *FileStorage) Clear(ctx context.Context) (int, error) {
+	count, err := s.MemoryStorage.Clear(ctx)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return count, err
+}
+
+func (s *FileStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	err := s.MemoryStorage.Import(ctx, snapshot)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return err
+}
+
+// WithTx 在内存存储的事务中执行 fn，提交后安排写入文件
+func (s *FileStorage) WithTx(ctx context.Context, fn func(TodoStorage) error) error {
+	err := s.MemoryStorage.WithTx(ctx, fn)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return err
+}
+
+// BeginTx 开启内存存储的显式事务，提交后安排写入文件
+func (s *FileStorage) BeginTx(ctx context.Context) (Tx, error) {
+	tx, err := s.MemoryStorage.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &commitHookTx{Tx: tx, commit: func(tx Tx) error {
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		s.scheduleFlush()
+		return nil
+	}}, nil
+}
@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"go-todolist/models"
+)
+
+// ErrFilterNotFound 不存在指定的保存的过滤器
+var ErrFilterNotFound = errors.New("过滤器未找到")
+
+// FilterStorage 由能保存过滤器（智能清单）的存储实现。过滤器与待办事项一起导出和导入，
+// 但不参与 WithTx、BeginTx 事务，Clear 也不会删除过滤器
+type FilterStorage interface {
+	// ListFilters 返回全部过滤器，按ID升序排列
+	ListFilters(ctx context.Context) ([]*models.SavedFilter, error)
+	GetFilter(ctx context.Context, id int) (*models.SavedFilter, error)
+	CreateFilter(ctx context.Context, req *models.SaveFilterRequest) (*models.SavedFilter, error)
+	// UpdateFilter 用 req 替换过滤器的名称和查询语句
+	UpdateFilter(ctx context.Context, id int, req *models.SaveFilterRequest) (*models.SavedFilter, error)
+	DeleteFilter(ctx context.Context, id int) error
+}
+
+// cloneFilter 复制过滤器，过滤器只包含值类型字段
+func cloneFilter(filter *models.SavedFilter) *models.SavedFilter {
+	copied := *filter
+	return &copied
+}
+
+// ListFilters 返回全部过滤器的副本，按ID升序排列
+func (s *MemoryStorage) ListFilters(ctx context.Context) ([]*models.SavedFilter, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.listFilters(), nil
+}
+
+// listFilters 在调用方持有锁的前提下返回全部过滤器的副本
+func (s *MemoryStorage) listFilters() []*models.SavedFilter {
+	filters := make([]*models.SavedFilter, 0, len(s.filters))
+	for _, filter := range s.filters {
+		filters = append(filters, cloneFilter(filter))
+	}
+	sort.Slice(filters, func(i, j int) bool { return filters[i].ID < filters[j].ID })
+	return filters
+}
+
+// GetFilter 根据ID获取过滤器的副本
+func (s *MemoryStorage) GetFilter(ctx context.Context, id int) (*models.SavedFilter, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	filter, exists := s.filters[id]
+	if !exists {
+		return nil, ErrFilterNotFound
+	}
+	return cloneFilter(filter), nil
+}
+
+// CreateFilter 保存新的过滤器
+func (s *MemoryStorage) CreateFilter(ctx context.Context, req *models.SaveFilterRequest) (*models.SavedFilter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	filter := &models.SavedFilter{
+		ID:        models.ID(s.nextFilterID),
+		Name:      req.Name,
+		Query:     req.Query,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.filters[s.nextFilterID] = filter
+	s.nextFilterID++
+	return cloneFilter(filter), nil
+}
+
+// UpdateFilter 替换过滤器的名称和查询语句
+func (s *MemoryStorage) UpdateFilter(ctx context.Context, id int, req *models.SaveFilterRequest) (*models.SavedFilter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	filter, exists := s.filters[id]
+	if !exists {
+		return nil, ErrFilterNotFound
+	}
+	filter.Name = req.Name
+	filter.Query = req.Query
+	filter.UpdatedAt = time.Now()
+	return cloneFilter(filter), nil
+}
+
+// DeleteFilter 删除过滤器
+func (s *MemoryStorage) DeleteFilter(ctx context.Context, id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.filters[id]; !exists {
+		return ErrFilterNotFound
+	}
+	delete(s.filters, id)
+	return nil
+}
+
+// CreateFilter 保存新的过滤器并安排写入文件
+func (s *FileStorage) CreateFilter(ctx context.Context, req *models.SaveFilterRequest) (*models.SavedFilter, error) {
+	filter, err := s.MemoryStorage.CreateFilter(ctx, req)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return filter, err
+}
+
+// UpdateFilter 替换过滤器的名称和查询语句并安排写入文件
+func (s *FileStorage) UpdateFilter(ctx context.Context, id int, req *models.SaveFilterRequest) (*models.SavedFilter, error) {
+	filter, err := s.MemoryStorage.UpdateFilter(ctx, id, req)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return filter, err
+}
+
+// DeleteFilter 删除过滤器并安排写入文件
+func (s *FileStorage) DeleteFilter(ctx context.Context, id int) error {
+	err := s.MemoryStorage.DeleteFilter(ctx, id)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return err
+}
+
+// 过滤器很少修改，预写日志直接记录为一条快照
+
+// CreateFilter 保存新的过滤器并在预写日志中记录快照
+func (s *WALMemoryStorage) CreateFilter(ctx context.Context, req *models.SaveFilterRequest) (*models.SavedFilter, error) {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	filter, err := s.MemoryStorage.CreateFilter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return filter, s.logSnapshot()
+}
+
+// UpdateFilter 替换过滤器的名称和查询语句并在预写日志中记录快照
+func (s *WALMemoryStorage) UpdateFilter(ctx context.Context, id int, req *models.SaveFilterRequest) (*models.SavedFilter, error) {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	filter, err := s.MemoryStorage.UpdateFilter(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	return filter, s.logSnapshot()
+}
+
+// DeleteFilter 删除过滤器并在预写日志中记录快照
+func (s *WALMemoryStorage) DeleteFilter(ctx context.Context, id int) error {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	if err := s.MemoryStorage.DeleteFilter(ctx, id); err != nil {
+		return err
+	}
+	return s.logSnapshot()
+}
+
+// ListFilters 返回全部过滤器，按ID升序排列
+func (s *SQLStorage) ListFilters(ctx context.Context) ([]*models.SavedFilter, error) {
+	rows, err := s.query(ctx, "SELECT id, name, query, created_at, updated_at FROM saved_filters ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	filters := []*models.SavedFilter{}
+	for rows.Next() {
+		filter, err := scanFilter(rows)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, rows.Err()
+}
+
+// GetFilter 根据ID获取过滤器
+func (s *SQLStorage) GetFilter(ctx context.Context, id int) (*models.SavedFilter, error) {
+	filter, err := scanFilter(s.queryRow(ctx, "SELECT id, name, query, created_at, updated_at FROM saved_filters WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFilterNotFound
+	}
+	return filter, err
+}
+
+// CreateFilter 保存新的过滤器
+func (s *SQLStorage) CreateFilter(ctx context.Context, req *models.SaveFilterRequest) (*models.SavedFilter, error) {
+	now := time.Now()
+	id, err := s.insert(ctx, "INSERT INTO saved_filters (name, query, created_at, updated_at) VALUES (?, ?, ?, ?)",
+		req.Name, req.Query, formatTime(now), formatTime(now))
+	if err != nil {
+		return nil, err
+	}
+	return &models.SavedFilter{ID: models.ID(id), Name: req.Name, Query: req.Query, CreatedAt: now, UpdatedAt: now}, nil
+}
+
+// UpdateFilter 替换过滤器的名称和查询语句
+func (s *SQLStorage) UpdateFilter(ctx context.Context, id int, req *models.SaveFilterRequest) (*models.SavedFilter, error) {
+	var filter *models.SavedFilter
+	err := s.atomic(ctx, func(tx *SQLStorage) error {
+		var err error
+		if filter, err = tx.GetFilter(ctx, id); err != nil {
+			return err
+		}
+		filter.Name, filter.Query, filter.UpdatedAt = req.Name, req.Query, time.Now()
+		_, err = tx.exec(ctx, "UPDATE saved_filters SET name = ?, query = ?, updated_at = ? WHERE id = ?",
+			filter.Name, filter.Query, formatTime(filter.UpdatedAt), id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// DeleteFilter 删除过滤器
+func (s *SQLStorage) DeleteFilter(ctx context.Context, id int) error {
+	result, err := s.exec(ctx, "DELETE FROM saved_filters WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrFilterNotFound
+	}
+	return nil
+}
+
+// scanFilter 从查询结果中读取一个过滤器
+func scanFilter(row interface{ Scan(dest ...any) error }) (*models.SavedFilter, error) {
+	var (
+		filter               models.SavedFilter
+		id                   int
+		createdAt, updatedAt string
+	)
+	if err := row.Scan(&id, &filter.Name, &filter.Query, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	filter.ID = models.ID(id)
+	var err error
+	if filter.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if filter.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
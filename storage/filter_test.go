@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"go-todolist/models"
+)
+
+// testFilterCRUD 检查过滤器的增删改查，结束时剩下 ID 为 2 的过滤器 "b"
+func testFilterCRUD(t *testing.T, s FilterStorage) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, name := range []string{"a", "b", "c"} {
+		filter, err := s.CreateFilter(ctx, &models.SaveFilterRequest{Name: name, Query: "tag:" + name})
+		if err != nil {
+			t.Fatalf("创建过滤器失败: %v", err)
+		}
+		if filter.Name != name || filter.Query != "tag:"+name || filter.CreatedAt.IsZero() {
+			t.Errorf("创建的过滤器 = %+v", filter)
+		}
+	}
+
+	updated, err := s.UpdateFilter(ctx, 2, &models.SaveFilterRequest{Name: "b2", Query: "is:open"})
+	if err != nil {
+		t.Fatalf("更新过滤器失败: %v", err)
+	}
+	if updated.ID != 2 || updated.Name != "b2" || updated.Query != "is:open" || updated.UpdatedAt.Before(updated.CreatedAt) {
+		t.Errorf("更新后的过滤器 = %+v", updated)
+	}
+	got, err := s.GetFilter(ctx, 2)
+	if err != nil {
+		t.Fatalf("获取过滤器失败: %v", err)
+	}
+	if got.Name != "b2" || got.Query != "is:open" {
+		t.Errorf("获取的过滤器 = %+v，期望更新后的名称和查询语句", got)
+	}
+
+	for _, id := range []int{1, 3} {
+		if err := s.DeleteFilter(ctx, id); err != nil {
+			t.Fatalf("删除过滤器 %d 失败: %v", id, err)
+		}
+	}
+	list, err := s.ListFilters(ctx)
+	if err != nil {
+		t.Fatalf("获取过滤器列表失败: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != 2 {
+		t.Errorf("过滤器列表 = %+v，期望只剩 ID 2", list)
+	}
+
+	if _, err := s.GetFilter(ctx, 1); !errors.Is(err, ErrFilterNotFound) {
+		t.Errorf("获取已删除的过滤器错误 = %v，期望 %v", err, ErrFilterNotFound)
+	}
+	if _, err := s.UpdateFilter(ctx, 9, &models.SaveFilterRequest{Name: "x", Query: "is:open"}); !errors.Is(err, ErrFilterNotFound) {
+		t.Errorf("更新不存在的过滤器错误 = %v，期望 %v", err, ErrFilterNotFound)
+	}
+	if err := s.DeleteFilter(ctx, 1); !errors.Is(err, ErrFilterNotFound) {
+		t.Errorf("重复删除过滤器错误 = %v，期望 %v", err, ErrFilterNotFound)
+	}
+}
+
+func TestMemoryFilters(t *testing.T) {
+	s := NewMemoryStorage()
+	testFilterCRUD(t, s)
+	ctx := context.Background()
+
+	// 返回的是副本，修改不影响存储
+	filter, _ := s.GetFilter(ctx, 2)
+	filter.Name = "changed"
+	if got, _ := s.GetFilter(ctx, 2); got.Name != "b2" {
+		t.Errorf("修改副本后名称 = %q，期望 b2", got.Name)
+	}
+
+	// Clear 不删除过滤器，导出和导入保留过滤器和下一个ID，已删除的ID不会复用
+	if _, err := s.Clear(ctx); err != nil {
+		t.Fatalf("清空失败: %v", err)
+	}
+	snapshot, err := s.Export(ctx)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	imported := NewMemoryStorage()
+	if err := imported.Import(ctx, snapshot); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if got, err := imported.GetFilter(ctx, 2); err != nil || got.Name != "b2" {
+		t.Errorf("导入后的过滤器 = %+v, %v", got, err)
+	}
+	created, err := imported.CreateFilter(ctx, &models.SaveFilterRequest{Name: "d", Query: "is:done"})
+	if err != nil || created.ID != 4 {
+		t.Errorf("导入后新建过滤器 ID = %v, %v，期望 4", created, err)
+	}
+}
+
+func TestSQLiteFilters(t *testing.T) {
+	testFilterCRUD(t, openTestSQLite(t))
+}
+
+func TestFileFilters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	s, err := OpenFile(path, 0)
+	if err != nil {
+		t.Fatalf("打开文件存储失败: %v", err)
+	}
+	testFilterCRUD(t, s)
+	if err := s.Close(); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+
+	reopened, err := OpenFile(path, 0)
+	if err != nil {
+		t.Fatalf("重新打开文件存储失败: %v", err)
+	}
+	defer reopened.Close()
+	list, _ := reopened.ListFilters(context.Background())
+	if len(list) != 1 || list[0].Name != "b2" {
+		t.Errorf("重新打开后的过滤器 = %+v，期望只剩 b2", list)
+	}
+}
+
+func TestWALMemoryFilters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.wal")
+	s, err := OpenWALMemory(path)
+	if err != nil {
+		t.Fatalf("打开预写日志存储失败: %v", err)
+	}
+	defer s.Close()
+	testFilterCRUD(t, s)
+	assertReplays(t, s, path)
+}
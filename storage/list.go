@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"go-todolist/models"
+)
+
+// ListOptions 列表查询条件，各条件同时满足才返回，零值表示返回全部
+type ListOptions struct {
+	// Completed、Starred 不为 nil 时只返回完成状态、星标状态与之相同的待办事项
+	Completed *bool
+	Starred   *bool
+	// Archived 不为 nil 时只返回归档状态与之相同的待办事项
+	Archived *bool
+	// ListID 不为 nil 时只返回属于该清单的待办事项，空字符串匹配不属于任何清单的
+	ListID *string
+	// Tag 非空时只返回包含该标签的待办事项，IgnoreTagCase 为 true 时比较忽略大小写
+	Tag           string
+	IgnoreTagCase bool
+	// Priorities 非空时只返回优先级在其中的待办事项
+	Priorities []models.Priority
+	// Query 非空时只返回标题或描述中包含该关键字（忽略大小写）的待办事项
+	Query string
+	// CreatedAfter、CreatedBefore、UpdatedAfter、UpdatedBefore 非零时只返回创建或更新时间在其之后或之前（不含）的待办事项
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	// DueAfter、DueBefore 非零时只返回截止时间在其之后或之前（不含）的待办事项，没有截止时间的不匹配
+	DueAfter  time.Time
+	DueBefore time.Time
+	// Limit、Offset 按 Sort 排序后跳过 Offset 项，最多返回 Limit 项，Limit 为 0 表示不限制
+	Limit  int
+	Offset int
+	// Sort、Desc 为分页前的排序方式，零值为按ID升序；Desc 为 true 时降序，排序键相同的仍按ID升序
+	Sort ListSort
+	Desc bool
+	// After 不为 nil 时改为按 (CreatedAt, ID) 升序排列，只返回排在 After 之后的最多 Limit 项并忽略 Offset、Sort 和 Desc。
+	// After 只影响分页，不影响总数
+	After *Keyset
+}
+
+// ListSort 列表在存储中排序使用的字段
+type ListSort string
+
+const (
+	// SortByID 按ID排序，为默认值
+	SortByID ListSort = ""
+	// SortByPriority 按优先级权重排序，未知优先级的权重为 0
+	SortByPriority ListSort = "priority"
+)
+
+// Keyset 游标分页的位置，即按 (CreatedAt, ID) 升序排列时上一页最后一项的创建时间和ID。ID 为 0 表示从第一项开始
+type Keyset struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// Precedes 判断位置是否排在 todo 之前
+func (k *Keyset) Precedes(todo *models.Todo) bool {
+	if k.ID == 0 || todo.CreatedAt.After(k.CreatedAt) {
+		return true
+	}
+	return todo.CreatedAt.Equal(k.CreatedAt) && int(todo.ID) > k.ID
+}
+
+// compareKeyset 按 (CreatedAt, ID) 比较两个待办事项
+func compareKeyset(a, b *models.Todo) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// HasFilters 判断是否设置了过滤条件，不考虑分页
+func (o *ListOptions) HasFilters() bool {
+	return o.Completed != nil || o.Starred != nil || o.Archived != nil || o.ListID != nil || o.Tag != "" || len(o.Priorities) > 0 || o.Query != "" ||
+		!o.CreatedAfter.IsZero() || !o.CreatedBefore.IsZero() || !o.UpdatedAfter.IsZero() || !o.UpdatedBefore.IsZero() ||
+		!o.DueAfter.IsZero() || !o.DueBefore.IsZero()
+}
+
+// Matches 判断待办事项是否满足全部过滤条件
+func (o *ListOptions) Matches(todo *models.Todo) bool {
+	if o.Completed != nil && todo.Completed != *o.Completed {
+		return false
+	}
+	if o.Starred != nil && todo.Starred != *o.Starred {
+		return false
+	}
+	if o.Archived != nil && todo.Archived != *o.Archived {
+		return false
+	}
+	if o.ListID != nil && todo.ListID != *o.ListID {
+		return false
+	}
+	if o.Tag != "" && !o.hasTag(todo) {
+		return false
+	}
+	if len(o.Priorities) > 0 && !slices.Contains(o.Priorities, todo.Priority) {
+		return false
+	}
+	if o.Query != "" && !containsFold(todo.Title, o.Query) && !containsFold(todo.Description, o.Query) {
+		return false
+	}
+	if (!o.DueAfter.IsZero() || !o.DueBefore.IsZero()) && (todo.DueDate == nil || !inRange(*todo.DueDate, o.DueAfter, o.DueBefore)) {
+		return false
+	}
+	return inRange(todo.CreatedAt, o.CreatedAfter, o.CreatedBefore) && inRange(todo.UpdatedAt, o.UpdatedAfter, o.UpdatedBefore)
+}
+
+// inRange 判断 t 是否在 after 之后且在 before 之前，零值表示该端不限制
+func inRange(t, after, before time.Time) bool {
+	return (after.IsZero() || t.After(after)) && (before.IsZero() || t.Before(before))
+}
+
+// hasTag 判断待办事项是否包含 Tag 指定的标签
+func (o *ListOptions) hasTag(todo *models.Todo) bool {
+	if !o.IgnoreTagCase {
+		return todo.HasTag(o.Tag)
+	}
+	return slices.ContainsFunc(todo.Tags, func(tag string) bool {
+		return strings.EqualFold(tag, o.Tag)
+	})
+}
+
+// Filter 返回 todos 中满足全部过滤条件的待办事项，不分页
+func (o *ListOptions) Filter(todos []*models.Todo) []*models.Todo {
+	if !o.HasFilters() {
+		return todos
+	}
+	result := make([]*models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if o.Matches(todo) {
+			result = append(result, todo)
+		}
+	}
+	return result
+}
+
+// Lister 由能在存储中过滤和分页的存储实现，避免为了部分数据读取全部待办事项
+type Lister interface {
+	// List 按 opts 的排序（设置了 After 时按 (CreatedAt, ID) 升序）返回满足 opts 的一页待办事项，同时返回分页前满足条件的总数
+	List(ctx context.Context, opts ListOptions) ([]*models.Todo, int, error)
+}
+
+// List 按 opts 获取待办事项和总数。存储实现了 Lister 时在存储中过滤和分页，否则读取全部后过滤
+func List(ctx context.Context, s TodoStorage, opts ListOptions) ([]*models.Todo, int, error) {
+	if lister, ok := s.(Lister); ok {
+		return lister.List(ctx, opts)
+	}
+	todos, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	todos = opts.Filter(todos)
+	return opts.page(todos), len(todos), nil
+}
+
+// Counter 由能在存储中统计数量的存储实现，避免为了数量读取全部待办事项
+type Counter interface {
+	// Count 返回满足 opts 的待办事项数量，忽略分页
+	Count(ctx context.Context, opts ListOptions) (int, error)
+}
+
+// Count 返回满足 opts 的待办事项数量。存储实现了 Counter 时在存储中统计，否则通过 List 只读取一项获得总数
+func Count(ctx context.Context, s TodoStorage, opts ListOptions) (int, error) {
+	if counter, ok := s.(Counter); ok {
+		return counter.Count(ctx, opts)
+	}
+	opts.Limit, opts.Offset = 1, 0
+	_, total, err := List(ctx, s, opts)
+	return total, err
+}
+
+// page 对已按ID升序排列并过滤的 todos 按 Sort 排序后分页。设置了 After 时按 (CreatedAt, ID) 重新排序后取 After 之后的项，
+// 会改变 todos 中的顺序
+func (o *ListOptions) page(todos []*models.Todo) []*models.Todo {
+	if o.After == nil {
+		o.sort(todos)
+		return pageOf(todos, o.Limit, o.Offset)
+	}
+	slices.SortStableFunc(todos, compareKeyset)
+	start := len(todos)
+	for i, todo := range todos {
+		if o.After.Precedes(todo) {
+			start = i
+			break
+		}
+	}
+	return pageOf(todos[start:], o.Limit, 0)
+}
+
+// sorted 判断是否需要按ID升序以外的顺序排列
+func (o *ListOptions) sorted() bool {
+	return o.Sort != SortByID || o.Desc
+}
+
+// sort 将按ID升序排列的 todos 原地按 Sort 和 Desc 重新排序，排序键相同的保持ID升序
+func (o *ListOptions) sort(todos []*models.Todo) {
+	if !o.sorted() {
+		return
+	}
+	slices.SortStableFunc(todos, func(a, b *models.Todo) int {
+		var c int
+		switch o.Sort {
+		case SortByPriority:
+			c = cmp.Compare(a.Priority.Weight(), b.Priority.Weight())
+		default:
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if o.Desc {
+			c = -c
+		}
+		return c
+	})
+}
+
+// pageOf 按 offset 和 limit 截取列表
+func pageOf(todos []*models.Todo, limit, offset int) []*models.Todo {
+	if offset >= len(todos) {
+		return []*models.Todo{}
+	}
+	todos = todos[offset:]
+	if limit > 0 && limit < len(todos) {
+		todos = todos[:limit]
+	}
+	return todos
+}
+
+// containsFold 判断 s 是否包含 substr，忽略大小写
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
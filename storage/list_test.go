@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+// keysetBackends 参与游标分页测试的存储
+var keysetBackends = []struct {
+	name string
+	open func(t *testing.T) TodoStorage
+}{
+	{"memory", func(t *testing.T) TodoStorage { return NewMemoryStorage() }},
+	// 只嵌入 TodoStorage，不实现 Lister，由 List 读取全部数据后分页
+	{"fallback", func(t *testing.T) TodoStorage { return struct{ TodoStorage }{NewMemoryStorage()} }},
+	{"sqlite", func(t *testing.T) TodoStorage { return openTestSQLite(t) }},
+}
+
+func TestListAfter(t *testing.T) {
+	for _, backend := range keysetBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			testListAfter(t, backend.open(t))
+		})
+	}
+}
+
+// listIDs 返回待办事项的ID
+func listIDs(todos []*models.Todo) []int {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = int(todo.ID)
+	}
+	return ids
+}
+
+// testListAfter 检查按 (CreatedAt, ID) 的游标分页，供各存储的测试共用。
+// 创建时间与ID顺序不一致且有相同的创建时间，按游标顺序为 2, 6, 3, 4, 1, 5
+func testListAfter(t *testing.T, s TodoStorage) {
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	offsets := []time.Duration{2 * time.Second, 0, time.Second, time.Second, 3 * time.Second, 0}
+	snapshot := &Snapshot{}
+	for i, offset := range offsets {
+		created := base.Add(offset)
+		todo := &models.Todo{ID: models.ID(i + 1), Title: "todo", CreatedAt: created, UpdatedAt: created, Completed: i == 4}
+		if i == 5 {
+			todo.Tags = []string{"x"}
+		}
+		snapshot.Todos = append(snapshot.Todos, todo)
+	}
+	if err := s.Import(ctx, snapshot); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+
+	notDone := false
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantIDs   []int
+		wantTotal int
+	}{
+		{"第一页", ListOptions{After: &Keyset{}, Limit: 2}, []int{2, 6}, 6},
+		{"相同创建时间按ID", ListOptions{After: &Keyset{CreatedAt: base, ID: 6}, Limit: 2}, []int{3, 4}, 6},
+		{"中间一页", ListOptions{After: &Keyset{CreatedAt: base.Add(time.Second), ID: 4}, Limit: 2}, []int{1, 5}, 6},
+		{"最后一项之后", ListOptions{After: &Keyset{CreatedAt: base.Add(3 * time.Second), ID: 5}, Limit: 2}, []int{}, 6},
+		{"不限制数量", ListOptions{After: &Keyset{CreatedAt: base, ID: 2}}, []int{6, 3, 4, 1, 5}, 6},
+		{"忽略 Offset", ListOptions{After: &Keyset{}, Limit: 1, Offset: 3}, []int{2}, 6},
+		{"存储过滤", ListOptions{After: &Keyset{}, Completed: &notDone, Limit: 3}, []int{2, 6, 3}, 5},
+		{"内存过滤", ListOptions{After: &Keyset{}, Tag: "x"}, []int{6}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, total, err := List(ctx, s, tt.opts)
+			if err != nil {
+				t.Fatalf("List 失败: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("总数 = %d，期望 %d", total, tt.wantTotal)
+			}
+			if got := listIDs(todos); !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("ID = %v，期望 %v", got, tt.wantIDs)
+			}
+		})
+	}
+
+	// 游标所在的待办事项被永久删除后，按游标中的创建时间继续
+	if err := s.Delete(ctx, 3); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if trash, ok := s.(TrashStorage); ok {
+		if _, err := trash.PurgeTrash(ctx, time.Time{}); err != nil {
+			t.Fatalf("清空回收站失败: %v", err)
+		}
+	}
+	todos, _, err := List(ctx, s, ListOptions{After: &Keyset{CreatedAt: base.Add(time.Second), ID: 3}})
+	if err != nil {
+		t.Fatalf("List 失败: %v", err)
+	}
+	if got := listIDs(todos); !slices.Equal(got, []int{4, 1, 5}) {
+		t.Errorf("删除游标所在项后 ID = %v，期望 [4 1 5]", got)
+	}
+}
+
+func TestKeysetPrecedes(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	todo := &models.Todo{ID: 5, CreatedAt: base}
+	tests := []struct {
+		name   string
+		keyset Keyset
+		want   bool
+	}{
+		{"第一页", Keyset{}, true},
+		{"更早的创建时间", Keyset{CreatedAt: base.Add(-time.Second), ID: 9}, true},
+		{"相同创建时间、更小的ID", Keyset{CreatedAt: base, ID: 4}, true},
+		{"就是该项", Keyset{CreatedAt: base, ID: 5}, false},
+		{"相同创建时间、更大的ID", Keyset{CreatedAt: base, ID: 6}, false},
+		{"更晚的创建时间", Keyset{CreatedAt: base.Add(time.Second), ID: 1}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.keyset.Precedes(todo); got != tt.want {
+			t.Errorf("%s: Precedes = %v，期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// 优先级依次为 medium、urgent、low、urgent、high、medium，5 已完成，1-3 带标签 x
+func TestListSort(t *testing.T) {
+	ctx := context.Background()
+	priorities := []models.Priority{models.PriorityMedium, models.PriorityUrgent, models.PriorityLow, models.PriorityUrgent, models.PriorityHigh, models.PriorityMedium}
+	notDone := false
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantIDs   []int
+		wantTotal int
+	}{
+		{"优先级升序", ListOptions{Sort: SortByPriority}, []int{3, 1, 6, 5, 2, 4}, 6},
+		// 降序时优先级相同的仍按ID升序
+		{"优先级降序", ListOptions{Sort: SortByPriority, Desc: true}, []int{2, 4, 5, 1, 6, 3}, 6},
+		{"优先级降序分页", ListOptions{Sort: SortByPriority, Desc: true, Limit: 2, Offset: 1}, []int{4, 5}, 6},
+		{"ID降序分页", ListOptions{Desc: true, Limit: 3}, []int{6, 5, 4}, 6},
+		{"过滤后按优先级排序", ListOptions{Completed: &notDone, Sort: SortByPriority, Desc: true}, []int{2, 4, 1, 6, 3}, 5},
+		{"内存中过滤后按优先级排序", ListOptions{Tag: "x", Sort: SortByPriority, Limit: 2}, []int{3, 1}, 3},
+	}
+	for _, backend := range keysetBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			s := backend.open(t)
+			for i, priority := range priorities {
+				req := &models.CreateTodoRequest{Title: "todo", Priority: priority}
+				if i < 3 {
+					req.Tags = []string{"x"}
+				}
+				if _, err := s.Create(ctx, req); err != nil {
+					t.Fatalf("创建失败: %v", err)
+				}
+			}
+			done := true
+			if _, err := s.Update(ctx, 5, &models.UpdateTodoRequest{Completed: &done}); err != nil {
+				t.Fatalf("更新失败: %v", err)
+			}
+
+			for _, tt := range tests {
+				todos, total, err := List(ctx, s, tt.opts)
+				if err != nil {
+					t.Fatalf("%s: List 失败: %v", tt.name, err)
+				}
+				if got := listIDs(todos); !slices.Equal(got, tt.wantIDs) || total != tt.wantTotal {
+					t.Errorf("%s: 结果 = %v，总数 %d，期望 %v，总数 %d", tt.name, got, total, tt.wantIDs, tt.wantTotal)
+				}
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,111 +13,470 @@ import (
 
 var (
 	ErrTodoNotFound = errors.New("待办事项未找到")
+	// ErrCommentNotFound 待办事项下不存在指定的备注
+	ErrCommentNotFound = errors.New("备注未找到")
+	// ErrValidation 存储层拒绝了无效数据，可使用 fmt.Errorf("%w: ...") 附带细节
+	ErrValidation = errors.New("数据无效")
+	// ErrConflict 写入与现有数据冲突
+	ErrConflict = errors.New("数据冲突")
+	// ErrTxConflict 事务或乐观锁写入期间数据被其他请求修改，重新执行整个操作可能成功。
+	// 它包装了 ErrConflict，对外仍映射为 409
+	ErrTxConflict = fmt.Errorf("%w: 事务期间数据已被其他请求修改", ErrConflict)
+	// ErrUnavailable 存储服务暂时不可用，可稍后重试
+	ErrUnavailable = errors.New("存储服务暂时不可用")
 )
 
-// MemoryStorage 内存存储实现
+// maxTodoVersions 每个待办事项保留的最近版本数
+const maxTodoVersions = 50
+
+// MemoryStorage 内存存储实现。读写方法返回的待办事项都是深拷贝，
+// 存储内部的数据只在持有锁时访问，调用方修改返回值不会与并发请求产生数据竞争
 type MemoryStorage struct {
-	todos  map[int]*models.Todo
-	nextID int
-	mutex  sync.RWMutex
+	todos       map[int]*models.Todo
+	history     map[int][]models.TodoVersion
+	externalIDs map[string]map[int]bool
+	order       []int
+	// trash 回收站，Delete 将待办事项连同备注移到这里，不再出现在其他读取方法中
+	trash         map[int]*models.Todo
+	nextID        int
+	nextCommentID int
+	// filters 保存的过滤器，不属于待办事项数据，事务的快照和回滚不包含它们
+	filters      map[int]*models.SavedFilter
+	nextFilterID int
+	// revision 每次修改后递增，显式事务提交时据此判断期间是否有其他修改
+	revision uint64
+	mutex    sync.RWMutex
 }
 
 // NewMemoryStorage 创建新的内存存储实例
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
-		todos:  make(map[int]*models.Todo),
-		nextID: 1,
+		todos:         make(map[int]*models.Todo),
+		history:       make(map[int][]models.TodoVersion),
+		externalIDs:   make(map[string]map[int]bool),
+		trash:         make(map[int]*models.Todo),
+		nextID:        1,
+		nextCommentID: 1,
+		filters:       make(map[int]*models.SavedFilter),
+		nextFilterID:  1,
 	}
 }
 
-// GetAll 获取所有待办事项
-func (s *MemoryStorage) GetAll() ([]*models.Todo, error) {
+// GetAll 获取所有待办事项，按ID升序排列
+func (s *MemoryStorage) GetAll(ctx context.Context) ([]*models.Todo, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	todos := make([]*models.Todo, 0, len(s.todos))
-	for _, todo := range s.todos {
-		todos = append(todos, todo)
+	return s.getAll()
+}
+
+// getAll 在调用方持有锁的前提下获取所有待办事项
+func (s *MemoryStorage) getAll() ([]*models.Todo, error) {
+	// order 始终按ID升序维护，这里无需再排序
+	todos := make([]*models.Todo, len(s.order))
+	for i, id := range s.order {
+		todos[i] = cloneTodo(s.todos[id])
 	}
 	return todos, nil
 }
 
-// GetByID 根据ID获取待办事项
-func (s *MemoryStorage) GetByID(id int) (*models.Todo, error) {
+// List 按 opts 的排序（设置了 After 时按创建时间）返回满足 opts 的一页待办事项和总数，只复制这一页的数据
+func (s *MemoryStorage) List(ctx context.Context, opts ListOptions) ([]*models.Todo, int, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
+	if opts.After != nil || opts.sorted() {
+		var matched []*models.Todo
+		for _, id := range s.order {
+			if todo := s.todos[id]; opts.Matches(todo) {
+				matched = append(matched, todo)
+			}
+		}
+		return cloneTodos(opts.page(matched)), len(matched), nil
+	}
+
+	todos := make([]*models.Todo, 0)
+	total := 0
+	for _, id := range s.order {
+		todo := s.todos[id]
+		if !opts.Matches(todo) {
+			continue
+		}
+		if total >= opts.Offset && (opts.Limit <= 0 || len(todos) < opts.Limit) {
+			todos = append(todos, cloneTodo(todo))
+		}
+		total++
+	}
+	return todos, total, nil
+}
+
+// Count 返回满足 opts 的待办事项数量，不复制数据
+func (s *MemoryStorage) Count(ctx context.Context, opts ListOptions) (int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	count := 0
+	for _, todo := range s.todos {
+		if opts.Matches(todo) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetByID 根据ID获取待办事项的副本
+func (s *MemoryStorage) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.getByID(id)
+}
+
+// getByID 在调用方持有锁的前提下根据ID获取待办事项
+func (s *MemoryStorage) getByID(id int) (*models.Todo, error) {
 	todo, exists := s.todos[id]
 	if !exists {
 		return nil, ErrTodoNotFound
 	}
-	return todo, nil
+	return cloneTodo(todo), nil
 }
 
 // Create 创建新的待办事项
-func (s *MemoryStorage) Create(req *models.CreateTodoRequest) (*models.Todo, error) {
+func (s *MemoryStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	now := time.Now()
-	todo := &models.Todo{
-		ID:          s.nextID,
-		Title:       req.Title,
-		Description: req.Description,
-		Completed:   false,
-		CreatedAt:   now,
-		UpdatedAt:   now,
-	}
+	return s.create(req)
+}
+
+// create 在调用方持有锁的前提下创建待办事项
+func (s *MemoryStorage) create(req *models.CreateTodoRequest) (*models.Todo, error) {
+	todo := newTodo(s.nextID, req, time.Now())
 
 	s.todos[s.nextID] = todo
+	// nextID 单调递增，追加后 order 仍保持升序
+	s.order = append(s.order, s.nextID)
+	s.indexExternalID(todo.ExternalID, s.nextID)
+	s.recordVersion(todo)
 	s.nextID++
+	s.revision++
 
-	return todo, nil
+	return cloneTodo(todo), nil
 }
 
 // Update 更新待办事项
-func (s *MemoryStorage) Update(id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+func (s *MemoryStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	return s.update(id, req)
+}
+
+// update 在调用方持有锁的前提下更新待办事项
+func (s *MemoryStorage) update(id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
 	todo, exists := s.todos[id]
 	if !exists {
 		return nil, ErrTodoNotFound
 	}
 
-	// 更新字段
-	if req.Title != nil {
-		todo.Title = *req.Title
+	externalID := todo.ExternalID
+	applyUpdate(todo, req, time.Now())
+	if todo.ExternalID != externalID {
+		s.unindexExternalID(externalID, id)
+		s.indexExternalID(todo.ExternalID, id)
 	}
-	if req.Description != nil {
-		todo.Description = *req.Description
+	s.recordVersion(todo)
+	s.revision++
+
+	return cloneTodo(todo), nil
+}
+
+// Delete 将待办事项移入回收站
+func (s *MemoryStorage) Delete(ctx context.Context, id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.delete(id, time.Now())
+}
+
+// delete 在调用方持有锁的前提下将待办事项移入回收站，删除时间记为 now
+func (s *MemoryStorage) delete(id int, now time.Time) error {
+	todo, err := s.remove(id)
+	if err != nil {
+		return err
 	}
-	if req.Completed != nil {
-		todo.Completed = *req.Completed
+	todo.DeletedAt = &now
+	s.trash[id] = todo
+	return nil
+}
+
+// remove 在调用方持有锁的前提下移除待办事项及其历史版本，返回被移除的待办事项
+func (s *MemoryStorage) remove(id int) (*models.Todo, error) {
+	todo, exists := s.todos[id]
+	if !exists {
+		return nil, ErrTodoNotFound
 	}
-	todo.UpdatedAt = time.Now()
 
+	s.unindexExternalID(todo.ExternalID, id)
+	if i := sort.SearchInts(s.order, id); i < len(s.order) && s.order[i] == id {
+		s.order = append(s.order[:i], s.order[i+1:]...)
+	}
+	delete(s.todos, id)
+	delete(s.history, id)
+	s.revision++
 	return todo, nil
 }
 
-// Delete 删除待办事项
-func (s *MemoryStorage) Delete(id int) error {
+// FindByExternalID 返回外部ID匹配的待办事项，按ID升序排列，没有匹配时返回空切片
+func (s *MemoryStorage) FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.findByExternalID(externalID)
+}
+
+// findByExternalID 在调用方持有锁的前提下按外部ID查找
+func (s *MemoryStorage) findByExternalID(externalID string) ([]*models.Todo, error) {
+	todos := make([]*models.Todo, 0, len(s.externalIDs[externalID]))
+	for id := range s.externalIDs[externalID] {
+		todos = append(todos, cloneTodo(s.todos[id]))
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		return todos[i].ID < todos[j].ID
+	})
+	return todos, nil
+}
+
+// indexExternalID 将待办事项加入外部ID索引，空外部ID不建立索引
+func (s *MemoryStorage) indexExternalID(externalID string, id int) {
+	if externalID == "" {
+		return
+	}
+	if s.externalIDs[externalID] == nil {
+		s.externalIDs[externalID] = make(map[int]bool)
+	}
+	s.externalIDs[externalID][id] = true
+}
+
+// unindexExternalID 将待办事项从外部ID索引中移除
+func (s *MemoryStorage) unindexExternalID(externalID string, id int) {
+	ids := s.externalIDs[externalID]
+	if ids == nil {
+		return
+	}
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(s.externalIDs, externalID)
+	}
+}
+
+// rebuildExternalIDIndex 根据当前数据重建外部ID索引
+func (s *MemoryStorage) rebuildExternalIDIndex() {
+	s.externalIDs = make(map[string]map[int]bool)
+	for id, todo := range s.todos {
+		s.indexExternalID(todo.ExternalID, id)
+	}
+}
+
+// History 返回待办事项保留的历史版本，按版本号升序排列
+func (s *MemoryStorage) History(ctx context.Context, id int) ([]models.TodoVersion, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.getHistory(id)
+}
+
+// getHistory 在调用方持有锁的前提下获取历史版本
+func (s *MemoryStorage) getHistory(id int) ([]models.TodoVersion, error) {
+	if _, exists := s.todos[id]; !exists {
+		return nil, ErrTodoNotFound
+	}
+	return append([]models.TodoVersion{}, s.history[id]...), nil
+}
+
+// recordVersion 记录待办事项当前状态为新版本，超出保留数量时丢弃最旧的版本
+func (s *MemoryStorage) recordVersion(todo *models.Todo) {
+	id := int(todo.ID)
+	versions := s.history[id]
+
+	version := 1
+	if len(versions) > 0 {
+		version = versions[len(versions)-1].Version + 1
+	}
+
+	versions = append(versions, newVersion(todo, version))
+	if len(versions) > maxTodoVersions {
+		versions = append([]models.TodoVersion{}, versions[len(versions)-maxTodoVersions:]...)
+	}
+	s.history[id] = versions
+}
+
+// AddComment 为待办事项添加备注，备注不视为对待办事项本身的修改，因此不更新 UpdatedAt
+func (s *MemoryStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if _, exists := s.todos[id]; !exists {
+	return s.addComment(todoID, req)
+}
+
+// addComment 在调用方持有锁的前提下添加备注
+func (s *MemoryStorage) addComment(todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	todo, exists := s.todos[todoID]
+	if !exists {
+		return nil, ErrTodoNotFound
+	}
+
+	comment := models.Comment{
+		ID:        models.ID(s.nextCommentID),
+		Body:      req.Body,
+		CreatedAt: time.Now(),
+	}
+	todo.Comments = append(todo.Comments, comment)
+	s.nextCommentID++
+	s.revision++
+
+	return &comment, nil
+}
+
+// DeleteComment 删除待办事项下的备注，同样不更新 UpdatedAt
+func (s *MemoryStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.deleteComment(todoID, commentID)
+}
+
+// deleteComment 在调用方持有锁的前提下删除备注
+func (s *MemoryStorage) deleteComment(todoID, commentID int) error {
+	todo, exists := s.todos[todoID]
+	if !exists {
 		return ErrTodoNotFound
 	}
 
-	delete(s.todos, id)
+	for i, comment := range todo.Comments {
+		if comment.ID == models.ID(commentID) {
+			comments := make([]models.Comment, 0, len(todo.Comments)-1)
+			comments = append(comments, todo.Comments[:i]...)
+			todo.Comments = append(comments, todo.Comments[i+1:]...)
+			s.revision++
+			return nil
+		}
+	}
+	return ErrCommentNotFound
+}
+
+// Clear 删除所有待办事项并清空回收站、重置ID序列，返回删除的数量（不含回收站）
+func (s *MemoryStorage) Clear(ctx context.Context) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.clear()
+}
+
+// clear 在调用方持有锁的前提下清空存储
+func (s *MemoryStorage) clear() (int, error) {
+	count := len(s.todos)
+	s.todos = make(map[int]*models.Todo)
+	s.history = make(map[int][]models.TodoVersion)
+	s.externalIDs = make(map[string]map[int]bool)
+	s.order = nil
+	// ID序列重置后回收站中的ID可能被新的待办事项占用，一并清空
+	s.trash = make(map[int]*models.Todo)
+	s.nextID = 1
+	s.nextCommentID = 1
+	s.revision++
+	return count, nil
+}
+
+// rebuildOrder 根据 todos 重建按ID升序排列的顺序索引
+func (s *MemoryStorage) rebuildOrder() {
+	s.order = make([]int, 0, len(s.todos))
+	for id := range s.todos {
+		s.order = append(s.order, id)
+	}
+	sort.Ints(s.order)
+}
+
+// Export 导出全部数据的快照
+func (s *MemoryStorage) Export(ctx context.Context) (*Snapshot, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.export(), nil
+}
+
+// export 在调用方持有锁的前提下导出快照，快照中的数据是深拷贝
+func (s *MemoryStorage) export() *Snapshot {
+	state := s.snapshot()
+	todos := make([]*models.Todo, len(s.order))
+	for i, id := range s.order {
+		todos[i] = state.todos[id]
+	}
+	return &Snapshot{
+		Format:        SnapshotFormat,
+		ExportedAt:    time.Now(),
+		NextID:        state.nextID,
+		NextCommentID: state.nextCommentID,
+		Todos:         todos,
+		History:       state.history,
+		Trash:         sortedTodos(state.trash),
+		NextFilterID:  s.nextFilterID,
+		Filters:       s.listFilters(),
+	}
+}
+
+// Import 用快照替换全部数据，快照中的待办事项此后归存储所有
+func (s *MemoryStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.importSnapshot(snapshot)
+}
+
+// importSnapshot 在调用方持有锁的前提下用快照替换全部数据
+func (s *MemoryStorage) importSnapshot(snapshot *Snapshot) error {
+	if err := snapshot.normalize(); err != nil {
+		return err
+	}
+	todos := make(map[int]*models.Todo, len(snapshot.Todos))
+	for _, todo := range snapshot.Todos {
+		todos[int(todo.ID)] = todo
+	}
+	trash := make(map[int]*models.Todo, len(snapshot.Trash))
+	for _, todo := range snapshot.Trash {
+		trash[int(todo.ID)] = todo
+	}
+	s.restore(memoryState{
+		todos:         todos,
+		history:       snapshot.History,
+		trash:         trash,
+		nextID:        snapshot.NextID,
+		nextCommentID: snapshot.NextCommentID,
+	})
+	s.filters = make(map[int]*models.SavedFilter, len(snapshot.Filters))
+	for _, filter := range snapshot.Filters {
+		s.filters[int(filter.ID)] = filter
+	}
+	s.nextFilterID = snapshot.NextFilterID
 	return nil
 }
 
-// TodoStorage 定义存储接口
+// TodoStorage 定义存储接口。ctx 携带请求的取消信号和截止时间，网络存储据此中止数据库调用，
+// 内存等本地存储可以忽略
 type TodoStorage interface {
-	GetAll() ([]*models.Todo, error)
-	GetByID(id int) (*models.Todo, error)
-	Create(req *models.CreateTodoRequest) (*models.Todo, error)
-	Update(id int, req *models.UpdateTodoRequest) (*models.Todo, error)
-	Delete(id int) error
+	GetAll(ctx context.Context) ([]*models.Todo, error)
+	GetByID(ctx context.Context, id int) (*models.Todo, error)
+	Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error)
+	Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error)
+	// Delete 删除待办事项，实现了 TrashStorage 的存储将其移入回收站
+	Delete(ctx context.Context, id int) error
+	AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error)
+	DeleteComment(ctx context.Context, todoID, commentID int) error
+	Clear(ctx context.Context) (int, error)
+	History(ctx context.Context, id int) ([]models.TodoVersion, error)
+	FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error)
+	// Export 导出全部数据的快照
+	Export(ctx context.Context) (*Snapshot, error)
+	// Import 用快照原子地替换全部数据，保留快照中的ID
+	Import(ctx context.Context, snapshot *Snapshot) error
 }
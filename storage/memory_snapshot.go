@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// SnapshotMemoryStorage 定期保存快照的内存存储。启动时从快照文件加载数据，之后每隔 interval
+// 以及 Close 时将全部数据写入快照文件，重启时最多丢失一个间隔内的修改。
+// 与 FileStorage 不同，写入频率与修改次数无关，适合写入频繁的场景
+type SnapshotMemoryStorage struct {
+	*MemoryStorage
+
+	path string
+	stop chan struct{}
+	done chan struct{}
+
+	// saveMutex 串行化写文件；lastSaved 为上次写入时的数据，用于跳过没有变化的快照
+	saveMutex sync.Mutex
+	lastSaved []byte
+}
+
+// OpenSnapshotMemory 从 path 加载数据（文件不存在时从空数据开始），
+// interval 大于 0 时启动后台协程定期保存快照，为 0 时只在 Close 时保存
+func OpenSnapshotMemory(path string, interval time.Duration) (*SnapshotMemoryStorage, error) {
+	s := &SnapshotMemoryStorage{
+		MemoryStorage: NewMemoryStorage(),
+		path:          path,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	snapshot, err := readSnapshotFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		if err := s.MemoryStorage.Import(context.Background(), snapshot); err != nil {
+			return nil, fmt.Errorf("加载快照文件失败: %w", err)
+		}
+	}
+
+	if interval > 0 {
+		go s.run(interval)
+	} else {
+		close(s.done)
+	}
+	return s, nil
+}
+
+// run 每隔 interval 保存一次快照，直到 Close
+func (s *SnapshotMemoryStorage) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Save(); err != nil {
+				log.Printf("保存内存快照失败: %v", err)
+			}
+		}
+	}
+}
+
+// Save 立即将当前数据写入快照文件，数据与上次写入时相同则跳过
+func (s *SnapshotMemoryStorage) Save() error {
+	s.saveMutex.Lock()
+	defer s.saveMutex.Unlock()
+
+	snapshot, err := s.MemoryStorage.Export(context.Background())
+	if err != nil {
+		return err
+	}
+	// 导出时间每次都不同，比较时不计入
+	exportedAt := snapshot.ExportedAt
+	snapshot.ExportedAt = time.Time{}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if s.lastSaved != nil && bytes.Equal(data, s.lastSaved) {
+		return nil
+	}
+
+	snapshot.ExportedAt = exportedAt
+	if err := writeSnapshotFile(s.path, snapshot); err != nil {
+		return err
+	}
+	s.lastSaved = data
+	return nil
+}
+
+// Close 停止后台协程并保存最后一次快照
+func (s *SnapshotMemoryStorage) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.Save()
+}
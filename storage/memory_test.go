@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"go-todolist/models"
+)
+
+// newFilledMemoryStorage 创建包含 n 个待办事项的内存存储
+func newFilledMemoryStorage(tb testing.TB, n int) *MemoryStorage {
+	tb.Helper()
+	s := NewMemoryStorage()
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: fmt.Sprintf("todo %d", i)}); err != nil {
+			tb.Fatalf("创建待办事项失败: %v", err)
+		}
+	}
+	return s
+}
+
+// assertAscending 检查待办事项按ID严格升序且与 want 中的ID一致
+func assertAscending(t *testing.T, todos []*models.Todo, want []int) {
+	t.Helper()
+	if len(todos) != len(want) {
+		t.Fatalf("返回 %d 项，期望 %d 项", len(todos), len(want))
+	}
+	for i, todo := range todos {
+		if int(todo.ID) != want[i] {
+			t.Fatalf("第 %d 项ID = %d，期望 %d", i, todo.ID, want[i])
+		}
+	}
+}
+
+func TestMemoryGetAllOrder(t *testing.T) {
+	ctx := context.Background()
+	s := newFilledMemoryStorage(t, 10)
+	for _, id := range []int{1, 4, 5, 10} {
+		if err := s.Delete(ctx, id); err != nil {
+			t.Fatalf("删除 %d 失败: %v", id, err)
+		}
+	}
+	if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: "new"}); err != nil {
+		t.Fatalf("创建待办事项失败: %v", err)
+	}
+	if _, err := s.Restore(ctx, 4); err != nil {
+		t.Fatalf("恢复 4 失败: %v", err)
+	}
+
+	todos, err := s.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll 失败: %v", err)
+	}
+	assertAscending(t, todos, []int{2, 3, 4, 6, 7, 8, 9, 11})
+
+	page, total, err := s.List(ctx, ListOptions{Offset: 2, Limit: 3})
+	if err != nil {
+		t.Fatalf("List 失败: %v", err)
+	}
+	if total != 8 {
+		t.Errorf("总数 = %d，期望 8", total)
+	}
+	assertAscending(t, page, []int{4, 6, 7})
+
+	// 返回值是副本，修改后不影响存储内的数据
+	todos[0].Title = "changed"
+	again, _ := s.GetAll(ctx)
+	if again[0].Title == "changed" {
+		t.Error("修改 GetAll 的返回值影响了存储内的数据")
+	}
+}
+
+func TestMemoryGetAllConcurrent(t *testing.T) {
+	ctx := context.Background()
+	s := newFilledMemoryStorage(t, 200)
+
+	var wg sync.WaitGroup
+	// 并发创建
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: "concurrent"}); err != nil {
+					t.Errorf("创建待办事项失败: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	// 并发删除偶数ID
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for id := 2 + 2*w; id <= 200; id += 8 {
+				if err := s.Delete(ctx, id); err != nil {
+					t.Errorf("删除 %d 失败: %v", id, err)
+					return
+				}
+			}
+		}(w)
+	}
+	// 并发读取，每次读到的结果都必须按ID升序
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				todos, err := s.GetAll(ctx)
+				if err != nil {
+					t.Errorf("GetAll 失败: %v", err)
+					return
+				}
+				if !sort.SliceIsSorted(todos, func(a, b int) bool { return todos[a].ID < todos[b].ID }) {
+					t.Error("并发读取时 GetAll 结果未按ID升序")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var want []int
+	for id := 1; id <= 600; id++ {
+		if id > 200 || id%2 == 1 {
+			want = append(want, id)
+		}
+	}
+	todos, err := s.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll 失败: %v", err)
+	}
+	assertAscending(t, todos, want)
+}
+
+// BenchmarkMemoryGetAll 比较内存存储维护有序ID列表与每次调用排序的开销，各存储之间的对比见 BenchmarkGetAll
+func BenchmarkMemoryGetAll(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range []int{100, 10_000, 100_000} {
+		s := newFilledMemoryStorage(b, n)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.GetAll(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		// 对照：每次调用都从 map 收集并排序，即维护 order 之前的做法
+		b.Run(fmt.Sprintf("n=%d/sort_each_call", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s.mutex.RLock()
+				todos := make([]*models.Todo, 0, len(s.todos))
+				for _, todo := range s.todos {
+					todos = append(todos, cloneTodo(todo))
+				}
+				s.mutex.RUnlock()
+				sort.Slice(todos, func(a, b int) bool { return todos[a].ID < todos[b].ID })
+			}
+		})
+	}
+}
+
+// TestMemoryDefensiveCopies 并发读取、修改返回值和更新同一个待办事项，需配合 go test -race 运行
+func TestMemoryDefensiveCopies(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	created, err := s.Create(ctx, &models.CreateTodoRequest{Title: "shared", Tags: []string{"a"}})
+	if err != nil {
+		t.Fatalf("创建待办事项失败: %v", err)
+	}
+	id := int(created.ID)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(3)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				title := fmt.Sprintf("title %d-%d", w, i)
+				tags := []string{"a", title}
+				done := i%2 == 0
+				if _, err := s.Update(ctx, id, &models.UpdateTodoRequest{Title: &title, Tags: &tags, Completed: &done}); err != nil {
+					t.Errorf("更新失败: %v", err)
+					return
+				}
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				todo, err := s.GetByID(ctx, id)
+				if err != nil {
+					t.Errorf("GetByID 失败: %v", err)
+					return
+				}
+				// 修改返回值不得影响存储内的数据，也不得与其他读写产生竞争
+				todo.Title = "mutated"
+				todo.Tags = append(todo.Tags[:0], "mutated")
+				if todo.CompletedAt != nil {
+					*todo.CompletedAt = todo.CompletedAt.Add(1)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				todos, err := s.GetAll(ctx)
+				if err != nil {
+					t.Errorf("GetAll 失败: %v", err)
+					return
+				}
+				for _, todo := range todos {
+					todo.Tags = append(todo.Tags[:0], "mutated")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	todo, err := s.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID 失败: %v", err)
+	}
+	if todo.Title == "mutated" || len(todo.Tags) != 2 || todo.Tags[0] != "a" {
+		t.Errorf("存储内的数据被调用方修改: title=%q tags=%v", todo.Title, todo.Tags)
+	}
+}
+
+func TestMemoryFindByExternalID(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	for _, externalID := range []string{"TICKET-1", "", "TICKET-2", "TICKET-1"} {
+		if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: "t", ExternalID: externalID}); err != nil {
+			t.Fatalf("创建待办事项失败: %v", err)
+		}
+	}
+	find := func(externalID string, want []int) {
+		t.Helper()
+		todos, err := s.FindByExternalID(ctx, externalID)
+		if err != nil {
+			t.Fatalf("FindByExternalID(%q) 失败: %v", externalID, err)
+		}
+		assertAscending(t, todos, want)
+	}
+
+	find("TICKET-1", []int{1, 4})
+	find("TICKET-2", []int{3})
+	find("TICKET-3", []int{})
+	// 空外部ID不建立索引
+	find("", []int{})
+
+	// 修改、删除和恢复时同步更新索引
+	changed := "TICKET-2"
+	if _, err := s.Update(ctx, 1, &models.UpdateTodoRequest{ExternalID: &changed}); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	find("TICKET-1", []int{4})
+	find("TICKET-2", []int{1, 3})
+	if err := s.Delete(ctx, 3); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	find("TICKET-2", []int{1})
+	if _, err := s.Restore(ctx, 3); err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	find("TICKET-2", []int{1, 3})
+}
@@ -0,0 +1,433 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go-todolist/models"
+)
+
+// walCompactThreshold 追加这么多条日志后自动压缩，避免日志无限增长
+const walCompactThreshold = 10000
+
+// 预写日志的操作类型
+const (
+	// walPut 写入待办事项修改后的完整内容
+	walPut = "put"
+	// walDelete 删除待办事项
+	walDelete = "delete"
+	// walSnapshot 用快照替换全部数据，用于清空、导入以及压缩后的日志开头
+	walSnapshot = "snapshot"
+	// walTx 事务修改的待办事项，Entries 中的 put 和 delete 写在同一行，重放时整体生效
+	walTx = "tx"
+)
+
+// walEntry 预写日志中的一行。记录的是操作后的结果而不是请求，重放时不依赖当前时间，结果与写入时一致
+type walEntry struct {
+	Op string `json:"op"`
+	ID int    `json:"id,omitempty"`
+	// DeletedAt 待办事项移入回收站的时间；旧版本写入的 delete 没有该字段，重放时直接删除
+	DeletedAt *time.Time   `json:"deleted_at,omitempty"`
+	Todo      *models.Todo `json:"todo,omitempty"`
+	Snapshot  *Snapshot    `json:"snapshot,omitempty"`
+	Entries   []walEntry   `json:"entries,omitempty"`
+	// Record 为 true 时重放 put 需要同时记录历史版本（创建和更新会记录，备注的增删不记录）
+	Record        bool `json:"record,omitempty"`
+	NextID        int  `json:"next_id"`
+	NextCommentID int  `json:"next_comment_id"`
+}
+
+// WALMemoryStorage 带预写日志的内存存储。每次修改成功后以一行 JSON 追加到日志文件并同步到磁盘，
+// 启动时重放日志恢复数据，进程崩溃也不会丢失已返回成功的修改。
+// 日志在启动、关闭以及追加 walCompactThreshold 条后压缩为一条快照
+type WALMemoryStorage struct {
+	*MemoryStorage
+
+	path string
+
+	// walMutex 串行化所有修改及其日志写入，保证日志顺序与内存中的修改顺序一致
+	walMutex sync.Mutex
+	file     *os.File
+	entries  int
+}
+
+// OpenWALMemory 重放 path 中的日志（文件不存在时从空数据开始）并打开日志用于追加
+func OpenWALMemory(path string) (*WALMemoryStorage, error) {
+	s := &WALMemoryStorage{MemoryStorage: NewMemoryStorage(), path: path}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// replay 依次应用日志中的每一行。最后一行不完整说明写入时进程崩溃，该修改未返回成功，直接丢弃
+func (s *WALMemoryStorage) replay() error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取预写日志失败: %w", err)
+	}
+	defer file.Close()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				log.Printf("预写日志第 %d 行不完整，已丢弃", lineNumber)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取预写日志失败: %w", err)
+		}
+
+		var entry walEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("预写日志第 %d 行无效: %w", lineNumber, err)
+		}
+		if err := s.MemoryStorage.applyWALEntry(&entry); err != nil {
+			return fmt.Errorf("预写日志第 %d 行无效: %w", lineNumber, err)
+		}
+	}
+}
+
+// applyWALEntry 在调用方持有锁的前提下重放一条日志
+func (s *MemoryStorage) applyWALEntry(entry *walEntry) error {
+	switch entry.Op {
+	case walPut:
+		if entry.Todo == nil {
+			return fmt.Errorf("%w: put 缺少待办事项", ErrValidation)
+		}
+		todo := entry.Todo
+		id := int(todo.ID)
+		if old, exists := s.todos[id]; exists {
+			s.unindexExternalID(old.ExternalID, id)
+		} else {
+			i := sort.SearchInts(s.order, id)
+			s.order = append(s.order[:i], append([]int{id}, s.order[i:]...)...)
+		}
+		// 从回收站恢复的待办事项以 put 记录
+		delete(s.trash, id)
+		s.todos[id] = todo
+		s.indexExternalID(todo.ExternalID, id)
+		if entry.Record {
+			s.recordVersion(todo)
+		}
+	case walDelete:
+		// 删除不存在的待办事项不会写入日志，这里忽略 ErrTodoNotFound 以容忍重复重放
+		var err error
+		if entry.DeletedAt != nil {
+			err = s.delete(entry.ID, *entry.DeletedAt)
+		} else {
+			_, err = s.remove(entry.ID)
+		}
+		if err != nil && !errors.Is(err, ErrTodoNotFound) {
+			return err
+		}
+	case walTx:
+		for i := range entry.Entries {
+			if err := s.applyWALEntry(&entry.Entries[i]); err != nil {
+				return err
+			}
+		}
+	case walSnapshot:
+		if entry.Snapshot == nil {
+			return fmt.Errorf("%w: snapshot 缺少快照", ErrValidation)
+		}
+		if err := s.importSnapshot(entry.Snapshot); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: 未知的操作 %q", ErrValidation, entry.Op)
+	}
+	s.nextID, s.nextCommentID = entry.NextID, entry.NextCommentID
+	return nil
+}
+
+// compact 将当前数据写为只包含一条快照的新日志并替换旧日志，调用方需持有 walMutex 或尚未对外提供存储
+func (s *WALMemoryStorage) compact() error {
+	snapshot, err := s.MemoryStorage.Export(context.Background())
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(walEntry{
+		Op:            walSnapshot,
+		Snapshot:      snapshot,
+		NextID:        snapshot.NextID,
+		NextCommentID: snapshot.NextCommentID,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("压缩预写日志失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("压缩预写日志失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("压缩预写日志失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("压缩预写日志失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("压缩预写日志失败: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("打开预写日志失败: %w", err)
+	}
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file = file
+	s.entries = 0
+	return nil
+}
+
+// append 追加一条日志，调用方需持有 walMutex
+func (s *WALMemoryStorage) append(entry walEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.write(data)
+}
+
+// write 追加一行已序列化的日志并同步到磁盘，调用方需持有 walMutex。
+// 写入失败时内存中的修改已经生效，但重启后会丢失，因此向调用方返回错误
+func (s *WALMemoryStorage) write(data []byte) error {
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入预写日志失败: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("写入预写日志失败: %w", err)
+	}
+
+	s.entries++
+	if s.entries >= walCompactThreshold {
+		if err := s.compact(); err != nil {
+			// 压缩失败不影响已写入的日志，下次达到阈值时重试
+			log.Printf("压缩预写日志失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// logPut 记录待办事项 id 修改后的内容，调用方需持有 walMutex
+func (s *WALMemoryStorage) logPut(id int, record bool) error {
+	s.mutex.RLock()
+	data, err := json.Marshal(walEntry{
+		Op:            walPut,
+		Todo:          s.todos[id],
+		Record:        record,
+		NextID:        s.nextID,
+		NextCommentID: s.nextCommentID,
+	})
+	s.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	return s.write(data)
+}
+
+// logTx 将事务修改的待办事项记录为一条日志，事务清空或导入了全部数据时记录快照，调用方需持有 walMutex
+func (s *WALMemoryStorage) logTx(tx *memoryTx) error {
+	if tx.replaced {
+		return s.logSnapshot()
+	}
+	if len(tx.touched) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(tx.touched))
+	for id := range tx.touched {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	s.mutex.RLock()
+	entries := make([]walEntry, 0, len(ids))
+	for _, id := range ids {
+		record := tx.touched[id]
+		if todo, exists := s.todos[id]; exists {
+			entries = append(entries, walEntry{Op: walPut, Todo: todo, Record: record})
+		} else if todo, exists := s.trash[id]; exists {
+			// 先写入最终内容再移入回收站，事务中新建后又删除的待办事项也能正确重放
+			entries = append(entries,
+				walEntry{Op: walPut, Todo: todo, Record: record},
+				walEntry{Op: walDelete, ID: id, DeletedAt: cloneTime(todo.DeletedAt)})
+		} else {
+			entries = append(entries, walEntry{Op: walDelete, ID: id})
+		}
+	}
+	data, err := json.Marshal(walEntry{
+		Op:            walTx,
+		Entries:       entries,
+		NextID:        s.nextID,
+		NextCommentID: s.nextCommentID,
+	})
+	s.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	return s.write(data)
+}
+
+// logSnapshot 记录当前全部数据，调用方需持有 walMutex
+func (s *WALMemoryStorage) logSnapshot() error {
+	snapshot, err := s.MemoryStorage.Export(context.Background())
+	if err != nil {
+		return err
+	}
+	return s.append(walEntry{
+		Op:            walSnapshot,
+		Snapshot:      snapshot,
+		NextID:        snapshot.NextID,
+		NextCommentID: snapshot.NextCommentID,
+	})
+}
+
+// Close 压缩日志并关闭文件
+func (s *WALMemoryStorage) Close() error {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	if err := s.compact(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+func (s *WALMemoryStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	todo, err := s.MemoryStorage.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return todo, s.logPut(int(todo.ID), true)
+}
+
+func (s *WALMemoryStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	todo, err := s.MemoryStorage.Update(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	return todo, s.logPut(id, true)
+}
+
+func (s *WALMemoryStorage) Delete(ctx context.Context, id int) error {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	if err := s.MemoryStorage.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.mutex.RLock()
+	entry := walEntry{Op: walDelete, ID: id, DeletedAt: cloneTime(s.trash[id].DeletedAt), NextID: s.nextID, NextCommentID: s.nextCommentID}
+	s.mutex.RUnlock()
+	return s.append(entry)
+}
+
+func (s *WALMemoryStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	comment, err := s.MemoryStorage.AddComment(ctx, todoID, req)
+	if err != nil {
+		return nil, err
+	}
+	return comment, s.logPut(todoID, false)
+}
+
+func (s *WALMemoryStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	if err := s.MemoryStorage.DeleteComment(ctx, todoID, commentID); err != nil {
+		return err
+	}
+	return s.logPut(todoID, false)
+}
+
+func (s *WALMemoryStorage) Clear(ctx context.Context) (int, error) {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	count, err := s.MemoryStorage.Clear(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return count, s.logSnapshot()
+}
+
+func (s *WALMemoryStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	if err := s.MemoryStorage.Import(ctx, snapshot); err != nil {
+		return err
+	}
+	return s.logSnapshot()
+}
+
+// WithTx 在内存存储的事务中执行 fn，提交后将事务修改的待办事项记录为一条日志
+func (s *WALMemoryStorage) WithTx(ctx context.Context, fn func(TodoStorage) error) error {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	tx, err := s.MemoryStorage.withTx(fn)
+	if err != nil {
+		return err
+	}
+	return s.logTx(tx)
+}
+
+// BeginTx 开启内存存储的显式事务，提交后将事务修改的待办事项记录为一条日志
+func (s *WALMemoryStorage) BeginTx(ctx context.Context) (Tx, error) {
+	tx, err := s.MemoryStorage.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &commitHookTx{Tx: tx, commit: func(tx Tx) error {
+		s.walMutex.Lock()
+		defer s.walMutex.Unlock()
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return s.logTx(&tx.(*memoryBeginTx).memoryTx)
+	}}, nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+// lastWALEntry 读取日志文件的最后一行
+func lastWALEntry(t *testing.T, path string) walEntry {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取预写日志失败: %v", err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	var entry walEntry
+	if err := json.Unmarshal(lines[len(lines)-1], &entry); err != nil {
+		t.Fatalf("解析预写日志失败: %v", err)
+	}
+	return entry
+}
+
+// exportJSON 导出存储的全部数据并序列化，忽略导出时间，用于比较重放前后的数据
+func exportJSON(t *testing.T, s TodoStorage) string {
+	t.Helper()
+	snapshot, err := s.Export(context.Background())
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	snapshot.ExportedAt = time.Time{}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	return string(data)
+}
+
+// assertReplays 在日志文件的副本上重新打开存储，检查重放的结果与 s 一致
+func assertReplays(t *testing.T, s *WALMemoryStorage, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取预写日志失败: %v", err)
+	}
+	copyPath := filepath.Join(t.TempDir(), "copy.wal")
+	if err := os.WriteFile(copyPath, data, 0o644); err != nil {
+		t.Fatalf("复制预写日志失败: %v", err)
+	}
+	replayed, err := OpenWALMemory(copyPath)
+	if err != nil {
+		t.Fatalf("重放预写日志失败: %v", err)
+	}
+	defer replayed.Close()
+
+	if got, want := exportJSON(t, replayed), exportJSON(t, s); got != want {
+		t.Errorf("重放结果与原存储不一致:\n得到 %s\n期望 %s", got, want)
+	}
+}
+
+// walTxWrites 在事务中更新、删除、创建待办事项，并创建一个随即删除的待办事项
+func walTxWrites(t *testing.T, tx TodoStorage) {
+	t.Helper()
+	ctx := context.Background()
+	title := "changed"
+	if _, err := tx.Update(ctx, 3, &models.UpdateTodoRequest{Title: &title}); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	if _, err := tx.AddComment(ctx, 5, &models.CreateCommentRequest{Body: "note"}); err != nil {
+		t.Fatalf("添加备注失败: %v", err)
+	}
+	if err := tx.Delete(ctx, 7); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if _, err := tx.Create(ctx, &models.CreateTodoRequest{Title: "new"}); err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	temp, err := tx.Create(ctx, &models.CreateTodoRequest{Title: "temp"})
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if err := tx.Delete(ctx, int(temp.ID)); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+}
+
+// assertTxEntry 检查日志最后一行是只包含事务涉及的待办事项的 tx 记录
+func assertTxEntry(t *testing.T, path string) {
+	t.Helper()
+	entry := lastWALEntry(t, path)
+	if entry.Op != walTx {
+		t.Fatalf("最后一条日志的操作 = %q，期望 %q", entry.Op, walTx)
+	}
+	if entry.Snapshot != nil {
+		t.Error("事务日志不应包含快照")
+	}
+	type op struct {
+		op string
+		id int
+	}
+	var got []op
+	for _, e := range entry.Entries {
+		id := e.ID
+		if e.Todo != nil {
+			id = int(e.Todo.ID)
+		}
+		got = append(got, op{e.Op, id})
+	}
+	want := []op{{walPut, 3}, {walPut, 5}, {walPut, 7}, {walDelete, 7}, {walPut, 11}, {walPut, 12}, {walDelete, 12}}
+	if len(got) != len(want) {
+		t.Fatalf("事务日志记录 = %v，期望 %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("事务日志记录 = %v，期望 %v", got, want)
+		}
+	}
+	if entry.NextID != 13 {
+		t.Errorf("NextID = %d，期望 13", entry.NextID)
+	}
+}
+
+// openFilledWAL 打开临时目录中的预写日志存储并创建 10 个待办事项
+func openFilledWAL(t *testing.T) (*WALMemoryStorage, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "todos.wal")
+	s, err := OpenWALMemory(path)
+	if err != nil {
+		t.Fatalf("打开预写日志存储失败: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	for i := 0; i < 10; i++ {
+		if _, err := s.Create(context.Background(), &models.CreateTodoRequest{Title: "todo"}); err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+	}
+	return s, path
+}
+
+func TestWALWithTxLogsTouchedTodos(t *testing.T) {
+	s, path := openFilledWAL(t)
+	err := s.WithTx(context.Background(), func(tx TodoStorage) error {
+		walTxWrites(t, tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx 失败: %v", err)
+	}
+	assertTxEntry(t, path)
+	assertReplays(t, s, path)
+}
+
+func TestWALBeginTxLogsTouchedTodos(t *testing.T) {
+	s, path := openFilledWAL(t)
+	tx, err := s.BeginTx(context.Background())
+	if err != nil {
+		t.Fatalf("BeginTx 失败: %v", err)
+	}
+	walTxWrites(t, tx)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit 失败: %v", err)
+	}
+	assertTxEntry(t, path)
+	assertReplays(t, s, path)
+}
+
+func TestWALTxClearLogsSnapshot(t *testing.T) {
+	s, path := openFilledWAL(t)
+	err := s.WithTx(context.Background(), func(tx TodoStorage) error {
+		_, err := tx.Clear(context.Background())
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx 失败: %v", err)
+	}
+	if entry := lastWALEntry(t, path); entry.Op != walSnapshot {
+		t.Errorf("清空后最后一条日志的操作 = %q，期望 %q", entry.Op, walSnapshot)
+	}
+	assertReplays(t, s, path)
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go-todolist/migrations"
+)
+
+// sqlDatabase 使用数据库迁移的 SQL 驱动
+type sqlDatabase struct {
+	open       func(dsn string) (*sql.DB, error)
+	dialect    sqlDialect
+	defaultDSN string
+}
+
+var sqlDatabases = map[string]sqlDatabase{
+	"sqlite":   {open: openSQLiteDB, dialect: sqliteDialect, defaultDSN: "todos.db"},
+	"postgres": {open: openPostgresDB, dialect: postgresDialect},
+	"mysql":    {open: openMySQLDB, dialect: mysqlDialect},
+}
+
+// Migrate 对 SQL 驱动执行未应用的数据库迁移，返回迁移前后的版本。
+// 正常启动时 Open 也会自动迁移，这里供命令行单独执行并查看版本
+func Migrate(driver, dsn string) (from, to int, err error) {
+	database, ok := sqlDatabases[driver]
+	if !ok {
+		return 0, 0, fmt.Errorf("存储驱动 %q 不使用数据库迁移", driver)
+	}
+	dsn = withDefault(dsn, database.defaultDSN)
+	if dsn == "" {
+		return 0, 0, fmt.Errorf("%s 驱动需要提供 DSN", driver)
+	}
+
+	db, err := database.open(dsn)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer db.Close()
+	return migrations.Up(db, database.dialect.name)
+}
+
+// MigrationTarget 返回 SQL 驱动的最新数据库版本
+func MigrationTarget(driver string) (int, error) {
+	database, ok := sqlDatabases[driver]
+	if !ok {
+		return 0, fmt.Errorf("存储驱动 %q 不使用数据库迁移", driver)
+	}
+	return migrations.Target(database.dialect.name)
+}
@@ -0,0 +1,23 @@
+package storage
+
+// MongoOptions MongoDB 存储的配置
+type MongoOptions struct {
+	// URI MongoDB 连接串，如 mongodb://localhost:27017
+	URI string
+	// Database 数据库名，默认 todolist
+	Database string
+	// Collection 保存待办事项的集合名，默认 todos；ID计数器保存在 <Collection>_counters 集合，
+	// 回收站保存在 <Collection>_trash 集合
+	Collection string
+}
+
+// withDefaults 填充未设置的数据库名和集合名
+func (o MongoOptions) withDefaults() MongoOptions {
+	if o.Database == "" {
+		o.Database = "todolist"
+	}
+	if o.Collection == "" {
+		o.Collection = "todos"
+	}
+	return o
+}
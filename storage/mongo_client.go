@@ -0,0 +1,524 @@
+//go:build mongodb
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-todolist/models"
+)
+
+// mongoTimeout 单次 MongoDB 操作的超时时间
+const mongoTimeout = 10 * time.Second
+
+// MongoStorage 基于 MongoDB 的存储。每个待办事项一个文档，完整内容以 JSON 保存在 data 字段，
+// completed、created_at、external_id 另存为顶层字段以便建立索引和查询；
+// 回收站中的待办事项以相同结构保存在独立的集合，deleted_at 另存为顶层字段
+type MongoStorage struct {
+	client   *mongo.Client
+	todos    *mongo.Collection
+	trash    *mongo.Collection
+	counters *mongo.Collection
+}
+
+// mongoTodo 集合中保存的文档
+type mongoTodo struct {
+	ID         int        `bson:"_id"`
+	ExternalID string     `bson:"external_id"`
+	Completed  bool       `bson:"completed"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	DeletedAt  *time.Time `bson:"deleted_at,omitempty"`
+	Data       string     `bson:"data"`
+	Versions   string     `bson:"versions"`
+}
+
+// OpenMongo 连接 MongoDB 并创建所需的索引
+func OpenMongo(opts MongoOptions) (*MongoStorage, error) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(opts.URI))
+	if err != nil {
+		return nil, fmt.Errorf("连接 MongoDB 失败: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("连接 MongoDB 失败: %w", err)
+	}
+
+	db := client.Database(opts.Database)
+	s := &MongoStorage{
+		client:   client,
+		todos:    db.Collection(opts.Collection),
+		trash:    db.Collection(opts.Collection + "_trash"),
+		counters: db.Collection(opts.Collection + "_counters"),
+	}
+	_, err = s.todos.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "completed", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "external_id", Value: 1}}},
+	})
+	if err == nil {
+		_, err = s.trash.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "deleted_at", Value: 1}}})
+	}
+	if err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("创建 MongoDB 索引失败: %w", err)
+	}
+	return s, nil
+}
+
+// Close 断开连接
+func (s *MongoStorage) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+	return s.client.Disconnect(ctx)
+}
+
+// nextSequence 原子地递增并返回计数器 name
+func (s *MongoStorage) nextSequence(ctx context.Context, name string) (int, error) {
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := s.counters.FindOneAndUpdate(ctx,
+		bson.D{{Key: "_id", Value: name}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: 1}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Seq, err
+}
+
+// encodeMongoTodo 将待办事项及其历史版本转换为文档
+func encodeMongoTodo(todo *models.Todo, versions []models.TodoVersion) (*mongoTodo, error) {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return nil, err
+	}
+	encodedVersions, err := json.Marshal(versions)
+	if err != nil {
+		return nil, err
+	}
+	return &mongoTodo{
+		ID:         int(todo.ID),
+		ExternalID: todo.ExternalID,
+		Completed:  todo.Completed,
+		CreatedAt:  todo.CreatedAt,
+		DeletedAt:  todo.DeletedAt,
+		Data:       string(data),
+		Versions:   string(encodedVersions),
+	}, nil
+}
+
+// decode 从文档还原待办事项及其历史版本
+func (doc *mongoTodo) decode() (*models.Todo, []models.TodoVersion, error) {
+	var todo models.Todo
+	if err := json.Unmarshal([]byte(doc.Data), &todo); err != nil {
+		return nil, nil, err
+	}
+	versions := []models.TodoVersion{}
+	if doc.Versions != "" {
+		if err := json.Unmarshal([]byte(doc.Versions), &versions); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &todo, versions, nil
+}
+
+// find 读取文档，不存在时返回 ErrTodoNotFound
+func (s *MongoStorage) find(ctx context.Context, id int) (*mongoTodo, error) {
+	var doc mongoTodo
+	err := s.todos.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// modify 读取、修改并写回待办事项；写回时以读取到的 data 为条件，期间被其他实例修改时返回 ErrTxConflict
+func (s *MongoStorage) modify(ctx context.Context, id int, record bool, fn func(todo *models.Todo) error) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	doc, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	todo, versions, err := doc.decode()
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(todo); err != nil {
+		return nil, err
+	}
+	if record {
+		versions = appendVersion(versions, todo)
+	}
+
+	updated, err := encodeMongoTodo(todo, versions)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.todos.ReplaceOne(ctx, bson.D{{Key: "_id", Value: id}, {Key: "data", Value: doc.Data}}, updated)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrTxConflict
+	}
+	return todo, nil
+}
+
+func (s *MongoStorage) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	return s.findTodos(ctx, bson.D{})
+}
+
+// findTodos 按ID升序返回匹配 filter 的待办事项
+func (s *MongoStorage) findTodos(ctx context.Context, filter bson.D) ([]*models.Todo, error) {
+	return findMongoTodos(ctx, s.todos, filter, bson.D{{Key: "_id", Value: 1}})
+}
+
+// findMongoTodos 按 sort 的顺序返回 collection 中匹配 filter 的待办事项
+func findMongoTodos(ctx context.Context, collection *mongo.Collection, filter, sort bson.D) ([]*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(sort))
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoTodo
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	todos := make([]*models.Todo, 0, len(docs))
+	for i := range docs {
+		todo, _, err := docs[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
+
+func (s *MongoStorage) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	doc, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	todo, _, err := doc.decode()
+	return todo, err
+}
+
+func (s *MongoStorage) FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error) {
+	return s.findTodos(ctx, bson.D{{Key: "external_id", Value: externalID}})
+}
+
+func (s *MongoStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	id, err := s.nextSequence(ctx, "todo")
+	if err != nil {
+		return nil, err
+	}
+	todo := newTodo(id, req, time.Now())
+	doc, err := encodeMongoTodo(todo, appendVersion(nil, todo))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.todos.InsertOne(ctx, doc); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *MongoStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	return s.modify(ctx, id, true, func(todo *models.Todo) error {
+		applyUpdate(todo, req, time.Now())
+		return nil
+	})
+}
+
+// Delete 将待办事项移入回收站集合并删除其历史版本。先写入回收站再以读取到的 data 为条件删除，
+// 期间被其他实例修改时撤销回收站中的文档并返回 ErrTxConflict
+func (s *MongoStorage) Delete(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	doc, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	todo, _, err := doc.decode()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	todo.DeletedAt = &now
+	trashed, err := encodeMongoTodo(todo, nil)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.D{{Key: "_id", Value: id}}
+	if _, err := s.trash.ReplaceOne(ctx, filter, trashed, options.Replace().SetUpsert(true)); err != nil {
+		return err
+	}
+	result, err := s.todos.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}, {Key: "data", Value: doc.Data}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		if _, err := s.trash.DeleteOne(ctx, filter); err != nil {
+			return err
+		}
+		return ErrTxConflict
+	}
+	return nil
+}
+
+// Trash 返回回收站中的待办事项，最近删除的排在最前
+func (s *MongoStorage) Trash(ctx context.Context) ([]*models.Todo, error) {
+	return findMongoTodos(ctx, s.trash, bson.D{}, bson.D{{Key: "deleted_at", Value: -1}, {Key: "_id", Value: -1}})
+}
+
+// PurgeTrash 永久删除回收站中删除时间早于 before 的待办事项，before 为零值时清空回收站
+func (s *MongoStorage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	filter := bson.D{}
+	if !before.IsZero() {
+		filter = bson.D{{Key: "deleted_at", Value: bson.D{{Key: "$lt", Value: before}}}}
+	}
+	result, err := s.trash.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.DeletedCount), nil
+}
+
+// Restore 将待办事项从回收站恢复并记录新的历史版本
+func (s *MongoStorage) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: id}}
+	var doc mongoTodo
+	err := s.trash.FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		_, findErr := s.find(ctx, id)
+		if findErr != nil && !errors.Is(findErr, ErrTodoNotFound) {
+			return nil, findErr
+		}
+		lastID, err := s.sequenceValue(ctx, "todo")
+		if err != nil {
+			return nil, err
+		}
+		return nil, restoreError(id, findErr == nil, lastID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	todo, _, err := doc.decode()
+	if err != nil {
+		return nil, err
+	}
+	todo.DeletedAt = nil
+	todo.UpdatedAt = time.Now()
+	restored, err := encodeMongoTodo(todo, appendVersion(nil, todo))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.todos.InsertOne(ctx, restored); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, restoreError(id, true, 0)
+		}
+		return nil, err
+	}
+	if _, err := s.trash.DeleteOne(ctx, filter); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *MongoStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	var comment models.Comment
+	_, err := s.modify(ctx, todoID, false, func(todo *models.Todo) error {
+		ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+		defer cancel()
+		id, err := s.nextSequence(ctx, "comment")
+		if err != nil {
+			return err
+		}
+		comment = models.Comment{ID: models.ID(id), Body: req.Body, CreatedAt: time.Now()}
+		todo.Comments = append(todo.Comments, comment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (s *MongoStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	_, err := s.modify(ctx, todoID, false, func(todo *models.Todo) error {
+		for i, comment := range todo.Comments {
+			if comment.ID == models.ID(commentID) {
+				todo.Comments = append(todo.Comments[:i], todo.Comments[i+1:]...)
+				return nil
+			}
+		}
+		return ErrCommentNotFound
+	})
+	return err
+}
+
+func (s *MongoStorage) Clear(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	result, err := s.todos.DeleteMany(ctx, bson.D{})
+	if err != nil {
+		return 0, err
+	}
+	for _, collection := range []*mongo.Collection{s.trash, s.counters} {
+		if _, err := collection.DeleteMany(ctx, bson.D{}); err != nil {
+			return 0, err
+		}
+	}
+	return int(result.DeletedCount), nil
+}
+
+func (s *MongoStorage) History(ctx context.Context, id int) ([]models.TodoVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	doc, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	_, versions, err := doc.decode()
+	return versions, err
+}
+
+// sequenceValue 返回计数器 name 的当前值，不存在时为 0
+func (s *MongoStorage) sequenceValue(ctx context.Context, name string) (int, error) {
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := s.counters.FindOne(ctx, bson.D{{Key: "_id", Value: name}}).Decode(&counter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return counter.Seq, err
+}
+
+func (s *MongoStorage) Export(ctx context.Context) (*Snapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	cursor, err := s.todos.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoTodo
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Format:     SnapshotFormat,
+		ExportedAt: time.Now(),
+		Todos:      make([]*models.Todo, 0, len(docs)),
+		History:    make(map[int][]models.TodoVersion, len(docs)),
+	}
+	for i := range docs {
+		todo, versions, err := docs[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Todos = append(snapshot.Todos, todo)
+		snapshot.History[docs[i].ID] = versions
+	}
+	lastID, err := s.sequenceValue(ctx, "todo")
+	if err != nil {
+		return nil, err
+	}
+	lastCommentID, err := s.sequenceValue(ctx, "comment")
+	if err != nil {
+		return nil, err
+	}
+	snapshot.NextID, snapshot.NextCommentID = lastID+1, lastCommentID+1
+	if snapshot.Trash, err = s.Trash(ctx); err != nil {
+		return nil, err
+	}
+	return snapshot, snapshot.normalize()
+}
+
+// Import 删除现有数据后写入快照。独立部署的 MongoDB 不支持多文档事务，中途失败时数据可能不完整
+func (s *MongoStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	if err := snapshot.normalize(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	docs := make([]any, 0, len(snapshot.Todos))
+	for _, todo := range snapshot.Todos {
+		doc, err := encodeMongoTodo(todo, snapshot.History[int(todo.ID)])
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+
+	trashed := make([]any, 0, len(snapshot.Trash))
+	for _, todo := range snapshot.Trash {
+		doc, err := encodeMongoTodo(todo, nil)
+		if err != nil {
+			return err
+		}
+		trashed = append(trashed, doc)
+	}
+
+	for collection, docs := range map[*mongo.Collection][]any{s.todos: docs, s.trash: trashed} {
+		if _, err := collection.DeleteMany(ctx, bson.D{}); err != nil {
+			return err
+		}
+		if len(docs) > 0 {
+			if _, err := collection.InsertMany(ctx, docs); err != nil {
+				return err
+			}
+		}
+	}
+	for name, value := range map[string]int{"todo": snapshot.NextID - 1, "comment": snapshot.NextCommentID - 1} {
+		_, err := s.counters.UpdateOne(ctx,
+			bson.D{{Key: "_id", Value: name}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "seq", Value: value}}}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
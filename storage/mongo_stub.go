@@ -0,0 +1,15 @@
+//go:build !mongodb
+
+package storage
+
+import "errors"
+
+// MongoStorage 未带 mongodb 构建标签时的占位类型，实际实现见 mongo_client.go
+type MongoStorage struct {
+	TodoStorage
+}
+
+// OpenMongo 未带 mongodb 构建标签时不可用
+func OpenMongo(opts MongoOptions) (*MongoStorage, error) {
+	return nil, errors.New("MongoDB 存储需要使用 -tags mongodb 构建")
+}
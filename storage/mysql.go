@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// mysqlDriverName MySQL 驱动注册的名称，驱动在带 mysql 构建标签时由 mysql_driver.go 引入
+const mysqlDriverName = "mysql"
+
+// mysqlDialect MySQL/MariaDB 使用 ? 占位符，通过 LastInsertId 获取新ID，表结构见 migrations/mysql
+var mysqlDialect = sqlDialect{
+	name: "mysql",
+	// TRUNCATE 会隐式提交事务，但能同时重置自增ID
+	clear: []string{
+		"TRUNCATE TABLE comments",
+		"TRUNCATE TABLE todo_versions",
+		"TRUNCATE TABLE todos",
+	},
+}
+
+// OpenMySQL 使用 DSN（如 user:pass@tcp(localhost:3306)/todos）连接 MySQL 或 MariaDB 并执行数据库迁移。
+// 需要使用 -tags mysql 构建以引入驱动
+func OpenMySQL(dsn string) (*SQLStorage, error) {
+	db, err := openMySQLDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSQLStorage(db, mysqlDialect)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化 MySQL 数据库失败: %w", err)
+	}
+	return s, nil
+}
+
+// openMySQLDB 打开 MySQL 连接并检查连通性
+func openMySQLDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open(mysqlDriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开 MySQL 连接失败（需使用 -tags mysql 构建）: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("连接 MySQL 失败: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,18 @@
+//go:build mysql
+
+package storage
+
+// 引入 MySQL 驱动，注册名称为 "mysql"
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	// 1213 死锁、1205 锁等待超时，事务已回滚，重试即可
+	transientChecks = append(transientChecks, func(err error) bool {
+		var mysqlErr *mysql.MySQLError
+		return errors.As(err, &mysqlErr) && (mysqlErr.Number == 1213 || mysqlErr.Number == 1205)
+	})
+}
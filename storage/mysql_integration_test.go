@@ -0,0 +1,70 @@
+//go:build integration && mysql
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// openTestMySQL 连接 MYSQL_TEST_DSN 指定的数据库并清空数据，未设置时跳过测试。
+// 例如 MYSQL_TEST_DSN='root:pass@tcp(localhost:3306)/todos_test' go test -tags 'integration mysql' ./storage
+func openTestMySQL(t *testing.T) *SQLStorage {
+	t.Helper()
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		t.Skip("未设置 MYSQL_TEST_DSN")
+	}
+	s, err := OpenMySQL(dsn)
+	if err != nil {
+		t.Fatalf("打开 MySQL 存储失败: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if _, err := s.Clear(context.Background()); err != nil {
+		t.Fatalf("清空数据失败: %v", err)
+	}
+	return s
+}
+
+func TestMySQLCRUD(t *testing.T) {
+	testSQLCRUD(t, openTestMySQL(t))
+}
+
+func TestMySQLList(t *testing.T) {
+	testSQLList(t, openTestMySQL(t))
+}
+
+func TestMySQLWithTxRollback(t *testing.T) {
+	s := openTestMySQL(t)
+	before := seedTx(t, s)
+	err := s.WithTx(context.Background(), func(tx TodoStorage) error {
+		writeTwice(t, tx)
+		return errAbortTx
+	})
+	if !errors.Is(err, errAbortTx) {
+		t.Fatalf("WithTx 错误 = %v，期望 %v", err, errAbortTx)
+	}
+	// InnoDB 的自增值不随事务回滚，因此只比较数据，不检查之后新建的ID
+	if after := mustGetAll(t, s); !reflect.DeepEqual(after, before) {
+		t.Errorf("回滚后的数据与事务前不一致:\n得到 %+v\n期望 %+v", after, before)
+	}
+}
+
+func TestMySQLTrash(t *testing.T) {
+	testTrash(t, openTestMySQL(t))
+}
+
+func TestMySQLRestore(t *testing.T) {
+	testRestore(t, openTestMySQL(t))
+}
+
+func TestMySQLListAfter(t *testing.T) {
+	testListAfter(t, openTestMySQL(t))
+}
+
+func TestMySQLClear(t *testing.T) {
+	testClear(t, openTestMySQL(t))
+}
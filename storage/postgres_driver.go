@@ -0,0 +1,19 @@
+//go:build postgres
+
+package storage
+
+// 引入 pgx 的 database/sql 驱动，注册名称为 "pgx"
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func init() {
+	// 40001 序列化失败、40P01 死锁，事务已回滚，重试即可
+	transientChecks = append(transientChecks, func(err error) bool {
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+	})
+}
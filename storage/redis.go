@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"strconv"
+	"time"
+)
+
+// RedisOptions Redis 存储的配置
+type RedisOptions struct {
+	// URL Redis 连接地址，如 redis://localhost:6379/0
+	URL string
+	// Prefix 所有键的前缀，多个应用共用同一个 Redis 时用于隔离
+	Prefix string
+	// CompletedTTL 已完成的待办事项在多久后自动过期，0 表示不过期
+	CompletedTTL time.Duration
+}
+
+// DefaultRedisPrefix 未指定前缀时使用的键前缀
+const DefaultRedisPrefix = "todolist:"
+
+// Redis 中使用的键，均带有 RedisOptions.Prefix 前缀：
+//
+//	todo:next_id     待办事项ID计数器（INCR）
+//	comment:next_id  备注ID计数器（INCR）
+//	todos            按ID排序的有序集合，成员为待办事项ID
+//	todo:<id>        每个待办事项一个哈希，data 字段为 JSON（含备注），versions 字段为历史版本
+//	trash            回收站哈希，字段为待办事项ID，值为 JSON（含备注和删除时间）
+func (o RedisOptions) todoSeqKey() string    { return o.Prefix + "todo:next_id" }
+func (o RedisOptions) commentSeqKey() string { return o.Prefix + "comment:next_id" }
+func (o RedisOptions) indexKey() string      { return o.Prefix + "todos" }
+func (o RedisOptions) todoKey(id int) string { return o.Prefix + "todo:" + strconv.Itoa(id) }
+func (o RedisOptions) trashKey() string      { return o.Prefix + "trash" }
@@ -0,0 +1,468 @@
+//go:build redis
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go-todolist/models"
+)
+
+// redisMaxRetries 乐观锁冲突时的最大重试次数，超过后返回 ErrTxConflict
+const redisMaxRetries = 5
+
+// RedisStorage 基于 Redis 的存储，多个应用实例可共享同一份数据。
+// 修改单个待办事项时通过 WATCH 实现乐观锁，不支持跨多个待办事项的事务
+type RedisStorage struct {
+	client *redis.Client
+	opts   RedisOptions
+}
+
+// OpenRedis 连接 opts.URL 指定的 Redis 并检查连通性
+func OpenRedis(opts RedisOptions) (*RedisStorage, error) {
+	clientOpts, err := redis.ParseURL(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("解析 Redis 地址失败: %w", err)
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = DefaultRedisPrefix
+	}
+
+	client := redis.NewClient(clientOpts)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("连接 Redis 失败: %w", err)
+	}
+	return &RedisStorage{client: client, opts: opts}, nil
+}
+
+// Close 关闭连接池
+func (s *RedisStorage) Close() error {
+	return s.client.Close()
+}
+
+// load 读取待办事项及其历史版本，不存在时返回 ErrTodoNotFound
+func (s *RedisStorage) load(ctx context.Context, c redis.Cmdable, id int) (*models.Todo, []models.TodoVersion, error) {
+	fields, err := c.HGetAll(ctx, s.opts.todoKey(id)).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, ok := fields["data"]
+	if !ok {
+		return nil, nil, ErrTodoNotFound
+	}
+
+	var todo models.Todo
+	if err := json.Unmarshal([]byte(data), &todo); err != nil {
+		return nil, nil, err
+	}
+	versions := []models.TodoVersion{}
+	if raw, ok := fields["versions"]; ok {
+		if err := json.Unmarshal([]byte(raw), &versions); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &todo, versions, nil
+}
+
+// save 写入待办事项哈希并维护索引；已完成的待办事项按配置设置过期时间，其余的移除过期时间
+func (s *RedisStorage) save(ctx context.Context, pipe redis.Pipeliner, todo *models.Todo, versions []models.TodoVersion) error {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+	encodedVersions, err := json.Marshal(versions)
+	if err != nil {
+		return err
+	}
+
+	key := s.opts.todoKey(int(todo.ID))
+	pipe.HSet(ctx, key, "data", data, "versions", encodedVersions)
+	pipe.ZAdd(ctx, s.opts.indexKey(), redis.Z{Score: float64(todo.ID), Member: int(todo.ID)})
+	if todo.Completed && s.opts.CompletedTTL > 0 {
+		pipe.Expire(ctx, key, s.opts.CompletedTTL)
+	} else {
+		pipe.Persist(ctx, key)
+	}
+	return nil
+}
+
+// watch 在 WATCH keys 的保护下执行 fn；期间 keys 被其他实例修改时重试
+func (s *RedisStorage) watch(ctx context.Context, fn func(tx *redis.Tx) error, keys ...string) error {
+	for range redisMaxRetries {
+		err := s.client.Watch(ctx, fn, keys...)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		return err
+	}
+	return ErrTxConflict
+}
+
+// modify 在 WATCH 保护下读取、修改并写回待办事项；期间被其他实例修改时重试
+func (s *RedisStorage) modify(ctx context.Context, id int, record bool, fn func(todo *models.Todo) error) (*models.Todo, error) {
+	var result *models.Todo
+	err := s.watch(ctx, func(tx *redis.Tx) error {
+		todo, versions, err := s.load(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(todo); err != nil {
+			return err
+		}
+		if record {
+			versions = appendVersion(versions, todo)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return s.save(ctx, pipe, todo, versions)
+		})
+		if err == nil {
+			result = todo
+		}
+		return err
+	}, s.opts.todoKey(id))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *RedisStorage) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	members, err := s.client.ZRange(ctx, s.opts.indexKey(), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.StringCmd, len(members))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, member := range members {
+			id, _ := strconv.Atoi(member)
+			cmds[i] = pipe.HGet(ctx, s.opts.todoKey(id), "data")
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	todos := make([]*models.Todo, 0, len(members))
+	var expired []any
+	for i, cmd := range cmds {
+		data, err := cmd.Result()
+		if errors.Is(err, redis.Nil) {
+			// 已过期的待办事项，顺便从索引中移除
+			expired = append(expired, members[i])
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var todo models.Todo
+		if err := json.Unmarshal([]byte(data), &todo); err != nil {
+			return nil, err
+		}
+		todos = append(todos, &todo)
+	}
+	if len(expired) > 0 {
+		s.client.ZRem(ctx, s.opts.indexKey(), expired...)
+	}
+	return todos, nil
+}
+
+func (s *RedisStorage) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	todo, _, err := s.load(ctx, s.client, id)
+	return todo, err
+}
+
+func (s *RedisStorage) FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error) {
+	todos, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]*models.Todo, 0)
+	for _, todo := range todos {
+		if todo.ExternalID == externalID {
+			matched = append(matched, todo)
+		}
+	}
+	return matched, nil
+}
+
+func (s *RedisStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	id, err := s.client.Incr(ctx, s.opts.todoSeqKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	todo := newTodo(int(id), req, time.Now())
+	versions := appendVersion(nil, todo)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return s.save(ctx, pipe, todo, versions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *RedisStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	return s.modify(ctx, id, true, func(todo *models.Todo) error {
+		applyUpdate(todo, req, time.Now())
+		return nil
+	})
+}
+
+// Delete 将待办事项移入回收站哈希并删除其历史版本
+func (s *RedisStorage) Delete(ctx context.Context, id int) error {
+	return s.watch(ctx, func(tx *redis.Tx) error {
+		todo, _, err := s.load(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		todo.DeletedAt = &now
+		data, err := json.Marshal(todo)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, s.opts.todoKey(id))
+			pipe.ZRem(ctx, s.opts.indexKey(), id)
+			pipe.HSet(ctx, s.opts.trashKey(), strconv.Itoa(id), data)
+			return nil
+		})
+		return err
+	}, s.opts.todoKey(id))
+}
+
+// Trash 返回回收站中的待办事项，最近删除的排在最前
+func (s *RedisStorage) Trash(ctx context.Context) ([]*models.Todo, error) {
+	entries, err := s.client.HGetAll(ctx, s.opts.trashKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	todos := make([]*models.Todo, 0, len(entries))
+	for _, data := range entries {
+		var todo models.Todo
+		if err := json.Unmarshal([]byte(data), &todo); err != nil {
+			return nil, err
+		}
+		todos = append(todos, &todo)
+	}
+	sortTrash(todos)
+	return todos, nil
+}
+
+// PurgeTrash 永久删除回收站中删除时间早于 before 的待办事项，before 为零值时清空回收站
+func (s *RedisStorage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	todos, err := s.Trash(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var fields []string
+	for _, todo := range todos {
+		if before.IsZero() || todo.DeletedAt.Before(before) {
+			fields = append(fields, strconv.Itoa(int(todo.ID)))
+		}
+	}
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	purged, err := s.client.HDel(ctx, s.opts.trashKey(), fields...).Result()
+	return int(purged), err
+}
+
+// Restore 将待办事项从回收站恢复并记录新的历史版本
+func (s *RedisStorage) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	var todo *models.Todo
+	err := s.watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.HGet(ctx, s.opts.trashKey(), strconv.Itoa(id)).Result()
+		if errors.Is(err, redis.Nil) {
+			live, err := tx.Exists(ctx, s.opts.todoKey(id)).Result()
+			if err != nil {
+				return err
+			}
+			lastID, err := tx.Get(ctx, s.opts.todoSeqKey()).Int()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+			return restoreError(id, live > 0, lastID)
+		}
+		if err != nil {
+			return err
+		}
+
+		todo = &models.Todo{}
+		if err := json.Unmarshal([]byte(data), todo); err != nil {
+			return err
+		}
+		todo.DeletedAt = nil
+		todo.UpdatedAt = time.Now()
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HDel(ctx, s.opts.trashKey(), strconv.Itoa(id))
+			return s.save(ctx, pipe, todo, appendVersion(nil, todo))
+		})
+		return err
+	}, s.opts.trashKey(), s.opts.todoKey(id))
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *RedisStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	exists, err := s.client.Exists(ctx, s.opts.todoKey(todoID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, ErrTodoNotFound
+	}
+	id, err := s.client.Incr(ctx, s.opts.commentSeqKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	comment := models.Comment{ID: models.ID(id), Body: req.Body, CreatedAt: time.Now()}
+	_, err = s.modify(ctx, todoID, false, func(todo *models.Todo) error {
+		todo.Comments = append(todo.Comments, comment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (s *RedisStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	_, err := s.modify(ctx, todoID, false, func(todo *models.Todo) error {
+		for i, comment := range todo.Comments {
+			if comment.ID == models.ID(commentID) {
+				todo.Comments = append(todo.Comments[:i], todo.Comments[i+1:]...)
+				return nil
+			}
+		}
+		return ErrCommentNotFound
+	})
+	return err
+}
+
+func (s *RedisStorage) Clear(ctx context.Context) (int, error) {
+	todos, err := s.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	keys := []string{s.opts.indexKey(), s.opts.trashKey(), s.opts.todoSeqKey(), s.opts.commentSeqKey()}
+	for _, todo := range todos {
+		keys = append(keys, s.opts.todoKey(int(todo.ID)))
+	}
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return 0, err
+	}
+	return len(todos), nil
+}
+
+func (s *RedisStorage) History(ctx context.Context, id int) ([]models.TodoVersion, error) {
+	_, versions, err := s.load(ctx, s.client, id)
+	return versions, err
+}
+
+// Export 导出全部数据。Redis 没有跨键的一致性读取，导出期间的并发修改可能只被部分包含
+func (s *RedisStorage) Export(ctx context.Context) (*Snapshot, error) {
+	todos, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versionCmds := make([]*redis.StringCmd, len(todos))
+	var lastID, lastCommentID *redis.StringCmd
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, todo := range todos {
+			versionCmds[i] = pipe.HGet(ctx, s.opts.todoKey(int(todo.ID)), "versions")
+		}
+		lastID = pipe.Get(ctx, s.opts.todoSeqKey())
+		lastCommentID = pipe.Get(ctx, s.opts.commentSeqKey())
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Format:     SnapshotFormat,
+		ExportedAt: time.Now(),
+		Todos:      todos,
+		History:    make(map[int][]models.TodoVersion, len(todos)),
+	}
+	if snapshot.Trash, err = s.Trash(ctx); err != nil {
+		return nil, err
+	}
+	snapshot.NextID, _ = lastID.Int()
+	snapshot.NextID++
+	snapshot.NextCommentID, _ = lastCommentID.Int()
+	snapshot.NextCommentID++
+	for i, todo := range todos {
+		raw, err := versionCmds[i].Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var versions []models.TodoVersion
+		if err := json.Unmarshal([]byte(raw), &versions); err != nil {
+			return nil, err
+		}
+		snapshot.History[int(todo.ID)] = versions
+	}
+	return snapshot, snapshot.normalize()
+}
+
+// Import 在一个 MULTI/EXEC 事务中删除现有数据并写入快照
+func (s *RedisStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	if err := snapshot.normalize(); err != nil {
+		return err
+	}
+	members, err := s.client.ZRange(ctx, s.opts.indexKey(), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		keys := []string{s.opts.indexKey(), s.opts.trashKey(), s.opts.todoSeqKey(), s.opts.commentSeqKey()}
+		for _, member := range members {
+			id, _ := strconv.Atoi(member)
+			keys = append(keys, s.opts.todoKey(id))
+		}
+		pipe.Del(ctx, keys...)
+
+		for _, todo := range snapshot.Todos {
+			versions := snapshot.History[int(todo.ID)]
+			if versions == nil {
+				versions = []models.TodoVersion{}
+			}
+			if err := s.save(ctx, pipe, todo, versions); err != nil {
+				return err
+			}
+		}
+		for _, todo := range snapshot.Trash {
+			data, err := json.Marshal(todo)
+			if err != nil {
+				return err
+			}
+			pipe.HSet(ctx, s.opts.trashKey(), strconv.Itoa(int(todo.ID)), data)
+		}
+		pipe.Set(ctx, s.opts.todoSeqKey(), snapshot.NextID-1, 0)
+		pipe.Set(ctx, s.opts.commentSeqKey(), snapshot.NextCommentID-1, 0)
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build !redis
+
+package storage
+
+import "errors"
+
+// RedisStorage 未带 redis 构建标签时的占位类型，实际实现见 redis_client.go
+type RedisStorage struct {
+	TodoStorage
+}
+
+// OpenRedis 未带 redis 构建标签时不可用
+func OpenRedis(opts RedisOptions) (*RedisStorage, error) {
+	return nil, errors.New("Redis 存储需要使用 -tags redis 构建")
+}
@@ -0,0 +1,324 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"go-todolist/models"
+)
+
+// transientChecks 由各数据库驱动注册的临时错误判断，如死锁、锁等待超时
+var transientChecks []func(error) bool
+
+// IsTransient 判断错误是否为重试后可能成功的临时错误：存储不可用、事务或乐观锁冲突（ErrTxConflict）、
+// 连接被重置或超时，以及驱动注册的死锁等错误。外部ID重复等其他 ErrConflict 重试也不会成功，
+// ctx 被取消或超时同样不视为临时错误
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTxConflict) ||
+		errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	for _, check := range transientChecks {
+		if check(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryPolicy 重试策略。第 n 次重试前等待 BaseDelay*2^(n-1)（不超过 MaxDelay），
+// 并在其一半到全部之间随机取值，避免多个请求同时重试
+type RetryPolicy struct {
+	// MaxRetries 每个操作的默认最大重试次数，不含首次执行
+	MaxRetries int
+	// Budgets 按操作名（如 "Create"、"WithTx"）覆盖最大重试次数
+	Budgets   map[string]int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy 返回默认的重试策略。Create 和 AddComment 不是幂等的，
+// 连接在提交后断开时重试会重复创建，因此默认不重试
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 3,
+		Budgets:    map[string]int{"Create": 0, "AddComment": 0},
+		BaseDelay:  50 * time.Millisecond,
+		MaxDelay:   time.Second,
+	}
+}
+
+// budget 返回操作 op 的最大重试次数
+func (p RetryPolicy) budget(op string) int {
+	if n, ok := p.Budgets[op]; ok {
+		return n
+	}
+	return p.MaxRetries
+}
+
+// delay 返回第 retry 次（从 1 开始）重试前的等待时间
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// RetryStats 单个操作的重试统计
+type RetryStats struct {
+	Operation string `json:"operation"`
+	// Retries 累计重试次数
+	Retries int `json:"retries"`
+	// Recovered 重试后成功的次数
+	Recovered int `json:"recovered"`
+	// Exhausted 用完重试次数仍失败的次数
+	Exhausted int `json:"exhausted"`
+}
+
+// RetryStorage 在任意存储外对临时错误按指数退避重试
+type RetryStorage struct {
+	TodoStorage
+
+	policy RetryPolicy
+
+	mutex sync.Mutex
+	stats map[string]*RetryStats
+}
+
+// NewRetrying 创建按 policy 重试临时错误的存储
+func NewRetrying(inner TodoStorage, policy RetryPolicy) *RetryStorage {
+	return &RetryStorage{
+		TodoStorage: inner,
+		policy:      policy,
+		stats:       make(map[string]*RetryStats),
+	}
+}
+
+// Stats 返回各操作的重试统计，按操作名排列，只包含发生过重试的操作
+func (s *RetryStorage) Stats() []RetryStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := make([]RetryStats, 0, len(s.stats))
+	for _, stat := range s.stats {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Operation < stats[j].Operation
+	})
+	return stats
+}
+
+// record 记录操作 op 的一次执行结果，retries 为本次执行的重试次数
+func (s *RetryStorage) record(op string, retries int, err error) {
+	if retries == 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stat, exists := s.stats[op]
+	if !exists {
+		stat = &RetryStats{Operation: op}
+		s.stats[op] = stat
+	}
+	stat.Retries += retries
+	if err == nil {
+		stat.Recovered++
+	} else {
+		stat.Exhausted++
+	}
+}
+
+// do 执行 fn，遇到临时错误时在重试次数内等待后重试；ctx 取消时停止等待并返回最后一次的错误
+func (s *RetryStorage) do(ctx context.Context, op string, fn func() error) error {
+	budget := s.policy.budget(op)
+	retries := 0
+	for {
+		err := fn()
+		if err == nil || retries >= budget || !IsTransient(err) {
+			s.record(op, retries, err)
+			return err
+		}
+
+		retries++
+		timer := time.NewTimer(s.policy.delay(retries))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.record(op, retries, err)
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *RetryStorage) GetAll(ctx context.Context) (todos []*models.Todo, err error) {
+	err = s.do(ctx, "GetAll", func() error {
+		todos, err = s.TodoStorage.GetAll(ctx)
+		return err
+	})
+	return todos, err
+}
+
+// List 按 opts 获取待办事项和总数，内层存储实现了 Lister 时在存储中过滤和分页
+func (s *RetryStorage) List(ctx context.Context, opts ListOptions) (todos []*models.Todo, total int, err error) {
+	err = s.do(ctx, "List", func() error {
+		todos, total, err = List(ctx, s.TodoStorage, opts)
+		return err
+	})
+	return todos, total, err
+}
+
+// Count 返回满足 opts 的待办事项数量，内层存储实现了 Counter 时在存储中统计
+func (s *RetryStorage) Count(ctx context.Context, opts ListOptions) (count int, err error) {
+	err = s.do(ctx, "Count", func() error {
+		count, err = Count(ctx, s.TodoStorage, opts)
+		return err
+	})
+	return count, err
+}
+
+func (s *RetryStorage) GetByID(ctx context.Context, id int) (todo *models.Todo, err error) {
+	err = s.do(ctx, "GetByID", func() error {
+		todo, err = s.TodoStorage.GetByID(ctx, id)
+		return err
+	})
+	return todo, err
+}
+
+func (s *RetryStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (todo *models.Todo, err error) {
+	err = s.do(ctx, "Create", func() error {
+		todo, err = s.TodoStorage.Create(ctx, req)
+		return err
+	})
+	return todo, err
+}
+
+func (s *RetryStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (todo *models.Todo, err error) {
+	err = s.do(ctx, "Update", func() error {
+		todo, err = s.TodoStorage.Update(ctx, id, req)
+		return err
+	})
+	return todo, err
+}
+
+func (s *RetryStorage) Delete(ctx context.Context, id int) error {
+	return s.do(ctx, "Delete", func() error {
+		return s.TodoStorage.Delete(ctx, id)
+	})
+}
+
+func (s *RetryStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (comment *models.Comment, err error) {
+	err = s.do(ctx, "AddComment", func() error {
+		comment, err = s.TodoStorage.AddComment(ctx, todoID, req)
+		return err
+	})
+	return comment, err
+}
+
+func (s *RetryStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	return s.do(ctx, "DeleteComment", func() error {
+		return s.TodoStorage.DeleteComment(ctx, todoID, commentID)
+	})
+}
+
+func (s *RetryStorage) Clear(ctx context.Context) (count int, err error) {
+	err = s.do(ctx, "Clear", func() error {
+		count, err = s.TodoStorage.Clear(ctx)
+		return err
+	})
+	return count, err
+}
+
+func (s *RetryStorage) History(ctx context.Context, id int) (versions []models.TodoVersion, err error) {
+	err = s.do(ctx, "History", func() error {
+		versions, err = s.TodoStorage.History(ctx, id)
+		return err
+	})
+	return versions, err
+}
+
+func (s *RetryStorage) FindByExternalID(ctx context.Context, externalID string) (todos []*models.Todo, err error) {
+	err = s.do(ctx, "FindByExternalID", func() error {
+		todos, err = s.TodoStorage.FindByExternalID(ctx, externalID)
+		return err
+	})
+	return todos, err
+}
+
+func (s *RetryStorage) Export(ctx context.Context) (snapshot *Snapshot, err error) {
+	err = s.do(ctx, "Export", func() error {
+		snapshot, err = s.TodoStorage.Export(ctx)
+		return err
+	})
+	return snapshot, err
+}
+
+func (s *RetryStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	return s.do(ctx, "Import", func() error {
+		return s.TodoStorage.Import(ctx, snapshot)
+	})
+}
+
+// WithTx 在内层存储的事务中执行 fn，事务因临时错误失败时重新执行整个事务，因此 fn 可能被调用多次。
+// 事务内的操作不单独重试；内层存储不支持事务时直接执行 fn
+func (s *RetryStorage) WithTx(ctx context.Context, fn func(TodoStorage) error) error {
+	inner, ok := s.TodoStorage.(Transactional)
+	if !ok {
+		return fn(s)
+	}
+	return s.do(ctx, "WithTx", func() error {
+		return inner.WithTx(ctx, fn)
+	})
+}
+
+// BeginTx 开启内层存储的显式事务，只重试开启事务本身，提交失败时由调用方决定是否重试
+func (s *RetryStorage) BeginTx(ctx context.Context) (tx Tx, err error) {
+	inner, ok := s.TodoStorage.(TxBeginner)
+	if !ok {
+		return nil, fmt.Errorf("%w: 存储不支持显式事务", ErrValidation)
+	}
+	err = s.do(ctx, "BeginTx", func() error {
+		tx, err = inner.BeginTx(ctx)
+		return err
+	})
+	return tx, err
+}
+
+// Unwrap 返回内层存储
+func (s *RetryStorage) Unwrap() TodoStorage {
+	return s.TodoStorage
+}
+
+// Close 关闭内层存储
+func (s *RetryStorage) Close() error {
+	if closer, ok := s.TodoStorage.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
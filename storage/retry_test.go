@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+// scriptedStorage 按预先设定的错误序列让各操作依次失败，错误用完后交给内存存储执行
+type scriptedStorage struct {
+	*MemoryStorage
+	errs  map[string][]error
+	calls map[string]int
+}
+
+func newScriptedStorage(errs map[string][]error) *scriptedStorage {
+	return &scriptedStorage{MemoryStorage: NewMemoryStorage(), errs: errs, calls: make(map[string]int)}
+}
+
+// next 记录一次 op 调用并返回其下一个预设错误
+func (s *scriptedStorage) next(op string) error {
+	s.calls[op]++
+	errs := s.errs[op]
+	if len(errs) == 0 {
+		return nil
+	}
+	s.errs[op] = errs[1:]
+	return errs[0]
+}
+
+func (s *scriptedStorage) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	if err := s.next("GetByID"); err != nil {
+		return nil, err
+	}
+	return s.MemoryStorage.GetByID(ctx, id)
+}
+
+func (s *scriptedStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	if err := s.next("Create"); err != nil {
+		return nil, err
+	}
+	return s.MemoryStorage.Create(ctx, req)
+}
+
+func (s *scriptedStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	if err := s.next("AddComment"); err != nil {
+		return nil, err
+	}
+	return s.MemoryStorage.AddComment(ctx, todoID, req)
+}
+
+func (s *scriptedStorage) Delete(ctx context.Context, id int) error {
+	if err := s.next("Delete"); err != nil {
+		return err
+	}
+	return s.MemoryStorage.Delete(ctx, id)
+}
+
+func (s *scriptedStorage) WithTx(ctx context.Context, fn func(TodoStorage) error) error {
+	return s.MemoryStorage.WithTx(ctx, func(tx TodoStorage) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return s.next("WithTx")
+	})
+}
+
+// repeat 返回 n 个 err
+func repeat(err error, n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// timeoutError 超时的网络错误
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"无错误", nil, false},
+		{"存储不可用", fmt.Errorf("查询失败: %w", ErrUnavailable), true},
+		{"事务冲突", ErrTxConflict, true},
+		{"包装的事务冲突", fmt.Errorf("更新失败: %w", ErrTxConflict), true},
+		{"外部ID重复", fmt.Errorf("%w: 外部ID TICKET-1 已被待办事项 1 使用", ErrConflict), false},
+		{"恢复冲突", fmt.Errorf("%w: 待办事项 1 不在回收站中", ErrConflict), false},
+		{"连接被重置", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"失效的连接", driver.ErrBadConn, true},
+		{"网络超时", timeoutError{}, true},
+		{"未找到", ErrTodoNotFound, false},
+		{"数据无效", ErrValidation, false},
+		{"请求取消", fmt.Errorf("%w: %w", ErrUnavailable, context.Canceled), false},
+		{"请求超时", context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("%s: IsTransient(%v) = %v，期望 %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	// 第 n 次重试的上限为 BaseDelay*2^(n-1)，不超过 MaxDelay，实际等待在上限的一半到全部之间
+	for retry, limit := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		10: time.Second,
+	} {
+		for range 200 {
+			if d := policy.delay(retry); d < limit/2 || d > limit {
+				t.Fatalf("第 %d 次重试等待 %v，期望在 %v 到 %v 之间", retry, d, limit/2, limit)
+			}
+		}
+	}
+	if d := (RetryPolicy{}).delay(1); d != 0 {
+		t.Errorf("未设置 BaseDelay 时等待 %v，期望 0", d)
+	}
+}
+
+func TestRetryStorage(t *testing.T) {
+	ctx := context.Background()
+	inner := newScriptedStorage(map[string][]error{
+		"GetByID":    {ErrUnavailable, ErrTxConflict},
+		"Delete":     repeat(ErrUnavailable, 10),
+		"Create":     {ErrUnavailable},
+		"AddComment": {ErrUnavailable},
+	})
+	if _, err := inner.MemoryStorage.Create(ctx, &models.CreateTodoRequest{Title: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay, policy.MaxDelay = 0, 0
+	policy.Budgets["Delete"] = 2
+	s := NewRetrying(inner, policy)
+
+	// 临时错误重试后成功
+	if todo, err := s.GetByID(ctx, 1); err != nil || todo.Title != "a" {
+		t.Errorf("GetByID = %v, %v", todo, err)
+	}
+	// 非临时错误不重试
+	if _, err := s.GetByID(ctx, 9); !errors.Is(err, ErrTodoNotFound) {
+		t.Errorf("GetByID(9) 错误 = %v，期望 %v", err, ErrTodoNotFound)
+	}
+	// 按操作覆盖的重试次数用完后返回最后一次的错误
+	if err := s.Delete(ctx, 1); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Delete 错误 = %v，期望 %v", err, ErrUnavailable)
+	}
+	// Create 和 AddComment 不是幂等的，默认不重试
+	if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: "b"}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Create 错误 = %v，期望 %v", err, ErrUnavailable)
+	}
+	if _, err := s.AddComment(ctx, 1, &models.CreateCommentRequest{Body: "x"}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("AddComment 错误 = %v，期望 %v", err, ErrUnavailable)
+	}
+
+	wantCalls := map[string]int{"GetByID": 4, "Delete": 3, "Create": 1, "AddComment": 1}
+	if !reflect.DeepEqual(inner.calls, wantCalls) {
+		t.Errorf("内层调用次数 = %v，期望 %v", inner.calls, wantCalls)
+	}
+	wantStats := []RetryStats{
+		{Operation: "Delete", Retries: 2, Exhausted: 1},
+		{Operation: "GetByID", Retries: 2, Recovered: 1},
+	}
+	if got := s.Stats(); !reflect.DeepEqual(got, wantStats) {
+		t.Errorf("重试统计 = %+v，期望 %+v", got, wantStats)
+	}
+}
+
+func TestRetryStorageWithTx(t *testing.T) {
+	ctx := context.Background()
+	inner := newScriptedStorage(map[string][]error{"WithTx": {ErrTxConflict}})
+	policy := RetryPolicy{MaxRetries: 3}
+	s := NewRetrying(inner, policy)
+
+	// 事务冲突时重新执行整个事务，失败的那次已回滚
+	runs := 0
+	err := s.WithTx(ctx, func(tx TodoStorage) error {
+		runs++
+		_, err := tx.Create(ctx, &models.CreateTodoRequest{Title: "a"})
+		return err
+	})
+	if err != nil || runs != 2 {
+		t.Fatalf("WithTx 错误 = %v，执行 %d 次，期望成功且执行 2 次", err, runs)
+	}
+	if todos, _ := s.GetAll(ctx); len(todos) != 1 {
+		t.Errorf("共 %d 项，期望 1", len(todos))
+	}
+
+	// 外部ID重复等永久冲突立即返回，不计入重试
+	runs = 0
+	conflict := fmt.Errorf("%w: 外部ID TICKET-1 已被待办事项 1 使用", ErrConflict)
+	err = s.WithTx(ctx, func(TodoStorage) error {
+		runs++
+		return conflict
+	})
+	if !errors.Is(err, ErrConflict) || runs != 1 {
+		t.Errorf("WithTx 错误 = %v，执行 %d 次，期望冲突且只执行 1 次", err, runs)
+	}
+	want := []RetryStats{{Operation: "WithTx", Retries: 1, Recovered: 1}}
+	if got := s.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("重试统计 = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestRetryStorageCanceled(t *testing.T) {
+	inner := newScriptedStorage(map[string][]error{"GetByID": repeat(ErrUnavailable, 10)})
+	s := NewRetrying(inner, RetryPolicy{MaxRetries: 5, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	// ctx 取消后不再等待重试，返回最后一次的错误
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if _, err := s.GetByID(ctx, 1); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("GetByID 错误 = %v，期望 %v", err, ErrUnavailable)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ctx 取消后仍等待了 %v", elapsed)
+	}
+	if inner.calls["GetByID"] != 1 {
+		t.Errorf("内层调用 %d 次，期望 1", inner.calls["GetByID"])
+	}
+	want := []RetryStats{{Operation: "GetByID", Retries: 1, Exhausted: 1}}
+	if got := s.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("重试统计 = %+v，期望 %+v", got, want)
+	}
+
+	// 内层返回的取消错误不重试
+	inner = newScriptedStorage(map[string][]error{"GetByID": {context.Canceled}})
+	s = NewRetrying(inner, RetryPolicy{MaxRetries: 5})
+	if _, err := s.GetByID(context.Background(), 1); !errors.Is(err, context.Canceled) || inner.calls["GetByID"] != 1 {
+		t.Errorf("GetByID 错误 = %v，调用 %d 次，期望取消且只调用 1 次", err, inner.calls["GetByID"])
+	}
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"go-todolist/models"
+)
+
+// SnapshotFormat 当前快照格式的版本号
+const SnapshotFormat = 1
+
+// Snapshot 存储中全部数据的快照，用于备份、恢复以及 JSON 文件存储的文件内容。
+// Todos 按ID升序排列并包含备注；History 以待办事项ID为键；
+// Trash 为回收站中的待办事项，Filters 为保存的过滤器，不支持的存储导出时为空，导入时忽略
+type Snapshot struct {
+	Format        int                          `json:"format"`
+	ExportedAt    time.Time                    `json:"exported_at"`
+	NextID        int                          `json:"next_id"`
+	NextCommentID int                          `json:"next_comment_id"`
+	Todos         []*models.Todo               `json:"todos"`
+	History       map[int][]models.TodoVersion `json:"history"`
+	Trash         []*models.Todo               `json:"trash,omitempty"`
+	NextFilterID  int                          `json:"next_filter_id,omitempty"`
+	Filters       []*models.SavedFilter        `json:"filters,omitempty"`
+}
+
+// normalize 校验快照并补齐缺省值：ID 必须为正且与回收站中的也不重复，NextID、NextCommentID 至少比已有的最大ID大 1，
+// 不存在的待办事项的历史版本会被丢弃
+func (snapshot *Snapshot) normalize() error {
+	if snapshot.Format > SnapshotFormat {
+		return fmt.Errorf("%w: 不支持的快照格式版本 %d", ErrValidation, snapshot.Format)
+	}
+	snapshot.Format = SnapshotFormat
+
+	maxID, maxCommentID := 0, 0
+	todoIDs := make(map[int]bool, len(snapshot.Todos)+len(snapshot.Trash))
+	commentIDs := make(map[int]bool)
+	// 回收站中的待办事项可以恢复，ID和备注ID不能与现有数据重复
+	for i, todo := range slices.Concat(snapshot.Todos, snapshot.Trash) {
+		if todo == nil {
+			return fmt.Errorf("%w: 快照中存在空的待办事项", ErrValidation)
+		}
+		id := int(todo.ID)
+		if id <= 0 || todoIDs[id] {
+			return fmt.Errorf("%w: 待办事项ID %d 无效或重复", ErrValidation, id)
+		}
+		if inTrash := i >= len(snapshot.Todos); inTrash != (todo.DeletedAt != nil) {
+			return fmt.Errorf("%w: 待办事项 %d 的删除时间与其是否在回收站中不符", ErrValidation, id)
+		}
+		todoIDs[id] = true
+		maxID = max(maxID, id)
+
+		for _, comment := range todo.Comments {
+			commentID := int(comment.ID)
+			if commentID <= 0 || commentIDs[commentID] {
+				return fmt.Errorf("%w: 备注ID %d 无效或重复", ErrValidation, commentID)
+			}
+			commentIDs[commentID] = true
+			maxCommentID = max(maxCommentID, commentID)
+		}
+
+		if todo.Tags == nil {
+			todo.Tags = []string{}
+		}
+		if todo.Subtasks == nil {
+			todo.Subtasks = []models.Subtask{}
+		}
+		if todo.Comments == nil {
+			todo.Comments = []models.Comment{}
+		}
+	}
+	sort.Slice(snapshot.Todos, func(i, j int) bool { return snapshot.Todos[i].ID < snapshot.Todos[j].ID })
+	sort.Slice(snapshot.Trash, func(i, j int) bool { return snapshot.Trash[i].ID < snapshot.Trash[j].ID })
+
+	// 回收站中的待办事项不保留历史版本
+	history := make(map[int][]models.TodoVersion, len(snapshot.History))
+	for _, todo := range snapshot.Todos {
+		if versions, ok := snapshot.History[int(todo.ID)]; ok {
+			history[int(todo.ID)] = versions
+		}
+	}
+	snapshot.History = history
+
+	maxFilterID := 0
+	filterIDs := make(map[int]bool, len(snapshot.Filters))
+	for _, filter := range snapshot.Filters {
+		if filter == nil {
+			return fmt.Errorf("%w: 快照中存在空的过滤器", ErrValidation)
+		}
+		id := int(filter.ID)
+		if id <= 0 || filterIDs[id] {
+			return fmt.Errorf("%w: 过滤器ID %d 无效或重复", ErrValidation, id)
+		}
+		filterIDs[id] = true
+		maxFilterID = max(maxFilterID, id)
+	}
+	sort.Slice(snapshot.Filters, func(i, j int) bool { return snapshot.Filters[i].ID < snapshot.Filters[j].ID })
+
+	snapshot.NextID = max(snapshot.NextID, maxID+1)
+	snapshot.NextCommentID = max(snapshot.NextCommentID, maxCommentID+1)
+	snapshot.NextFilterID = max(snapshot.NextFilterID, maxFilterID+1)
+	return nil
+}
+
+// readSnapshotFile 读取快照文件，文件不存在时返回 nil
+func readSnapshotFile(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取数据文件失败: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("解析数据文件失败: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// writeSnapshotFile 将快照写入文件，先写临时文件再重命名，避免留下不完整的文件
+func writeSnapshotFile(path string, snapshot *Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入数据文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入数据文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入数据文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入数据文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("写入数据文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,841 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-todolist/migrations"
+	"go-todolist/models"
+)
+
+// sqlDialect 描述不同数据库在 SQL 语法上的差异
+type sqlDialect struct {
+	name string
+	// placeholder 返回第 n 个（从 1 开始）参数的占位符
+	placeholder func(n int) string
+	// returningID 为 true 时插入语句通过 RETURNING id 获取新ID，否则使用 LastInsertId
+	returningID bool
+	// clear 删除全部数据并重置ID序列的语句
+	clear []string
+	// syncSequences 以显式ID导入数据后，使ID序列从已有最大ID之后继续的语句
+	syncSequences []string
+}
+
+// todoColumns 除 id 和 data 外从待办事项中提取、用于查找和过滤的列，值由 todoColumnValues 按相同顺序给出
+// deleted_at 为移入回收站的 UTC 纳秒时间戳，未删除时为 NULL，回收站之外的查询都带有 deleted_at IS NULL 条件；
+// created_at 为 UTC 纳秒时间戳，供游标分页按 (created_at, id) 排序
+const todoColumns = "external_id, completed, priority, title, description, archived, deleted_at, created_at"
+
+// todoColumnValues 返回 todoColumns 各列的值
+func todoColumnValues(todo *models.Todo) []any {
+	var deletedAt any
+	if todo.DeletedAt != nil {
+		deletedAt = todo.DeletedAt.UnixNano()
+	}
+	return []any{todo.ExternalID, todo.Completed, string(todo.Priority), todo.Title, todo.Description, todo.Archived, deletedAt, todo.CreatedAt.UnixNano()}
+}
+
+// likeEscaper 转义 LIKE 模式中的通配符，转义符为 !
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// SQLStorage 基于 database/sql 的存储实现。待办事项以 JSON 保存在 todos.data 中，
+// 外部ID、完成状态等用于查找和过滤的字段另外单独成列（见 todoColumns），备注和历史版本各自一张表
+type SQLStorage struct {
+	db      *sql.DB
+	dialect sqlDialect
+	stmts   *stmtCache
+	// tx 不为 nil 时所有语句都在该事务中执行，写操作无需再开启新事务
+	tx *sql.Tx
+}
+
+// stmtCache 按查询语句缓存预编译语句，同一存储的所有事务共享
+type stmtCache struct {
+	mutex sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// lookup 返回已缓存的预编译语句
+func (c *stmtCache) lookup(query string) (*sql.Stmt, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stmt, ok := c.stmts[query]
+	return stmt, ok
+}
+
+// get 返回查询对应的预编译语句，首次使用时预编译
+func (c *stmtCache) get(ctx context.Context, db *sql.DB, query string) (*sql.Stmt, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// close 关闭所有预编译语句
+func (c *stmtCache) close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for query, stmt := range c.stmts {
+		stmt.Close()
+		delete(c.stmts, query)
+	}
+}
+
+// sqlRow 包装 *sql.Row，使预编译失败的错误在 Scan 时返回
+type sqlRow struct {
+	row *sql.Row
+	err error
+}
+
+func (r sqlRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+// newSQLStorage 创建存储并执行未应用的数据库迁移
+func newSQLStorage(db *sql.DB, dialect sqlDialect) (*SQLStorage, error) {
+	if _, _, err := migrations.Up(db, dialect.name); err != nil {
+		return nil, err
+	}
+	return &SQLStorage{
+		db:      db,
+		dialect: dialect,
+		stmts:   &stmtCache{stmts: make(map[string]*sql.Stmt)},
+	}, nil
+}
+
+// Close 关闭预编译语句和数据库连接
+func (s *SQLStorage) Close() error {
+	s.stmts.close()
+	return s.db.Close()
+}
+
+// rebind 将查询中的 ? 替换为当前数据库的参数占位符
+func (s *SQLStorage) rebind(query string) string {
+	if s.dialect.placeholder == nil {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString(s.dialect.placeholder(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// stmt 返回查询的预编译语句。处于事务中时绑定到该事务；未缓存的语句直接在事务上预编译，
+// 避免连接数为 1 时（如 SQLite）另取连接而死锁，这类语句随事务结束关闭
+func (s *SQLStorage) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	query = s.rebind(query)
+	if s.tx == nil {
+		return s.stmts.get(ctx, s.db, query)
+	}
+	if stmt, ok := s.stmts.lookup(query); ok {
+		return s.tx.StmtContext(ctx, stmt), nil
+	}
+	return s.tx.PrepareContext(ctx, query)
+}
+
+func (s *SQLStorage) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt, err := s.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (s *SQLStorage) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	stmt, err := s.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (s *SQLStorage) queryRow(ctx context.Context, query string, args ...any) sqlRow {
+	stmt, err := s.stmt(ctx, query)
+	if err != nil {
+		return sqlRow{err: err}
+	}
+	return sqlRow{row: stmt.QueryRowContext(ctx, args...)}
+}
+
+// insert 执行插入语句并返回新记录的ID
+func (s *SQLStorage) insert(ctx context.Context, query string, args ...any) (int, error) {
+	if s.dialect.returningID {
+		var id int
+		err := s.queryRow(ctx, query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+	result, err := s.exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// WithTx 在数据库事务中执行 fn，fn 返回错误或 panic 时回滚
+func (s *SQLStorage) WithTx(ctx context.Context, fn func(TodoStorage) error) error {
+	return s.atomic(ctx, func(tx *SQLStorage) error {
+		return fn(tx)
+	})
+}
+
+// BeginTx 开启数据库事务，ctx 被取消时数据库驱动会回滚事务
+func (s *SQLStorage) BeginTx(ctx context.Context) (Tx, error) {
+	if s.tx != nil {
+		return nil, errors.New("不支持嵌套事务")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTx{&SQLStorage{db: s.db, dialect: s.dialect, stmts: s.stmts, tx: tx}}, nil
+}
+
+// sqlTx BeginTx 返回的事务
+type sqlTx struct {
+	*SQLStorage
+}
+
+func (t *sqlTx) Commit() error {
+	err := t.tx.Commit()
+	if errors.Is(err, sql.ErrTxDone) {
+		return ErrTxDone
+	}
+	return err
+}
+
+func (t *sqlTx) Rollback() error {
+	err := t.tx.Rollback()
+	if errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+	return err
+}
+
+// atomic 在事务中执行 fn；已处于事务中时直接执行
+func (s *SQLStorage) atomic(ctx context.Context, fn func(tx *SQLStorage) error) (err error) {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	return fn(&SQLStorage{db: s.db, dialect: s.dialect, stmts: s.stmts, tx: tx})
+}
+
+// GetAll 获取所有待办事项，按ID升序排列
+func (s *SQLStorage) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	todos, err := s.queryTodos(ctx, "SELECT id, data FROM todos WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadComments(ctx, todos, "SELECT id, todo_id, body, created_at FROM comments ORDER BY id"); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// listWhere 将 opts 中能在数据库中执行的条件（完成状态、归档状态、优先级和关键字）转换为 WHERE 子句并排除回收站中的待办事项，
+// 返回的 rest 为剩余需要在内存中执行的条件
+func listWhere(opts ListOptions) (where string, args []any, rest ListOptions) {
+	conditions := []string{"deleted_at IS NULL"}
+	if opts.Completed != nil {
+		conditions = append(conditions, "completed = ?")
+		args = append(args, *opts.Completed)
+	}
+	if opts.Archived != nil {
+		conditions = append(conditions, "archived = ?")
+		args = append(args, *opts.Archived)
+	}
+	if len(opts.Priorities) > 0 {
+		conditions = append(conditions, "priority IN (?"+strings.Repeat(", ?", len(opts.Priorities)-1)+")")
+		for _, priority := range opts.Priorities {
+			args = append(args, string(priority))
+		}
+	}
+	if opts.Query != "" {
+		// 使用 ! 作为转义符：MySQL 字符串字面量中的反斜杠本身需要转义，各数据库写法不一致
+		conditions = append(conditions, "(LOWER(title) LIKE ? ESCAPE '!' OR LOWER(description) LIKE ? ESCAPE '!')")
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(opts.Query)) + "%"
+		args = append(args, pattern, pattern)
+	}
+	where = " WHERE " + strings.Join(conditions, " AND ")
+
+	rest = opts
+	rest.Completed, rest.Archived, rest.Priorities, rest.Query = nil, nil, nil, ""
+	rest.Limit, rest.Offset = 0, 0
+	return where, args, rest
+}
+
+// priorityWeightSQL 与 models.Priority.Weight 一致的优先级权重表达式，未知优先级为 0
+var priorityWeightSQL = fmt.Sprintf("CASE priority WHEN '%s' THEN 1 WHEN '%s' THEN 2 WHEN '%s' THEN 3 WHEN '%s' THEN 4 ELSE 0 END",
+	models.PriorityLow, models.PriorityMedium, models.PriorityHigh, models.PriorityUrgent)
+
+// listOrder 返回 opts 的排序对应的 ORDER BY 子句，排序键相同时按ID升序
+func listOrder(opts ListOptions) string {
+	direction := ""
+	if opts.Desc {
+		direction = " DESC"
+	}
+	if opts.Sort == SortByPriority {
+		return " ORDER BY " + priorityWeightSQL + direction + ", id"
+	}
+	return " ORDER BY id" + direction
+}
+
+// keysetWhere 在 where 上追加只选取排在 after 之后的待办事项的条件。
+// 迁移前创建的待办事项 created_at 列的精度可能低于 JSON 中的创建时间，因此优先使用游标所在行的列值，该行不存在时才使用游标中的时间
+func keysetWhere(where string, args []any, after *Keyset) (string, []any) {
+	if after.ID == 0 {
+		return where, args
+	}
+	condition := "(created_at, id) > (COALESCE((SELECT k.created_at FROM todos k WHERE k.id = ?), ?), ?)"
+	args = append(slices.Clip(args), after.ID, after.CreatedAt.UnixNano(), after.ID)
+	if where == "" {
+		return " WHERE " + condition, args
+	}
+	return where + " AND " + condition, args
+}
+
+// List 按 opts 的排序（设置了 After 时按 (created_at, id) 升序）返回满足 opts 的一页待办事项和总数。
+// 完成状态、归档状态、优先级和关键字在数据库中过滤，没有其他条件时排序和分页也在数据库中执行；否则读取预过滤的结果后在内存中过滤、排序和分页
+func (s *SQLStorage) List(ctx context.Context, opts ListOptions) ([]*models.Todo, int, error) {
+	where, args, rest := listWhere(opts)
+	if rest.HasFilters() {
+		todos, err := s.queryTodos(ctx, "SELECT id, data FROM todos"+where+" ORDER BY id", args...)
+		if err != nil {
+			return nil, 0, err
+		}
+		todos = rest.Filter(todos)
+		total := len(todos)
+		todos = opts.page(todos)
+		return todos, total, s.loadPageComments(ctx, todos)
+	}
+
+	var total int
+	if err := s.queryRow(ctx, "SELECT COUNT(*) FROM todos"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		// 各数据库表示“不限制”的写法不同，用总数代替
+		limit = max(total, 1)
+	}
+	if opts.After != nil {
+		where, args := keysetWhere(where, args, opts.After)
+		todos, err := s.queryTodos(ctx, "SELECT id, data FROM todos"+where+" ORDER BY created_at, id LIMIT ?", append(args, limit)...)
+		if err != nil {
+			return nil, 0, err
+		}
+		return todos, total, s.loadPageComments(ctx, todos)
+	}
+	todos, err := s.queryTodos(ctx, "SELECT id, data FROM todos"+where+listOrder(opts)+" LIMIT ? OFFSET ?", append(args, limit, opts.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return todos, total, s.loadPageComments(ctx, todos)
+}
+
+// Count 返回满足 opts 的待办事项数量。只有数据库能执行的条件时直接 COUNT，否则读取预过滤的结果后在内存中统计
+func (s *SQLStorage) Count(ctx context.Context, opts ListOptions) (int, error) {
+	where, args, rest := listWhere(opts)
+	if rest.HasFilters() {
+		todos, err := s.queryTodos(ctx, "SELECT id, data FROM todos"+where, args...)
+		if err != nil {
+			return 0, err
+		}
+		return len(rest.Filter(todos)), nil
+	}
+
+	var count int
+	err := s.queryRow(ctx, "SELECT COUNT(*) FROM todos"+where, args...).Scan(&count)
+	return count, err
+}
+
+// loadPageComments 为一页待办事项加载备注。按页内最小到最大ID的区间查询，区间内不属于这一页的备注会被跳过；
+// MySQL 不支持在 IN 子查询中使用 LIMIT，因此不按子查询加载
+func (s *SQLStorage) loadPageComments(ctx context.Context, todos []*models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	first, last := int(todos[0].ID), int(todos[0].ID)
+	for _, todo := range todos[1:] {
+		first, last = min(first, int(todo.ID)), max(last, int(todo.ID))
+	}
+	return s.loadComments(ctx, todos, "SELECT id, todo_id, body, created_at FROM comments WHERE todo_id BETWEEN ? AND ? ORDER BY id", first, last)
+}
+
+// GetByID 根据ID获取待办事项
+func (s *SQLStorage) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	var data string
+	err := s.queryRow(ctx, "SELECT data FROM todos WHERE id = ? AND deleted_at IS NULL", id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := decodeTodo(id, data)
+	if err != nil {
+		return nil, err
+	}
+	todos := []*models.Todo{todo}
+	if err := s.loadComments(ctx, todos, "SELECT id, todo_id, body, created_at FROM comments WHERE todo_id = ? ORDER BY id", id); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// FindByExternalID 返回外部ID匹配的待办事项，按ID升序排列，没有匹配时返回空切片
+func (s *SQLStorage) FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error) {
+	todos, err := s.queryTodos(ctx, "SELECT id, data FROM todos WHERE external_id = ? AND deleted_at IS NULL ORDER BY id", externalID)
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range todos {
+		if err := s.loadComments(ctx, []*models.Todo{todo}, "SELECT id, todo_id, body, created_at FROM comments WHERE todo_id = ? ORDER BY id", int(todo.ID)); err != nil {
+			return nil, err
+		}
+	}
+	return todos, nil
+}
+
+// Create 创建待办事项并记录第一个历史版本
+func (s *SQLStorage) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	var todo *models.Todo
+	err := s.atomic(ctx, func(tx *SQLStorage) error {
+		todo = newTodo(0, req, time.Now())
+		data, err := encodeTodo(todo)
+		if err != nil {
+			return err
+		}
+		id, err := tx.insert(ctx, "INSERT INTO todos ("+todoColumns+", data) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			append(todoColumnValues(todo), data)...)
+		if err != nil {
+			return err
+		}
+		todo.ID = models.ID(id)
+		return tx.recordVersion(ctx, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// Update 更新待办事项并记录新的历史版本
+func (s *SQLStorage) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	var todo *models.Todo
+	err := s.atomic(ctx, func(tx *SQLStorage) error {
+		var err error
+		if todo, err = tx.GetByID(ctx, id); err != nil {
+			return err
+		}
+		applyUpdate(todo, req, time.Now())
+		if err := tx.updateRow(ctx, todo); err != nil {
+			return err
+		}
+		return tx.recordVersion(ctx, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// Delete 将待办事项连同备注移入回收站并删除其历史版本
+func (s *SQLStorage) Delete(ctx context.Context, id int) error {
+	return s.atomic(ctx, func(tx *SQLStorage) error {
+		todo, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		todo.DeletedAt = &now
+		if err := tx.updateRow(ctx, todo); err != nil {
+			return err
+		}
+		_, err = tx.exec(ctx, "DELETE FROM todo_versions WHERE todo_id = ?", id)
+		return err
+	})
+}
+
+// AddComment 为待办事项添加备注，不更新 UpdatedAt
+func (s *SQLStorage) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	var comment *models.Comment
+	err := s.atomic(ctx, func(tx *SQLStorage) error {
+		if err := tx.checkExists(ctx, todoID); err != nil {
+			return err
+		}
+		now := time.Now()
+		id, err := tx.insert(ctx, "INSERT INTO comments (todo_id, body, created_at) VALUES (?, ?, ?)", todoID, req.Body, formatTime(now))
+		if err != nil {
+			return err
+		}
+		comment = &models.Comment{ID: models.ID(id), Body: req.Body, CreatedAt: now}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteComment 删除待办事项下的备注
+func (s *SQLStorage) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	return s.atomic(ctx, func(tx *SQLStorage) error {
+		if err := tx.checkExists(ctx, todoID); err != nil {
+			return err
+		}
+		result, err := tx.exec(ctx, "DELETE FROM comments WHERE id = ? AND todo_id = ?", commentID, todoID)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrCommentNotFound
+		}
+		return nil
+	})
+}
+
+// Clear 删除所有数据（包括回收站）并重置ID序列，返回删除的回收站之外的待办事项数量
+func (s *SQLStorage) Clear(ctx context.Context) (int, error) {
+	var count int
+	err := s.atomic(ctx, func(tx *SQLStorage) error {
+		if err := tx.queryRow(ctx, "SELECT COUNT(*) FROM todos WHERE deleted_at IS NULL").Scan(&count); err != nil {
+			return err
+		}
+		for _, stmt := range tx.dialect.clear {
+			if _, err := tx.exec(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// History 返回待办事项保留的历史版本，按版本号升序排列
+func (s *SQLStorage) History(ctx context.Context, id int) ([]models.TodoVersion, error) {
+	if err := s.checkExists(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.query(ctx, "SELECT version, recorded_at, data FROM todo_versions WHERE todo_id = ? ORDER BY version", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.TodoVersion{}
+	for rows.Next() {
+		var (
+			version          models.TodoVersion
+			recordedAt, data string
+		)
+		if err := rows.Scan(&version.Version, &recordedAt, &data); err != nil {
+			return nil, err
+		}
+		if version.RecordedAt, err = parseTime(recordedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &version.Todo); err != nil {
+			return nil, err
+		}
+		version.Todo.ID = models.ID(id)
+		version.Todo.Comments = nil
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// Export 在同一事务中导出全部数据，NextID 和 NextCommentID 取已有最大ID加 1
+func (s *SQLStorage) Export(ctx context.Context) (*Snapshot, error) {
+	snapshot := &Snapshot{Format: SnapshotFormat, ExportedAt: time.Now(), History: make(map[int][]models.TodoVersion)}
+	err := s.atomic(ctx, func(tx *SQLStorage) error {
+		var err error
+		if snapshot.Todos, err = tx.GetAll(ctx); err != nil {
+			return err
+		}
+		if snapshot.Trash, err = tx.Trash(ctx); err != nil {
+			return err
+		}
+
+		rows, err := tx.query(ctx, "SELECT todo_id, version, recorded_at, data FROM todo_versions ORDER BY todo_id, version")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				todoID           int
+				version          models.TodoVersion
+				recordedAt, data string
+			)
+			if err := rows.Scan(&todoID, &version.Version, &recordedAt, &data); err != nil {
+				return err
+			}
+			if version.RecordedAt, err = parseTime(recordedAt); err != nil {
+				return err
+			}
+			if err := json.Unmarshal([]byte(data), &version.Todo); err != nil {
+				return err
+			}
+			version.Todo.ID = models.ID(todoID)
+			version.Todo.Comments = nil
+			snapshot.History[todoID] = append(snapshot.History[todoID], version)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		snapshot.Filters, err = tx.ListFilters(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, snapshot.normalize()
+}
+
+// Import 在一个事务中删除全部数据并以快照中的ID重新写入
+func (s *SQLStorage) Import(ctx context.Context, snapshot *Snapshot) error {
+	if err := snapshot.normalize(); err != nil {
+		return err
+	}
+	return s.atomic(ctx, func(tx *SQLStorage) error {
+		// 不使用 dialect.clear：MySQL 的 TRUNCATE 会隐式提交事务
+		for _, stmt := range []string{"DELETE FROM comments", "DELETE FROM todo_versions", "DELETE FROM todos", "DELETE FROM saved_filters"} {
+			if _, err := tx.exec(ctx, stmt); err != nil {
+				return err
+			}
+		}
+
+		for _, todo := range slices.Concat(snapshot.Todos, snapshot.Trash) {
+			data, err := encodeTodo(todo)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.exec(ctx, "INSERT INTO todos (id, "+todoColumns+", data) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				append([]any{int(todo.ID)}, append(todoColumnValues(todo), data)...)...); err != nil {
+				return err
+			}
+			for _, comment := range todo.Comments {
+				if _, err := tx.exec(ctx, "INSERT INTO comments (id, todo_id, body, created_at) VALUES (?, ?, ?, ?)",
+					int(comment.ID), int(todo.ID), comment.Body, formatTime(comment.CreatedAt)); err != nil {
+					return err
+				}
+			}
+			for _, version := range snapshot.History[int(todo.ID)] {
+				state := version.Todo
+				state.Comments = nil
+				data, err := json.Marshal(state)
+				if err != nil {
+					return err
+				}
+				if _, err := tx.exec(ctx, "INSERT INTO todo_versions (todo_id, version, recorded_at, data) VALUES (?, ?, ?, ?)",
+					int(todo.ID), version.Version, formatTime(version.RecordedAt), string(data)); err != nil {
+					return err
+				}
+			}
+		}
+		for _, filter := range snapshot.Filters {
+			if _, err := tx.exec(ctx, "INSERT INTO saved_filters (id, name, query, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+				int(filter.ID), filter.Name, filter.Query, formatTime(filter.CreatedAt), formatTime(filter.UpdatedAt)); err != nil {
+				return err
+			}
+		}
+
+		for _, stmt := range tx.dialect.syncSequences {
+			if _, err := tx.exec(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// updateRow 将待办事项的内容写回 todos 表，不包括备注
+func (s *SQLStorage) updateRow(ctx context.Context, todo *models.Todo) error {
+	data, err := encodeTodo(todo)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(ctx, "UPDATE todos SET external_id = ?, completed = ?, priority = ?, title = ?, description = ?, archived = ?, deleted_at = ?, created_at = ?, data = ? WHERE id = ?",
+		append(todoColumnValues(todo), data, int(todo.ID))...)
+	return err
+}
+
+// recordVersion 记录待办事项当前状态为新版本，并删除超出保留数量的旧版本
+func (s *SQLStorage) recordVersion(ctx context.Context, todo *models.Todo) error {
+	id := int(todo.ID)
+	var latest sql.NullInt64
+	if err := s.queryRow(ctx, "SELECT MAX(version) FROM todo_versions WHERE todo_id = ?", id).Scan(&latest); err != nil {
+		return err
+	}
+
+	version := newVersion(todo, int(latest.Int64)+1)
+	data, err := json.Marshal(version.Todo)
+	if err != nil {
+		return err
+	}
+	if _, err := s.exec(ctx, "INSERT INTO todo_versions (todo_id, version, recorded_at, data) VALUES (?, ?, ?, ?)",
+		id, version.Version, formatTime(version.RecordedAt), string(data)); err != nil {
+		return err
+	}
+	_, err = s.exec(ctx, "DELETE FROM todo_versions WHERE todo_id = ? AND version <= ?", id, version.Version-maxTodoVersions)
+	return err
+}
+
+// checkExists 检查待办事项是否存在且不在回收站中
+func (s *SQLStorage) checkExists(ctx context.Context, id int) error {
+	var exists int
+	err := s.queryRow(ctx, "SELECT 1 FROM todos WHERE id = ? AND deleted_at IS NULL", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTodoNotFound
+	}
+	return err
+}
+
+// queryTodos 执行返回 (id, data) 的查询并解码为待办事项
+func (s *SQLStorage) queryTodos(ctx context.Context, query string, args ...any) ([]*models.Todo, error) {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := make([]*models.Todo, 0)
+	for rows.Next() {
+		var (
+			id   int
+			data string
+		)
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		todo, err := decodeTodo(id, data)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// loadComments 执行返回 (id, todo_id, body, created_at) 的查询，将备注按顺序挂到对应的待办事项上
+func (s *SQLStorage) loadComments(ctx context.Context, todos []*models.Todo, query string, args ...any) error {
+	byID := make(map[int]*models.Todo, len(todos))
+	for _, todo := range todos {
+		byID[int(todo.ID)] = todo
+	}
+
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			comment   models.Comment
+			id        int
+			todoID    int
+			createdAt string
+		)
+		if err := rows.Scan(&id, &todoID, &comment.Body, &createdAt); err != nil {
+			return err
+		}
+		todo, ok := byID[todoID]
+		if !ok {
+			continue
+		}
+		comment.ID = models.ID(id)
+		if comment.CreatedAt, err = parseTime(createdAt); err != nil {
+			return err
+		}
+		todo.Comments = append(todo.Comments, comment)
+	}
+	return rows.Err()
+}
+
+// encodeTodo 将待办事项序列化为 data 列的内容，ID 和备注另行存储
+func encodeTodo(todo *models.Todo) (string, error) {
+	stored := *todo
+	stored.ID = 0
+	stored.Comments = nil
+	data, err := json.Marshal(stored)
+	return string(data), err
+}
+
+// decodeTodo 从 data 列还原待办事项
+func decodeTodo(id int, data string) (*models.Todo, error) {
+	var todo models.Todo
+	if err := json.Unmarshal([]byte(data), &todo); err != nil {
+		return nil, err
+	}
+	todo.ID = models.ID(id)
+	todo.Comments = []models.Comment{}
+	if todo.Tags == nil {
+		todo.Tags = []string{}
+	}
+	if todo.Subtasks == nil {
+		todo.Subtasks = []models.Subtask{}
+	}
+	return &todo, nil
+}
+
+// formatTime 以文本形式保存时间，避免不同数据库时间类型的差异
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseTime 解析 formatTime 保存的时间
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-todolist/models"
+)
+
+// testSQLCRUD 在空的 SQL 存储上检查增删改查、备注、外部ID和历史版本，供各数据库的测试共用
+func testSQLCRUD(t *testing.T, s *SQLStorage) {
+	ctx := context.Background()
+
+	created, err := s.Create(ctx, &models.CreateTodoRequest{Title: "a", Tags: []string{"work"}, ExternalID: "ext-1"})
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if created.ID != 1 {
+		t.Errorf("ID = %d，期望 1", created.ID)
+	}
+
+	title, done := "b", true
+	updated, err := s.Update(ctx, 1, &models.UpdateTodoRequest{Title: &title, Completed: &done})
+	if err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	if updated.Title != "b" || !updated.Completed {
+		t.Errorf("更新后 = %+v", updated)
+	}
+
+	if _, err := s.AddComment(ctx, 1, &models.CreateCommentRequest{Body: "note"}); err != nil {
+		t.Fatalf("添加备注失败: %v", err)
+	}
+	got, err := s.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetByID 失败: %v", err)
+	}
+	if got.Title != "b" || len(got.Comments) != 1 || len(got.Tags) != 1 {
+		t.Errorf("GetByID = %+v", got)
+	}
+	byExternal, err := s.FindByExternalID(ctx, "ext-1")
+	if err != nil || len(byExternal) != 1 {
+		t.Errorf("FindByExternalID = %v, %v", byExternal, err)
+	}
+	history, err := s.History(ctx, 1)
+	if err != nil || len(history) != 2 {
+		t.Errorf("History 返回 %d 个版本, %v，期望 2", len(history), err)
+	}
+
+	if err := s.Delete(ctx, 1); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if _, err := s.GetByID(ctx, 1); !errors.Is(err, ErrTodoNotFound) {
+		t.Errorf("删除后 GetByID 错误 = %v，期望 %v", err, ErrTodoNotFound)
+	}
+}
+
+// testSQLList 在空的 SQL 存储上检查数据库中和内存中的过滤与分页，供各数据库的测试共用
+func testSQLList(t *testing.T, s *SQLStorage) {
+	ctx := context.Background()
+	for _, title := range []string{"alpha", "beta", "gamma", "delta"} {
+		if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: title, Tags: []string{title[:1]}}); err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+	}
+	done := true
+	if _, err := s.Update(ctx, 2, &models.UpdateTodoRequest{Completed: &done}); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+
+	notDone := false
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantIDs   []int
+		wantTotal int
+	}{
+		{"数据库中分页", ListOptions{Limit: 2, Offset: 1}, []int{2, 3}, 4},
+		{"数据库中过滤", ListOptions{Completed: &notDone}, []int{1, 3, 4}, 3},
+		{"关键字", ListOptions{Query: "TA"}, []int{2, 4}, 2},
+		{"内存中过滤后分页", ListOptions{Tag: "a", Limit: 1}, []int{1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, total, err := s.List(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("List 失败: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("总数 = %d，期望 %d", total, tt.wantTotal)
+			}
+			assertAscending(t, todos, tt.wantIDs)
+		})
+	}
+}
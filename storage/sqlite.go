@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqliteDriverName SQLite 驱动注册的名称，驱动由 sqlite_driver.go 引入
+const sqliteDriverName = "sqlite"
+
+// sqliteDialect SQLite 的清空语句，表结构见 migrations/sqlite
+var sqliteDialect = sqlDialect{
+	name: "sqlite",
+	clear: []string{
+		"DELETE FROM comments",
+		"DELETE FROM todo_versions",
+		"DELETE FROM todos",
+		"DELETE FROM sqlite_sequence WHERE name IN ('todos', 'comments')",
+	},
+}
+
+// OpenSQLite 打开（不存在时创建）path 指定的 SQLite 数据库文件并执行数据库迁移
+func OpenSQLite(path string) (*SQLStorage, error) {
+	db, err := openSQLiteDB(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSQLStorage(db, sqliteDialect)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化 SQLite 数据库失败: %w", err)
+	}
+	return s, nil
+}
+
+// openSQLiteDB 打开 SQLite 数据库连接
+func openSQLiteDB(path string) (*sql.DB, error) {
+	db, err := sql.Open(sqliteDriverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 SQLite 数据库失败: %w", err)
+	}
+	// SQLite 同一时间只允许一个写入者，单连接可避免 database is locked 错误
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
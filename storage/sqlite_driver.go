@@ -0,0 +1,20 @@
+package storage
+
+// 引入纯 Go 实现的 SQLite 驱动，注册名称为 "sqlite"。不需要 cgo，默认编译
+import (
+	"errors"
+
+	"modernc.org/sqlite"
+)
+
+func init() {
+	// SQLITE_BUSY(5)、SQLITE_LOCKED(6) 表示数据库被其他连接锁定，低 8 位为主错误码
+	transientChecks = append(transientChecks, func(err error) bool {
+		var sqliteErr *sqlite.Error
+		if !errors.As(err, &sqliteErr) {
+			return false
+		}
+		code := sqliteErr.Code() & 0xff
+		return code == 5 || code == 6
+	})
+}
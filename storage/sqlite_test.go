@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todolist/migrations"
+	"go-todolist/models"
+)
+
+// openTestSQLite 在临时目录中打开 SQLite 存储，测试结束时关闭
+func openTestSQLite(t *testing.T) *SQLStorage {
+	t.Helper()
+	s, err := OpenSQLite(filepath.Join(t.TempDir(), "todos.db"))
+	if err != nil {
+		t.Fatalf("打开 SQLite 存储失败: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSQLiteCRUD(t *testing.T) {
+	testSQLCRUD(t, openTestSQLite(t))
+}
+
+func TestSQLiteList(t *testing.T) {
+	testSQLList(t, openTestSQLite(t))
+}
+
+// 迁移 0007 从 JSON 回填 created_at 列，精度为毫秒级
+func TestSQLiteCreatedAtBackfill(t *testing.T) {
+	s := openTestSQLite(t)
+	ctx := context.Background()
+	created, err := s.Create(ctx, &models.CreateTodoRequest{Title: "a"})
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if _, err := s.db.Exec("UPDATE todos SET created_at = 0"); err != nil {
+		t.Fatalf("清空 created_at 列失败: %v", err)
+	}
+
+	all, err := migrations.Load("sqlite")
+	if err != nil {
+		t.Fatalf("读取迁移失败: %v", err)
+	}
+	i := slices.IndexFunc(all, func(m migrations.Migration) bool { return m.Name == "created_at_column" })
+	if i < 0 {
+		t.Fatal("找不到 created_at_column 迁移")
+	}
+	for _, statement := range all[i].Statements {
+		if strings.HasPrefix(strings.TrimSpace(statement), "UPDATE") {
+			if _, err := s.db.Exec(statement); err != nil {
+				t.Fatalf("执行回填失败: %v", err)
+			}
+		}
+	}
+
+	var got int64
+	if err := s.db.QueryRow("SELECT created_at FROM todos WHERE id = ?", created.ID).Scan(&got); err != nil {
+		t.Fatalf("读取 created_at 列失败: %v", err)
+	}
+	if diff := time.Duration(got - created.CreatedAt.UnixNano()).Abs(); diff > time.Millisecond {
+		t.Errorf("回填的 created_at = %d，与创建时间 %d 相差 %v，期望不超过 1ms", got, created.CreatedAt.UnixNano(), diff)
+	}
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"time"
+
+	"go-todolist/models"
+)
+
+// newTodo 根据创建请求构造ID为 id 的待办事项，供各存储实现共用
+func newTodo(id int, req *models.CreateTodoRequest, now time.Time) *models.Todo {
+	priority := req.Priority
+	if priority == "" {
+		priority = models.PriorityMedium
+	}
+
+	return &models.Todo{
+		ID:              models.ID(id),
+		Title:           req.Title,
+		Description:     req.Description,
+		Completed:       false,
+		Color:           req.Color,
+		Priority:        priority,
+		DueDate:         cloneTime(req.DueDate),
+		RemindBefore:    req.RemindBefore,
+		EstimateMinutes: req.EstimateMinutes,
+		SpentMinutes:    req.SpentMinutes,
+		Tags:            append([]string{}, req.Tags...),
+		Subtasks:        append([]models.Subtask{}, req.Subtasks...),
+		ListID:          req.ListID,
+		ExternalID:      req.ExternalID,
+		Comments:        []models.Comment{},
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+// applyUpdate 将更新请求中已设置的字段应用到待办事项上，并更新 UpdatedAt
+func applyUpdate(todo *models.Todo, req *models.UpdateTodoRequest, now time.Time) {
+	if req.Title != nil {
+		todo.Title = *req.Title
+	}
+	if req.Description != nil {
+		todo.Description = *req.Description
+	}
+	if req.Completed != nil {
+		// 只在完成状态变化时更新完成时间，重复标记完成不改变原完成时间
+		if *req.Completed && !todo.Completed {
+			todo.CompletedAt = &now
+		} else if !*req.Completed {
+			todo.CompletedAt = nil
+		}
+		todo.Completed = *req.Completed
+	}
+	if req.Archived != nil {
+		// 与完成时间相同，重复归档不改变原归档时间
+		if *req.Archived && !todo.Archived {
+			todo.ArchivedAt = &now
+		} else if !*req.Archived {
+			todo.ArchivedAt = nil
+		}
+		todo.Archived = *req.Archived
+	}
+	if req.Starred != nil {
+		todo.Starred = *req.Starred
+	}
+	if req.Color != nil {
+		todo.Color = *req.Color
+	}
+	if req.Priority != nil {
+		todo.Priority = *req.Priority
+	}
+	if req.DueDate != nil {
+		todo.DueDate = cloneTime(req.DueDate)
+	} else if req.ClearDueDate {
+		todo.DueDate = nil
+	}
+	if req.RemindBefore != nil {
+		todo.RemindBefore = *req.RemindBefore
+	}
+	if req.EstimateMinutes != nil {
+		todo.EstimateMinutes = *req.EstimateMinutes
+	}
+	if req.SpentMinutes != nil {
+		todo.SpentMinutes = *req.SpentMinutes
+	}
+	if req.Tags != nil {
+		todo.Tags = append([]string{}, (*req.Tags)...)
+	}
+	if req.Subtasks != nil {
+		todo.Subtasks = append([]models.Subtask{}, (*req.Subtasks)...)
+	}
+	if req.ListID != nil {
+		todo.ListID = *req.ListID
+	}
+	if req.Position != nil {
+		todo.Position = *req.Position
+	}
+	if req.ExternalID != nil {
+		todo.ExternalID = *req.ExternalID
+	}
+	todo.UpdatedAt = now
+}
+
+// cloneTodo 深拷贝待办事项，调用方修改副本不会影响原数据
+func cloneTodo(todo *models.Todo) *models.Todo {
+	copied := *todo
+	copied.CompletedAt = cloneTime(todo.CompletedAt)
+	copied.ArchivedAt = cloneTime(todo.ArchivedAt)
+	copied.DueDate = cloneTime(todo.DueDate)
+	copied.DeletedAt = cloneTime(todo.DeletedAt)
+	copied.Tags = append([]string{}, todo.Tags...)
+	copied.Subtasks = append([]models.Subtask{}, todo.Subtasks...)
+	copied.Comments = append([]models.Comment{}, todo.Comments...)
+	return &copied
+}
+
+// cloneTime 复制时间指针指向的值
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+// newVersion 以待办事项的当前状态构造历史版本，备注不属于版本内容
+func newVersion(todo *models.Todo, version int) models.TodoVersion {
+	state := *todo
+	state.Tags = append([]string{}, todo.Tags...)
+	state.Subtasks = append([]models.Subtask{}, todo.Subtasks...)
+	state.Comments = nil
+	return models.TodoVersion{Version: version, Todo: state, RecordedAt: todo.UpdatedAt}
+}
+
+// appendVersion 追加新版本，超出保留数量时丢弃最旧的版本
+func appendVersion(versions []models.TodoVersion, todo *models.Todo) []models.TodoVersion {
+	version := 1
+	if len(versions) > 0 {
+		version = versions[len(versions)-1].Version + 1
+	}
+	versions = append(versions, newVersion(todo, version))
+	if len(versions) > maxTodoVersions {
+		versions = versions[len(versions)-maxTodoVersions:]
+	}
+	return versions
+}
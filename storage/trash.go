@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-todolist/models"
+)
+
+// TrashStorage 由支持回收站的存储实现。Delete 将待办事项连同备注移入回收站并记录 DeletedAt，
+// 回收站中的待办事项不会出现在 GetAll、List 等读取方法中，随备份导出和导入
+type TrashStorage interface {
+	// Trash 返回回收站中的待办事项，最近删除的排在最前
+	Trash(ctx context.Context) ([]*models.Todo, error)
+	// PurgeTrash 永久删除回收站中删除时间早于 before 的待办事项，before 为零值时清空回收站，返回删除的数量
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	// Restore 将待办事项从回收站恢复。ID曾经存在但已被永久删除时返回 ErrConflict，
+	// 待办事项未被删除时同样返回 ErrConflict，从未存在时返回 ErrTodoNotFound
+	Restore(ctx context.Context, id int) (*models.Todo, error)
+}
+
+// sortedTodos 按ID升序返回 todos 中的待办事项
+func sortedTodos(todos map[int]*models.Todo) []*models.Todo {
+	sorted := make([]*models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		sorted = append(sorted, todo)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// sortTrash 将回收站中的待办事项按删除时间倒序排列，删除时间相同时ID大的在前
+func sortTrash(todos []*models.Todo) {
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].DeletedAt.Equal(*todos[j].DeletedAt) {
+			return todos[i].DeletedAt.After(*todos[j].DeletedAt)
+		}
+		return todos[i].ID > todos[j].ID
+	})
+}
+
+// restoreError 返回待办事项不在回收站中时 Restore 的错误。live 表示待办事项未被删除，
+// lastID 为已分配过的最大ID，不大于它的ID曾经存在过
+func restoreError(id int, live bool, lastID int) error {
+	switch {
+	case live:
+		return fmt.Errorf("%w: 待办事项 %d 不在回收站中", ErrConflict, id)
+	case id > 0 && id <= lastID:
+		return fmt.Errorf("%w: 待办事项 %d 已被永久删除，无法恢复", ErrConflict, id)
+	}
+	return ErrTodoNotFound
+}
+
+// Trash 返回回收站中待办事项的副本，最近删除的排在最前
+func (s *MemoryStorage) Trash(ctx context.Context) ([]*models.Todo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	todos := make([]*models.Todo, 0, len(s.trash))
+	for _, todo := range s.trash {
+		todos = append(todos, cloneTodo(todo))
+	}
+	sortTrash(todos)
+	return todos, nil
+}
+
+// PurgeTrash 永久删除回收站中删除时间早于 before 的待办事项，before 为零值时清空回收站
+func (s *MemoryStorage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	purged := 0
+	for id, todo := range s.trash {
+		if before.IsZero() || todo.DeletedAt.Before(before) {
+			delete(s.trash, id)
+			purged++
+		}
+	}
+	if purged > 0 {
+		s.revision++
+	}
+	return purged, nil
+}
+
+// Restore 将待办事项从回收站恢复并记录新的历史版本
+func (s *MemoryStorage) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	todo, exists := s.trash[id]
+	if !exists {
+		return nil, restoreError(id, s.todos[id] != nil, s.nextID-1)
+	}
+
+	delete(s.trash, id)
+	todo.DeletedAt = nil
+	todo.UpdatedAt = time.Now()
+	s.todos[id] = todo
+	i := sort.SearchInts(s.order, id)
+	s.order = append(s.order[:i], append([]int{id}, s.order[i:]...)...)
+	s.indexExternalID(todo.ExternalID, id)
+	s.recordVersion(todo)
+	s.revision++
+	return cloneTodo(todo), nil
+}
+
+func (s *FileStorage) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	todo, err := s.MemoryStorage.Restore(ctx, id)
+	if err == nil {
+		s.scheduleFlush()
+	}
+	return todo, err
+}
+
+func (s *FileStorage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	purged, err := s.MemoryStorage.PurgeTrash(ctx, before)
+	if err == nil && purged > 0 {
+		s.scheduleFlush()
+	}
+	return purged, err
+}
+
+// 清理回收站不频繁，预写日志直接记录为一条快照
+func (s *WALMemoryStorage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	purged, err := s.MemoryStorage.PurgeTrash(ctx, before)
+	if err != nil || purged == 0 {
+		return purged, err
+	}
+	return purged, s.logSnapshot()
+}
+
+func (s *WALMemoryStorage) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	todo, err := s.MemoryStorage.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return todo, s.logPut(id, true)
+}
+
+// Trash 返回回收站中的待办事项，最近删除的排在最前
+func (s *SQLStorage) Trash(ctx context.Context) ([]*models.Todo, error) {
+	todos, err := s.queryTodos(ctx, "SELECT id, data FROM todos WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadComments(ctx, todos, "SELECT c.id, c.todo_id, c.body, c.created_at FROM comments c JOIN todos t ON t.id = c.todo_id WHERE t.deleted_at IS NOT NULL ORDER BY c.id"); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// PurgeTrash 永久删除回收站中删除时间早于 before 的待办事项及其备注，before 为零值时清空回收站
+func (s *SQLStorage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	where, args := " WHERE deleted_at IS NOT NULL", []any(nil)
+	if !before.IsZero() {
+		where, args = " WHERE deleted_at < ?", []any{before.UnixNano()}
+	}
+
+	var purged int
+	err := s.atomic(ctx, func(tx *SQLStorage) error {
+		if _, err := tx.exec(ctx, "DELETE FROM comments WHERE todo_id IN (SELECT id FROM todos"+where+")", args...); err != nil {
+			return err
+		}
+		result, err := tx.exec(ctx, "DELETE FROM todos"+where, args...)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		purged = int(n)
+		return err
+	})
+	return purged, err
+}
+
+// Restore 将待办事项从回收站恢复并记录新的历史版本。
+// SQL 存储以表中现有的最大ID判断ID是否曾经存在，最大ID被永久删除后会视为从未存在
+func (s *SQLStorage) Restore(ctx context.Context, id int) (*models.Todo, error) {
+	var todo *models.Todo
+	err := s.atomic(ctx, func(tx *SQLStorage) error {
+		var (
+			data      string
+			deletedAt sql.NullInt64
+		)
+		err := tx.queryRow(ctx, "SELECT data, deleted_at FROM todos WHERE id = ?", id).Scan(&data, &deletedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			var maxID int
+			if err := tx.queryRow(ctx, "SELECT COALESCE(MAX(id), 0) FROM todos").Scan(&maxID); err != nil {
+				return err
+			}
+			return restoreError(id, false, maxID)
+		}
+		if err != nil {
+			return err
+		}
+		if !deletedAt.Valid {
+			return restoreError(id, true, 0)
+		}
+
+		if todo, err = decodeTodo(id, data); err != nil {
+			return err
+		}
+		todo.DeletedAt = nil
+		todo.UpdatedAt = time.Now()
+		if err := tx.updateRow(ctx, todo); err != nil {
+			return err
+		}
+		if err := tx.recordVersion(ctx, todo); err != nil {
+			return err
+		}
+		return tx.loadComments(ctx, []*models.Todo{todo}, "SELECT id, todo_id, body, created_at FROM comments WHERE todo_id = ? ORDER BY id", id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-todolist/models"
+)
+
+// trashTestStorage 回收站测试所需的存储能力
+type trashTestStorage interface {
+	TodoStorage
+	TrashStorage
+}
+
+// trashBackends 参与回收站测试的存储，带构建标签的存储在各自的测试文件中追加
+var trashBackends = []struct {
+	name string
+	open func(t *testing.T) trashTestStorage
+}{
+	{"memory", func(t *testing.T) trashTestStorage { return NewMemoryStorage() }},
+	{"wal", func(t *testing.T) trashTestStorage {
+		s, err := OpenWALMemory(filepath.Join(t.TempDir(), "todos.wal"))
+		if err != nil {
+			t.Fatalf("打开预写日志存储失败: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}},
+	{"file", func(t *testing.T) trashTestStorage {
+		s, err := OpenFile(filepath.Join(t.TempDir(), "todos.json"), 0)
+		if err != nil {
+			t.Fatalf("打开文件存储失败: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}},
+	{"sqlite", func(t *testing.T) trashTestStorage { return openTestSQLite(t) }},
+}
+
+func TestTrash(t *testing.T) {
+	for _, backend := range trashBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			testTrash(t, backend.open(t))
+		})
+	}
+}
+
+// testTrash 检查软删除、回收站、导出和清理，供各存储的测试共用
+func testTrash(t *testing.T, s trashTestStorage) {
+	ctx := context.Background()
+	for _, title := range []string{"a", "b", "c"} {
+		if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: title}); err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+	}
+	if _, err := s.AddComment(ctx, 2, &models.CreateCommentRequest{Body: "note"}); err != nil {
+		t.Fatalf("添加备注失败: %v", err)
+	}
+	if err := s.Delete(ctx, 2); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+
+	// 回收站中的待办事项不出现在其他读取方法中，也不能再修改
+	assertAscending(t, mustGetAll(t, s), []int{1, 3})
+	if _, err := s.GetByID(ctx, 2); !errors.Is(err, ErrTodoNotFound) {
+		t.Errorf("GetByID 错误 = %v，期望 %v", err, ErrTodoNotFound)
+	}
+	if _, total, err := List(ctx, s, ListOptions{}); err != nil || total != 2 {
+		t.Errorf("List 总数 = %d, %v，期望 2", total, err)
+	}
+	title := "x"
+	if _, err := s.Update(ctx, 2, &models.UpdateTodoRequest{Title: &title}); !errors.Is(err, ErrTodoNotFound) {
+		t.Errorf("Update 错误 = %v，期望 %v", err, ErrTodoNotFound)
+	}
+	if err := s.Delete(ctx, 2); !errors.Is(err, ErrTodoNotFound) {
+		t.Errorf("重复删除错误 = %v，期望 %v", err, ErrTodoNotFound)
+	}
+
+	trash, err := s.Trash(ctx)
+	if err != nil {
+		t.Fatalf("Trash 失败: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != 2 || trash[0].DeletedAt == nil || len(trash[0].Comments) != 1 {
+		t.Fatalf("回收站 = %+v，期望只有带备注和删除时间的 2", trash)
+	}
+
+	// 回收站随快照导出
+	snapshot, err := s.Export(ctx)
+	if err != nil {
+		t.Fatalf("Export 失败: %v", err)
+	}
+	if len(snapshot.Trash) != 1 || snapshot.Trash[0].ID != 2 || len(snapshot.Todos) != 2 {
+		t.Errorf("快照中待办事项 %d 项、回收站 %+v，期望 2 项和回收站中的 2", len(snapshot.Todos), snapshot.Trash)
+	}
+
+	// 按删除时间清理，再清空回收站
+	if err := s.Delete(ctx, 1); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if purged, err := s.PurgeTrash(ctx, time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+		t.Errorf("清理一小时前删除的 = %d, %v，期望 0", purged, err)
+	}
+	if err := s.Delete(ctx, 3); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if purged, err := s.PurgeTrash(ctx, time.Time{}); err != nil || purged != 3 {
+		t.Errorf("清空回收站 = %d, %v，期望 3", purged, err)
+	}
+	if trash, _ := s.Trash(ctx); len(trash) != 0 {
+		t.Errorf("清空后回收站还有 %d 项", len(trash))
+	}
+	assertAscending(t, mustGetAll(t, s), nil)
+}
+
+func TestRestore(t *testing.T) {
+	for _, backend := range trashBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			testRestore(t, backend.open(t))
+		})
+	}
+}
+
+// testRestore 检查从回收站恢复以及各种无法恢复的情况，供各存储的测试共用
+func testRestore(t *testing.T, s trashTestStorage) {
+	ctx := context.Background()
+	for _, title := range []string{"a", "b", "c"} {
+		if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: title}); err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+	}
+	if _, err := s.AddComment(ctx, 2, &models.CreateCommentRequest{Body: "note"}); err != nil {
+		t.Fatalf("添加备注失败: %v", err)
+	}
+	for _, id := range []int{1, 2} {
+		if err := s.Delete(ctx, id); err != nil {
+			t.Fatalf("删除 %d 失败: %v", id, err)
+		}
+	}
+	if _, err := s.PurgeTrash(ctx, time.Time{}); err != nil {
+		t.Fatalf("清空回收站失败: %v", err)
+	}
+	if err := s.Delete(ctx, 2); !errors.Is(err, ErrTodoNotFound) {
+		t.Fatalf("删除已清理的待办事项错误 = %v，期望 %v", err, ErrTodoNotFound)
+	}
+
+	// 重新删除并恢复 2 之前先验证各种失败情况
+	if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: "d"}); err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if _, err := s.AddComment(ctx, 4, &models.CreateCommentRequest{Body: "note"}); err != nil {
+		t.Fatalf("添加备注失败: %v", err)
+	}
+	if err := s.Delete(ctx, 4); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	for _, tc := range []struct {
+		name string
+		id   int
+		want error
+	}{
+		{"已永久删除", 1, ErrConflict},
+		{"未被删除", 3, ErrConflict},
+		{"从未存在", 99, ErrTodoNotFound},
+		{"无效ID", 0, ErrTodoNotFound},
+	} {
+		if _, err := s.Restore(ctx, tc.id); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Restore(%d) 错误 = %v，期望 %v", tc.name, tc.id, err, tc.want)
+		}
+	}
+
+	restored, err := s.Restore(ctx, 4)
+	if err != nil {
+		t.Fatalf("Restore 失败: %v", err)
+	}
+	if restored.ID != 4 || restored.Title != "d" || restored.DeletedAt != nil || len(restored.Comments) != 1 {
+		t.Errorf("恢复的待办事项 = %+v，期望带备注、没有删除时间的 4", restored)
+	}
+	if !restored.UpdatedAt.After(restored.CreatedAt) {
+		t.Errorf("恢复后的更新时间 %v 应晚于创建时间 %v", restored.UpdatedAt, restored.CreatedAt)
+	}
+	assertAscending(t, mustGetAll(t, s), []int{3, 4})
+	if trash, _ := s.Trash(ctx); len(trash) != 0 {
+		t.Errorf("恢复后回收站还有 %d 项", len(trash))
+	}
+	if _, err := s.Restore(ctx, 4); !errors.Is(err, ErrConflict) {
+		t.Errorf("重复恢复错误 = %v，期望 %v", err, ErrConflict)
+	}
+
+	// 恢复后可以正常读取和修改，历史版本从恢复时重新开始
+	got, err := s.GetByID(ctx, 4)
+	if err != nil || len(got.Comments) != 1 {
+		t.Fatalf("GetByID = %+v, %v", got, err)
+	}
+	title := "e"
+	if _, err := s.Update(ctx, 4, &models.UpdateTodoRequest{Title: &title}); err != nil {
+		t.Fatalf("Update 失败: %v", err)
+	}
+	if versions, err := s.History(ctx, 4); err != nil || len(versions) != 2 {
+		t.Errorf("History = %d 个版本, %v，期望 2", len(versions), err)
+	}
+}
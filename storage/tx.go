@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-todolist/models"
+)
+
+// ErrTxDone 事务已经提交或回滚
+var ErrTxDone = errors.New("事务已结束")
+
+// Transactional 由支持事务的存储实现，fn 返回错误时其中的所有修改都会被回滚
+type Transactional interface {
+	WithTx(ctx context.Context, fn func(tx TodoStorage) error) error
+}
+
+// Tx 由 BeginTx 开启的事务，其中的修改在 Commit 之前对其他请求不可见。
+// 事务不能并发使用；结束后再调用 Rollback 没有影响，便于 defer tx.Rollback()
+type Tx interface {
+	TodoStorage
+	Commit() error
+	Rollback() error
+}
+
+// TxBeginner 由支持显式开启事务的存储实现，适用于无法写成单个 WithTx 回调的多步操作。
+// ctx 在提交前被取消时事务不会提交
+type TxBeginner interface {
+	BeginTx(ctx context.Context) (Tx, error)
+}
+
+// commitHookTx 用 commit 代替事务原有的提交，供需要在提交前后附加操作（如写文件、写日志）的存储使用
+type commitHookTx struct {
+	Tx
+	commit func(tx Tx) error
+}
+
+func (t *commitHookTx) Commit() error {
+	return t.commit(t.Tx)
+}
+
+// WithTx 在事务中执行 fn，期间独占存储；fn 返回错误或 panic 时恢复到执行前的状态
+func (s *MemoryStorage) WithTx(ctx context.Context, fn func(TodoStorage) error) error {
+	_, err := s.withTx(fn)
+	return err
+}
+
+// withTx 执行 WithTx 并返回事务视图，供需要知道事务修改了哪些待办事项的预写日志使用
+func (s *MemoryStorage) withTx(fn func(TodoStorage) error) (tx *memoryTx, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state := s.snapshot()
+	defer func() {
+		if r := recover(); r != nil {
+			s.restore(state)
+			panic(r)
+		}
+		if err != nil {
+			s.restore(state)
+		}
+	}()
+
+	tx = newMemoryTx(s)
+	return tx, fn(tx)
+}
+
+// BeginTx 开启显式事务。事务在开启时复制的全部数据上执行，不阻塞其他请求；
+// 提交时如果存储在此期间已被修改则返回 ErrTxConflict，调用方可以重试整个事务
+func (s *MemoryStorage) BeginTx(ctx context.Context) (Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	private := &MemoryStorage{}
+	private.restore(s.snapshot())
+	return &memoryBeginTx{
+		memoryTx: *newMemoryTx(private),
+		ctx:      ctx,
+		base:     s,
+		revision: s.revision,
+	}, nil
+}
+
+// memoryBeginTx BeginTx 返回的事务，在私有副本上执行操作，提交时整体替换原存储的数据
+type memoryBeginTx struct {
+	memoryTx
+	ctx      context.Context
+	base     *MemoryStorage
+	revision uint64
+	done     bool
+}
+
+func (tx *memoryBeginTx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	if err := tx.ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.base.mutex.Lock()
+	defer tx.base.mutex.Unlock()
+	if tx.base.revision != tx.revision {
+		return ErrTxConflict
+	}
+	tx.base.restore(tx.storage.snapshot())
+	return nil
+}
+
+func (tx *memoryBeginTx) Rollback() error {
+	tx.done = true
+	return nil
+}
+
+// memoryState 内存存储的完整状态快照
+type memoryState struct {
+	todos         map[int]*models.Todo
+	history       map[int][]models.TodoVersion
+	trash         map[int]*models.Todo
+	nextID        int
+	nextCommentID int
+}
+
+// snapshot 深拷贝当前全部数据，用于事务回滚
+func (s *MemoryStorage) snapshot() memoryState {
+	todos := make(map[int]*models.Todo, len(s.todos))
+	for id, todo := range s.todos {
+		todos[id] = cloneTodo(todo)
+	}
+	// 历史版本记录后不会被修改，复制切片即可
+	history := make(map[int][]models.TodoVersion, len(s.history))
+	for id, versions := range s.history {
+		history[id] = append([]models.TodoVersion{}, versions...)
+	}
+	trash := make(map[int]*models.Todo, len(s.trash))
+	for id, todo := range s.trash {
+		trash[id] = cloneTodo(todo)
+	}
+	return memoryState{
+		todos:         todos,
+		history:       history,
+		trash:         trash,
+		nextID:        s.nextID,
+		nextCommentID: s.nextCommentID,
+	}
+}
+
+// restore 将存储恢复为快照中的状态
+func (s *MemoryStorage) restore(state memoryState) {
+	s.todos = state.todos
+	s.history = state.history
+	s.trash = state.trash
+	s.rebuildExternalIDIndex()
+	s.rebuildOrder()
+	s.nextID = state.nextID
+	s.nextCommentID = state.nextCommentID
+	s.revision++
+}
+
+// memoryTx 事务内使用的存储视图，调用方已持有锁
+type memoryTx struct {
+	storage *MemoryStorage
+	// touched 事务中修改过的待办事项ID，值为 true 表示记录了历史版本；
+	// replaced 为 true 表示事务清空或导入了全部数据。预写日志据此只记录事务涉及的待办事项
+	touched  map[int]bool
+	replaced bool
+}
+
+func newMemoryTx(s *MemoryStorage) *memoryTx {
+	return &memoryTx{storage: s, touched: make(map[int]bool)}
+}
+
+// touch 记录事务修改了待办事项 id
+func (tx *memoryTx) touch(id int, record bool) {
+	tx.touched[id] = tx.touched[id] || record
+}
+
+func (tx *memoryTx) GetAll(ctx context.Context) ([]*models.Todo, error) {
+	return tx.storage.getAll()
+}
+
+func (tx *memoryTx) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	return tx.storage.getByID(id)
+}
+
+func (tx *memoryTx) Create(ctx context.Context, req *models.CreateTodoRequest) (*models.Todo, error) {
+	todo, err := tx.storage.create(req)
+	if err != nil {
+		return nil, err
+	}
+	tx.touch(int(todo.ID), true)
+	return todo, nil
+}
+
+func (tx *memoryTx) Update(ctx context.Context, id int, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	todo, err := tx.storage.update(id, req)
+	if err != nil {
+		return nil, err
+	}
+	tx.touch(id, true)
+	return todo, nil
+}
+
+func (tx *memoryTx) Delete(ctx context.Context, id int) error {
+	if err := tx.storage.delete(id, time.Now()); err != nil {
+		return err
+	}
+	tx.touch(id, false)
+	return nil
+}
+
+func (tx *memoryTx) AddComment(ctx context.Context, todoID int, req *models.CreateCommentRequest) (*models.Comment, error) {
+	comment, err := tx.storage.addComment(todoID, req)
+	if err != nil {
+		return nil, err
+	}
+	tx.touch(todoID, false)
+	return comment, nil
+}
+
+func (tx *memoryTx) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	if err := tx.storage.deleteComment(todoID, commentID); err != nil {
+		return err
+	}
+	tx.touch(todoID, false)
+	return nil
+}
+
+func (tx *memoryTx) Clear(ctx context.Context) (int, error) {
+	count, err := tx.storage.clear()
+	if err != nil {
+		return 0, err
+	}
+	tx.replaced = true
+	return count, nil
+}
+
+func (tx *memoryTx) History(ctx context.Context, id int) ([]models.TodoVersion, error) {
+	return tx.storage.getHistory(id)
+}
+
+func (tx *memoryTx) FindByExternalID(ctx context.Context, externalID string) ([]*models.Todo, error) {
+	return tx.storage.findByExternalID(externalID)
+}
+
+func (tx *memoryTx) Export(ctx context.Context) (*Snapshot, error) {
+	return tx.storage.export(), nil
+}
+
+func (tx *memoryTx) Import(ctx context.Context, snapshot *Snapshot) error {
+	if err := tx.storage.importSnapshot(snapshot); err != nil {
+		return err
+	}
+	tx.replaced = true
+	return nil
+}
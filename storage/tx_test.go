@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-todolist/models"
+)
+
+// txTestStorage 事务测试所需的存储能力
+type txTestStorage interface {
+	TodoStorage
+	Transactional
+	TxBeginner
+}
+
+// txBackend 参与事务回滚测试的存储。reopen 为 true 时关闭后从同一路径重新打开，验证回滚的修改没有持久化；
+// singleConn 为 true 表示存储只有一个连接，显式事务未结束时不能在事务外读取
+type txBackend struct {
+	name       string
+	open       func(t *testing.T, path string) txTestStorage
+	reopen     bool
+	singleConn bool
+}
+
+var txBackends = []txBackend{
+	{name: "memory", open: func(t *testing.T, path string) txTestStorage {
+		return NewMemoryStorage()
+	}},
+	{name: "wal", reopen: true, open: func(t *testing.T, path string) txTestStorage {
+		s, err := OpenWALMemory(path)
+		if err != nil {
+			t.Fatalf("打开预写日志存储失败: %v", err)
+		}
+		return s
+	}},
+	{name: "file", reopen: true, open: func(t *testing.T, path string) txTestStorage {
+		s, err := OpenFile(path, 0)
+		if err != nil {
+			t.Fatalf("打开文件存储失败: %v", err)
+		}
+		return s
+	}},
+	{name: "sqlite", reopen: true, singleConn: true, open: func(t *testing.T, path string) txTestStorage {
+		s, err := OpenSQLite(path)
+		if err != nil {
+			t.Fatalf("打开 SQLite 存储失败: %v", err)
+		}
+		return s
+	}},
+}
+
+var errAbortTx = errors.New("abort")
+
+// mustGetAll 获取全部待办事项，失败时终止测试
+func mustGetAll(t *testing.T, s TodoStorage) []*models.Todo {
+	t.Helper()
+	todos, err := s.GetAll(context.Background())
+	if err != nil {
+		t.Fatalf("GetAll 失败: %v", err)
+	}
+	return todos
+}
+
+// closeStorage 关闭实现了 io.Closer 的存储
+func closeStorage(t *testing.T, s TodoStorage) {
+	t.Helper()
+	if closer, ok := s.(interface{ Close() error }); ok {
+		if err := closer.Close(); err != nil {
+			t.Fatalf("关闭存储失败: %v", err)
+		}
+	}
+}
+
+// seedTx 创建两个待办事项，返回此时的全部数据作为事务前的快照
+func seedTx(t *testing.T, s TodoStorage) []*models.Todo {
+	t.Helper()
+	ctx := context.Background()
+	for _, title := range []string{"first", "second"} {
+		if _, err := s.Create(ctx, &models.CreateTodoRequest{Title: title, Tags: []string{"keep"}}); err != nil {
+			t.Fatalf("创建待办事项失败: %v", err)
+		}
+	}
+	return mustGetAll(t, s)
+}
+
+// writeTwice 在事务中执行两次写入：更新第一个待办事项并创建一个新的
+func writeTwice(t *testing.T, tx TodoStorage) {
+	t.Helper()
+	ctx := context.Background()
+	title := "changed"
+	if _, err := tx.Update(ctx, 1, &models.UpdateTodoRequest{Title: &title}); err != nil {
+		t.Fatalf("事务中更新失败: %v", err)
+	}
+	if _, err := tx.Create(ctx, &models.CreateTodoRequest{Title: "third"}); err != nil {
+		t.Fatalf("事务中创建失败: %v", err)
+	}
+}
+
+// assertRolledBack 检查数据与事务前一致，且回滚的创建没有占用ID
+func assertRolledBack(t *testing.T, s TodoStorage, before []*models.Todo) {
+	t.Helper()
+	if after := mustGetAll(t, s); !reflect.DeepEqual(after, before) {
+		t.Errorf("回滚后的数据与事务前不一致:\n得到 %+v\n期望 %+v", after, before)
+	}
+	created, err := s.Create(context.Background(), &models.CreateTodoRequest{Title: "after"})
+	if err != nil {
+		t.Fatalf("创建待办事项失败: %v", err)
+	}
+	if created.ID != 3 {
+		t.Errorf("回滚后新建的ID = %d，期望 3", created.ID)
+	}
+}
+
+// assertPersisted 重新打开存储，检查持久化的数据中没有回滚的修改
+func assertPersisted(t *testing.T, backend txBackend, s TodoStorage, path string) {
+	t.Helper()
+	if !backend.reopen {
+		return
+	}
+	closeStorage(t, s)
+	reopened := backend.open(t, path)
+	defer closeStorage(t, reopened)
+
+	var titles []string
+	for _, todo := range mustGetAll(t, reopened) {
+		titles = append(titles, todo.Title)
+	}
+	if want := []string{"first", "second", "after"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("重新打开后的标题 = %v，期望 %v", titles, want)
+	}
+}
+
+func TestWithTxRollback(t *testing.T) {
+	for _, backend := range txBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "todos")
+			s := backend.open(t, path)
+			before := seedTx(t, s)
+
+			err := s.WithTx(context.Background(), func(tx TodoStorage) error {
+				writeTwice(t, tx)
+				return errAbortTx
+			})
+			if !errors.Is(err, errAbortTx) {
+				t.Fatalf("WithTx 错误 = %v，期望 %v", err, errAbortTx)
+			}
+			assertRolledBack(t, s, before)
+			assertPersisted(t, backend, s, path)
+		})
+	}
+}
+
+func TestWithTxRollbackOnPanic(t *testing.T) {
+	for _, backend := range txBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "todos")
+			s := backend.open(t, path)
+			before := seedTx(t, s)
+
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Error("期望 WithTx 重新抛出 panic")
+					}
+				}()
+				_ = s.WithTx(context.Background(), func(tx TodoStorage) error {
+					writeTwice(t, tx)
+					panic("boom")
+				})
+			}()
+			assertRolledBack(t, s, before)
+			assertPersisted(t, backend, s, path)
+		})
+	}
+}
+
+func TestBeginTxRollback(t *testing.T) {
+	for _, backend := range txBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "todos")
+			s := backend.open(t, path)
+			before := seedTx(t, s)
+
+			tx, err := s.BeginTx(context.Background())
+			if err != nil {
+				t.Fatalf("BeginTx 失败: %v", err)
+			}
+			writeTwice(t, tx)
+			// 提交前其他请求看不到事务中的修改
+			if !backend.singleConn {
+				if after := mustGetAll(t, s); !reflect.DeepEqual(after, before) {
+					t.Error("事务提交前其修改对存储可见")
+				}
+			}
+			if err := tx.Rollback(); err != nil {
+				t.Fatalf("Rollback 失败: %v", err)
+			}
+			if err := tx.Commit(); !errors.Is(err, ErrTxDone) {
+				t.Errorf("回滚后 Commit 错误 = %v，期望 %v", err, ErrTxDone)
+			}
+			assertRolledBack(t, s, before)
+			assertPersisted(t, backend, s, path)
+		})
+	}
+}
+
+func TestWithTxCommit(t *testing.T) {
+	for _, backend := range txBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "todos")
+			s := backend.open(t, path)
+			seedTx(t, s)
+
+			err := s.WithTx(context.Background(), func(tx TodoStorage) error {
+				writeTwice(t, tx)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("WithTx 失败: %v", err)
+			}
+			var titles []string
+			for _, todo := range mustGetAll(t, s) {
+				titles = append(titles, todo.Title)
+			}
+			if want := []string{"changed", "second", "third"}; !reflect.DeepEqual(titles, want) {
+				t.Errorf("提交后的标题 = %v，期望 %v", titles, want)
+			}
+			closeStorage(t, s)
+		})
+	}
+}